@@ -13,6 +13,13 @@ const (
 	TssSyncFail   = "signers fail to sync before keygen/keysign"
 	TssBrokenMsg  = "tss share verification failed"
 	InternalError = "fail to start the join party "
+	RateLimited   = "peers exceeded their p2p rate limits"
+	// VersionMismatch blames the peers that do not support the version of the ceremony
+	VersionMismatch = "peers do not support the version of the ceremony"
+	// SignerConstraintViolated blames the leader that picked signers the signer constraint of the keysign excludes
+	SignerConstraintViolated = "the leader picked signers the signer constraint excludes"
+	// LeaderNotReady blames the leader that received our join party request but never sent us the party
+	LeaderNotReady = "the leader never formed the party"
 )
 
 var (
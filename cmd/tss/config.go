@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// loadConfigFile apply the values of the given json config file to the flags, the keys of the file are the
+// flag names, e.g. {"p2p-timeout-connecting": "1m", "peer": ["/ip4/..."]}. The flags given on the command line
+// take precedence over the config file.
+func loadConfigFile(fs *flag.FlagSet, path string) error {
+	if len(path) == 0 {
+		return nil
+	}
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("fail to read config file(%s): %w", path, err)
+	}
+	// numbers are kept as they are written, so large integers are not turned into floats
+	decoder := json.NewDecoder(bytes.NewReader(buf))
+	decoder.UseNumber()
+	var values map[string]interface{}
+	if err := decoder.Decode(&values); err != nil {
+		return fmt.Errorf("fail to unmarshal config file(%s): %w", path, err)
+	}
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+	for key, value := range values {
+		if fs.Lookup(key) == nil {
+			return fmt.Errorf("unknown config key(%s)", key)
+		}
+		if explicit[key] {
+			continue
+		}
+		items, ok := value.([]interface{})
+		if !ok {
+			items = []interface{}{value}
+		}
+		for _, el := range items {
+			if err := fs.Set(key, fmt.Sprint(el)); err != nil {
+				return fmt.Errorf("invalid value of config key(%s): %w", key, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type ConfigFileTestSuite struct{}
+
+var _ = Suite(&ConfigFileTestSuite{})
+
+func writeConfigFile(c *C, content string) string {
+	path := filepath.Join(c.MkDir(), "config.json")
+	c.Assert(os.WriteFile(path, []byte(content), 0o600), IsNil)
+	return path
+}
+
+func (ConfigFileTestSuite) TestLoadConfigFile(c *C) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	timeout := fs.Duration("p2p-timeout-connecting", time.Second, "")
+	readTimeout := fs.Duration("p2p-timeout-read", time.Second, "")
+	maxPayload := fs.Int("p2p-max-payload", 1, "")
+	compression := fs.Bool("enable-compression", true, "")
+	var peers []string
+	fs.Func("peer", "", func(value string) error {
+		peers = append(peers, value)
+		return nil
+	})
+	c.Assert(fs.Parse([]string{"-p2p-timeout-read", "3s"}), IsNil)
+
+	c.Assert(loadConfigFile(fs, ""), IsNil)
+	path := writeConfigFile(c, `{
+		"p2p-timeout-connecting": "1m",
+		"p2p-timeout-read": "10s",
+		"p2p-max-payload": 40000000,
+		"enable-compression": false,
+		"peer": ["/ip4/127.0.0.1/tcp/6668", "/ip4/127.0.0.2/tcp/6668"]
+	}`)
+	c.Assert(loadConfigFile(fs, path), IsNil)
+	c.Assert(*timeout, Equals, time.Minute)
+	// the command line takes precedence over the config file
+	c.Assert(*readTimeout, Equals, 3*time.Second)
+	c.Assert(*maxPayload, Equals, 40000000)
+	c.Assert(*compression, Equals, false)
+	c.Assert(peers, HasLen, 2)
+
+	c.Assert(loadConfigFile(fs, writeConfigFile(c, `{"unknown-key": 1}`)), NotNil)
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Int("p2p-max-payload", 1, "")
+	c.Assert(loadConfigFile(fs, writeConfigFile(c, `{"p2p-max-payload": "many"}`)), NotNil)
+	c.Assert(loadConfigFile(fs, writeConfigFile(c, `not json`)), NotNil)
+	c.Assert(loadConfigFile(fs, filepath.Join(c.MkDir(), "missing.json")), NotNil)
+}
@@ -27,6 +27,7 @@ var (
 	pretty     bool
 	baseFolder string
 	tssAddr    string
+	configFile string
 )
 
 func main() {
@@ -36,6 +37,12 @@ func main() {
 		flag.PrintDefaults()
 		return
 	}
+	if err := loadConfigFile(flag.CommandLine, configFile); err != nil {
+		log.Fatal(err)
+	}
+	if err := tssConf.Network.Validate(); err != nil {
+		log.Fatal(fmt.Errorf("invalid p2p network config: %w", err))
+	}
 	// Setup logging
 	golog.SetAllLoggers(golog.LevelInfo)
 	_ = golog.SetLogLevel("tss-lib", "INFO")
@@ -93,19 +100,64 @@ func parseFlags() (tssConf common.TssConfig, p2pConf p2p.Config) {
 	flag.StringVar(&logLevel, "loglevel", "info", "Log Level")
 	flag.BoolVar(&pretty, "pretty-log", false, "Enables unstructured prettified logging. This is useful for local debugging")
 	flag.StringVar(&baseFolder, "home", "", "home folder to store the keygen state file")
+	flag.StringVar(&configFile, "config", "", "json config file keyed by the flag names, the flags given on the command line take precedence")
 
 	// we setup the Tss parameter configuration
 	flag.DurationVar(&tssConf.KeyGenTimeout, "gentimeout", 30*time.Second, "keygen timeout")
 	flag.DurationVar(&tssConf.KeySignTimeout, "signtimeout", 30*time.Second, "keysign timeout")
 	flag.DurationVar(&tssConf.PreParamTimeout, "preparamtimeout", 5*time.Minute, "pre-parameter generation timeout")
 	flag.BoolVar(&tssConf.EnableMonitor, "enablemonitor", true, "enable the tss monitor")
+	flag.DurationVar(&tssConf.JournalRetention, "journal-retention", 30*24*time.Hour, "how long to keep the ceremony journal entries, 0 keeps them forever")
+	flag.IntVar(&tssConf.JournalMaxEntries, "journal-max-entries", 0, "maximum number of ceremony journal entries to keep, 0 means no limit")
+	flag.IntVar(&tssConf.MaxConcurrentKeysigns, "max-keysigns", 0, "how many keysigns run at the same time, 0 means no limit")
+	flag.IntVar(&tssConf.MaxConcurrentKeysignsPerPool, "max-pool-keysigns", 0, "how many keysigns of the same pool run at the same time, 0 means no limit")
+	flag.IntVar(&tssConf.KeysignQueueSize, "keysign-queue-size", 100, "how many keysigns of each priority wait for the concurrency limits before they are rejected")
+	flag.DurationVar(&tssConf.JobRetention, "job-retention", 7*24*time.Hour, "how long to keep the finished keygen and keysign jobs, 0 keeps them forever")
+	flag.DurationVar(&tssConf.CeremonyCacheTTL, "ceremony-cache-ttl", 10*time.Minute, "how long do we return the outcome of a successful keysign to the retries of its request")
+	flag.BoolVar(&tssConf.EnableCompression, "enable-compression", true, "compress the large tss messages sent to the peers that support it")
 
 	// we setup the p2p network configuration
 	flag.StringVar(&p2pConf.RendezvousString, "rendezvous", "Asgard",
 		"Unique string to identify group of nodes. Share this with your friends to let them connect with you")
 	flag.IntVar(&p2pConf.Port, "p2p-port", 6668, "listening port local")
-	flag.StringVar(&p2pConf.ExternalIP, "external-ip", "", "external IP of this node")
+	flag.StringVar(&p2pConf.ExternalIP, "external-ip", "", "external IPv4 or IPv6 address of this node, advertised on every listen address of the same family")
+	flag.Var(&tssConf.Network.ListenAddrs, "listen-addr", "Adds a TCP or QUIC multiaddress to listen on, e.g. /ip6/::/udp/6668/quic-v1, none listens on TCP IPv4 at p2p-port")
+	flag.Var(&tssConf.Network.ExternalAddrs, "external-addr", "Adds a multiaddress to advertise to the other nodes")
 	flag.Var(&p2pConf.BootstrapPeers, "peer", "Adds a peer multiaddress to the bootstrap list")
+	flag.StringVar(&tssConf.Network.Reachability, "p2p-reachability", "", "force the reachability of this node to public or private, empty lets AutoNAT find it out")
+	flag.BoolVar(&tssConf.Network.EnableNATService, "p2p-nat-service", false, "answer the AutoNAT requests of the other nodes")
+	flag.Var(&tssConf.Network.RelayPeers, "p2p-relay", "Adds a circuit relay multiaddress to reserve a slot on when this node is behind a NAT")
+	flag.BoolVar(&tssConf.Network.EnableRelayService, "p2p-relay-service", false, "relay the connections of the committee members that are behind a NAT")
+	flag.BoolVar(&tssConf.Network.EnableHolePunching, "p2p-hole-punching", false, "upgrade the relayed connections to direct ones with hole punching")
+	flag.StringVar((*string)(&tssConf.Network.Discovery), "p2p-discovery", string(p2p.DefaultDiscoveryMode), "how to find the other nodes: dht, static (bootstrap peers only) or mdns (local network)")
+	flag.DurationVar(&tssConf.Network.TimeoutConnecting, "p2p-timeout-connecting", p2p.DefaultTimeoutConnecting, "maximum time to wait for a peer to connect")
+	flag.DurationVar(&tssConf.Network.TimeoutReadPayload, "p2p-timeout-read", p2p.DefaultTimeoutReadPayload, "maximum time to read a message from a stream")
+	flag.DurationVar(&tssConf.Network.TimeoutWritePayload, "p2p-timeout-write", p2p.DefaultTimeoutWritePayload, "maximum time to write a message to a stream")
+	flag.IntVar(&tssConf.Network.MaxPayload, "p2p-max-payload", p2p.DefaultMaxPayload, "largest message in bytes accepted from a peer")
+	flag.DurationVar(&tssConf.Network.PingTimeout, "p2p-ping-timeout", p2p.DefaultPingTimeout, "maximum time to wait for a bootstrap node to answer the ping")
+	flag.DurationVar(&tssConf.Network.LeaderRetryInterval, "p2p-leader-retry-interval", p2p.DefaultLeaderRetryInterval, "how long to wait before asking the party leader again")
+	flag.DurationVar(&tssConf.Network.LeaderFailoverTimeout, "p2p-leader-failover-timeout", p2p.DefaultLeaderFailoverTimeout, "how long to try reaching the party leader before moving to the next one")
+	flag.StringVar((*string)(&tssConf.Network.SignerSelection), "p2p-signer-selection", string(p2p.DefaultSignerSelection), "how the leader picks the keysign signers when more peers than needed are ready: arrival, rtt, success-rate or preference")
+	flag.Var(&tssConf.Network.PreferredSigners, "p2p-preferred-signer", "Adds a peer ID to the signers the preference signer selection picks first")
+	flag.DurationVar(&tssConf.Network.SignerSelectionWindow, "p2p-signer-selection-window", p2p.DefaultSignerSelectionWindow, "how long the leader waits for more peers before it picks the keysign signers")
+	flag.IntVar(&tssConf.Network.BootstrapAttempts, "p2p-bootstrap-attempts", p2p.DefaultBootstrapAttempts, "how many times to try connecting to the bootstrap nodes")
+	flag.DurationVar(&tssConf.Network.BootstrapRetryInterval, "p2p-bootstrap-retry-interval", p2p.DefaultBootstrapRetryInterval, "how long to wait between two attempts to connect to the bootstrap nodes")
+	flag.IntVar(&tssConf.Network.BroadcastBufferSize, "p2p-broadcast-buffer", p2p.DefaultBroadcastBufferSize, "how many outbound messages the broadcast channel buffers")
+	flag.DurationVar(&tssConf.Network.HealthCheckInterval, "p2p-health-interval", p2p.DefaultHealthCheckInterval, "how often to ping the committee peers")
+	flag.IntVar(&tssConf.Network.HealthFailureThreshold, "p2p-health-failures", p2p.DefaultHealthFailureThreshold, "how many pings in a row a peer fails before it is considered unreachable")
+	flag.IntVar(&tssConf.Network.MaxInboundStreamsPerPeer, "p2p-max-peer-streams", p2p.DefaultMaxInboundStreamsPerPeer, "how many inbound streams a peer can have open at the same time")
+	flag.Float64Var(&tssConf.Network.StreamRate, "p2p-stream-rate", p2p.DefaultStreamRate, "how many streams per second a peer can open on average")
+	flag.IntVar(&tssConf.Network.StreamBurst, "p2p-stream-burst", p2p.DefaultStreamBurst, "how many streams a peer can open at once")
+	flag.IntVar(&tssConf.Network.ByteRate, "p2p-byte-rate", p2p.DefaultByteRate, "how many bytes per second a peer can send on average")
+	flag.IntVar(&tssConf.Network.ByteBurst, "p2p-byte-burst", 0, "how many bytes a peer can send at once, 0 is twice p2p-max-payload")
+	flag.Float64Var(&tssConf.Network.MessageRate, "p2p-message-rate", p2p.DefaultMessageRate, "how many messages per second of a ceremony a peer can send on average")
+	flag.IntVar(&tssConf.Network.MessageBurst, "p2p-message-burst", p2p.DefaultMessageBurst, "how many messages of a ceremony a peer can send at once")
+	flag.IntVar(&tssConf.Network.BanThreshold, "p2p-ban-threshold", p2p.DefaultBanThreshold, "how many rate limit violations get a peer banned")
+	flag.DurationVar(&tssConf.Network.BanDuration, "p2p-ban-duration", p2p.DefaultBanDuration, "how long a peer that violates its rate limits is banned")
+	flag.Func("allowed-pubkey", "Adds a node pub key to the allowlist of the p2p connections, no allowlist accepts everyone", func(value string) error {
+		tssConf.AllowedPubKeys = append(tssConf.AllowedPubKeys, value)
+		return nil
+	})
 	flag.Parse()
 	return
 }
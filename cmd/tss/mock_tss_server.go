@@ -1,20 +1,36 @@
 package main
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/ordinox/thorchain-tss/blame"
 	"github.com/ordinox/thorchain-tss/common"
 	"github.com/ordinox/thorchain-tss/conversion"
 	"github.com/ordinox/thorchain-tss/keygen"
 	"github.com/ordinox/thorchain-tss/keysign"
+	"github.com/ordinox/thorchain-tss/monitor"
+	"github.com/ordinox/thorchain-tss/p2p"
+	"github.com/ordinox/thorchain-tss/storage"
 	"github.com/ordinox/thorchain-tss/tss"
 )
 
 type MockTssServer struct {
-	failToStart   bool
-	failToKeyGen  bool
-	failToKeySign bool
+	failToStart      bool
+	failToKeyGen     bool
+	failToKeySign    bool
+	keysignQueueFull bool
+	failToGetHistory bool
+	history          []storage.CeremonyRecord
+	allowedPubKeys   []string
+	natStatus        p2p.NATStatus
+	peersHealth      []p2p.PeerHealth
+	peerVersions     []p2p.PeerVersion
+	failToSubmitJob  bool
+	jobs             map[string]storage.JobRecord
+	eventBus         *monitor.EventBus
 }
 
 func (mts *MockTssServer) Start() error {
@@ -46,10 +62,106 @@ func (mts *MockTssServer) Keygen(req keygen.Request) (keygen.Response, error) {
 	return keygen.NewResponse(conversion.GetRandomPubKey(), "whatever", common.Success, blame.Blame{}), nil
 }
 
+func (mts *MockTssServer) KeygenCtx(ctx context.Context, req keygen.Request) (keygen.Response, error) {
+	if err := ctx.Err(); err != nil {
+		return keygen.Response{}, err
+	}
+	return mts.Keygen(req)
+}
+
+func (mts *MockTssServer) KeySignCtx(ctx context.Context, req keysign.Request) (keysign.Response, error) {
+	if err := ctx.Err(); err != nil {
+		return keysign.Response{}, err
+	}
+	return mts.KeySign(req)
+}
+
 func (mts *MockTssServer) KeySign(req keysign.Request) (keysign.Response, error) {
 	if mts.failToKeySign {
 		return keysign.Response{}, errors.New("you ask for it")
 	}
+	if mts.keysignQueueFull {
+		return keysign.Response{}, fmt.Errorf("%w, you ask for it", tss.ErrQueueFull)
+	}
 	newSig := keysign.NewSignature("", "", "", "")
 	return keysign.NewResponse([]keysign.Signature{newSig}, common.Success, blame.Blame{}), nil
 }
+
+func (mts *MockTssServer) GetCeremonyHistory(filter storage.CeremonyFilter) ([]storage.CeremonyRecord, error) {
+	if mts.failToGetHistory {
+		return nil, errors.New("you ask for it")
+	}
+	var records []storage.CeremonyRecord
+	for _, el := range mts.history {
+		if filter.Match(el) {
+			records = append(records, el)
+		}
+	}
+	return records, nil
+}
+
+func (mts *MockTssServer) SetAllowedPubKeys(pubKeys []string) error {
+	for _, el := range pubKeys {
+		if _, err := conversion.GetPeerIDFromPubKey(el); err != nil {
+			return err
+		}
+	}
+	mts.allowedPubKeys = pubKeys
+	return nil
+}
+
+func (mts *MockTssServer) GetAllowedPubKeys() []string {
+	return mts.allowedPubKeys
+}
+
+func (mts *MockTssServer) GetNATStatus() p2p.NATStatus {
+	return mts.natStatus
+}
+
+func (mts *MockTssServer) GetPeersHealth() []p2p.PeerHealth {
+	return mts.peersHealth
+}
+
+func (mts *MockTssServer) GetPeerVersions() []p2p.PeerVersion {
+	return mts.peerVersions
+}
+
+func (mts *MockTssServer) submitJob(jobType, callbackURL string) (storage.JobRecord, error) {
+	if mts.failToSubmitJob {
+		return storage.JobRecord{}, errors.New("you ask for it")
+	}
+	if strings.HasPrefix(callbackURL, "ftp") {
+		return storage.JobRecord{}, fmt.Errorf("%w(%s)", tss.ErrInvalidCallbackURL, callbackURL)
+	}
+	job := storage.JobRecord{
+		ID:          fmt.Sprintf("%02x", len(mts.jobs)),
+		Type:        jobType,
+		Phase:       storage.JobRunning,
+		CallbackURL: callbackURL,
+	}
+	if mts.jobs == nil {
+		mts.jobs = make(map[string]storage.JobRecord)
+	}
+	mts.jobs[job.ID] = job
+	return job, nil
+}
+
+func (mts *MockTssServer) SubmitKeygenJob(req keygen.Request, callbackURL string) (storage.JobRecord, error) {
+	return mts.submitJob(storage.CeremonyKeygen, callbackURL)
+}
+
+func (mts *MockTssServer) SubmitKeySignJob(req keysign.Request, callbackURL string) (storage.JobRecord, error) {
+	return mts.submitJob(storage.CeremonyKeysign, callbackURL)
+}
+
+func (mts *MockTssServer) SubscribeEvents(msgID string) (<-chan monitor.Event, func()) {
+	return mts.eventBus.Subscribe(msgID)
+}
+
+func (mts *MockTssServer) GetJob(id string) (storage.JobRecord, error) {
+	job, ok := mts.jobs[id]
+	if !ok {
+		return storage.JobRecord{}, storage.ErrJobNotFound
+	}
+	return job, nil
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -15,6 +16,8 @@ import (
 
 	"github.com/ordinox/thorchain-tss/keygen"
 	"github.com/ordinox/thorchain-tss/keysign"
+	"github.com/ordinox/thorchain-tss/p2p"
+	"github.com/ordinox/thorchain-tss/storage"
 	"github.com/ordinox/thorchain-tss/tss"
 )
 
@@ -44,9 +47,19 @@ func (t *TssHttpServer) tssNewHandler() http.Handler {
 	router := mux.NewRouter()
 	router.Handle("/keygen", http.HandlerFunc(t.keygenHandler)).Methods(http.MethodPost)
 	router.Handle("/keysign", http.HandlerFunc(t.keySignHandler)).Methods(http.MethodPost)
+	router.Handle("/jobs/keygen", http.HandlerFunc(t.keygenJobHandler)).Methods(http.MethodPost)
+	router.Handle("/jobs/keysign", http.HandlerFunc(t.keysignJobHandler)).Methods(http.MethodPost)
+	router.Handle("/jobs/{id}", http.HandlerFunc(t.jobHandler)).Methods(http.MethodGet)
 	router.Handle("/ping", http.HandlerFunc(t.pingHandler)).Methods(http.MethodGet)
 	router.Handle("/p2pid", http.HandlerFunc(t.getP2pIDHandler)).Methods(http.MethodGet)
 	router.Handle("/pubkey", http.HandlerFunc(t.getPubKeyHandler)).Methods(http.MethodGet)
+	router.Handle("/history", http.HandlerFunc(t.historyHandler)).Methods(http.MethodGet)
+	router.Handle("/events", http.HandlerFunc(t.eventsHandler)).Methods(http.MethodGet)
+	router.Handle("/allowlist", http.HandlerFunc(t.getAllowlistHandler)).Methods(http.MethodGet)
+	router.Handle("/allowlist", http.HandlerFunc(t.setAllowlistHandler)).Methods(http.MethodPost)
+	router.Handle("/nat", http.HandlerFunc(t.natStatusHandler)).Methods(http.MethodGet)
+	router.Handle("/peers/health", http.HandlerFunc(t.peersHealthHandler)).Methods(http.MethodGet)
+	router.Handle("/peers/versions", http.HandlerFunc(t.peerVersionsHandler)).Methods(http.MethodGet)
 	router.Handle("/metrics", promhttp.Handler())
 	router.Use(logMiddleware())
 	return router
@@ -71,7 +84,12 @@ func (t *TssHttpServer) keygenHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp, err := t.tssServer.Keygen(keygenReq)
+	// the keygen is cancelled when the client goes away, unless a retry of the request waits for it
+	resp, err := t.tssServer.KeygenCtx(r.Context(), keygenReq)
+	if r.Context().Err() != nil {
+		t.logger.Info().Err(r.Context().Err()).Msg("the client is gone, drop the keygen response")
+		return
+	}
 	if err != nil {
 		t.logger.Error().Err(err).Msg("fail to key gen")
 	}
@@ -107,8 +125,23 @@ func (t *TssHttpServer) keySignHandler(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
+	if err := keySignReq.Priority.Validate(); err != nil {
+		t.logger.Error().Err(err).Msg("invalid key sign request")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
 	t.logger.Info().Msgf("request:%+v", keySignReq)
-	signResp, err := t.tssServer.KeySign(keySignReq)
+	signResp, err := t.tssServer.KeySignCtx(r.Context(), keySignReq)
+	if r.Context().Err() != nil {
+		t.logger.Info().Err(r.Context().Err()).Msg("the client is gone, drop the key sign response")
+		return
+	}
+	if errors.Is(err, tss.ErrQueueFull) {
+		// the caller should retry later
+		t.logger.Error().Err(err).Msg("fail to key sign")
+		w.WriteHeader(http.StatusTooManyRequests)
+		return
+	}
 	if err != nil {
 		t.logger.Error().Err(err).Msg("fail to key sign")
 		w.WriteHeader(http.StatusInternalServerError)
@@ -127,6 +160,108 @@ func (t *TssHttpServer) keySignHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// KeygenJobRequest is the request of a keygen job, the finished job is posted to the callback url if one is given
+type KeygenJobRequest struct {
+	keygen.Request
+	CallbackURL string `json:"callback_url"`
+}
+
+// KeysignJobRequest is the request of a keysign job, the finished job is posted to the callback url if one is given
+type KeysignJobRequest struct {
+	keysign.Request
+	CallbackURL string `json:"callback_url"`
+}
+
+func (t *TssHttpServer) keygenJobHandler(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		if err := r.Body.Close(); nil != err {
+			t.logger.Error().Err(err).Msg("fail to close request body")
+		}
+	}()
+	var req KeygenJobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); nil != err {
+		t.logger.Error().Err(err).Msg("fail to decode keygen job request")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	job, err := t.tssServer.SubmitKeygenJob(req.Request, req.CallbackURL)
+	t.writeSubmittedJob(w, job, err)
+}
+
+func (t *TssHttpServer) keysignJobHandler(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		if err := r.Body.Close(); nil != err {
+			t.logger.Error().Err(err).Msg("fail to close request body")
+		}
+	}()
+	var req KeysignJobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); nil != err {
+		t.logger.Error().Err(err).Msg("fail to decode key sign job request")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if err := req.Priority.Validate(); err != nil {
+		t.logger.Error().Err(err).Msg("invalid key sign job request")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	job, err := t.tssServer.SubmitKeySignJob(req.Request, req.CallbackURL)
+	t.writeSubmittedJob(w, job, err)
+}
+
+func (t *TssHttpServer) writeSubmittedJob(w http.ResponseWriter, job storage.JobRecord, err error) {
+	if errors.Is(err, tss.ErrInvalidCallbackURL) {
+		t.logger.Error().Err(err).Msg("fail to submit the job")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to submit the job")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	buf, err := json.Marshal(job)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to marshal response to json")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	_, err = w.Write(buf)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to write to response")
+	}
+}
+
+func (t *TssHttpServer) jobHandler(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if err := storage.ValidateJobID(id); err != nil {
+		t.logger.Error().Err(err).Msg("invalid job id")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	job, err := t.tssServer.GetJob(id)
+	if errors.Is(err, storage.ErrJobNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to get the job")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	buf, err := json.Marshal(job)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to marshal response to json")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	_, err = w.Write(buf)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to write to response")
+	}
+}
+
 func (t *TssHttpServer) Start() error {
 	if t.s == nil {
 		return errors.New("invalid http server instance")
@@ -187,3 +322,190 @@ func (t *TssHttpServer) getPubKeyHandler(w http.ResponseWriter, _ *http.Request)
 		t.logger.Error().Err(err).Msg("fail to write to response")
 	}
 }
+
+func parseHistoryFilter(r *http.Request) (storage.CeremonyFilter, error) {
+	query := r.URL.Query()
+	filter := storage.CeremonyFilter{
+		MsgID:      query.Get("msg_id"),
+		Type:       query.Get("type"),
+		PoolPubKey: query.Get("pool_pub_key"),
+		Signer:     query.Get("signer"),
+		Status:     query.Get("status"),
+	}
+	var err error
+	if from := query.Get("from"); len(from) != 0 {
+		filter.From, err = time.Parse(time.RFC3339, from)
+		if err != nil {
+			return filter, fmt.Errorf("invalid from time(%s): %w", from, err)
+		}
+	}
+	if to := query.Get("to"); len(to) != 0 {
+		filter.To, err = time.Parse(time.RFC3339, to)
+		if err != nil {
+			return filter, fmt.Errorf("invalid to time(%s): %w", to, err)
+		}
+	}
+	if limit := query.Get("limit"); len(limit) != 0 {
+		filter.Limit, err = strconv.Atoi(limit)
+		if err != nil || filter.Limit < 0 {
+			return filter, fmt.Errorf("invalid limit(%s)", limit)
+		}
+	}
+	return filter, nil
+}
+
+func (t *TssHttpServer) historyHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseHistoryFilter(r)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to parse the history filter")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	records, err := t.tssServer.GetCeremonyHistory(filter)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to get the ceremony history")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if records == nil {
+		records = []storage.CeremonyRecord{}
+	}
+	buf, err := json.Marshal(records)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to marshal response to json")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	_, err = w.Write(buf)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to write to response")
+	}
+}
+
+// eventsHandler stream the progress events of the ceremonies as server-sent events, the msg_id query parameter
+// limits them to a single ceremony
+func (t *TssHttpServer) eventsHandler(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		t.logger.Error().Msg("the response writer does not support streaming")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	events, cancel := t.tssServer.SubscribeEvents(r.URL.Query().Get("msg_id"))
+	defer cancel()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			buf, err := json.Marshal(event)
+			if err != nil {
+				t.logger.Error().Err(err).Msg("fail to marshal the event to json")
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, buf); err != nil {
+				t.logger.Error().Err(err).Msg("fail to write to response")
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// AllowlistRequest is the request to replace the allowlist of the node pub keys we accept p2p connections from
+type AllowlistRequest struct {
+	PubKeys []string `json:"pub_keys"`
+}
+
+func (t *TssHttpServer) getAllowlistHandler(w http.ResponseWriter, _ *http.Request) {
+	pubKeys := t.tssServer.GetAllowedPubKeys()
+	if pubKeys == nil {
+		pubKeys = []string{}
+	}
+	buf, err := json.Marshal(AllowlistRequest{PubKeys: pubKeys})
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to marshal response to json")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	_, err = w.Write(buf)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to write to response")
+	}
+}
+
+func (t *TssHttpServer) setAllowlistHandler(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		if err := r.Body.Close(); nil != err {
+			t.logger.Error().Err(err).Msg("fail to close request body")
+		}
+	}()
+	var req AllowlistRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); nil != err {
+		t.logger.Error().Err(err).Msg("fail to decode allowlist request")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if err := t.tssServer.SetAllowedPubKeys(req.PubKeys); err != nil {
+		t.logger.Error().Err(err).Msg("fail to update the allowlist")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	t.logger.Info().Msgf("allowlist updated with %d node pub keys", len(req.PubKeys))
+	w.WriteHeader(http.StatusOK)
+}
+
+func (t *TssHttpServer) natStatusHandler(w http.ResponseWriter, _ *http.Request) {
+	buf, err := json.Marshal(t.tssServer.GetNATStatus())
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to marshal response to json")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	_, err = w.Write(buf)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to write to response")
+	}
+}
+
+func (t *TssHttpServer) peersHealthHandler(w http.ResponseWriter, _ *http.Request) {
+	health := t.tssServer.GetPeersHealth()
+	if health == nil {
+		health = []p2p.PeerHealth{}
+	}
+	buf, err := json.Marshal(health)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to marshal response to json")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	_, err = w.Write(buf)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to write to response")
+	}
+}
+
+func (t *TssHttpServer) peerVersionsHandler(w http.ResponseWriter, _ *http.Request) {
+	versions := t.tssServer.GetPeerVersions()
+	if versions == nil {
+		versions = []p2p.PeerVersion{}
+	}
+	buf, err := json.Marshal(versions)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to marshal response to json")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	_, err = w.Write(buf)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to write to response")
+	}
+}
@@ -1,17 +1,26 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/libp2p/go-libp2p/core/peer"
 	. "gopkg.in/check.v1"
 
+	"github.com/ordinox/thorchain-tss/conversion"
 	"github.com/ordinox/thorchain-tss/keygen"
+	"github.com/ordinox/thorchain-tss/messages"
+	"github.com/ordinox/thorchain-tss/monitor"
+	"github.com/ordinox/thorchain-tss/p2p"
+	"github.com/ordinox/thorchain-tss/storage"
 )
 
 func TestPackage(t *testing.T) { TestingT(t) }
@@ -174,6 +183,41 @@ func (TssHttpServerTestSuite) TestKeysignHandler(c *C) {
 				c.Assert(w.Code, Equals, http.StatusInternalServerError)
 			},
 		},
+		{
+			name: "unknown priority should return status bad request",
+			reqProvider: func() *http.Request {
+				return httptest.NewRequest(http.MethodPost, "/keysign",
+					bytes.NewBufferString(`{"pool_pub_key": "whatever", "priority": "urgent"}`))
+			},
+			resultChecker: func(c *C, w *httptest.ResponseRecorder) {
+				c.Assert(w.Code, Equals, http.StatusBadRequest)
+			},
+		},
+		{
+			name: "full keysign queue should return status too many requests",
+			reqProvider: func() *http.Request {
+				return httptest.NewRequest(http.MethodPost, "/keysign",
+					bytes.NewBufferString(normalKeySignRequest))
+			},
+			setter: func(s *MockTssServer) {
+				s.keysignQueueFull = true
+			},
+			resultChecker: func(c *C, w *httptest.ResponseRecorder) {
+				c.Assert(w.Code, Equals, http.StatusTooManyRequests)
+			},
+		},
+		{
+			name: "gone client should get no response",
+			reqProvider: func() *http.Request {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return httptest.NewRequest(http.MethodPost, "/keysign",
+					bytes.NewBufferString(normalKeySignRequest)).WithContext(ctx)
+			},
+			resultChecker: func(c *C, w *httptest.ResponseRecorder) {
+				c.Assert(w.Body.Len(), Equals, 0)
+			},
+		},
 		{
 			name: "normal",
 			reqProvider: func() *http.Request {
@@ -202,3 +246,278 @@ func (TssHttpServerTestSuite) TestKeysignHandler(c *C) {
 		tc.resultChecker(c, res)
 	}
 }
+
+func (TssHttpServerTestSuite) TestHistoryHandler(c *C) {
+	history := []storage.CeremonyRecord{
+		{MsgID: "msg1", Type: storage.CeremonyKeysign, Status: "success", StartTime: time.Unix(1000, 0)},
+		{MsgID: "msg2", Type: storage.CeremonyKeygen, Status: "fail", StartTime: time.Unix(2000, 0)},
+	}
+	testCases := []struct {
+		name          string
+		reqProvider   func() *http.Request
+		setter        func(s *MockTssServer)
+		resultChecker func(c *C, w *httptest.ResponseRecorder)
+	}{
+		{
+			name: "invalid time should return status bad request",
+			reqProvider: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/history?from=yesterday", nil)
+			},
+			resultChecker: func(c *C, w *httptest.ResponseRecorder) {
+				c.Assert(w.Code, Equals, http.StatusBadRequest)
+			},
+		},
+		{
+			name: "invalid limit should return status bad request",
+			reqProvider: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/history?limit=-1", nil)
+			},
+			resultChecker: func(c *C, w *httptest.ResponseRecorder) {
+				c.Assert(w.Code, Equals, http.StatusBadRequest)
+			},
+		},
+		{
+			name: "fail to get history should return status internal server error",
+			reqProvider: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/history", nil)
+			},
+			setter: func(s *MockTssServer) {
+				s.failToGetHistory = true
+			},
+			resultChecker: func(c *C, w *httptest.ResponseRecorder) {
+				c.Assert(w.Code, Equals, http.StatusInternalServerError)
+			},
+		},
+		{
+			name: "filter by type",
+			reqProvider: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/history?type=keygen", nil)
+			},
+			resultChecker: func(c *C, w *httptest.ResponseRecorder) {
+				c.Assert(w.Code, Equals, http.StatusOK)
+				var resp []storage.CeremonyRecord
+				c.Assert(json.Unmarshal(w.Body.Bytes(), &resp), IsNil)
+				c.Assert(resp, HasLen, 1)
+				c.Assert(resp[0].MsgID, Equals, "msg2")
+			},
+		},
+		{
+			name: "filter by time",
+			reqProvider: func() *http.Request {
+				from := time.Unix(1500, 0).UTC().Format(time.RFC3339)
+				return httptest.NewRequest(http.MethodGet, "/history?status=fail&from="+from, nil)
+			},
+			resultChecker: func(c *C, w *httptest.ResponseRecorder) {
+				c.Assert(w.Code, Equals, http.StatusOK)
+				var resp []storage.CeremonyRecord
+				c.Assert(json.Unmarshal(w.Body.Bytes(), &resp), IsNil)
+				c.Assert(resp, HasLen, 1)
+				c.Assert(resp[0].MsgID, Equals, "msg2")
+			},
+		},
+		{
+			name: "no match should return an empty list",
+			reqProvider: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/history?msg_id=whatever", nil)
+			},
+			resultChecker: func(c *C, w *httptest.ResponseRecorder) {
+				c.Assert(w.Code, Equals, http.StatusOK)
+				c.Assert(w.Body.String(), Equals, "[]")
+			},
+		},
+	}
+	for _, tc := range testCases {
+		c.Log(tc.name)
+		tssServer := &MockTssServer{history: history}
+		s := NewTssHttpServer("127.0.0.1:8080", tssServer)
+		c.Assert(s, NotNil)
+		if tc.setter != nil {
+			tc.setter(tssServer)
+		}
+		req := tc.reqProvider()
+		res := httptest.NewRecorder()
+		s.historyHandler(res, req)
+		tc.resultChecker(c, res)
+	}
+}
+
+func (TssHttpServerTestSuite) TestJobHandlers(c *C) {
+	tssServer := &MockTssServer{}
+	s := NewTssHttpServer("127.0.0.1:8080", tssServer)
+	c.Assert(s, NotNil)
+	handler := s.tssNewHandler()
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, httptest.NewRequest(method, target, bytes.NewBufferString(body)))
+		return res
+	}
+
+	c.Assert(serve(http.MethodPost, "/jobs/keysign", "whatever").Code, Equals, http.StatusBadRequest)
+	c.Assert(serve(http.MethodPost, "/jobs/keysign", `{"priority": "urgent"}`).Code, Equals, http.StatusBadRequest)
+	c.Assert(serve(http.MethodPost, "/jobs/keysign", `{"callback_url": "ftp://whatever"}`).Code, Equals, http.StatusBadRequest)
+	tssServer.failToSubmitJob = true
+	c.Assert(serve(http.MethodPost, "/jobs/keygen", `{"keys": []}`).Code, Equals, http.StatusInternalServerError)
+	tssServer.failToSubmitJob = false
+
+	res := serve(http.MethodPost, "/jobs/keysign", `{"pool_pub_key": "pool", "callback_url": "http://127.0.0.1/done"}`)
+	c.Assert(res.Code, Equals, http.StatusAccepted)
+	var job storage.JobRecord
+	c.Assert(json.Unmarshal(res.Body.Bytes(), &job), IsNil)
+	c.Assert(job.Type, Equals, storage.CeremonyKeysign)
+	c.Assert(job.CallbackURL, Equals, "http://127.0.0.1/done")
+	res = serve(http.MethodPost, "/jobs/keygen", `{"keys": []}`)
+	c.Assert(res.Code, Equals, http.StatusAccepted)
+
+	res = serve(http.MethodGet, "/jobs/"+job.ID, "")
+	c.Assert(res.Code, Equals, http.StatusOK)
+	var polled storage.JobRecord
+	c.Assert(json.Unmarshal(res.Body.Bytes(), &polled), IsNil)
+	c.Assert(polled.ID, Equals, job.ID)
+	c.Assert(polled.Phase, Equals, storage.JobRunning)
+	c.Assert(serve(http.MethodGet, "/jobs/ff", "").Code, Equals, http.StatusNotFound)
+	c.Assert(serve(http.MethodGet, "/jobs/whatever", "").Code, Equals, http.StatusBadRequest)
+}
+
+func (TssHttpServerTestSuite) TestEventsHandler(c *C) {
+	tssServer := &MockTssServer{
+		eventBus: monitor.NewEventBus(),
+	}
+	s := NewTssHttpServer("127.0.0.1:8080", tssServer)
+	c.Assert(s, NotNil)
+	server := httptest.NewServer(s.tssNewHandler())
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/events?msg_id=msg1", nil)
+	c.Assert(err, IsNil)
+	resp, err := http.DefaultClient.Do(req)
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+	c.Assert(resp.Header.Get("Content-Type"), Equals, "text/event-stream")
+
+	// we only get the events of the ceremony we asked for
+	tssServer.eventBus.Publish(monitor.NewEvent("msg2", monitor.EventDone, "", nil, "success"))
+	tssServer.eventBus.Publish(monitor.NewEvent("msg1", monitor.EventRoundSent, "round1", []string{"peer1"}, ""))
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	c.Assert(err, IsNil)
+	c.Assert(line, Equals, "event: round_sent\n")
+	line, err = reader.ReadString('\n')
+	c.Assert(err, IsNil)
+	c.Assert(strings.HasPrefix(line, "data: "), Equals, true)
+	var event monitor.Event
+	c.Assert(json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event), IsNil)
+	c.Assert(event.MsgID, Equals, "msg1")
+	c.Assert(event.Round, Equals, "round1")
+	c.Assert(event.Peers, DeepEquals, []string{"peer1"})
+}
+
+func (TssHttpServerTestSuite) TestAllowlistHandler(c *C) {
+	conversion.SetupBech32Prefix()
+	tssServer := &MockTssServer{}
+	s := NewTssHttpServer("127.0.0.1:8080", tssServer)
+	c.Assert(s, NotNil)
+
+	res := httptest.NewRecorder()
+	s.getAllowlistHandler(res, httptest.NewRequest(http.MethodGet, "/allowlist", nil))
+	c.Assert(res.Code, Equals, http.StatusOK)
+	c.Assert(res.Body.String(), Equals, `{"pub_keys":[]}`)
+
+	res = httptest.NewRecorder()
+	s.setAllowlistHandler(res, httptest.NewRequest(http.MethodPost, "/allowlist", bytes.NewBufferString("whatever")))
+	c.Assert(res.Code, Equals, http.StatusBadRequest)
+
+	res = httptest.NewRecorder()
+	s.setAllowlistHandler(res, httptest.NewRequest(http.MethodPost, "/allowlist", bytes.NewBufferString(`{"pub_keys":["invalid"]}`)))
+	c.Assert(res.Code, Equals, http.StatusBadRequest)
+
+	pubKey := conversion.GetRandomPubKey()
+	buf, err := json.Marshal(AllowlistRequest{PubKeys: []string{pubKey}})
+	c.Assert(err, IsNil)
+	res = httptest.NewRecorder()
+	s.setAllowlistHandler(res, httptest.NewRequest(http.MethodPost, "/allowlist", bytes.NewBuffer(buf)))
+	c.Assert(res.Code, Equals, http.StatusOK)
+
+	res = httptest.NewRecorder()
+	s.getAllowlistHandler(res, httptest.NewRequest(http.MethodGet, "/allowlist", nil))
+	c.Assert(res.Code, Equals, http.StatusOK)
+	var resp AllowlistRequest
+	c.Assert(json.Unmarshal(res.Body.Bytes(), &resp), IsNil)
+	c.Assert(resp.PubKeys, DeepEquals, []string{pubKey})
+}
+
+func (TssHttpServerTestSuite) TestNATStatusHandler(c *C) {
+	tssServer := &MockTssServer{
+		natStatus: p2p.NATStatus{
+			Reachability: p2p.ReachabilityPrivate,
+			Addresses:    []string{"/ip4/11.22.33.44/tcp/6668/p2p/16Uiu2HAm4TmEzUqy3q3Dv7HvdoSboHk5sFj2FH3npiN5vDbJC6gh/p2p-circuit"},
+		},
+	}
+	s := NewTssHttpServer("127.0.0.1:8080", tssServer)
+	c.Assert(s, NotNil)
+	res := httptest.NewRecorder()
+	s.natStatusHandler(res, httptest.NewRequest(http.MethodGet, "/nat", nil))
+	c.Assert(res.Code, Equals, http.StatusOK)
+	var status p2p.NATStatus
+	c.Assert(json.Unmarshal(res.Body.Bytes(), &status), IsNil)
+	c.Assert(status, DeepEquals, tssServer.natStatus)
+}
+
+func (TssHttpServerTestSuite) TestPeersHealthHandler(c *C) {
+	tssServer := &MockTssServer{}
+	s := NewTssHttpServer("127.0.0.1:8080", tssServer)
+	c.Assert(s, NotNil)
+	res := httptest.NewRecorder()
+	s.peersHealthHandler(res, httptest.NewRequest(http.MethodGet, "/peers/health", nil))
+	c.Assert(res.Code, Equals, http.StatusOK)
+	c.Assert(res.Body.String(), Equals, "[]")
+
+	tssServer.peersHealth = []p2p.PeerHealth{
+		{
+			PeerID:    "16Uiu2HAm4TmEzUqy3q3Dv7HvdoSboHk5sFj2FH3npiN5vDbJC6gh",
+			Reachable: true,
+			LastSeen:  time.Now().UTC().Truncate(time.Second),
+			Successes: 10,
+			LastRTT:   time.Millisecond,
+			RTTP50:    time.Millisecond,
+			RTTP90:    2 * time.Millisecond,
+			RTTP99:    3 * time.Millisecond,
+		},
+	}
+	res = httptest.NewRecorder()
+	s.peersHealthHandler(res, httptest.NewRequest(http.MethodGet, "/peers/health", nil))
+	c.Assert(res.Code, Equals, http.StatusOK)
+	var health []p2p.PeerHealth
+	c.Assert(json.Unmarshal(res.Body.Bytes(), &health), IsNil)
+	c.Assert(health, DeepEquals, tssServer.peersHealth)
+}
+
+func (TssHttpServerTestSuite) TestPeerVersionsHandler(c *C) {
+	tssServer := &MockTssServer{}
+	s := NewTssHttpServer("127.0.0.1:8080", tssServer)
+	c.Assert(s, NotNil)
+	res := httptest.NewRecorder()
+	s.peerVersionsHandler(res, httptest.NewRequest(http.MethodGet, "/peers/versions", nil))
+	c.Assert(res.Code, Equals, http.StatusOK)
+	c.Assert(res.Body.String(), Equals, "[]")
+
+	peerID, err := peer.Decode("16Uiu2HAm4TmEzUqy3q3Dv7HvdoSboHk5sFj2FH3npiN5vDbJC6gh")
+	c.Assert(err, IsNil)
+	tssServer.peerVersions = []p2p.PeerVersion{
+		{
+			PeerID:    peerID,
+			Version:   messages.PROTOBUFWIREVERSION,
+			Features:  messages.Features(messages.PROTOBUFWIREVERSION),
+			Legacy:    true,
+			UpdatedAt: time.Now().UTC().Truncate(time.Second),
+		},
+	}
+	res = httptest.NewRecorder()
+	s.peerVersionsHandler(res, httptest.NewRequest(http.MethodGet, "/peers/versions", nil))
+	c.Assert(res.Code, Equals, http.StatusOK)
+	var versions []p2p.PeerVersion
+	c.Assert(json.Unmarshal(res.Body.Bytes(), &versions), IsNil)
+	c.Assert(versions, DeepEquals, tssServer.peerVersions)
+}
@@ -0,0 +1,97 @@
+package common
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/tendermint/tendermint/crypto/secp256k1"
+
+	"github.com/ordinox/thorchain-tss/messages"
+)
+
+var (
+	ErrStaleEnvelope     = errors.New("the message belongs to another ceremony session")
+	ErrDuplicateEnvelope = errors.New("the message has already been received")
+)
+
+// SetSessionNonce set the nonce the leader picked for this ceremony session, once it is set our messages carry
+// it along with their sequence number, and we only accept the messages of the same session
+func (t *TssCommon) SetSessionNonce(nonce []byte) {
+	t.envelopeLock.Lock()
+	defer t.envelopeLock.Unlock()
+	t.sessionNonce = nonce
+}
+
+// GetSessionNonce return the nonce of this ceremony session, it is empty for the legacy ceremonies
+func (t *TssCommon) GetSessionNonce() []byte {
+	t.envelopeLock.Lock()
+	defer t.envelopeLock.Unlock()
+	return t.sessionNonce
+}
+
+// sealEnvelope set the session nonce and the next sequence number of the message
+func (t *TssCommon) sealEnvelope(wireMsg *messages.WireMessage) {
+	t.envelopeLock.Lock()
+	defer t.envelopeLock.Unlock()
+	if len(t.sessionNonce) == 0 {
+		return
+	}
+	t.seq++
+	wireMsg.SessionNonce = t.sessionNonce
+	wireMsg.Seq = t.seq
+}
+
+// envelopeData is the data the sender signs, it binds the message to its session and sequence number so neither
+// can be replaced, the legacy messages without a nonce sign the message alone
+func envelopeData(msg, nonce []byte, seq uint64) []byte {
+	if len(nonce) == 0 {
+		return msg
+	}
+	var buf bytes.Buffer
+	buf.Write(msg)
+	buf.Write(nonce)
+	_ = binary.Write(&buf, binary.BigEndian, seq)
+	return buf.Bytes()
+}
+
+// checkEnvelope verify the signature of the message, and reject it if it belongs to another session, or we have
+// already received its sequence number from the sender. The forwarded messages are the ones we requested after
+// the hash check, so we do not check their sequence number.
+func (t *TssCommon) checkEnvelope(wireMsg *messages.WireMessage, forward bool) error {
+	if wireMsg == nil || wireMsg.Routing == nil || wireMsg.Routing.From == nil {
+		t.logger.Warn().Msg("received msg invalid")
+		return errors.New("invalid wireMsg")
+	}
+	partyIDMap := t.getPartyInfo().PartyIDMap
+	dataOwner, ok := partyIDMap[wireMsg.Routing.From.Id]
+	if !ok {
+		t.logger.Error().Msg("error in find the data owner")
+		return errors.New("error in find the data owner")
+	}
+	var pk secp256k1.PubKey = dataOwner.GetKey()
+	if !verifySignature(pk, envelopeData(wireMsg.Message, wireMsg.SessionNonce, wireMsg.Seq), wireMsg.Sig, t.msgID) {
+		t.logger.Error().Msg("fail to verify the signature")
+		return errors.New("signature verify failed")
+	}
+
+	t.envelopeLock.Lock()
+	defer t.envelopeLock.Unlock()
+	if !bytes.Equal(wireMsg.SessionNonce, t.sessionNonce) {
+		return fmt.Errorf("%w: message from party %s", ErrStaleEnvelope, wireMsg.Routing.From.Id)
+	}
+	if len(t.sessionNonce) == 0 || forward {
+		return nil
+	}
+	seen, ok := t.seenSeqs[wireMsg.Routing.From.Id]
+	if !ok {
+		seen = make(map[uint64]bool)
+		t.seenSeqs[wireMsg.Routing.From.Id] = seen
+	}
+	if seen[wireMsg.Seq] {
+		return fmt.Errorf("%w: message %d from party %s", ErrDuplicateEnvelope, wireMsg.Seq, wireMsg.Routing.From.Id)
+	}
+	seen[wireMsg.Seq] = true
+	return nil
+}
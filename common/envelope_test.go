@@ -0,0 +1,109 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+
+	btss "github.com/ordinox/thorchain-tss-lib/tss"
+	tcrypto "github.com/tendermint/tendermint/crypto"
+	. "gopkg.in/check.v1"
+
+	"github.com/ordinox/thorchain-tss/messages"
+)
+
+func fabricateEnvelope(c *C, privKey tcrypto.PrivKey, partyID *btss.PartyID, nonce []byte, seq uint64, msgID string) *messages.WireMessage {
+	routingInfo := btss.MessageRouting{
+		From:        partyID,
+		IsBroadcast: true,
+	}
+	buf, err := json.Marshal([]BulkWireMsg{NewBulkWireMsg([]byte("testEnvelope"), "tester", &routingInfo)})
+	c.Assert(err, IsNil)
+	sig, err := generateSignature(envelopeData(buf, nonce, seq), msgID, privKey)
+	c.Assert(err, IsNil)
+	return &messages.WireMessage{
+		Routing:      &routingInfo,
+		RoundInfo:    "round testEnvelope",
+		Message:      buf,
+		Sig:          sig,
+		SessionNonce: nonce,
+		Seq:          seq,
+	}
+}
+
+func (t *TssTestSuite) TestCheckEnvelope(c *C) {
+	tssCommonStruct, _, partiesID := setupProcessVerMsgEnv(c, t.privKey, testBlamePubKeys, 4)
+	sender := findSender(partiesID)
+	msgID := tssCommonStruct.msgID
+	nonce := []byte("session nonce")
+
+	// the legacy ceremonies accept the messages without a nonce as many times as they come
+	legacyMsg := fabricateEnvelope(c, t.privKey, sender, nil, 0, msgID)
+	c.Assert(tssCommonStruct.checkEnvelope(legacyMsg, false), IsNil)
+	c.Assert(tssCommonStruct.checkEnvelope(legacyMsg, false), IsNil)
+	c.Assert(tssCommonStruct.checkEnvelope(fabricateEnvelope(c, t.privKey, sender, nonce, 1, msgID), false), ErrorMatches, ErrStaleEnvelope.Error()+".*")
+
+	tssCommonStruct.SetSessionNonce(nonce)
+	c.Assert(tssCommonStruct.GetSessionNonce(), DeepEquals, nonce)
+	c.Assert(errors.Is(tssCommonStruct.checkEnvelope(legacyMsg, false), ErrStaleEnvelope), Equals, true)
+	staleMsg := fabricateEnvelope(c, t.privKey, sender, []byte("previous session"), 1, msgID)
+	c.Assert(errors.Is(tssCommonStruct.checkEnvelope(staleMsg, false), ErrStaleEnvelope), Equals, true)
+
+	msg := fabricateEnvelope(c, t.privKey, sender, nonce, 1, msgID)
+	c.Assert(tssCommonStruct.checkEnvelope(msg, false), IsNil)
+	c.Assert(errors.Is(tssCommonStruct.checkEnvelope(msg, false), ErrDuplicateEnvelope), Equals, true)
+	// the forwarded messages are requested, they may carry a sequence number we have seen
+	c.Assert(tssCommonStruct.checkEnvelope(msg, true), IsNil)
+	c.Assert(tssCommonStruct.checkEnvelope(fabricateEnvelope(c, t.privKey, sender, nonce, 2, msgID), false), IsNil)
+
+	// the sequence number is signed
+	tampered := fabricateEnvelope(c, t.privKey, sender, nonce, 3, msgID)
+	tampered.Seq = 4
+	c.Assert(tssCommonStruct.checkEnvelope(tampered, false), ErrorMatches, "signature verify failed")
+
+	// the duplicates never reach processTSSMsg
+	payload, err := msg.Marshal(messages.WireFormatJSON)
+	c.Assert(err, IsNil)
+	err = tssCommonStruct.ProcessOneMessage(&messages.WrappedMessage{
+		MessageType: messages.TSSKeyGenMsg,
+		Payload:     payload,
+	}, tssCommonStruct.PartyIDtoP2PID[sender.Id].String())
+	c.Assert(errors.Is(err, ErrDuplicateEnvelope), Equals, true)
+	c.Assert(tssCommonStruct.TryGetLocalCacheItem(msg.GetCacheKey()), IsNil)
+}
+
+func (t *TssTestSuite) TestSealEnvelope(c *C) {
+	tssCommonStruct := NewTssCommon("", nil, TssConfig{}, "test", t.privKey, 1)
+	var wireMsg messages.WireMessage
+	tssCommonStruct.sealEnvelope(&wireMsg)
+	c.Assert(wireMsg.SessionNonce, IsNil)
+	c.Assert(wireMsg.Seq, Equals, uint64(0))
+
+	nonce := []byte("session nonce")
+	tssCommonStruct.SetSessionNonce(nonce)
+	for i := 1; i <= 2; i++ {
+		tssCommonStruct.sealEnvelope(&wireMsg)
+		c.Assert(wireMsg.SessionNonce, DeepEquals, nonce)
+		c.Assert(wireMsg.Seq, Equals, uint64(i))
+	}
+}
+
+// the peer stream delivers at least once, a share resent after a lost acknowledgement is only taken once, see
+// testVerMsgDuplication for the ceremonies without session nonce and testVerMsgAndUpdateFromPeer for the confirmations
+func (t *TssTestSuite) TestResentMessage(c *C) {
+	tssCommonStruct, _, partiesID := setupProcessVerMsgEnv(c, t.privKey, testBlamePubKeys, 4)
+	sender := findSender(partiesID)
+	senderPeer := tssCommonStruct.PartyIDtoP2PID[sender.Id].String()
+	nonce := []byte("session nonce")
+	tssCommonStruct.SetSessionNonce(nonce)
+	msg := fabricateEnvelope(c, t.privKey, sender, nonce, 1, tssCommonStruct.msgID)
+	payload, err := msg.Marshal(messages.WireFormatJSON)
+	c.Assert(err, IsNil)
+	wrappedMsg := &messages.WrappedMessage{MessageType: messages.TSSKeyGenMsg, Payload: payload}
+	c.Assert(tssCommonStruct.ProcessOneMessage(wrappedMsg, senderPeer), IsNil)
+	localItem := tssCommonStruct.TryGetLocalCacheItem(msg.GetCacheKey())
+	c.Assert(localItem, NotNil)
+	c.Assert(localItem.ConfirmedList, HasLen, 1)
+	err = tssCommonStruct.ProcessOneMessage(wrappedMsg, senderPeer)
+	c.Assert(errors.Is(err, ErrDuplicateEnvelope), Equals, true)
+	c.Assert(localItem.ConfirmedList, HasLen, 1)
+}
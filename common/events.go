@@ -0,0 +1,28 @@
+package common
+
+import (
+	"github.com/ordinox/thorchain-tss/messages"
+	"github.com/ordinox/thorchain-tss/monitor"
+)
+
+// SetEventBus set the bus we publish the progress of this ceremony on
+func (t *TssCommon) SetEventBus(bus *monitor.EventBus) {
+	t.eventBus = bus
+}
+
+// publishEvent publish a progress event of this ceremony
+func (t *TssCommon) publishEvent(eventType monitor.EventType, round string, peers []string, detail string) {
+	t.eventBus.Publish(monitor.NewEvent(t.msgID, eventType, round, peers, detail))
+}
+
+// senderOf return the peer id of the sender of the wire message, it is empty if the sender is unknown
+func (t *TssCommon) senderOf(wireMsg *messages.WireMessage) []string {
+	if wireMsg == nil || wireMsg.Routing == nil || wireMsg.Routing.From == nil {
+		return nil
+	}
+	peerID, ok := t.PartyIDtoP2PID[wireMsg.Routing.From.Id]
+	if !ok {
+		return nil
+	}
+	return []string{peerID.String()}
+}
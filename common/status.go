@@ -7,3 +7,15 @@ const (
 	Success
 	Fail
 )
+
+// String implement fmt.Stringer
+func (s Status) String() string {
+	switch s {
+	case Success:
+		return "success"
+	case Fail:
+		return "fail"
+	default:
+		return "na"
+	}
+}
@@ -1,23 +1,23 @@
 package common
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"runtime"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/libp2p/go-libp2p/core/peer"
 	btss "github.com/ordinox/thorchain-tss-lib/tss"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	tcrypto "github.com/tendermint/tendermint/crypto"
-	"github.com/tendermint/tendermint/crypto/secp256k1"
 
 	"github.com/ordinox/thorchain-tss/blame"
 	"github.com/ordinox/thorchain-tss/conversion"
 	"github.com/ordinox/thorchain-tss/messages"
+	"github.com/ordinox/thorchain-tss/monitor"
 	"github.com/ordinox/thorchain-tss/p2p"
 )
 
@@ -51,6 +51,13 @@ type TssCommon struct {
 	cachedWireBroadcastMsgLists *sync.Map
 	cachedWireUnicastMsgLists   *sync.Map
 	msgNum                      int
+	wireFormat                  messages.WireFormat
+	deliveryTimeout             time.Duration
+	envelopeLock                *sync.Mutex
+	sessionNonce                []byte
+	seq                         uint64
+	seenSeqs                    map[string]map[uint64]bool
+	eventBus                    *monitor.EventBus
 }
 
 func NewTssCommon(peerID string, broadcastChannel chan *messages.BroadcastMsgChan, conf TssConfig, msgID string, privKey tcrypto.PrivKey, msgNum int) *TssCommon {
@@ -76,6 +83,9 @@ func NewTssCommon(peerID string, broadcastChannel chan *messages.BroadcastMsgCha
 		cachedWireBroadcastMsgLists: &sync.Map{},
 		cachedWireUnicastMsgLists:   &sync.Map{},
 		msgNum:                      msgNum,
+		wireFormat:                  messages.WireFormatJSON,
+		envelopeLock:                &sync.Mutex{},
+		seenSeqs:                    make(map[string]map[uint64]bool),
 	}
 }
 
@@ -146,9 +156,19 @@ func (t *TssCommon) renderToP2P(broadcastMsg *messages.BroadcastMsgChan) {
 		t.logger.Warn().Msg("broadcast channel is not set")
 		return
 	}
+	broadcastMsg.Format = t.wireFormat
+	if t.deliveryTimeout > 0 {
+		broadcastMsg.Deadline = time.Now().Add(t.deliveryTimeout)
+	}
 	t.broadcastChannel <- broadcastMsg
 }
 
+// SetDeliveryTimeout set how long the p2p layer keeps retrying to deliver our messages, it should match the
+// ceremony timeout, as the messages are of no use once the ceremony times out
+func (t *TssCommon) SetDeliveryTimeout(timeout time.Duration) {
+	t.deliveryTimeout = timeout
+}
+
 // GetConf get current configuration for Tss
 func (t *TssCommon) GetConf() TssConfig {
 	return t.conf
@@ -247,8 +267,7 @@ func (t *TssCommon) updateLocal(wireMsg *messages.WireMessage) error {
 		t.blameMgr.SetLastUnicastPeer(dataOwnerPeerID, wireMsg.RoundInfo)
 	}
 
-	var bulkMsg []BulkWireMsg
-	err := json.Unmarshal(wireMsg.Message, &bulkMsg)
+	bulkMsg, err := UnmarshalBulkWireMsgs(wireMsg.Message)
 	if err != nil {
 		t.logger.Error().Err(err).Msg("error to unmarshal the BulkMsg")
 		return err
@@ -344,13 +363,16 @@ func (t *TssCommon) ProcessOneMessage(wrappedMsg *messages.WrappedMessage, peerI
 	switch wrappedMsg.MessageType {
 	case messages.TSSKeyGenMsg, messages.TSSKeySignMsg:
 		var wireMsg messages.WireMessage
-		if err := json.Unmarshal(wrappedMsg.Payload, &wireMsg); nil != err {
+		if err := wireMsg.Unmarshal(wrappedMsg.Payload); nil != err {
 			return fmt.Errorf("fail to unmarshal wire message: %w", err)
 		}
+		if err := t.checkEnvelope(&wireMsg, false); err != nil {
+			return err
+		}
 		return t.processTSSMsg(&wireMsg, wrappedMsg.MessageType, false)
 	case messages.TSSKeyGenVerMsg, messages.TSSKeySignVerMsg:
 		var bMsg messages.BroadcastConfirmMessage
-		if err := json.Unmarshal(wrappedMsg.Payload, &bMsg); nil != err {
+		if err := bMsg.Unmarshal(wrappedMsg.Payload); nil != err {
 			return errors.New("fail to unmarshal broadcast confirm message")
 		}
 		// we check whether this peer has already send us the VerMsg before update
@@ -360,7 +382,7 @@ func (t *TssCommon) ProcessOneMessage(wrappedMsg *messages.WrappedMessage, peerI
 		}
 	case messages.TSSTaskDone:
 		var wireMsg messages.TssTaskNotifier
-		err := json.Unmarshal(wrappedMsg.Payload, &wireMsg)
+		err := wireMsg.Unmarshal(wrappedMsg.Payload)
 		if err != nil {
 			t.logger.Error().Err(err).Msg("fail to unmarshal the notify message")
 			return nil
@@ -379,7 +401,7 @@ func (t *TssCommon) ProcessOneMessage(wrappedMsg *messages.WrappedMessage, peerI
 		}
 	case messages.TSSControlMsg:
 		var wireMsg messages.TssControl
-		if err := json.Unmarshal(wrappedMsg.Payload, &wireMsg); nil != err {
+		if err := wireMsg.Unmarshal(wrappedMsg.Payload); nil != err {
 			return fmt.Errorf("fail to unmarshal wire message: %w", err)
 		}
 		if wireMsg.Msg == nil {
@@ -396,6 +418,9 @@ func (t *TssCommon) ProcessOneMessage(wrappedMsg *messages.WrappedMessage, peerI
 			return nil
 		}
 		t.logger.Debug().Msg("we got the missing share from the peer")
+		if err := t.checkEnvelope(wireMsg.Msg, true); err != nil {
+			return err
+		}
 		return t.processTSSMsg(wireMsg.Msg, wireMsg.RequestType, true)
 	}
 
@@ -454,24 +479,23 @@ func (t *TssCommon) sendBulkMsg(wiredMsgType string, tssMsgType messages.THORCha
 	// we just need to get the routing info of the first message
 	r := wiredMsgList[0].Routing
 
-	buf, err := json.Marshal(wiredMsgList)
+	buf, err := MarshalBulkWireMsgs(t.wireFormat, wiredMsgList)
 	if err != nil {
 		return fmt.Errorf("error in marshal the cachedWireMsg: %w", err)
 	}
 
-	sig, err := generateSignature(buf, t.msgID, t.privateKey)
-	if err != nil {
-		t.logger.Error().Err(err).Msg("fail to generate the share's signature")
-		return err
-	}
-
 	wireMsg := messages.WireMessage{
 		Routing:   r,
 		RoundInfo: wiredMsgType,
 		Message:   buf,
-		Sig:       sig,
 	}
-	wireMsgBytes, err := json.Marshal(wireMsg)
+	t.sealEnvelope(&wireMsg)
+	wireMsg.Sig, err = generateSignature(envelopeData(buf, wireMsg.SessionNonce, wireMsg.Seq), t.msgID, t.privateKey)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to generate the share's signature")
+		return err
+	}
+	wireMsgBytes, err := wireMsg.Marshal(t.wireFormat)
 	if err != nil {
 		return fmt.Errorf("fail to convert tss msg to wire bytes: %w", err)
 	}
@@ -500,6 +524,7 @@ func (t *TssCommon) sendBulkMsg(wiredMsgType string, tssMsgType messages.THORCha
 		WrappedMessage: wrappedMsg,
 		PeersID:        peerIDs,
 	})
+	t.publishEvent(monitor.EventRoundSent, wiredMsgType, conversion.PeerIDsToStrings(peerIDs), "")
 
 	return nil
 }
@@ -591,8 +616,10 @@ func (t *TssCommon) applyShare(localCacheItem *LocalCacheItem, threshold int, ke
 		}
 		blameNode := blame.NewNode(blamePk, localCacheItem.Msg.Message, localCacheItem.Msg.Sig)
 		t.blameMgr.GetBlame().SetBlame(blame.HashCheckFail, []blame.Node{blameNode}, unicast, t.RoundInfo)
+		t.publishEvent(monitor.EventBlame, localCacheItem.Msg.RoundInfo, []string{blamePk}, blame.HashCheckFail)
 		return blame.ErrHashCheck
 	}
+	t.publishEvent(monitor.EventHashConfirmed, localCacheItem.Msg.RoundInfo, t.senderOf(localCacheItem.Msg), "")
 
 	t.blameMgr.GetRoundMgr().Set(key, localCacheItem.Msg)
 	if err := t.updateLocal(localCacheItem.Msg); nil != err {
@@ -690,7 +717,7 @@ func (t *TssCommon) broadcastHashToPeers(key, msgHash string, peerIDs []peer.ID,
 		Key:   key,
 		Hash:  msgHash,
 	}
-	buf, err := json.Marshal(broadcastConfirmMsg)
+	buf, err := broadcastConfirmMsg.Marshal(t.wireFormat)
 	if err != nil {
 		return fmt.Errorf("fail to marshal borad cast confirm message: %w", err)
 	}
@@ -738,29 +765,11 @@ func (t *TssCommon) receiverBroadcastHashToPeers(wireMsg *messages.WireMessage,
 	return nil
 }
 
-// processTSSMsg
+// processTSSMsg apply the message, its envelope must have been checked by checkEnvelope
 func (t *TssCommon) processTSSMsg(wireMsg *messages.WireMessage, msgType messages.THORChainTSSMessageType, forward bool) error {
 	t.logger.Debug().Msg("process wire message")
 	defer t.logger.Debug().Msg("finish process wire message")
-
-	if wireMsg == nil || wireMsg.Routing == nil || wireMsg.Routing.From == nil {
-		t.logger.Warn().Msg("received msg invalid")
-		return errors.New("invalid wireMsg")
-	}
-	partyIDMap := t.getPartyInfo().PartyIDMap
-	dataOwner, ok := partyIDMap[wireMsg.Routing.From.Id]
-	if !ok {
-		t.logger.Error().Msg("error in find the data owner")
-		return errors.New("error in find the data owner")
-	}
-	keyBytes := dataOwner.GetKey()
-	var pk secp256k1.PubKey
-	pk = keyBytes
-	ok = verifySignature(pk, wireMsg.Message, wireMsg.Sig, t.msgID)
-	if !ok {
-		t.logger.Error().Msg("fail to verify the signature")
-		return errors.New("signature verify failed")
-	}
+	t.publishEvent(monitor.EventRoundReceived, wireMsg.RoundInfo, t.senderOf(wireMsg), "")
 
 	// for the unicast message, we only update it local party
 	if !wireMsg.Routing.IsBroadcast {
@@ -861,7 +870,7 @@ func (t *TssCommon) ProcessInboundMessages(finishChan chan struct{}, wg *sync.Wa
 				return
 			}
 			var wrappedMsg messages.WrappedMessage
-			if err := json.Unmarshal(m.Payload, &wrappedMsg); nil != err {
+			if err := wrappedMsg.Unmarshal(m.Payload); nil != err {
 				t.logger.Error().Err(err).Msg("fail to unmarshal wrapped message bytes")
 				continue
 			}
@@ -5,7 +5,6 @@ import (
 	"crypto/elliptic"
 	"crypto/sha256"
 	"encoding/hex"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -220,7 +219,7 @@ func GetMsgRound(msg []byte, partyID *btss.PartyID, isBroadcast bool) (blame.Rou
 
 func (t *TssCommon) NotifyTaskDone() error {
 	msg := messages.TssTaskNotifier{TaskDone: true}
-	data, err := json.Marshal(msg)
+	data, err := msg.Marshal(t.wireFormat)
 	if err != nil {
 		return fmt.Errorf("fail to marshal the request body %w", err)
 	}
@@ -254,7 +253,7 @@ func (t *TssCommon) processRequestMsgFromPeer(peersID []peer.ID, msg *messages.T
 		msg.Msg = storedMsg
 	}
 
-	data, err := json.Marshal(msg)
+	data, err := msg.Marshal(t.wireFormat)
 	if err != nil {
 		return fmt.Errorf("fail to marshal the request body %w", err)
 	}
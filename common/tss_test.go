@@ -23,6 +23,7 @@ import (
 	"github.com/ordinox/thorchain-tss/blame"
 	"github.com/ordinox/thorchain-tss/conversion"
 	"github.com/ordinox/thorchain-tss/messages"
+	"github.com/ordinox/thorchain-tss/monitor"
 	"github.com/ordinox/thorchain-tss/p2p"
 )
 
@@ -398,7 +399,21 @@ func (t *TssTestSuite) TestProcessVerMessage(c *C) {
 	t.testVerMsgDuplication(c, t.privKey, tssCommonStruct, sender, peerPartiesID)
 	t.testVerMsgAndUpdateFromPeer(c, tssCommonStruct, sender, partiesID)
 	t.testDropMsgOwner(c, t.privKey, tssCommonStruct, sender, peerPartiesID)
+	bus := monitor.NewEventBus()
+	events, cancelEvents := bus.Subscribe("")
+	tssCommonStruct.SetEventBus(bus)
 	t.testVerMsgAndUpdate(c, tssCommonStruct, sender, partiesID)
+	tssCommonStruct.SetEventBus(nil)
+	cancelEvents()
+	// we published the message we received and its confirmed hash
+	senderPeer := tssCommonStruct.PartyIDtoP2PID[sender.Id].String()
+	var eventTypes []monitor.EventType
+	for event := range events {
+		eventTypes = append(eventTypes, event.Type)
+		c.Assert(event.Round, Equals, "round testVerMsgAndUpdate")
+		c.Assert(event.Peers, DeepEquals, []string{senderPeer})
+	}
+	c.Assert(eventTypes, DeepEquals, []monitor.EventType{monitor.EventRoundReceived, monitor.EventHashConfirmed})
 	t.testProcessControlMsg(c, tssCommonStruct)
 	t.testProcessTaskDone(c, tssCommonStruct)
 }
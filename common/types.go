@@ -2,6 +2,8 @@ package common
 
 import (
 	"time"
+
+	"github.com/ordinox/thorchain-tss/p2p"
 )
 
 type TssConfig struct {
@@ -15,4 +17,25 @@ type TssConfig struct {
 	PreParamTimeout time.Duration
 	// enable the tss monitor
 	EnableMonitor bool
+	// JournalRetention defines how long do we keep the ceremony journal entries, 0 means forever
+	JournalRetention time.Duration
+	// JournalMaxEntries defines how many ceremony journal entries do we keep at most, 0 means no limit
+	JournalMaxEntries int
+	// EnableCompression compress the large tss messages sent to the peers that support it
+	EnableCompression bool
+	// MaxConcurrentKeysigns defines how many keysigns run at the same time, 0 means no limit
+	MaxConcurrentKeysigns int
+	// MaxConcurrentKeysignsPerPool defines how many keysigns of the same pool run at the same time, 0 means no limit
+	MaxConcurrentKeysignsPerPool int
+	// KeysignQueueSize defines how many keysigns of each priority wait for the limits before we reject them
+	KeysignQueueSize int
+	// CeremonyCacheTTL defines how long do we return the outcome of a successful keysign to the retries of its
+	// request, 0 means the retries only attach to the ceremonies still running, the keygen retries always do
+	CeremonyCacheTTL time.Duration
+	// JobRetention defines how long do we keep the finished keygen/keysign jobs, 0 means forever
+	JobRetention time.Duration
+	// AllowedPubKeys is the allowlist of the node pub keys we accept p2p connections from, empty allows everyone
+	AllowedPubKeys []string
+	// Network holds the timeouts and limits of the p2p layer, the fields left to zero use the default values
+	Network p2p.NetworkConfig
 }
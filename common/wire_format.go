@@ -0,0 +1,55 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ordinox/thorchain-tss/messages"
+)
+
+// MarshalBulkWireMsgs encode the bulk messages with the given wire format
+func MarshalBulkWireMsgs(format messages.WireFormat, msgs []BulkWireMsg) ([]byte, error) {
+	switch format {
+	case messages.WireFormatJSON:
+		return json.Marshal(msgs)
+	case messages.WireFormatProtobuf:
+		list := messages.BulkWireMsgListPb{}
+		for _, el := range msgs {
+			list.Msgs = append(list.Msgs, &messages.BulkWireMsgPb{
+				WiredBulkMsgs: el.WiredBulkMsgs,
+				MsgIdentifier: el.MsgIdentifier,
+				Routing:       messages.RoutingToProto(el.Routing),
+			})
+		}
+		return messages.MarshalProtobuf(&list)
+	default:
+		return nil, fmt.Errorf("unknown wire format %d", format)
+	}
+}
+
+// UnmarshalBulkWireMsgs decode the bulk messages, the wire format is given by its first byte
+func UnmarshalBulkWireMsgs(buf []byte) ([]BulkWireMsg, error) {
+	var bulkMsg []BulkWireMsg
+	if messages.WireFormatOf(buf) == messages.WireFormatJSON {
+		err := json.Unmarshal(buf, &bulkMsg)
+		return bulkMsg, err
+	}
+	var list messages.BulkWireMsgListPb
+	if err := messages.UnmarshalProtobuf(buf, &list); err != nil {
+		return nil, err
+	}
+	for _, el := range list.Msgs {
+		bulkMsg = append(bulkMsg, NewBulkWireMsg(el.WiredBulkMsgs, el.MsgIdentifier, messages.RoutingFromProto(el.Routing)))
+	}
+	return bulkMsg, nil
+}
+
+// SetWireFormat set the encoding we use to send the messages of this ceremony
+func (t *TssCommon) SetWireFormat(format messages.WireFormat) {
+	t.wireFormat = format
+}
+
+// GetWireFormat return the encoding we use to send the messages of this ceremony
+func (t *TssCommon) GetWireFormat() messages.WireFormat {
+	return t.wireFormat
+}
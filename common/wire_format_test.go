@@ -0,0 +1,104 @@
+package common
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	btss "github.com/ordinox/thorchain-tss-lib/tss"
+	. "gopkg.in/check.v1"
+
+	"github.com/ordinox/thorchain-tss/messages"
+)
+
+type WireFormatTestSuite struct{}
+
+var _ = Suite(&WireFormatTestSuite{})
+
+// newTestBulkMsgs create a batch of messages of the given size, it mimics the keygen round messages that
+// carry the paillier proofs
+func newTestBulkMsgs(batch, shareSize int) []BulkWireMsg {
+	from := btss.NewPartyID("1", "moniker1", new(big.Int).SetBytes([]byte("key1")))
+	routing := &btss.MessageRouting{
+		From:        from,
+		IsBroadcast: true,
+	}
+	var msgs []BulkWireMsg
+	for i := 0; i < batch; i++ {
+		share := make([]byte, shareSize)
+		_, _ = rand.Read(share)
+		msgs = append(msgs, NewBulkWireMsg(share, "moniker", routing))
+	}
+	return msgs
+}
+
+func encodeTestTssMessage(format messages.WireFormat, msgs []BulkWireMsg) ([]byte, error) {
+	buf, err := MarshalBulkWireMsgs(format, msgs)
+	if err != nil {
+		return nil, err
+	}
+	wireMsg := messages.WireMessage{
+		Routing:   msgs[0].Routing,
+		RoundInfo: "KGRound2Message1",
+		Message:   buf,
+		Sig:       make([]byte, 64),
+	}
+	wireMsgBytes, err := wireMsg.Marshal(format)
+	if err != nil {
+		return nil, err
+	}
+	wrappedMsg := messages.WrappedMessage{
+		MessageType: messages.TSSKeyGenMsg,
+		MsgID:       "msgID",
+		Payload:     wireMsgBytes,
+	}
+	return wrappedMsg.Marshal(format)
+}
+
+func (WireFormatTestSuite) TestBulkWireMsgs(c *C) {
+	msgs := newTestBulkMsgs(3, 128)
+	for _, format := range []messages.WireFormat{messages.WireFormatJSON, messages.WireFormatProtobuf} {
+		buf, err := MarshalBulkWireMsgs(format, msgs)
+		c.Assert(err, IsNil)
+		decoded, err := UnmarshalBulkWireMsgs(buf)
+		c.Assert(err, IsNil)
+		c.Assert(decoded, HasLen, len(msgs))
+		for i, el := range decoded {
+			c.Assert(el.WiredBulkMsgs, DeepEquals, msgs[i].WiredBulkMsgs)
+			c.Assert(el.MsgIdentifier, Equals, msgs[i].MsgIdentifier)
+			c.Assert(el.Routing.From.Id, Equals, msgs[i].Routing.From.Id)
+			c.Assert(el.Routing.IsBroadcast, Equals, true)
+		}
+	}
+	_, err := MarshalBulkWireMsgs(messages.WireFormat(100), msgs)
+	c.Assert(err, NotNil)
+	_, err = UnmarshalBulkWireMsgs([]byte("invalid"))
+	c.Assert(err, NotNil)
+}
+
+func (WireFormatTestSuite) TestPayloadSize(c *C) {
+	msgs := newTestBulkMsgs(3, 4096)
+	jsonBuf, err := encodeTestTssMessage(messages.WireFormatJSON, msgs)
+	c.Assert(err, IsNil)
+	protoBuf, err := encodeTestTssMessage(messages.WireFormatProtobuf, msgs)
+	c.Assert(err, IsNil)
+	// the share is base64 encoded twice in json, so it is almost doubled
+	c.Assert(len(protoBuf)*3/2 < len(jsonBuf), Equals, true)
+}
+
+func BenchmarkWireFormat(b *testing.B) {
+	msgs := newTestBulkMsgs(10, 16*1024)
+	for _, format := range []messages.WireFormat{messages.WireFormatJSON, messages.WireFormatProtobuf} {
+		b.Run(format.String(), func(b *testing.B) {
+			var size int
+			for i := 0; i < b.N; i++ {
+				buf, err := encodeTestTssMessage(format, msgs)
+				if err != nil {
+					b.Fatal(err)
+				}
+				size = len(buf)
+			}
+			b.ReportMetric(float64(size), "payload-bytes")
+		})
+	}
+}
@@ -54,6 +54,15 @@ func GetPeerIDs(pubkeys []string) ([]peer.ID, error) {
 	return peerIDs, nil
 }
 
+// PeerIDsToStrings return the string form of the given peer ids
+func PeerIDsToStrings(peerIDs []peer.ID) []string {
+	result := make([]string, len(peerIDs))
+	for i, el := range peerIDs {
+		result[i] = el.String()
+	}
+	return result
+}
+
 // GetPubKeysFromPeerIDs given a list of peer ids, and get a list og pub keys.
 func GetPubKeysFromPeerIDs(peers []string) ([]string, error) {
 	var result []string
@@ -62,6 +62,10 @@ func (KeyProviderTestSuite) TestGetPeerIDs(c *C) {
 	c.Assert(peers, HasLen, 2)
 	c.Assert(peers[0].String(), Equals, "16Uiu2HAmBdJRswX94UwYj6VLhh4GeUf9X3SjBRgTqFkeEMLmfk2M")
 	c.Assert(peers[1].String(), Equals, "16Uiu2HAkyR9dsFqkj1BqKw8ZHAUU2yur6ZLRJxPTiiVYP5uBMeMG")
+	c.Assert(PeerIDsToStrings(peers), DeepEquals, []string{
+		"16Uiu2HAmBdJRswX94UwYj6VLhh4GeUf9X3SjBRgTqFkeEMLmfk2M",
+		"16Uiu2HAkyR9dsFqkj1BqKw8ZHAUU2yur6ZLRJxPTiiVYP5uBMeMG",
+	})
 	pubKeys1 := append(pubKeys, "helloworld")
 	peers, err = GetPeerIDs(pubKeys1)
 	c.Assert(err, NotNil)
@@ -0,0 +1,428 @@
+// Package faultinject wraps a p2p transport to tamper with the tss messages, it is only meant for the tests that
+// check how the ceremonies recover from the faults or blame the faulty nodes, it is internal so nothing outside
+// of this module can wire it into a node
+package faultinject
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"github.com/ordinox/thorchain-tss/messages"
+	"github.com/ordinox/thorchain-tss/p2p"
+)
+
+// Action is what the fault injector does to the tss messages a fault matches
+type Action uint8
+
+const (
+	// Drop lose the message
+	Drop Action = iota
+	// Delay hold the message for the delay of the fault
+	Delay
+	// Duplicate send the message along with the number of copies of the fault
+	Duplicate
+	// Reorder hold the message until the next message to or from the same peer has passed
+	Reorder
+	// Corrupt tamper with the content of the message
+	Corrupt
+)
+
+// DefaultReorderWindow is how long a Reorder fault holds a message when the fault has no delay and no other
+// message comes to overtake it
+const DefaultReorderWindow = time.Second
+
+// String implement fmt.Stringer
+func (a Action) String() string {
+	switch a {
+	case Drop:
+		return "drop"
+	case Delay:
+		return "delay"
+	case Duplicate:
+		return "duplicate"
+	case Reorder:
+		return "reorder"
+	case Corrupt:
+		return "corrupt"
+	default:
+		return "unknown"
+	}
+}
+
+// Fault tells which tss messages the fault injector tampers with and how, the empty fields match every message
+type Fault struct {
+	Action Action
+	// Inbound applies the fault to the messages we receive rather than to the ones we send
+	Inbound bool
+	// MessageTypes are the types of the messages the fault applies to
+	MessageTypes []messages.THORChainTSSMessageType
+	// Round is the tss-lib round of the messages the fault applies to, such as messages.KEYGEN3, the hash
+	// confirmations and the share requests of the round match it as well
+	Round string
+	// From is the sender of the messages the fault applies to
+	From peer.ID
+	// To is the receiver of the messages the fault applies to
+	To peer.ID
+	// Delay is how long a Delay fault holds the messages, and the longest a Reorder fault holds them
+	Delay time.Duration
+	// Copies is how many more copies of the messages a Duplicate fault sends
+	Copies int
+	// Corrupt tamper with the message for a Corrupt fault, by default it flips a bit of the tss-lib message so its
+	// signature no longer verifies
+	Corrupt func(msg *messages.WrappedMessage)
+	// Limit is how many messages the fault applies to, zero means all of them
+	Limit int
+}
+
+type faultState struct {
+	fault   Fault
+	applied int
+}
+
+func (f *faultState) match(inbound bool, from, to peer.ID, msg *messages.WrappedMessage) bool {
+	fault := f.fault
+	if fault.Inbound != inbound || (fault.Limit > 0 && f.applied >= fault.Limit) {
+		return false
+	}
+	if (len(fault.From) != 0 && fault.From != from) || (len(fault.To) != 0 && fault.To != to) {
+		return false
+	}
+	if len(fault.MessageTypes) != 0 {
+		found := false
+		for _, el := range fault.MessageTypes {
+			if el == msg.MessageType {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return len(fault.Round) == 0 || fault.Round == messageRound(msg)
+}
+
+// messageRound return the tss-lib round a tss message belongs to, it is empty for the messages that are not
+// part of a round
+func messageRound(msg *messages.WrappedMessage) string {
+	switch msg.MessageType {
+	case messages.TSSKeyGenMsg, messages.TSSKeySignMsg:
+		var wireMsg messages.WireMessage
+		if err := wireMsg.Unmarshal(msg.Payload); err != nil {
+			return ""
+		}
+		return wireMsg.RoundInfo
+	case messages.TSSKeyGenVerMsg, messages.TSSKeySignVerMsg:
+		var confirmMsg messages.BroadcastConfirmMessage
+		if err := confirmMsg.Unmarshal(msg.Payload); err != nil {
+			return ""
+		}
+		return cacheKeyRound(confirmMsg.Key)
+	case messages.TSSControlMsg:
+		var control messages.TssControl
+		if err := control.Unmarshal(msg.Payload); err != nil {
+			return ""
+		}
+		if control.Msg != nil {
+			return control.Msg.RoundInfo
+		}
+		return cacheKeyRound(control.ReqKey)
+	default:
+		return ""
+	}
+}
+
+// cacheKeyRound return the round of a message cache key, which is the party ID of the sender followed by the round
+func cacheKeyRound(key string) string {
+	return key[strings.LastIndex(key, "-")+1:]
+}
+
+// corruptMessage flip a bit of the tss-lib message, or of the payload for the other messages
+func corruptMessage(msg *messages.WrappedMessage) {
+	if msg.MessageType == messages.TSSKeyGenMsg || msg.MessageType == messages.TSSKeySignMsg {
+		var wireMsg messages.WireMessage
+		if err := wireMsg.Unmarshal(msg.Payload); err == nil && len(wireMsg.Message) != 0 {
+			wireMsg.Message = flipLastBit(wireMsg.Message)
+			if buf, err := wireMsg.Marshal(messages.WireFormatOf(msg.Payload)); err == nil {
+				msg.Payload = buf
+				return
+			}
+		}
+	}
+	if len(msg.Payload) != 0 {
+		msg.Payload = flipLastBit(msg.Payload)
+	}
+}
+
+func flipLastBit(buf []byte) []byte {
+	tampered := make([]byte, len(buf))
+	copy(tampered, buf)
+	tampered[len(tampered)-1] ^= 1
+	return tampered
+}
+
+type relayKey struct {
+	topic messages.THORChainTSSMessageType
+	msgID string
+}
+
+type reorderKey struct {
+	inbound bool
+	peer    peer.ID
+}
+
+// Injector wraps a transport to drop, delay, duplicate, reorder or corrupt the tss messages it sends and
+// receives, it is meant for the tests that check how the ceremonies blame the faulty nodes. The streams of the
+// party coordinator and the signature notifier go through untouched.
+type Injector struct {
+	p2p.Transport
+	logger        zerolog.Logger
+	lock          *sync.Mutex
+	faults        []*faultState
+	held          map[reorderKey][]func()
+	broadcastChan chan *messages.BroadcastMsgChan
+	relays        map[relayKey]chan struct{}
+	stopChan      chan struct{}
+	stopOnce      sync.Once
+	wg            *sync.WaitGroup
+}
+
+var _ p2p.Transport = &Injector{}
+
+// NewInjector create a new instance of Injector on top of the given transport, it lets every message
+// through until a fault is added
+func NewInjector(transport p2p.Transport) *Injector {
+	fi := &Injector{
+		Transport:     transport,
+		logger:        log.With().Str("module", "fault_injector").Logger(),
+		lock:          &sync.Mutex{},
+		held:          make(map[reorderKey][]func()),
+		broadcastChan: make(chan *messages.BroadcastMsgChan, cap(transport.BroadcastChannel())),
+		relays:        make(map[relayKey]chan struct{}),
+		stopChan:      make(chan struct{}),
+		wg:            &sync.WaitGroup{},
+	}
+	fi.wg.Add(1)
+	go fi.processBroadcast()
+	return fi
+}
+
+// AddFault add a fault, when several faults match a message the first one added applies
+func (fi *Injector) AddFault(fault Fault) {
+	fi.lock.Lock()
+	defer fi.lock.Unlock()
+	fi.faults = append(fi.faults, &faultState{fault: fault})
+}
+
+// ClearFaults remove all the faults, the held messages are still delivered
+func (fi *Injector) ClearFaults() {
+	fi.lock.Lock()
+	defer fi.lock.Unlock()
+	fi.faults = nil
+}
+
+// match return the first fault that applies to the message and count it, or nil
+func (fi *Injector) match(inbound bool, from, to peer.ID, msg *messages.WrappedMessage) *Fault {
+	fi.lock.Lock()
+	defer fi.lock.Unlock()
+	for _, el := range fi.faults {
+		if el.match(inbound, from, to, msg) {
+			el.applied++
+			fault := el.fault
+			return &fault
+		}
+	}
+	return nil
+}
+
+// BroadcastChannel implement p2p.Transport, the messages sent to it go through the outbound faults
+func (fi *Injector) BroadcastChannel() chan *messages.BroadcastMsgChan {
+	return fi.broadcastChan
+}
+
+// SetSubscribe implement p2p.Transport, the messages delivered to the channel go through the inbound faults
+func (fi *Injector) SetSubscribe(topic messages.THORChainTSSMessageType, msgID string, channel chan *p2p.Message) {
+	relay := make(chan *p2p.Message, cap(channel))
+	stop := make(chan struct{})
+	key := relayKey{topic: topic, msgID: msgID}
+	fi.lock.Lock()
+	if previous, ok := fi.relays[key]; ok {
+		close(previous)
+	}
+	fi.relays[key] = stop
+	fi.lock.Unlock()
+	fi.Transport.SetSubscribe(topic, msgID, relay)
+	fi.wg.Add(1)
+	go fi.processInbound(relay, channel, stop)
+}
+
+// CancelSubscribe implement p2p.Transport
+func (fi *Injector) CancelSubscribe(topic messages.THORChainTSSMessageType, msgID string) {
+	fi.Transport.CancelSubscribe(topic, msgID)
+	key := relayKey{topic: topic, msgID: msgID}
+	fi.lock.Lock()
+	defer fi.lock.Unlock()
+	if stop, ok := fi.relays[key]; ok {
+		close(stop)
+		delete(fi.relays, key)
+	}
+}
+
+func (fi *Injector) processBroadcast() {
+	defer fi.wg.Done()
+	for {
+		select {
+		case msg := <-fi.broadcastChan:
+			fi.intercept(msg)
+		case <-fi.stopChan:
+			return
+		}
+	}
+}
+
+// intercept apply the outbound faults to the message, to each of its receivers on its own
+func (fi *Injector) intercept(msg *messages.BroadcastMsgChan) {
+	var untouched []peer.ID
+	for _, pID := range msg.PeersID {
+		wrapped := msg.WrappedMessage
+		fault := fi.match(false, fi.ID(), pID, &wrapped)
+		if fault == nil {
+			untouched = append(untouched, pID)
+			continue
+		}
+		fi.logger.Debug().Msgf("%s the %s message of %s to %s", fault.Action, wrapped.MessageType, wrapped.MsgID, pID)
+		single := *msg
+		single.PeersID = []peer.ID{pID}
+		fi.apply(fault, reorderKey{peer: pID}, &single.WrappedMessage, func() { fi.send(&single) })
+	}
+	if len(untouched) != 0 {
+		rest := *msg
+		rest.PeersID = untouched
+		fi.send(&rest)
+		for _, pID := range untouched {
+			fi.release(reorderKey{peer: pID})
+		}
+	}
+}
+
+func (fi *Injector) send(msg *messages.BroadcastMsgChan) {
+	select {
+	case fi.Transport.BroadcastChannel() <- msg:
+	case <-fi.stopChan:
+	}
+}
+
+func (fi *Injector) processInbound(relay, channel chan *p2p.Message, stop chan struct{}) {
+	defer fi.wg.Done()
+	for {
+		select {
+		case msg := <-relay:
+			var wrapped messages.WrappedMessage
+			if err := wrapped.Unmarshal(msg.Payload); err != nil {
+				fi.deliver(channel, msg, stop)
+				continue
+			}
+			fault := fi.match(true, msg.PeerID, fi.ID(), &wrapped)
+			key := reorderKey{inbound: true, peer: msg.PeerID}
+			if fault == nil {
+				fi.deliver(channel, msg, stop)
+				fi.release(key)
+				continue
+			}
+			fi.logger.Debug().Msgf("%s the %s message of %s from %s", fault.Action, wrapped.MessageType, wrapped.MsgID, msg.PeerID)
+			fi.apply(fault, key, &wrapped, func() {
+				payload := msg.Payload
+				if fault.Action == Corrupt {
+					buf, err := wrapped.Marshal(messages.WireFormatOf(msg.Payload))
+					if err != nil {
+						fi.logger.Error().Err(err).Msg("fail to marshal the corrupted message")
+						return
+					}
+					payload = buf
+				}
+				fi.deliver(channel, &p2p.Message{PeerID: msg.PeerID, Payload: payload}, stop)
+			})
+		case <-stop:
+			return
+		case <-fi.stopChan:
+			return
+		}
+	}
+}
+
+func (fi *Injector) deliver(channel chan *p2p.Message, msg *p2p.Message, stop chan struct{}) {
+	select {
+	case channel <- msg:
+	case <-stop:
+	case <-fi.stopChan:
+	}
+}
+
+// apply carry out the action of the fault, deliver passes the message on
+func (fi *Injector) apply(fault *Fault, key reorderKey, msg *messages.WrappedMessage, deliver func()) {
+	switch fault.Action {
+	case Drop:
+	case Delay:
+		fi.after(fault.Delay, deliver)
+	case Duplicate:
+		for i := 0; i <= fault.Copies; i++ {
+			deliver()
+		}
+	case Reorder:
+		window := fault.Delay
+		if window == 0 {
+			window = DefaultReorderWindow
+		}
+		once := &sync.Once{}
+		held := func() { once.Do(deliver) }
+		fi.lock.Lock()
+		fi.held[key] = append(fi.held[key], held)
+		fi.lock.Unlock()
+		// nothing may come to overtake the message, we let it go after the window
+		fi.after(window, held)
+	case Corrupt:
+		if fault.Corrupt != nil {
+			fault.Corrupt(msg)
+		} else {
+			corruptMessage(msg)
+		}
+		deliver()
+	}
+}
+
+// release deliver the messages held for reordering once another message to or from the same peer has passed
+func (fi *Injector) release(key reorderKey) {
+	fi.lock.Lock()
+	held := fi.held[key]
+	delete(fi.held, key)
+	fi.lock.Unlock()
+	for _, deliver := range held {
+		deliver()
+	}
+}
+
+func (fi *Injector) after(delay time.Duration, deliver func()) {
+	fi.wg.Add(1)
+	go func() {
+		defer fi.wg.Done()
+		select {
+		case <-time.After(delay):
+			deliver()
+		case <-fi.stopChan:
+		}
+	}()
+}
+
+// Stop implement p2p.Transport, it stops the underlying transport as well
+func (fi *Injector) Stop() error {
+	fi.stopOnce.Do(func() {
+		close(fi.stopChan)
+	})
+	fi.wg.Wait()
+	return fi.Transport.Stop()
+}
@@ -0,0 +1,175 @@
+package faultinject
+
+import (
+	"crypto/rand"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ordinox/thorchain-tss/messages"
+	"github.com/ordinox/thorchain-tss/p2p"
+)
+
+func sendRoundMessages(sender p2p.Transport, receiver peer.ID, round string, count int) {
+	for i := 0; i < count; i++ {
+		wireMsg := messages.WireMessage{
+			RoundInfo: round,
+			Message:   []byte(fmt.Sprintf("%d", i)),
+		}
+		payload, _ := wireMsg.Marshal(messages.WireFormatProtobuf)
+		sender.BroadcastChannel() <- &messages.BroadcastMsgChan{
+			WrappedMessage: messages.WrappedMessage{
+				MessageType: messages.TSSKeyGenMsg,
+				MsgID:       "faults",
+				Payload:     payload,
+			},
+			PeersID: []peer.ID{receiver},
+			Format:  messages.WireFormatProtobuf,
+		}
+	}
+}
+
+// receiveRoundMessages return the tss-lib messages the channel gets within the timeout
+func receiveRoundMessages(ch chan *p2p.Message, timeout time.Duration) []string {
+	var received []string
+	for {
+		select {
+		case msg := <-ch:
+			var wrapped messages.WrappedMessage
+			var wireMsg messages.WireMessage
+			if wrapped.Unmarshal(msg.Payload) == nil && wireMsg.Unmarshal(wrapped.Payload) == nil {
+				received = append(received, string(wireMsg.Message))
+			}
+		case <-time.After(timeout):
+			return received
+		}
+	}
+}
+
+func newInjectors(t *testing.T) (*p2p.MemoryNetwork, *Injector, *Injector) {
+	mn, err := p2p.NewMemoryNetwork(p2p.MemoryNetworkConfig{})
+	assert.Nil(t, err)
+	injectors := make([]*Injector, 2)
+	for i := range injectors {
+		sk, _, err := crypto.GenerateSecp256k1Key(rand.Reader)
+		assert.Nil(t, err)
+		skRaw, err := sk.Raw()
+		assert.Nil(t, err)
+		transport, err := mn.NewTransport(skRaw, p2p.NetworkConfig{})
+		assert.Nil(t, err)
+		injectors[i] = NewInjector(transport)
+	}
+	return mn, injectors[0], injectors[1]
+}
+
+func TestInjectorOutbound(t *testing.T) {
+	mn, sender, receiver := newInjectors(t)
+	defer mn.Close()
+	ch := make(chan *p2p.Message, 50)
+	receiver.SetSubscribe(messages.TSSKeyGenMsg, "faults", ch)
+
+	// no fault, everything goes through
+	sendRoundMessages(sender, receiver.ID(), messages.KEYGEN1, 2)
+	assert.Equal(t, []string{"0", "1"}, receiveRoundMessages(ch, 100*time.Millisecond))
+
+	// only the messages of the round are dropped
+	sender.AddFault(Fault{Action: Drop, Round: messages.KEYGEN3, To: receiver.ID()})
+	sendRoundMessages(sender, receiver.ID(), messages.KEYGEN3, 2)
+	sendRoundMessages(sender, receiver.ID(), messages.KEYGEN2b, 1)
+	assert.Equal(t, []string{"0"}, receiveRoundMessages(ch, 100*time.Millisecond))
+	sender.ClearFaults()
+
+	sender.AddFault(Fault{Action: Duplicate, Copies: 2, Limit: 1})
+	sendRoundMessages(sender, receiver.ID(), messages.KEYGEN1, 2)
+	assert.Equal(t, []string{"0", "0", "0", "1"}, receiveRoundMessages(ch, 100*time.Millisecond))
+	sender.ClearFaults()
+
+	sender.AddFault(Fault{Action: Delay, Delay: 300 * time.Millisecond})
+	sendRoundMessages(sender, receiver.ID(), messages.KEYGEN1, 1)
+	assert.Empty(t, receiveRoundMessages(ch, 100*time.Millisecond))
+	assert.Equal(t, []string{"0"}, receiveRoundMessages(ch, 400*time.Millisecond))
+	sender.ClearFaults()
+
+	// the first message is held until the second one overtakes it
+	sender.AddFault(Fault{Action: Reorder, Limit: 1})
+	sendRoundMessages(sender, receiver.ID(), messages.KEYGEN1, 2)
+	assert.Equal(t, []string{"1", "0"}, receiveRoundMessages(ch, 200*time.Millisecond))
+	sender.ClearFaults()
+
+	// with nothing to overtake it, the message is let go after the window
+	sender.AddFault(Fault{Action: Reorder, Delay: 200 * time.Millisecond})
+	sendRoundMessages(sender, receiver.ID(), messages.KEYGEN1, 1)
+	assert.Equal(t, []string{"0"}, receiveRoundMessages(ch, 400*time.Millisecond))
+	sender.ClearFaults()
+
+	sender.AddFault(Fault{Action: Corrupt, MessageTypes: []messages.THORChainTSSMessageType{messages.TSSKeyGenMsg}})
+	sendRoundMessages(sender, receiver.ID(), messages.KEYGEN1, 1)
+	assert.Equal(t, []string{"1"}, receiveRoundMessages(ch, 100*time.Millisecond))
+	sender.ClearFaults()
+
+	// the faults of another message type do not apply
+	sender.AddFault(Fault{Action: Drop, MessageTypes: []messages.THORChainTSSMessageType{messages.TSSKeySignMsg}})
+	sendRoundMessages(sender, receiver.ID(), messages.KEYGEN1, 1)
+	assert.Equal(t, []string{"0"}, receiveRoundMessages(ch, 100*time.Millisecond))
+
+	assert.Nil(t, sender.Stop())
+	assert.Nil(t, receiver.Stop())
+}
+
+func TestInjectorInbound(t *testing.T) {
+	mn, sender, receiver := newInjectors(t)
+	defer mn.Close()
+	ch := make(chan *p2p.Message, 50)
+	receiver.SetSubscribe(messages.TSSKeyGenMsg, "faults", ch)
+
+	// the outbound faults do not apply to the messages we receive
+	receiver.AddFault(Fault{Action: Drop, From: sender.ID()})
+	sendRoundMessages(sender, receiver.ID(), messages.KEYGEN1, 1)
+	assert.Equal(t, []string{"0"}, receiveRoundMessages(ch, 100*time.Millisecond))
+	receiver.ClearFaults()
+
+	receiver.AddFault(Fault{Action: Drop, Inbound: true, From: sender.ID(), Limit: 1})
+	sendRoundMessages(sender, receiver.ID(), messages.KEYGEN1, 2)
+	assert.Equal(t, []string{"1"}, receiveRoundMessages(ch, 100*time.Millisecond))
+	receiver.ClearFaults()
+
+	receiver.AddFault(Fault{Action: Corrupt, Inbound: true})
+	sendRoundMessages(sender, receiver.ID(), messages.KEYGEN1, 1)
+	assert.Equal(t, []string{"1"}, receiveRoundMessages(ch, 100*time.Millisecond))
+	receiver.ClearFaults()
+
+	receiver.AddFault(Fault{Action: Reorder, Inbound: true, Limit: 1})
+	sendRoundMessages(sender, receiver.ID(), messages.KEYGEN1, 2)
+	assert.Equal(t, []string{"1", "0"}, receiveRoundMessages(ch, 200*time.Millisecond))
+	receiver.ClearFaults()
+
+	// no more delivery once we unsubscribe
+	receiver.CancelSubscribe(messages.TSSKeyGenMsg, "faults")
+	sendRoundMessages(sender, receiver.ID(), messages.KEYGEN1, 1)
+	assert.Empty(t, receiveRoundMessages(ch, 100*time.Millisecond))
+
+	assert.Nil(t, sender.Stop())
+	assert.Nil(t, receiver.Stop())
+}
+
+func TestMessageRound(t *testing.T) {
+	wireMsg := messages.WireMessage{RoundInfo: messages.KEYSIGN2Unicast}
+	payload, err := wireMsg.Marshal(messages.WireFormatJSON)
+	assert.Nil(t, err)
+	assert.Equal(t, messages.KEYSIGN2Unicast, messageRound(&messages.WrappedMessage{MessageType: messages.TSSKeySignMsg, Payload: payload}))
+
+	confirm := messages.BroadcastConfirmMessage{Key: "1-2-" + messages.KEYGEN3}
+	payload, err = confirm.Marshal(messages.WireFormatProtobuf)
+	assert.Nil(t, err)
+	assert.Equal(t, messages.KEYGEN3, messageRound(&messages.WrappedMessage{MessageType: messages.TSSKeyGenVerMsg, Payload: payload}))
+
+	control := messages.TssControl{ReqKey: "1-" + messages.KEYGEN2b}
+	payload, err = control.Marshal(messages.WireFormatProtobuf)
+	assert.Nil(t, err)
+	assert.Equal(t, messages.KEYGEN2b, messageRound(&messages.WrappedMessage{MessageType: messages.TSSControlMsg, Payload: payload}))
+	assert.Empty(t, messageRound(&messages.WrappedMessage{MessageType: messages.TSSTaskDone}))
+}
@@ -5,6 +5,9 @@ type Request struct {
 	Keys        []string `json:"keys"`
 	BlockHeight int64    `json:"block_height"`
 	Version     string   `json:"tss_version"`
+	// LeaderWeights is the weight of the node pub keys to lead the join party, such as their bond, a node leads in
+	// proportion to its weight, and the hash of the msg id picks the leader when it is empty
+	LeaderWeights map[string]uint64 `json:"leader_weights,omitempty"`
 }
 
 // NewRequest creeate a new instance of keygen.Request
@@ -30,7 +30,7 @@ type TssKeyGen struct {
 	localParty      *btss.PartyID
 	stateManager    storage.LocalStateManager
 	commStopChan    chan struct{}
-	p2pComm         *p2p.Communication
+	p2pComm         p2p.Transport
 }
 
 func NewTssKeyGen(localP2PID string,
@@ -42,14 +42,16 @@ func NewTssKeyGen(localP2PID string,
 	msgID string,
 	stateManager storage.LocalStateManager,
 	privateKey tcrypto.PrivKey,
-	p2pComm *p2p.Communication) *TssKeyGen {
+	p2pComm p2p.Transport) *TssKeyGen {
+	tssCommon := common.NewTssCommon(localP2PID, broadcastChan, conf, msgID, privateKey, 1)
+	tssCommon.SetDeliveryTimeout(conf.KeyGenTimeout)
 	return &TssKeyGen{
 		logger: log.With().
 			Str("module", "keygen").
 			Str("msgID", msgID).Logger(),
 		localNodePubKey: localNodePubKey,
 		preParams:       preParam,
-		tssCommonStruct: common.NewTssCommon(localP2PID, broadcastChan, conf, msgID, privateKey, 1),
+		tssCommonStruct: tssCommon,
 		stopChan:        stopChan,
 		localParty:      nil,
 		stateManager:    stateManager,
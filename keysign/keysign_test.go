@@ -92,6 +92,34 @@ func (s *MockLocalStateManager) RetrieveP2PAddresses() ([]maddr.Multiaddr, error
 	return nil, os.ErrNotExist
 }
 
+func (s *MockLocalStateManager) SaveCeremonyRecord(record storage.CeremonyRecord) error {
+	return nil
+}
+
+func (s *MockLocalStateManager) GetCeremonyRecords(filter storage.CeremonyFilter) ([]storage.CeremonyRecord, error) {
+	return nil, nil
+}
+
+func (s *MockLocalStateManager) PruneCeremonyRecords(before time.Time, maxEntries int) error {
+	return nil
+}
+
+func (s *MockLocalStateManager) SaveJob(job storage.JobRecord) error {
+	return nil
+}
+
+func (s *MockLocalStateManager) GetJob(id string) (storage.JobRecord, error) {
+	return storage.JobRecord{}, storage.ErrJobNotFound
+}
+
+func (s *MockLocalStateManager) GetJobs() ([]storage.JobRecord, error) {
+	return nil, nil
+}
+
+func (s *MockLocalStateManager) PruneJobs(before time.Time) error {
+	return nil
+}
+
 type TssKeysignTestSuite struct {
 	comms        []*p2p.Communication
 	partyNum     int
@@ -141,13 +169,13 @@ func (s *TssKeysignTestSuite) SetUpTest(c *C) {
 		buf, err := base64.StdEncoding.DecodeString(testPriKeyArr[i])
 		c.Assert(err, IsNil)
 		if i == 0 {
-			comm, err := p2p.NewCommunication("asgard", nil, ports[i], "")
+			comm, err := p2p.NewCommunication(p2p.Config{RendezvousString: "asgard", Port: ports[i]})
 			c.Assert(err, IsNil)
 			c.Assert(comm.Start(buf), IsNil)
 			s.comms[i] = comm
 			continue
 		}
-		comm, err := p2p.NewCommunication("asgard", []maddr.Multiaddr{multiAddr}, ports[i], "")
+		comm, err := p2p.NewCommunication(p2p.Config{RendezvousString: "asgard", BootstrapPeers: []maddr.Multiaddr{multiAddr}, Port: ports[i]})
 		c.Assert(err, IsNil)
 		c.Assert(comm.Start(buf), IsNil)
 		s.comms[i] = comm
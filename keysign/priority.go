@@ -0,0 +1,36 @@
+package keysign
+
+import "fmt"
+
+// Priority is the class of a keysign in the queue of the tss server, the higher classes run first when the
+// keysigns are over the concurrency limits
+type Priority string
+
+const (
+	// PriorityHigh is for the keysigns that should not wait behind the routine ones, e.g. the refunds
+	PriorityHigh Priority = "high"
+	// PriorityNormal is for the routine outbound keysigns, it is the priority of the requests that set none
+	PriorityNormal Priority = "normal"
+	// PriorityLow is for the keysigns that can wait, e.g. the consolidations
+	PriorityLow Priority = "low"
+)
+
+// Priorities are the priority classes from the highest to the lowest
+var Priorities = []Priority{PriorityHigh, PriorityNormal, PriorityLow}
+
+// Validate check whether the priority is supported, the empty priority is the normal one
+func (p Priority) Validate() error {
+	switch p {
+	case "", PriorityHigh, PriorityNormal, PriorityLow:
+		return nil
+	}
+	return fmt.Errorf("unknown keysign priority(%s), it should be one of %s, %s or %s", p, PriorityHigh, PriorityNormal, PriorityLow)
+}
+
+// OrDefault return the priority, or the normal one when it is empty
+func (p Priority) OrDefault() Priority {
+	if p == "" {
+		return PriorityNormal
+	}
+	return p
+}
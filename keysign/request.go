@@ -7,6 +7,16 @@ type Request struct {
 	SignerPubKeys []string `json:"signer_pub_keys"`
 	BlockHeight   int64    `json:"block_height"`
 	Version       string   `json:"tss_version"`
+	// Priority is the class of the keysign in the queue when the keysigns are over the concurrency limits
+	Priority Priority `json:"priority,omitempty"`
+	// LeaderWeights is the weight of the node pub keys to lead the join party, such as their bond, a node leads in
+	// proportion to its weight, and the hash of the msg id picks the leader when it is empty
+	LeaderWeights map[string]uint64 `json:"leader_weights,omitempty"`
+	// AllowedSigners and DeniedSigners restrict the node pub keys the join party leader may pick as signers, a node
+	// signs only if it is allowed, or the allowed list is empty, and it is not denied. The SignerPubKeys, when given,
+	// restrict them the same way, a node must be both among them and among the allowed signers.
+	AllowedSigners []string `json:"allowed_signers,omitempty"`
+	DeniedSigners  []string `json:"denied_signers,omitempty"`
 }
 
 func NewRequest(pk string, msgs []string, blockHeight int64, signers []string, version string) Request {
@@ -7,7 +7,6 @@ import (
 	"time"
 
 	"github.com/golang/protobuf/proto"
-	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/core/protocol"
@@ -31,15 +30,16 @@ type signatureItem struct {
 // SignatureNotifier is design to notify the
 type SignatureNotifier struct {
 	logger       zerolog.Logger
-	host         host.Host
+	host         p2p.StreamHost
 	notifierLock *sync.Mutex
 	notifiers    map[string]*Notifier
 	messages     chan *signatureItem
 	streamMgr    *p2p.StreamMgr
+	rateLimiter  *p2p.RateLimiter
 }
 
 // NewSignatureNotifier create a new instance of SignatureNotifier
-func NewSignatureNotifier(host host.Host) *SignatureNotifier {
+func NewSignatureNotifier(host p2p.StreamHost) *SignatureNotifier {
 	s := &SignatureNotifier{
 		logger:       log.With().Str("module", "signature_notifier").Logger(),
 		host:         host,
@@ -48,16 +48,29 @@ func NewSignatureNotifier(host host.Host) *SignatureNotifier {
 		messages:     make(chan *signatureItem),
 		streamMgr:    p2p.NewStreamMgr(),
 	}
-	host.SetStreamHandler(signatureNotifierProtocol, s.handleStream)
+	for _, pID := range p2p.WithLegacyProtocolIDs(signatureNotifierProtocol) {
+		host.SetStreamHandler(pID, s.handleStream)
+	}
 	return s
 }
 
+// SetRateLimiter set the limiter that bounds the streams, bytes and messages the peers send to the signature
+// notifier, it should be the one of the communication so a peer has the same limits on all the protocols
+func (s *SignatureNotifier) SetRateLimiter(limiter *p2p.RateLimiter) {
+	s.rateLimiter = limiter
+}
+
 // HandleStream handle signature notify stream
 func (s *SignatureNotifier) handleStream(stream network.Stream) {
 	remotePeer := stream.Conn().RemotePeer()
 	logger := s.logger.With().Str("remote peer", remotePeer.String()).Logger()
 	logger.Debug().Msg("reading signature notifier message")
-	payload, err := p2p.ReadStreamWithBuffer(stream)
+	if err := s.rateLimiter.AllowStream(remotePeer); err != nil {
+		logger.Warn().Err(err).Msg("drop the signature notifier stream")
+		_ = stream.Reset()
+		return
+	}
+	payload, err := p2p.ReadStreamWithLimiter(stream, p2p.DefaultNetworkConfig(), s.rateLimiter)
 	if err != nil {
 		logger.Err(err).Msgf("fail to read payload from stream")
 		s.streamMgr.AddStream("UNKNOWN", stream)
@@ -75,6 +88,10 @@ func (s *SignatureNotifier) handleStream(stream network.Stream) {
 		return
 	}
 	s.streamMgr.AddStream(msg.ID, stream)
+	if err := s.rateLimiter.AllowMessage(remotePeer, msg.ID); err != nil {
+		logger.Warn().Err(err).Msg("drop the signature message")
+		return
+	}
 	var signatures []*common.ECSignature
 	if len(msg.Signatures) > 0 && msg.KeysignStatus == messages.KeysignSignature_Success {
 		for _, el := range msg.Signatures {
@@ -106,7 +123,7 @@ func (s *SignatureNotifier) handleStream(stream network.Stream) {
 func (s *SignatureNotifier) sendOneMsgToPeer(m *signatureItem) error {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
 	defer cancel()
-	stream, err := s.host.NewStream(ctx, m.peerID, signatureNotifierProtocol)
+	stream, err := s.host.NewStream(network.WithAllowLimitedConn(ctx, "signature"), m.peerID, p2p.WithLegacyProtocolIDs(signatureNotifierProtocol)...)
 	if err != nil {
 		return fmt.Errorf("fail to create stream to peer(%s):%w", m.peerID, err)
 	}
@@ -197,6 +214,11 @@ func (s *SignatureNotifier) removeNotifier(n *Notifier) {
 
 // WaitForSignature wait until keysign finished and signature is available
 func (s *SignatureNotifier) WaitForSignature(messageID string, message [][]byte, poolPubKey string, timeout time.Duration, sigChan chan string) ([]*common.ECSignature, error) {
+	return s.WaitForSignatureCtx(context.Background(), messageID, message, poolPubKey, timeout, sigChan)
+}
+
+// WaitForSignatureCtx is WaitForSignature that gives up with the error of the context once it is done
+func (s *SignatureNotifier) WaitForSignatureCtx(ctx context.Context, messageID string, message [][]byte, poolPubKey string, timeout time.Duration, sigChan chan string) ([]*common.ECSignature, error) {
 	n, err := NewNotifier(messageID, message, poolPubKey)
 	if err != nil {
 		return nil, fmt.Errorf("fail to create notifier")
@@ -211,6 +233,8 @@ func (s *SignatureNotifier) WaitForSignature(messageID string, message [][]byte,
 		return nil, fmt.Errorf("timeout: didn't receive signature after %s", timeout)
 	case <-sigChan:
 		return nil, p2p.ErrSigGenerated
+	case <-ctx.Done():
+		return nil, ctx.Err()
 	}
 }
 
@@ -1,6 +1,7 @@
 package keysign
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"io/ioutil"
@@ -89,3 +90,23 @@ func TestSignatureNotifierHappyPath(t *testing.T) {
 	}))
 	wg.Wait()
 }
+
+func TestSignatureNotifierCancelled(t *testing.T) {
+	poolPubKey := `thorpub1addwnpepq0ul3xt882a6nm6m7uhxj4tk2n82zyu647dyevcs5yumuadn4uamqx7neak`
+	buf, err := base64.StdEncoding.DecodeString("yhEwrxWuNBGnPT/L7PNnVWg7gFWNzCYTV+GuX3tKRH8=")
+	assert.Nil(t, err)
+	messageID, err := common.MsgToHashString(buf)
+	assert.Nil(t, err)
+	mn := mocknet.New()
+	id := tnet.RandIdentityOrFatal(t)
+	h, err := mn.AddPeer(id.PrivateKey(), tnet.RandLocalTCPAddress())
+	assert.Nil(t, err)
+	n := NewSignatureNotifier(h)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	sig, err := n.WaitForSignatureCtx(ctx, messageID, [][]byte{buf}, poolPubKey, time.Second*30, make(chan string))
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, sig)
+	assert.Empty(t, n.notifiers)
+}
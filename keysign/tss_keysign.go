@@ -31,18 +31,20 @@ type TssKeySign struct {
 	stopChan        chan struct{} // channel to indicate whether we should stop
 	localParties    []*btss.PartyID
 	commStopChan    chan struct{}
-	p2pComm         *p2p.Communication
+	p2pComm         p2p.Transport
 	stateManager    storage.LocalStateManager
 }
 
 func NewTssKeySign(localP2PID string,
 	conf common.TssConfig,
 	broadcastChan chan *messages.BroadcastMsgChan,
-	stopChan chan struct{}, msgID string, privKey tcrypto.PrivKey, p2pComm *p2p.Communication, stateManager storage.LocalStateManager, msgNum int) *TssKeySign {
+	stopChan chan struct{}, msgID string, privKey tcrypto.PrivKey, p2pComm p2p.Transport, stateManager storage.LocalStateManager, msgNum int) *TssKeySign {
 	logItems := []string{"keySign", msgID}
+	tssCommon := common.NewTssCommon(localP2PID, broadcastChan, conf, msgID, privKey, msgNum)
+	tssCommon.SetDeliveryTimeout(conf.KeySignTimeout)
 	return &TssKeySign{
 		logger:          log.With().Strs("module", logItems).Logger(),
-		tssCommonStruct: common.NewTssCommon(localP2PID, broadcastChan, conf, msgID, privKey, msgNum),
+		tssCommonStruct: tssCommon,
 		stopChan:        stopChan,
 		localParties:    make([]*btss.PartyID, 0),
 		commStopChan:    make(chan struct{}),
@@ -164,7 +166,7 @@ func (tKeySign *TssKeySign) SignMessage(msgsToSign [][]byte, localStateItem stor
 	}
 	keySignWg.Wait()
 
-	tKeySign.logger.Info().Msgf("%s successfully sign the message", tKeySign.p2pComm.GetHost().ID().String())
+	tKeySign.logger.Info().Msgf("%s successfully sign the message", tKeySign.p2pComm.ID().String())
 	sort.SliceStable(results, func(i, j int) bool {
 		a := new(big.Int).SetBytes(results[i].M)
 		b := new(big.Int).SetBytes(results[j].M)
@@ -0,0 +1,155 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        v4.23.4
+// source: messages/handshake.proto
+
+package messages
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// HandshakeMsg tells a peer which versions of the tss protocol we speak
+type HandshakeMsg struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Version  string   `protobuf:"bytes,1,opt,name=Version,proto3" json:"Version,omitempty"`   // the highest version we support
+	Features []string `protobuf:"bytes,2,rep,name=Features,proto3" json:"Features,omitempty"` // the features of that version
+}
+
+func (x *HandshakeMsg) Reset() {
+	*x = HandshakeMsg{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_messages_handshake_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HandshakeMsg) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HandshakeMsg) ProtoMessage() {}
+
+func (x *HandshakeMsg) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_handshake_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HandshakeMsg.ProtoReflect.Descriptor instead.
+func (*HandshakeMsg) Descriptor() ([]byte, []int) {
+	return file_messages_handshake_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *HandshakeMsg) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *HandshakeMsg) GetFeatures() []string {
+	if x != nil {
+		return x.Features
+	}
+	return nil
+}
+
+var File_messages_handshake_proto protoreflect.FileDescriptor
+
+var file_messages_handshake_proto_rawDesc = []byte{
+	0x0a, 0x18, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x2f, 0x68, 0x61, 0x6e, 0x64, 0x73,
+	0x68, 0x61, 0x6b, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x08, 0x6d, 0x65, 0x73, 0x73,
+	0x61, 0x67, 0x65, 0x73, 0x22, 0x44, 0x0a, 0x0c, 0x48, 0x61, 0x6e, 0x64, 0x73, 0x68, 0x61, 0x6b,
+	0x65, 0x4d, 0x73, 0x67, 0x12, 0x18, 0x0a, 0x07, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1a,
+	0x0a, 0x08, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09,
+	0x52, 0x08, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x73, 0x42, 0x2a, 0x5a, 0x28, 0x67, 0x69,
+	0x74, 0x6c, 0x61, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x74, 0x68, 0x6f, 0x72, 0x63, 0x68, 0x61,
+	0x69, 0x6e, 0x2f, 0x74, 0x73, 0x73, 0x2f, 0x67, 0x6f, 0x2d, 0x74, 0x73, 0x73, 0x2f, 0x6d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_messages_handshake_proto_rawDescOnce sync.Once
+	file_messages_handshake_proto_rawDescData = file_messages_handshake_proto_rawDesc
+)
+
+func file_messages_handshake_proto_rawDescGZIP() []byte {
+	file_messages_handshake_proto_rawDescOnce.Do(func() {
+		file_messages_handshake_proto_rawDescData = protoimpl.X.CompressGZIP(file_messages_handshake_proto_rawDescData)
+	})
+	return file_messages_handshake_proto_rawDescData
+}
+
+var file_messages_handshake_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_messages_handshake_proto_goTypes = []any{
+	(*HandshakeMsg)(nil), // 0: messages.HandshakeMsg
+}
+var file_messages_handshake_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_messages_handshake_proto_init() }
+func file_messages_handshake_proto_init() {
+	if File_messages_handshake_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_messages_handshake_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*HandshakeMsg); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_messages_handshake_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_messages_handshake_proto_goTypes,
+		DependencyIndexes: file_messages_handshake_proto_depIdxs,
+		MessageInfos:      file_messages_handshake_proto_msgTypes,
+	}.Build()
+	File_messages_handshake_proto = out.File
+	file_messages_handshake_proto_rawDesc = nil
+	file_messages_handshake_proto_goTypes = nil
+	file_messages_handshake_proto_depIdxs = nil
+}
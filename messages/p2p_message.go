@@ -2,6 +2,7 @@ package messages
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/libp2p/go-libp2p/core/peer"
 	btss "github.com/ordinox/thorchain-tss-lib/tss"
@@ -54,6 +55,9 @@ type WrappedMessage struct {
 type BroadcastMsgChan struct {
 	WrappedMessage WrappedMessage
 	PeersID        []peer.ID
+	Format         WireFormat
+	// Deadline is when we give up delivering the message, zero means the default delivery timeout
+	Deadline time.Time
 }
 
 // BroadcastConfirmMessage is used to broadcast to all parties what message they receive
@@ -69,6 +73,10 @@ type WireMessage struct {
 	RoundInfo string               `json:"round_info"`
 	Message   []byte               `json:"message"`
 	Sig       []byte               `json:"signature"`
+	// SessionNonce is the random nonce of the ceremony session, it is empty for the legacy ceremonies
+	SessionNonce []byte `json:"session_nonce,omitempty"`
+	// Seq is the sequence number of the message among the ones its sender sent in the session
+	Seq uint64 `json:"seq,omitempty"`
 }
 
 // GetCacheKey return the key we used to cache it locally
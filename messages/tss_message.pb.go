@@ -0,0 +1,867 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.1
+// 	protoc        v4.23.4
+// source: messages/tss_message.proto
+
+package messages
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type PartyIDPb struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ID      string `protobuf:"bytes,1,opt,name=ID,proto3" json:"ID,omitempty"` // the party id used in tss-lib
+	Moniker string `protobuf:"bytes,2,opt,name=Moniker,proto3" json:"Moniker,omitempty"`
+	Key     []byte `protobuf:"bytes,3,opt,name=Key,proto3" json:"Key,omitempty"` // the public key of the party
+	Index   int32  `protobuf:"varint,4,opt,name=Index,proto3" json:"Index,omitempty"`
+}
+
+func (x *PartyIDPb) Reset() {
+	*x = PartyIDPb{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_messages_tss_message_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PartyIDPb) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PartyIDPb) ProtoMessage() {}
+
+func (x *PartyIDPb) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_tss_message_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PartyIDPb.ProtoReflect.Descriptor instead.
+func (*PartyIDPb) Descriptor() ([]byte, []int) {
+	return file_messages_tss_message_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *PartyIDPb) GetID() string {
+	if x != nil {
+		return x.ID
+	}
+	return ""
+}
+
+func (x *PartyIDPb) GetMoniker() string {
+	if x != nil {
+		return x.Moniker
+	}
+	return ""
+}
+
+func (x *PartyIDPb) GetKey() []byte {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+func (x *PartyIDPb) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+type MessageRoutingPb struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	From                    *PartyIDPb   `protobuf:"bytes,1,opt,name=From,proto3" json:"From,omitempty"`
+	To                      []*PartyIDPb `protobuf:"bytes,2,rep,name=To,proto3" json:"To,omitempty"` // empty if it is a broadcast message
+	IsBroadcast             bool         `protobuf:"varint,3,opt,name=IsBroadcast,proto3" json:"IsBroadcast,omitempty"`
+	IsToOldCommittee        bool         `protobuf:"varint,4,opt,name=IsToOldCommittee,proto3" json:"IsToOldCommittee,omitempty"`
+	IsToOldAndNewCommittees bool         `protobuf:"varint,5,opt,name=IsToOldAndNewCommittees,proto3" json:"IsToOldAndNewCommittees,omitempty"`
+}
+
+func (x *MessageRoutingPb) Reset() {
+	*x = MessageRoutingPb{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_messages_tss_message_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MessageRoutingPb) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MessageRoutingPb) ProtoMessage() {}
+
+func (x *MessageRoutingPb) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_tss_message_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MessageRoutingPb.ProtoReflect.Descriptor instead.
+func (*MessageRoutingPb) Descriptor() ([]byte, []int) {
+	return file_messages_tss_message_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *MessageRoutingPb) GetFrom() *PartyIDPb {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *MessageRoutingPb) GetTo() []*PartyIDPb {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+func (x *MessageRoutingPb) GetIsBroadcast() bool {
+	if x != nil {
+		return x.IsBroadcast
+	}
+	return false
+}
+
+func (x *MessageRoutingPb) GetIsToOldCommittee() bool {
+	if x != nil {
+		return x.IsToOldCommittee
+	}
+	return false
+}
+
+func (x *MessageRoutingPb) GetIsToOldAndNewCommittees() bool {
+	if x != nil {
+		return x.IsToOldAndNewCommittees
+	}
+	return false
+}
+
+type WrappedMessagePb struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	MessageType uint32 `protobuf:"varint,1,opt,name=MessageType,proto3" json:"MessageType,omitempty"`
+	MsgID       string `protobuf:"bytes,2,opt,name=MsgID,proto3" json:"MsgID,omitempty"` // unique hash id
+	Payload     []byte `protobuf:"bytes,3,opt,name=Payload,proto3" json:"Payload,omitempty"`
+}
+
+func (x *WrappedMessagePb) Reset() {
+	*x = WrappedMessagePb{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_messages_tss_message_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WrappedMessagePb) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WrappedMessagePb) ProtoMessage() {}
+
+func (x *WrappedMessagePb) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_tss_message_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WrappedMessagePb.ProtoReflect.Descriptor instead.
+func (*WrappedMessagePb) Descriptor() ([]byte, []int) {
+	return file_messages_tss_message_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *WrappedMessagePb) GetMessageType() uint32 {
+	if x != nil {
+		return x.MessageType
+	}
+	return 0
+}
+
+func (x *WrappedMessagePb) GetMsgID() string {
+	if x != nil {
+		return x.MsgID
+	}
+	return ""
+}
+
+func (x *WrappedMessagePb) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+type WireMessagePb struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Routing      *MessageRoutingPb `protobuf:"bytes,1,opt,name=Routing,proto3" json:"Routing,omitempty"`
+	RoundInfo    string            `protobuf:"bytes,2,opt,name=RoundInfo,proto3" json:"RoundInfo,omitempty"`
+	Message      []byte            `protobuf:"bytes,3,opt,name=Message,proto3" json:"Message,omitempty"` // the encoded bulk messages generated by tss-lib
+	Sig          []byte            `protobuf:"bytes,4,opt,name=Sig,proto3" json:"Sig,omitempty"`
+	SessionNonce []byte            `protobuf:"bytes,5,opt,name=SessionNonce,proto3" json:"SessionNonce,omitempty"` // the random nonce of the ceremony session, empty for the legacy ceremonies
+	Seq          uint64            `protobuf:"varint,6,opt,name=Seq,proto3" json:"Seq,omitempty"`                  // the sequence number of the message among the ones its sender sent in the session
+}
+
+func (x *WireMessagePb) Reset() {
+	*x = WireMessagePb{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_messages_tss_message_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WireMessagePb) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WireMessagePb) ProtoMessage() {}
+
+func (x *WireMessagePb) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_tss_message_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WireMessagePb.ProtoReflect.Descriptor instead.
+func (*WireMessagePb) Descriptor() ([]byte, []int) {
+	return file_messages_tss_message_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *WireMessagePb) GetRouting() *MessageRoutingPb {
+	if x != nil {
+		return x.Routing
+	}
+	return nil
+}
+
+func (x *WireMessagePb) GetRoundInfo() string {
+	if x != nil {
+		return x.RoundInfo
+	}
+	return ""
+}
+
+func (x *WireMessagePb) GetMessage() []byte {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+func (x *WireMessagePb) GetSig() []byte {
+	if x != nil {
+		return x.Sig
+	}
+	return nil
+}
+
+func (x *WireMessagePb) GetSessionNonce() []byte {
+	if x != nil {
+		return x.SessionNonce
+	}
+	return nil
+}
+
+func (x *WireMessagePb) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+type BroadcastConfirmMessagePb struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	P2PID string `protobuf:"bytes,1,opt,name=P2PID,proto3" json:"P2PID,omitempty"`
+	Key   string `protobuf:"bytes,2,opt,name=Key,proto3" json:"Key,omitempty"`
+	Hash  string `protobuf:"bytes,3,opt,name=Hash,proto3" json:"Hash,omitempty"`
+}
+
+func (x *BroadcastConfirmMessagePb) Reset() {
+	*x = BroadcastConfirmMessagePb{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_messages_tss_message_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BroadcastConfirmMessagePb) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BroadcastConfirmMessagePb) ProtoMessage() {}
+
+func (x *BroadcastConfirmMessagePb) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_tss_message_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BroadcastConfirmMessagePb.ProtoReflect.Descriptor instead.
+func (*BroadcastConfirmMessagePb) Descriptor() ([]byte, []int) {
+	return file_messages_tss_message_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *BroadcastConfirmMessagePb) GetP2PID() string {
+	if x != nil {
+		return x.P2PID
+	}
+	return ""
+}
+
+func (x *BroadcastConfirmMessagePb) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *BroadcastConfirmMessagePb) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+type TssControlPb struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ReqHash     string         `protobuf:"bytes,1,opt,name=ReqHash,proto3" json:"ReqHash,omitempty"`
+	ReqKey      string         `protobuf:"bytes,2,opt,name=ReqKey,proto3" json:"ReqKey,omitempty"`
+	RequestType uint32         `protobuf:"varint,3,opt,name=RequestType,proto3" json:"RequestType,omitempty"`
+	Msg         *WireMessagePb `protobuf:"bytes,4,opt,name=Msg,proto3" json:"Msg,omitempty"` // empty if it is a request
+}
+
+func (x *TssControlPb) Reset() {
+	*x = TssControlPb{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_messages_tss_message_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TssControlPb) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TssControlPb) ProtoMessage() {}
+
+func (x *TssControlPb) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_tss_message_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TssControlPb.ProtoReflect.Descriptor instead.
+func (*TssControlPb) Descriptor() ([]byte, []int) {
+	return file_messages_tss_message_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *TssControlPb) GetReqHash() string {
+	if x != nil {
+		return x.ReqHash
+	}
+	return ""
+}
+
+func (x *TssControlPb) GetReqKey() string {
+	if x != nil {
+		return x.ReqKey
+	}
+	return ""
+}
+
+func (x *TssControlPb) GetRequestType() uint32 {
+	if x != nil {
+		return x.RequestType
+	}
+	return 0
+}
+
+func (x *TssControlPb) GetMsg() *WireMessagePb {
+	if x != nil {
+		return x.Msg
+	}
+	return nil
+}
+
+type TssTaskNotifierPb struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	TaskDone bool `protobuf:"varint,1,opt,name=TaskDone,proto3" json:"TaskDone,omitempty"`
+}
+
+func (x *TssTaskNotifierPb) Reset() {
+	*x = TssTaskNotifierPb{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_messages_tss_message_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TssTaskNotifierPb) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TssTaskNotifierPb) ProtoMessage() {}
+
+func (x *TssTaskNotifierPb) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_tss_message_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TssTaskNotifierPb.ProtoReflect.Descriptor instead.
+func (*TssTaskNotifierPb) Descriptor() ([]byte, []int) {
+	return file_messages_tss_message_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *TssTaskNotifierPb) GetTaskDone() bool {
+	if x != nil {
+		return x.TaskDone
+	}
+	return false
+}
+
+type BulkWireMsgPb struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	WiredBulkMsgs []byte            `protobuf:"bytes,1,opt,name=WiredBulkMsgs,proto3" json:"WiredBulkMsgs,omitempty"`
+	MsgIdentifier string            `protobuf:"bytes,2,opt,name=MsgIdentifier,proto3" json:"MsgIdentifier,omitempty"`
+	Routing       *MessageRoutingPb `protobuf:"bytes,3,opt,name=Routing,proto3" json:"Routing,omitempty"`
+}
+
+func (x *BulkWireMsgPb) Reset() {
+	*x = BulkWireMsgPb{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_messages_tss_message_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BulkWireMsgPb) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BulkWireMsgPb) ProtoMessage() {}
+
+func (x *BulkWireMsgPb) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_tss_message_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BulkWireMsgPb.ProtoReflect.Descriptor instead.
+func (*BulkWireMsgPb) Descriptor() ([]byte, []int) {
+	return file_messages_tss_message_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *BulkWireMsgPb) GetWiredBulkMsgs() []byte {
+	if x != nil {
+		return x.WiredBulkMsgs
+	}
+	return nil
+}
+
+func (x *BulkWireMsgPb) GetMsgIdentifier() string {
+	if x != nil {
+		return x.MsgIdentifier
+	}
+	return ""
+}
+
+func (x *BulkWireMsgPb) GetRouting() *MessageRoutingPb {
+	if x != nil {
+		return x.Routing
+	}
+	return nil
+}
+
+type BulkWireMsgListPb struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Msgs []*BulkWireMsgPb `protobuf:"bytes,1,rep,name=Msgs,proto3" json:"Msgs,omitempty"`
+}
+
+func (x *BulkWireMsgListPb) Reset() {
+	*x = BulkWireMsgListPb{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_messages_tss_message_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BulkWireMsgListPb) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BulkWireMsgListPb) ProtoMessage() {}
+
+func (x *BulkWireMsgListPb) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_tss_message_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BulkWireMsgListPb.ProtoReflect.Descriptor instead.
+func (*BulkWireMsgListPb) Descriptor() ([]byte, []int) {
+	return file_messages_tss_message_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *BulkWireMsgListPb) GetMsgs() []*BulkWireMsgPb {
+	if x != nil {
+		return x.Msgs
+	}
+	return nil
+}
+
+var File_messages_tss_message_proto protoreflect.FileDescriptor
+
+var file_messages_tss_message_proto_rawDesc = []byte{
+	0x0a, 0x1a, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x2f, 0x74, 0x73, 0x73, 0x5f, 0x6d,
+	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x08, 0x6d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x22, 0x5d, 0x0a, 0x09, 0x50, 0x61, 0x72, 0x74, 0x79, 0x49,
+	0x44, 0x50, 0x62, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x02, 0x49, 0x44, 0x12, 0x18, 0x0a, 0x07, 0x4d, 0x6f, 0x6e, 0x69, 0x6b, 0x65, 0x72, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x4d, 0x6f, 0x6e, 0x69, 0x6b, 0x65, 0x72, 0x12, 0x10, 0x0a,
+	0x03, 0x4b, 0x65, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x4b, 0x65, 0x79, 0x12,
+	0x14, 0x0a, 0x05, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05,
+	0x49, 0x6e, 0x64, 0x65, 0x78, 0x22, 0xe8, 0x01, 0x0a, 0x10, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x52, 0x6f, 0x75, 0x74, 0x69, 0x6e, 0x67, 0x50, 0x62, 0x12, 0x27, 0x0a, 0x04, 0x46, 0x72,
+	0x6f, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x6d, 0x65, 0x73, 0x73, 0x61,
+	0x67, 0x65, 0x73, 0x2e, 0x50, 0x61, 0x72, 0x74, 0x79, 0x49, 0x44, 0x50, 0x62, 0x52, 0x04, 0x46,
+	0x72, 0x6f, 0x6d, 0x12, 0x23, 0x0a, 0x02, 0x54, 0x6f, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x13, 0x2e, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x2e, 0x50, 0x61, 0x72, 0x74, 0x79,
+	0x49, 0x44, 0x50, 0x62, 0x52, 0x02, 0x54, 0x6f, 0x12, 0x20, 0x0a, 0x0b, 0x49, 0x73, 0x42, 0x72,
+	0x6f, 0x61, 0x64, 0x63, 0x61, 0x73, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x49,
+	0x73, 0x42, 0x72, 0x6f, 0x61, 0x64, 0x63, 0x61, 0x73, 0x74, 0x12, 0x2a, 0x0a, 0x10, 0x49, 0x73,
+	0x54, 0x6f, 0x4f, 0x6c, 0x64, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x65, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x10, 0x49, 0x73, 0x54, 0x6f, 0x4f, 0x6c, 0x64, 0x43, 0x6f, 0x6d,
+	0x6d, 0x69, 0x74, 0x74, 0x65, 0x65, 0x12, 0x38, 0x0a, 0x17, 0x49, 0x73, 0x54, 0x6f, 0x4f, 0x6c,
+	0x64, 0x41, 0x6e, 0x64, 0x4e, 0x65, 0x77, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x65,
+	0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x17, 0x49, 0x73, 0x54, 0x6f, 0x4f, 0x6c, 0x64,
+	0x41, 0x6e, 0x64, 0x4e, 0x65, 0x77, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x65, 0x73,
+	0x22, 0x64, 0x0a, 0x10, 0x57, 0x72, 0x61, 0x70, 0x70, 0x65, 0x64, 0x4d, 0x65, 0x73, 0x73, 0x61,
+	0x67, 0x65, 0x50, 0x62, 0x12, 0x20, 0x0a, 0x0b, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x54,
+	0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b, 0x4d, 0x65, 0x73, 0x73, 0x61,
+	0x67, 0x65, 0x54, 0x79, 0x70, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x4d, 0x73, 0x67, 0x49, 0x44, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x4d, 0x73, 0x67, 0x49, 0x44, 0x12, 0x18, 0x0a, 0x07,
+	0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x50,
+	0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x22, 0xc5, 0x01, 0x0a, 0x0d, 0x57, 0x69, 0x72, 0x65, 0x4d,
+	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x50, 0x62, 0x12, 0x34, 0x0a, 0x07, 0x52, 0x6f, 0x75, 0x74,
+	0x69, 0x6e, 0x67, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x6d, 0x65, 0x73, 0x73,
+	0x61, 0x67, 0x65, 0x73, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x52, 0x6f, 0x75, 0x74,
+	0x69, 0x6e, 0x67, 0x50, 0x62, 0x52, 0x07, 0x52, 0x6f, 0x75, 0x74, 0x69, 0x6e, 0x67, 0x12, 0x1c,
+	0x0a, 0x09, 0x52, 0x6f, 0x75, 0x6e, 0x64, 0x49, 0x6e, 0x66, 0x6f, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x09, 0x52, 0x6f, 0x75, 0x6e, 0x64, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x18, 0x0a, 0x07,
+	0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x4d,
+	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x53, 0x69, 0x67, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x03, 0x53, 0x69, 0x67, 0x12, 0x22, 0x0a, 0x0c, 0x53, 0x65, 0x73, 0x73,
+	0x69, 0x6f, 0x6e, 0x4e, 0x6f, 0x6e, 0x63, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0c,
+	0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x4e, 0x6f, 0x6e, 0x63, 0x65, 0x12, 0x10, 0x0a, 0x03,
+	0x53, 0x65, 0x71, 0x18, 0x06, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x53, 0x65, 0x71, 0x22, 0x57,
+	0x0a, 0x19, 0x42, 0x72, 0x6f, 0x61, 0x64, 0x63, 0x61, 0x73, 0x74, 0x43, 0x6f, 0x6e, 0x66, 0x69,
+	0x72, 0x6d, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x50, 0x62, 0x12, 0x14, 0x0a, 0x05, 0x50,
+	0x32, 0x50, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x50, 0x32, 0x50, 0x49,
+	0x44, 0x12, 0x10, 0x0a, 0x03, 0x4b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03,
+	0x4b, 0x65, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x48, 0x61, 0x73, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x48, 0x61, 0x73, 0x68, 0x22, 0x8d, 0x01, 0x0a, 0x0c, 0x54, 0x73, 0x73, 0x43,
+	0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x50, 0x62, 0x12, 0x18, 0x0a, 0x07, 0x52, 0x65, 0x71, 0x48,
+	0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x52, 0x65, 0x71, 0x48, 0x61,
+	0x73, 0x68, 0x12, 0x16, 0x0a, 0x06, 0x52, 0x65, 0x71, 0x4b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x06, 0x52, 0x65, 0x71, 0x4b, 0x65, 0x79, 0x12, 0x20, 0x0a, 0x0b, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x54, 0x79, 0x70, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x0b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x54, 0x79, 0x70, 0x65, 0x12, 0x29, 0x0a, 0x03,
+	0x4d, 0x73, 0x67, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x6d, 0x65, 0x73, 0x73,
+	0x61, 0x67, 0x65, 0x73, 0x2e, 0x57, 0x69, 0x72, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x50, 0x62, 0x52, 0x03, 0x4d, 0x73, 0x67, 0x22, 0x2f, 0x0a, 0x11, 0x54, 0x73, 0x73, 0x54, 0x61,
+	0x73, 0x6b, 0x4e, 0x6f, 0x74, 0x69, 0x66, 0x69, 0x65, 0x72, 0x50, 0x62, 0x12, 0x1a, 0x0a, 0x08,
+	0x54, 0x61, 0x73, 0x6b, 0x44, 0x6f, 0x6e, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08,
+	0x54, 0x61, 0x73, 0x6b, 0x44, 0x6f, 0x6e, 0x65, 0x22, 0x91, 0x01, 0x0a, 0x0d, 0x42, 0x75, 0x6c,
+	0x6b, 0x57, 0x69, 0x72, 0x65, 0x4d, 0x73, 0x67, 0x50, 0x62, 0x12, 0x24, 0x0a, 0x0d, 0x57, 0x69,
+	0x72, 0x65, 0x64, 0x42, 0x75, 0x6c, 0x6b, 0x4d, 0x73, 0x67, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x0d, 0x57, 0x69, 0x72, 0x65, 0x64, 0x42, 0x75, 0x6c, 0x6b, 0x4d, 0x73, 0x67, 0x73,
+	0x12, 0x24, 0x0a, 0x0d, 0x4d, 0x73, 0x67, 0x49, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x66, 0x69, 0x65,
+	0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x4d, 0x73, 0x67, 0x49, 0x64, 0x65, 0x6e,
+	0x74, 0x69, 0x66, 0x69, 0x65, 0x72, 0x12, 0x34, 0x0a, 0x07, 0x52, 0x6f, 0x75, 0x74, 0x69, 0x6e,
+	0x67, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x73, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x52, 0x6f, 0x75, 0x74, 0x69, 0x6e,
+	0x67, 0x50, 0x62, 0x52, 0x07, 0x52, 0x6f, 0x75, 0x74, 0x69, 0x6e, 0x67, 0x22, 0x40, 0x0a, 0x11,
+	0x42, 0x75, 0x6c, 0x6b, 0x57, 0x69, 0x72, 0x65, 0x4d, 0x73, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x50,
+	0x62, 0x12, 0x2b, 0x0a, 0x04, 0x4d, 0x73, 0x67, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x17, 0x2e, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x2e, 0x42, 0x75, 0x6c, 0x6b, 0x57,
+	0x69, 0x72, 0x65, 0x4d, 0x73, 0x67, 0x50, 0x62, 0x52, 0x04, 0x4d, 0x73, 0x67, 0x73, 0x42, 0x2a,
+	0x5a, 0x28, 0x67, 0x69, 0x74, 0x6c, 0x61, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x74, 0x68, 0x6f,
+	0x72, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x2f, 0x74, 0x73, 0x73, 0x2f, 0x67, 0x6f, 0x2d, 0x74, 0x73,
+	0x73, 0x2f, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x33,
+}
+
+var (
+	file_messages_tss_message_proto_rawDescOnce sync.Once
+	file_messages_tss_message_proto_rawDescData = file_messages_tss_message_proto_rawDesc
+)
+
+func file_messages_tss_message_proto_rawDescGZIP() []byte {
+	file_messages_tss_message_proto_rawDescOnce.Do(func() {
+		file_messages_tss_message_proto_rawDescData = protoimpl.X.CompressGZIP(file_messages_tss_message_proto_rawDescData)
+	})
+	return file_messages_tss_message_proto_rawDescData
+}
+
+var file_messages_tss_message_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_messages_tss_message_proto_goTypes = []interface{}{
+	(*PartyIDPb)(nil),                 // 0: messages.PartyIDPb
+	(*MessageRoutingPb)(nil),          // 1: messages.MessageRoutingPb
+	(*WrappedMessagePb)(nil),          // 2: messages.WrappedMessagePb
+	(*WireMessagePb)(nil),             // 3: messages.WireMessagePb
+	(*BroadcastConfirmMessagePb)(nil), // 4: messages.BroadcastConfirmMessagePb
+	(*TssControlPb)(nil),              // 5: messages.TssControlPb
+	(*TssTaskNotifierPb)(nil),         // 6: messages.TssTaskNotifierPb
+	(*BulkWireMsgPb)(nil),             // 7: messages.BulkWireMsgPb
+	(*BulkWireMsgListPb)(nil),         // 8: messages.BulkWireMsgListPb
+}
+var file_messages_tss_message_proto_depIdxs = []int32{
+	0, // 0: messages.MessageRoutingPb.From:type_name -> messages.PartyIDPb
+	0, // 1: messages.MessageRoutingPb.To:type_name -> messages.PartyIDPb
+	1, // 2: messages.WireMessagePb.Routing:type_name -> messages.MessageRoutingPb
+	3, // 3: messages.TssControlPb.Msg:type_name -> messages.WireMessagePb
+	1, // 4: messages.BulkWireMsgPb.Routing:type_name -> messages.MessageRoutingPb
+	7, // 5: messages.BulkWireMsgListPb.Msgs:type_name -> messages.BulkWireMsgPb
+	6, // [6:6] is the sub-list for method output_type
+	6, // [6:6] is the sub-list for method input_type
+	6, // [6:6] is the sub-list for extension type_name
+	6, // [6:6] is the sub-list for extension extendee
+	0, // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_messages_tss_message_proto_init() }
+func file_messages_tss_message_proto_init() {
+	if File_messages_tss_message_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_messages_tss_message_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PartyIDPb); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_messages_tss_message_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MessageRoutingPb); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_messages_tss_message_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*WrappedMessagePb); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_messages_tss_message_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*WireMessagePb); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_messages_tss_message_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BroadcastConfirmMessagePb); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_messages_tss_message_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TssControlPb); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_messages_tss_message_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TssTaskNotifierPb); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_messages_tss_message_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BulkWireMsgPb); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_messages_tss_message_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BulkWireMsgListPb); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_messages_tss_message_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_messages_tss_message_proto_goTypes,
+		DependencyIndexes: file_messages_tss_message_proto_depIdxs,
+		MessageInfos:      file_messages_tss_message_proto_msgTypes,
+	}.Build()
+	File_messages_tss_message_proto = out.File
+	file_messages_tss_message_proto_rawDesc = nil
+	file_messages_tss_message_proto_goTypes = nil
+	file_messages_tss_message_proto_depIdxs = nil
+}
@@ -1,5 +1,48 @@
 package messages
 
+import (
+	"github.com/blang/semver"
+)
+
 const (
-	NEWJOINPARTYVERSION = "0.14.0"
+	NEWJOINPARTYVERSION     = "0.14.0"
+	PROTOBUFWIREVERSION     = "0.15.0"
+	VERSIONHANDSHAKEVERSION = "0.16.0"
+	REPLAYPROTECTIONVERSION = "0.17.0"
+	// CURRENTVERSION is the highest version of the tss protocol we support
+	CURRENTVERSION = REPLAYPROTECTIONVERSION
 )
+
+// the features of the tss protocol, the peers announce the ones they support in the version handshake
+const (
+	FeatureLeaderJoinParty  = "leader-join-party"
+	FeatureProtobufWire     = "protobuf-wire"
+	FeatureVersionHandshake = "version-handshake"
+	FeatureReplayProtection = "replay-protection"
+)
+
+// featureVersions is the version every feature is introduced in
+var featureVersions = []struct {
+	feature string
+	version string
+}{
+	{FeatureLeaderJoinParty, NEWJOINPARTYVERSION},
+	{FeatureProtobufWire, PROTOBUFWIREVERSION},
+	{FeatureVersionHandshake, VERSIONHANDSHAKEVERSION},
+	{FeatureReplayProtection, REPLAYPROTECTIONVERSION},
+}
+
+// Features return the features of the given version of the tss protocol, it is empty if the version is invalid
+func Features(version string) []string {
+	v, err := semver.Make(version)
+	if err != nil {
+		return nil
+	}
+	var features []string
+	for _, el := range featureVersions {
+		if v.GTE(semver.MustParse(el.version)) {
+			features = append(features, el.feature)
+		}
+	}
+	return features
+}
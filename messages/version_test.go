@@ -0,0 +1,18 @@
+package messages
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type VersionTestSuite struct{}
+
+var _ = Suite(&VersionTestSuite{})
+
+func (s *VersionTestSuite) TestFeatures(c *C) {
+	c.Assert(Features("0.13.0"), HasLen, 0)
+	c.Assert(Features(NEWJOINPARTYVERSION), DeepEquals, []string{FeatureLeaderJoinParty})
+	c.Assert(Features(PROTOBUFWIREVERSION), DeepEquals, []string{FeatureLeaderJoinParty, FeatureProtobufWire})
+	c.Assert(Features(VERSIONHANDSHAKEVERSION), DeepEquals, []string{FeatureLeaderJoinParty, FeatureProtobufWire, FeatureVersionHandshake})
+	c.Assert(Features(CURRENTVERSION), DeepEquals, []string{FeatureLeaderJoinParty, FeatureProtobufWire, FeatureVersionHandshake, FeatureReplayProtection})
+	c.Assert(Features("invalid"), IsNil)
+}
@@ -0,0 +1,278 @@
+package messages
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	btss "github.com/ordinox/thorchain-tss-lib/tss"
+	"google.golang.org/protobuf/proto"
+)
+
+// WireFormat is the encoding we apply to the tss messages before we send them to the wire
+type WireFormat uint8
+
+const (
+	// WireFormatJSON is the json encoding used by the nodes before PROTOBUFWIREVERSION
+	WireFormatJSON WireFormat = iota
+	// WireFormatProtobuf is the protobuf encoding defined in tss_message.proto
+	WireFormatProtobuf
+)
+
+// String implement fmt.Stringer
+func (f WireFormat) String() string {
+	switch f {
+	case WireFormatJSON:
+		return "json"
+	case WireFormatProtobuf:
+		return "protobuf"
+	default:
+		return "unknown"
+	}
+}
+
+// protobufMarker is the first byte of every protobuf encoded message, it carries the wire format explicitly as
+// neither a json document nor a protobuf message can start with it, 0 is not a valid protobuf field number
+const protobufMarker byte = 0x00
+
+// WireFormatOf return the wire format of the given buffer, the protobuf encoded messages start with the
+// protobuf marker, anything else is the json sent by the nodes before PROTOBUFWIREVERSION
+func WireFormatOf(buf []byte) WireFormat {
+	if len(buf) > 0 && buf[0] == protobufMarker {
+		return WireFormatProtobuf
+	}
+	return WireFormatJSON
+}
+
+// MarshalProtobuf encode the given message with protobuf, prefixed with the protobuf marker
+func MarshalProtobuf(msg proto.Message) ([]byte, error) {
+	return proto.MarshalOptions{}.MarshalAppend([]byte{protobufMarker}, msg)
+}
+
+// UnmarshalProtobuf decode the given buffer that MarshalProtobuf encoded
+func UnmarshalProtobuf(buf []byte, msg proto.Message) error {
+	if WireFormatOf(buf) != WireFormatProtobuf {
+		return errors.New("the buffer is not protobuf encoded")
+	}
+	return proto.Unmarshal(buf[1:], msg)
+}
+
+func marshal(format WireFormat, jsonMsg interface{}, protoMsg func() proto.Message) ([]byte, error) {
+	switch format {
+	case WireFormatJSON:
+		return json.Marshal(jsonMsg)
+	case WireFormatProtobuf:
+		return MarshalProtobuf(protoMsg())
+	default:
+		return nil, fmt.Errorf("unknown wire format %d", format)
+	}
+}
+
+// PartyIDToProto convert the tss-lib party id to its protobuf representation
+func PartyIDToProto(p *btss.PartyID) *PartyIDPb {
+	if p == nil || p.MessageWrapper_PartyID == nil {
+		return nil
+	}
+	return &PartyIDPb{
+		ID:      p.Id,
+		Moniker: p.Moniker,
+		Key:     p.Key,
+		Index:   int32(p.Index),
+	}
+}
+
+// PartyIDFromProto convert the protobuf party id back to tss-lib party id
+func PartyIDFromProto(p *PartyIDPb) *btss.PartyID {
+	if p == nil {
+		return nil
+	}
+	return &btss.PartyID{
+		MessageWrapper_PartyID: &btss.MessageWrapper_PartyID{
+			Id:      p.ID,
+			Moniker: p.Moniker,
+			Key:     p.Key,
+		},
+		Index: int(p.Index),
+	}
+}
+
+// RoutingToProto convert the tss-lib message routing to its protobuf representation
+func RoutingToProto(r *btss.MessageRouting) *MessageRoutingPb {
+	if r == nil {
+		return nil
+	}
+	routing := &MessageRoutingPb{
+		From:                    PartyIDToProto(r.From),
+		IsBroadcast:             r.IsBroadcast,
+		IsToOldCommittee:        r.IsToOldCommittee,
+		IsToOldAndNewCommittees: r.IsToOldAndNewCommittees,
+	}
+	for _, el := range r.To {
+		routing.To = append(routing.To, PartyIDToProto(el))
+	}
+	return routing
+}
+
+// RoutingFromProto convert the protobuf message routing back to tss-lib message routing
+func RoutingFromProto(r *MessageRoutingPb) *btss.MessageRouting {
+	if r == nil {
+		return nil
+	}
+	routing := &btss.MessageRouting{
+		From:                    PartyIDFromProto(r.From),
+		IsBroadcast:             r.IsBroadcast,
+		IsToOldCommittee:        r.IsToOldCommittee,
+		IsToOldAndNewCommittees: r.IsToOldAndNewCommittees,
+	}
+	for _, el := range r.To {
+		routing.To = append(routing.To, PartyIDFromProto(el))
+	}
+	return routing
+}
+
+func (m *WrappedMessage) toProto() *WrappedMessagePb {
+	return &WrappedMessagePb{
+		MessageType: uint32(m.MessageType),
+		MsgID:       m.MsgID,
+		Payload:     m.Payload,
+	}
+}
+
+// Marshal encode the wrapped message with the given wire format
+func (m *WrappedMessage) Marshal(format WireFormat) ([]byte, error) {
+	return marshal(format, m, func() proto.Message { return m.toProto() })
+}
+
+// Unmarshal decode the wrapped message, the wire format is given by its first byte
+func (m *WrappedMessage) Unmarshal(buf []byte) error {
+	if WireFormatOf(buf) == WireFormatJSON {
+		return json.Unmarshal(buf, m)
+	}
+	var msg WrappedMessagePb
+	if err := UnmarshalProtobuf(buf, &msg); err != nil {
+		return err
+	}
+	m.MessageType = THORChainTSSMessageType(msg.MessageType)
+	m.MsgID = msg.MsgID
+	m.Payload = msg.Payload
+	return nil
+}
+
+func (m *WireMessage) toProto() *WireMessagePb {
+	return &WireMessagePb{
+		Routing:      RoutingToProto(m.Routing),
+		RoundInfo:    m.RoundInfo,
+		Message:      m.Message,
+		Sig:          m.Sig,
+		SessionNonce: m.SessionNonce,
+		Seq:          m.Seq,
+	}
+}
+
+func (m *WireMessage) fromProto(msg *WireMessagePb) {
+	m.Routing = RoutingFromProto(msg.Routing)
+	m.RoundInfo = msg.RoundInfo
+	m.Message = msg.Message
+	m.Sig = msg.Sig
+	m.SessionNonce = msg.SessionNonce
+	m.Seq = msg.Seq
+}
+
+// Marshal encode the wire message with the given wire format
+func (m *WireMessage) Marshal(format WireFormat) ([]byte, error) {
+	return marshal(format, m, func() proto.Message { return m.toProto() })
+}
+
+// Unmarshal decode the wire message, the wire format is given by its first byte
+func (m *WireMessage) Unmarshal(buf []byte) error {
+	if WireFormatOf(buf) == WireFormatJSON {
+		return json.Unmarshal(buf, m)
+	}
+	var msg WireMessagePb
+	if err := UnmarshalProtobuf(buf, &msg); err != nil {
+		return err
+	}
+	m.fromProto(&msg)
+	return nil
+}
+
+// Marshal encode the broadcast confirm message with the given wire format
+func (m *BroadcastConfirmMessage) Marshal(format WireFormat) ([]byte, error) {
+	return marshal(format, m, func() proto.Message {
+		return &BroadcastConfirmMessagePb{
+			P2PID: m.P2PID,
+			Key:   m.Key,
+			Hash:  m.Hash,
+		}
+	})
+}
+
+// Unmarshal decode the broadcast confirm message, the wire format is given by its first byte
+func (m *BroadcastConfirmMessage) Unmarshal(buf []byte) error {
+	if WireFormatOf(buf) == WireFormatJSON {
+		return json.Unmarshal(buf, m)
+	}
+	var msg BroadcastConfirmMessagePb
+	if err := UnmarshalProtobuf(buf, &msg); err != nil {
+		return err
+	}
+	m.P2PID = msg.P2PID
+	m.Key = msg.Key
+	m.Hash = msg.Hash
+	return nil
+}
+
+// Marshal encode the tss control message with the given wire format
+func (m *TssControl) Marshal(format WireFormat) ([]byte, error) {
+	return marshal(format, m, func() proto.Message {
+		msg := &TssControlPb{
+			ReqHash:     m.ReqHash,
+			ReqKey:      m.ReqKey,
+			RequestType: uint32(m.RequestType),
+		}
+		if m.Msg != nil {
+			msg.Msg = m.Msg.toProto()
+		}
+		return msg
+	})
+}
+
+// Unmarshal decode the tss control message, the wire format is given by its first byte
+func (m *TssControl) Unmarshal(buf []byte) error {
+	if WireFormatOf(buf) == WireFormatJSON {
+		return json.Unmarshal(buf, m)
+	}
+	var msg TssControlPb
+	if err := UnmarshalProtobuf(buf, &msg); err != nil {
+		return err
+	}
+	m.ReqHash = msg.ReqHash
+	m.ReqKey = msg.ReqKey
+	m.RequestType = THORChainTSSMessageType(msg.RequestType)
+	m.Msg = nil
+	if msg.Msg != nil {
+		m.Msg = &WireMessage{}
+		m.Msg.fromProto(msg.Msg)
+	}
+	return nil
+}
+
+// Marshal encode the task notifier with the given wire format
+func (m *TssTaskNotifier) Marshal(format WireFormat) ([]byte, error) {
+	return marshal(format, m, func() proto.Message {
+		return &TssTaskNotifierPb{TaskDone: m.TaskDone}
+	})
+}
+
+// Unmarshal decode the task notifier, the wire format is given by its first byte
+func (m *TssTaskNotifier) Unmarshal(buf []byte) error {
+	if WireFormatOf(buf) == WireFormatJSON {
+		return json.Unmarshal(buf, m)
+	}
+	var msg TssTaskNotifierPb
+	if err := UnmarshalProtobuf(buf, &msg); err != nil {
+		return err
+	}
+	m.TaskDone = msg.TaskDone
+	return nil
+}
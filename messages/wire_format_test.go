@@ -0,0 +1,129 @@
+package messages
+
+import (
+	"math/big"
+	"strings"
+
+	btss "github.com/ordinox/thorchain-tss-lib/tss"
+	"google.golang.org/protobuf/proto"
+	. "gopkg.in/check.v1"
+)
+
+type WireFormatTestSuite struct{}
+
+var _ = Suite(&WireFormatTestSuite{})
+
+func newTestWireMessage() *WireMessage {
+	from := btss.NewPartyID("1", "moniker1", new(big.Int).SetBytes([]byte("key1")))
+	from.Index = 1
+	to := btss.NewPartyID("2", "moniker2", new(big.Int).SetBytes([]byte("key2")))
+	return &WireMessage{
+		Routing: &btss.MessageRouting{
+			From:        from,
+			To:          []*btss.PartyID{to},
+			IsBroadcast: false,
+		},
+		RoundInfo:    "KGRound1Message",
+		Message:      []byte("hello world"),
+		Sig:          []byte("signature"),
+		SessionNonce: []byte("nonce"),
+		Seq:          7,
+	}
+}
+
+func (WireFormatTestSuite) TestWireFormatOf(c *C) {
+	c.Assert(WireFormatOf([]byte(`{"a":1}`)), Equals, WireFormatJSON)
+	c.Assert(WireFormatOf(nil), Equals, WireFormatJSON)
+	wrapped := WrappedMessage{MessageType: TSSKeyGenMsg, MsgID: "msgID", Payload: []byte("{")}
+	buf, err := wrapped.Marshal(WireFormatProtobuf)
+	c.Assert(err, IsNil)
+	c.Assert(WireFormatOf(buf), Equals, WireFormatProtobuf)
+	// the routing of 123 bytes is encoded as "\n{", which looks like json with a leading whitespace
+	wireMsg := WireMessage{Routing: &btss.MessageRouting{From: &btss.PartyID{MessageWrapper_PartyID: &btss.MessageWrapper_PartyID{Id: strings.Repeat("a", 119)}}}}
+	buf, err = wireMsg.Marshal(WireFormatProtobuf)
+	c.Assert(err, IsNil)
+	c.Assert(buf[1:3], DeepEquals, []byte("\n{"))
+	c.Assert(WireFormatOf(buf), Equals, WireFormatProtobuf)
+	var decoded WireMessage
+	c.Assert(decoded.Unmarshal(buf), IsNil)
+	c.Assert(decoded.Routing.From.Id, Equals, strings.Repeat("a", 119))
+	// the protobuf of the messages without the marker is rejected
+	raw, err := proto.Marshal(wireMsg.toProto())
+	c.Assert(err, IsNil)
+	c.Assert(UnmarshalProtobuf(raw, &WireMessagePb{}), NotNil)
+}
+
+func (WireFormatTestSuite) TestWrappedMessage(c *C) {
+	for _, format := range []WireFormat{WireFormatJSON, WireFormatProtobuf} {
+		wrapped := WrappedMessage{MessageType: TSSKeySignVerMsg, MsgID: "msgID", Payload: []byte("payload")}
+		buf, err := wrapped.Marshal(format)
+		c.Assert(err, IsNil)
+		var decoded WrappedMessage
+		c.Assert(decoded.Unmarshal(buf), IsNil)
+		c.Assert(decoded, DeepEquals, wrapped)
+	}
+	wrapped := WrappedMessage{}
+	_, err := wrapped.Marshal(WireFormat(100))
+	c.Assert(err, NotNil)
+	c.Assert(wrapped.Unmarshal([]byte("invalid")), NotNil)
+}
+
+func (WireFormatTestSuite) TestWireMessage(c *C) {
+	for _, format := range []WireFormat{WireFormatJSON, WireFormatProtobuf} {
+		wireMsg := newTestWireMessage()
+		buf, err := wireMsg.Marshal(format)
+		c.Assert(err, IsNil)
+		var decoded WireMessage
+		c.Assert(decoded.Unmarshal(buf), IsNil)
+		c.Assert(decoded.RoundInfo, Equals, wireMsg.RoundInfo)
+		c.Assert(decoded.Message, DeepEquals, wireMsg.Message)
+		c.Assert(decoded.Sig, DeepEquals, wireMsg.Sig)
+		c.Assert(decoded.SessionNonce, DeepEquals, wireMsg.SessionNonce)
+		c.Assert(decoded.Seq, Equals, wireMsg.Seq)
+		c.Assert(decoded.Routing.From.Id, Equals, "1")
+		c.Assert(decoded.Routing.From.Moniker, Equals, "moniker1")
+		c.Assert(decoded.Routing.From.Key, DeepEquals, []byte("key1"))
+		c.Assert(decoded.Routing.From.Index, Equals, 1)
+		c.Assert(decoded.Routing.To, HasLen, 1)
+		c.Assert(decoded.Routing.To[0].Id, Equals, "2")
+		c.Assert(decoded.GetCacheKey(), Equals, wireMsg.GetCacheKey())
+	}
+}
+
+func (WireFormatTestSuite) TestControlMessages(c *C) {
+	for _, format := range []WireFormat{WireFormatJSON, WireFormatProtobuf} {
+		confirm := BroadcastConfirmMessage{P2PID: "p2pid", Key: "key", Hash: "hash"}
+		buf, err := confirm.Marshal(format)
+		c.Assert(err, IsNil)
+		var decodedConfirm BroadcastConfirmMessage
+		c.Assert(decodedConfirm.Unmarshal(buf), IsNil)
+		c.Assert(decodedConfirm, DeepEquals, confirm)
+
+		request := TssControl{ReqHash: "hash", ReqKey: "key", RequestType: TSSKeySignMsg}
+		buf, err = request.Marshal(format)
+		c.Assert(err, IsNil)
+		var decodedRequest TssControl
+		c.Assert(decodedRequest.Unmarshal(buf), IsNil)
+		c.Assert(decodedRequest.Msg, IsNil)
+		c.Assert(decodedRequest.RequestType, Equals, TSSKeySignMsg)
+		c.Assert(decodedRequest.ReqHash, Equals, "hash")
+
+		request.Msg = newTestWireMessage()
+		buf, err = request.Msg.Marshal(format)
+		c.Assert(err, IsNil)
+		buf, err = request.Marshal(format)
+		c.Assert(err, IsNil)
+		c.Assert(decodedRequest.Unmarshal(buf), IsNil)
+		c.Assert(decodedRequest.Msg, NotNil)
+		c.Assert(decodedRequest.Msg.Message, DeepEquals, request.Msg.Message)
+
+		for _, done := range []bool{true, false} {
+			notifier := TssTaskNotifier{TaskDone: done}
+			buf, err = notifier.Marshal(format)
+			c.Assert(err, IsNil)
+			var decodedNotifier TssTaskNotifier
+			c.Assert(decodedNotifier.Unmarshal(buf), IsNil)
+			c.Assert(decodedNotifier.TaskDone, Equals, done)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package monitor
+
+import (
+	"sync"
+	"time"
+)
+
+// EventType is the kind of the progress a ceremony made
+type EventType string
+
+const (
+	// EventJoinPartyStart is published when we start to form the party of a ceremony
+	EventJoinPartyStart EventType = "join_party_start"
+	// EventLeader is published when we turn to a leader of the join party, the peer is the leader
+	EventLeader EventType = "leader"
+	// EventJoinPartyFormed is published once the party is formed, the peers run the ceremony
+	EventJoinPartyFormed EventType = "join_party_formed"
+	// EventRoundSent is published when we send the message of a round, the peers are the receivers
+	EventRoundSent EventType = "round_sent"
+	// EventRoundReceived is published when we receive the message of a round, the peer is the sender
+	EventRoundReceived EventType = "round_received"
+	// EventHashConfirmed is published once the peers agree on the hash of a broadcast message, the peer is its
+	// sender
+	EventHashConfirmed EventType = "hash_confirmed"
+	// EventBlame is published when nodes are blamed, the peers are the pub keys of the blamed nodes
+	EventBlame EventType = "blame"
+	// EventDone is published once the ceremony is finished, the detail is its status
+	EventDone EventType = "done"
+
+	// eventBufferSize is how many events a subscriber can fall behind before we drop its events
+	eventBufferSize = 256
+)
+
+// Event is a step of the progress of a ceremony
+type Event struct {
+	MsgID  string    `json:"msg_id"`
+	Type   EventType `json:"type"`
+	Time   time.Time `json:"time"`
+	Round  string    `json:"round,omitempty"`
+	Peers  []string  `json:"peers,omitempty"`
+	Detail string    `json:"detail,omitempty"`
+}
+
+// NewEvent create a new event of the ceremony of the given msg id
+func NewEvent(msgID string, eventType EventType, round string, peers []string, detail string) Event {
+	return Event{
+		MsgID:  msgID,
+		Type:   eventType,
+		Time:   time.Now(),
+		Round:  round,
+		Peers:  peers,
+		Detail: detail,
+	}
+}
+
+type eventSubscription struct {
+	msgID  string
+	events chan Event
+}
+
+// EventBus deliver the progress events of the ceremonies to the subscribers, a subscriber that falls behind
+// misses events rather than slowing the ceremonies down. The methods of a nil EventBus do nothing.
+type EventBus struct {
+	lock          *sync.RWMutex
+	subscriptions map[*eventSubscription]bool
+}
+
+// NewEventBus create a new instance of EventBus
+func NewEventBus() *EventBus {
+	return &EventBus{
+		lock:          &sync.RWMutex{},
+		subscriptions: make(map[*eventSubscription]bool),
+	}
+}
+
+// Publish deliver the event to the subscribers of its ceremony and to the ones of all the ceremonies
+func (b *EventBus) Publish(event Event) {
+	if b == nil {
+		return
+	}
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	for sub := range b.subscriptions {
+		if len(sub.msgID) != 0 && sub.msgID != event.MsgID {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+		}
+	}
+}
+
+// Subscribe return the events of the ceremony of the given msg id, or of all the ceremonies if it is empty, the
+// returned function ends the subscription and closes the channel
+func (b *EventBus) Subscribe(msgID string) (<-chan Event, func()) {
+	sub := &eventSubscription{
+		msgID:  msgID,
+		events: make(chan Event, eventBufferSize),
+	}
+	if b == nil {
+		close(sub.events)
+		return sub.events, func() {}
+	}
+	b.lock.Lock()
+	b.subscriptions[sub] = true
+	b.lock.Unlock()
+	once := &sync.Once{}
+	return sub.events, func() {
+		once.Do(func() {
+			b.lock.Lock()
+			defer b.lock.Unlock()
+			delete(b.subscriptions, sub)
+			close(sub.events)
+		})
+	}
+}
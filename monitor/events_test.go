@@ -0,0 +1,47 @@
+package monitor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventBus(t *testing.T) {
+	bus := NewEventBus()
+	all, cancelAll := bus.Subscribe("")
+	one, cancelOne := bus.Subscribe("msg1")
+	bus.Publish(NewEvent("msg1", EventJoinPartyStart, "", []string{"A", "B"}, ""))
+	bus.Publish(NewEvent("msg2", EventDone, "", nil, "success"))
+
+	event := <-one
+	assert.Equal(t, "msg1", event.MsgID)
+	assert.Equal(t, EventJoinPartyStart, event.Type)
+	assert.Equal(t, []string{"A", "B"}, event.Peers)
+	assert.Len(t, one, 0)
+	assert.Equal(t, "msg1", (<-all).MsgID)
+	assert.Equal(t, "msg2", (<-all).MsgID)
+
+	// a subscriber that falls behind misses the events
+	for i := 0; i < eventBufferSize+10; i++ {
+		bus.Publish(NewEvent("msg1", EventRoundSent, "round", nil, ""))
+	}
+	assert.Len(t, one, eventBufferSize)
+
+	cancelOne()
+	cancelOne()
+	for range one {
+	}
+	cancelAll()
+	for range all {
+	}
+	assert.Len(t, bus.subscriptions, 0)
+	bus.Publish(NewEvent("msg1", EventDone, "", nil, ""))
+
+	// a nil bus does nothing
+	var nilBus *EventBus
+	nilBus.Publish(NewEvent("msg1", EventDone, "", nil, ""))
+	events, cancel := nilBus.Subscribe("msg1")
+	_, ok := <-events
+	assert.False(t, ok)
+	cancel()
+}
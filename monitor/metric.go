@@ -15,6 +15,16 @@ type Metric struct {
 	keySignTime      prometheus.Gauge
 	keyGenTime       prometheus.Gauge
 	joinPartyTime    *prometheus.GaugeVec
+	rejectedConn     *prometheus.CounterVec
+	peerRTT          *prometheus.SummaryVec
+	peerPingFailure  *prometheus.CounterVec
+	peerLastSeen     *prometheus.GaugeVec
+	rateLimited      *prometheus.CounterVec
+	bannedPeers      prometheus.Counter
+	keysignQueued    *prometheus.GaugeVec
+	keysignRunning   prometheus.Gauge
+	keysignRejected  *prometheus.CounterVec
+	keysignQueueWait *prometheus.SummaryVec
 	logger           zerolog.Logger
 }
 
@@ -54,6 +64,49 @@ func (m *Metric) KeysignJoinParty(joinpartyTime time.Duration, success bool) {
 	}
 }
 
+// RejectedConnection count the connection attempts rejected by the connection gater
+func (m *Metric) RejectedConnection(reason string) {
+	m.rejectedConn.WithLabelValues(reason).Inc()
+}
+
+// PeerPing record the result of a health check ping to the given peer
+func (m *Metric) PeerPing(peerID string, rtt time.Duration, success bool) {
+	if !success {
+		m.peerPingFailure.WithLabelValues(peerID).Inc()
+		return
+	}
+	m.peerRTT.WithLabelValues(peerID).Observe(rtt.Seconds())
+	m.peerLastSeen.WithLabelValues(peerID).SetToCurrentTime()
+}
+
+// RateLimited count the violations of the p2p rate limits, and the bans they lead to
+func (m *Metric) RateLimited(reason string, banned bool) {
+	m.rateLimited.WithLabelValues(reason).Inc()
+	if banned {
+		m.bannedPeers.Inc()
+	}
+}
+
+// KeysignQueued set how many keysigns of the given priority wait in the queue
+func (m *Metric) KeysignQueued(priority string, queued int) {
+	m.keysignQueued.WithLabelValues(priority).Set(float64(queued))
+}
+
+// KeysignRunning set how many keysigns run at the moment
+func (m *Metric) KeysignRunning(running int) {
+	m.keysignRunning.Set(float64(running))
+}
+
+// KeysignRejected count the keysigns of the given priority rejected as their queue is full
+func (m *Metric) KeysignRejected(priority string) {
+	m.keysignRejected.WithLabelValues(priority).Inc()
+}
+
+// KeysignQueueWait record how long a keysign of the given priority waited in the queue
+func (m *Metric) KeysignQueueWait(priority string, wait time.Duration) {
+	m.keysignQueueWait.WithLabelValues(priority).Observe(wait.Seconds())
+}
+
 func (m *Metric) Enable() {
 	prometheus.MustRegister(m.keygenCounter)
 	prometheus.MustRegister(m.keysignCounter)
@@ -61,6 +114,16 @@ func (m *Metric) Enable() {
 	prometheus.MustRegister(m.keyGenTime)
 	prometheus.MustRegister(m.keySignTime)
 	prometheus.MustRegister(m.joinPartyTime)
+	prometheus.MustRegister(m.rejectedConn)
+	prometheus.MustRegister(m.peerRTT)
+	prometheus.MustRegister(m.peerPingFailure)
+	prometheus.MustRegister(m.peerLastSeen)
+	prometheus.MustRegister(m.rateLimited)
+	prometheus.MustRegister(m.bannedPeers)
+	prometheus.MustRegister(m.keysignQueued)
+	prometheus.MustRegister(m.keysignRunning)
+	prometheus.MustRegister(m.keysignRejected)
+	prometheus.MustRegister(m.keysignQueueWait)
 }
 
 func NewMetric() *Metric {
@@ -122,6 +185,78 @@ func NewMetric() *Metric {
 				Help:      "the time spend for the latest keysign/keygen join party",
 			}, []string{"type"}),
 
+		rejectedConn: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "Tss",
+			Subsystem: "P2P",
+			Name:      "rejected_connection",
+			Help:      "connection attempts rejected as the peer is not in the allowlist",
+		}, []string{"reason"}),
+
+		peerRTT: prometheus.NewSummaryVec(prometheus.SummaryOpts{
+			Namespace:  "Tss",
+			Subsystem:  "P2P",
+			Name:       "peer_rtt_seconds",
+			Help:       "round trip time of the health check pings to the committee peers",
+			Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+		}, []string{"peer"}),
+
+		peerPingFailure: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "Tss",
+			Subsystem: "P2P",
+			Name:      "peer_ping_failure",
+			Help:      "health check pings the committee peers failed to answer",
+		}, []string{"peer"}),
+
+		peerLastSeen: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "Tss",
+			Subsystem: "P2P",
+			Name:      "peer_last_seen",
+			Help:      "unix time the committee peers last answered a health check ping",
+		}, []string{"peer"}),
+
+		rateLimited: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "Tss",
+			Subsystem: "P2P",
+			Name:      "rate_limited",
+			Help:      "inbound streams, payloads and messages dropped as the peer exceeded its rate limit",
+		}, []string{"reason"}),
+
+		bannedPeers: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "Tss",
+			Subsystem: "P2P",
+			Name:      "banned_peer",
+			Help:      "peers temporarily banned for exceeding their rate limits",
+		}),
+
+		keysignQueued: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "Tss",
+			Subsystem: "Tss",
+			Name:      "keysign_queued",
+			Help:      "keysigns waiting in the queue for the concurrency limits",
+		}, []string{"priority"}),
+
+		keysignRunning: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "Tss",
+			Subsystem: "Tss",
+			Name:      "keysign_running",
+			Help:      "keysigns running at the moment",
+		}),
+
+		keysignRejected: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "Tss",
+			Subsystem: "Tss",
+			Name:      "keysign_rejected",
+			Help:      "keysigns rejected as the queue of their priority is full",
+		}, []string{"priority"}),
+
+		keysignQueueWait: prometheus.NewSummaryVec(prometheus.SummaryOpts{
+			Namespace:  "Tss",
+			Subsystem:  "Tss",
+			Name:       "keysign_queue_wait_seconds",
+			Help:       "time the keysigns waited in the queue before they run",
+			Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+		}, []string{"priority"}),
+
 		logger: log.With().Str("module", "tssMonitor").Logger(),
 	}
 	return &metrics
@@ -64,3 +64,66 @@ func TestMetric_UpdateKeyGen(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, float64(5), val)
 }
+
+func TestMetric_RejectedConnection(t *testing.T) {
+	metrics := NewMetric()
+	metrics.RejectedConnection("dial")
+	metrics.RejectedConnection("secured")
+	metrics.RejectedConnection("secured")
+	val, err := getCounterValue(metrics.rejectedConn, "secured")
+	assert.Nil(t, err)
+	assert.Equal(t, float64(2), val)
+}
+
+func TestMetric_PeerPing(t *testing.T) {
+	metrics := NewMetric()
+	metrics.PeerPing("peer1", time.Millisecond*10, true)
+	metrics.PeerPing("peer1", time.Millisecond*30, true)
+	metrics.PeerPing("peer1", 0, false)
+	val, err := getCounterValue(metrics.peerPingFailure, "peer1")
+	assert.Nil(t, err)
+	assert.Equal(t, float64(1), val)
+
+	m := &dto.Metric{}
+	observer, err := metrics.peerRTT.GetMetricWithLabelValues("peer1")
+	assert.Nil(t, err)
+	assert.Nil(t, observer.(prometheus.Metric).Write(m))
+	assert.Equal(t, uint64(2), m.Summary.GetSampleCount())
+	assert.InDelta(t, 0.04, m.Summary.GetSampleSum(), 1e-9)
+
+	m = &dto.Metric{}
+	assert.Nil(t, metrics.peerLastSeen.WithLabelValues("peer1").Write(m))
+	assert.InDelta(t, float64(time.Now().Unix()), m.Gauge.GetValue(), 5)
+}
+
+func TestMetric_RateLimited(t *testing.T) {
+	metrics := NewMetric()
+	metrics.RateLimited("messages", false)
+	metrics.RateLimited("messages", true)
+	metrics.RateLimited("streams", false)
+	val, err := getCounterValue(metrics.rateLimited, "messages")
+	assert.Nil(t, err)
+	assert.Equal(t, float64(2), val)
+	m := &dto.Metric{}
+	assert.Nil(t, metrics.bannedPeers.Write(m))
+	assert.Equal(t, float64(1), m.Counter.GetValue())
+}
+
+func TestMetric_KeysignQueue(t *testing.T) {
+	metrics := NewMetric()
+	metrics.KeysignQueued("high", 2)
+	metrics.KeysignRunning(3)
+	metrics.KeysignRejected("low")
+	metrics.KeysignRejected("low")
+	metrics.KeysignQueueWait("high", time.Second)
+
+	m := &dto.Metric{}
+	assert.Nil(t, metrics.keysignQueued.WithLabelValues("high").Write(m))
+	assert.Equal(t, float64(2), m.Gauge.GetValue())
+	m = &dto.Metric{}
+	assert.Nil(t, metrics.keysignRunning.Write(m))
+	assert.Equal(t, float64(3), m.Gauge.GetValue())
+	val, err := getCounterValue(metrics.keysignRejected, "low")
+	assert.Nil(t, err)
+	assert.Equal(t, float64(2), val)
+}
@@ -1,25 +1,25 @@
 package p2p
 
 import (
+	"bufio"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	libp2p "github.com/libp2p/go-libp2p"
-	dht "github.com/libp2p/go-libp2p-kad-dht"
 	"github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/core/protocol"
-	routing "github.com/libp2p/go-libp2p/p2p/discovery/routing"
-	routingutil "github.com/libp2p/go-libp2p/p2p/discovery/util"
 	"github.com/libp2p/go-libp2p/p2p/protocol/ping"
 	maddr "github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
@@ -34,11 +34,6 @@ var (
 // TSSProtocolID protocol id used for tss
 var TSSProtocolID protocol.ID = "/p2p/tss"
 
-const (
-	// TimeoutConnecting maximum time for wait for peers to connect
-	TimeoutConnecting = time.Second * 20
-)
-
 // Message that get transfer across the wire
 type Message struct {
 	PeerID  peer.ID
@@ -47,55 +42,213 @@ type Message struct {
 
 // Communication use p2p to broadcast messages among all the TSS nodes
 type Communication struct {
+	*messageRouter
 	rendezvous       string // based on group
 	bootstrapPeers   []maddr.Multiaddr
 	logger           zerolog.Logger
-	listenAddr       maddr.Multiaddr
+	listenAddrs      []maddr.Multiaddr
 	host             host.Host
 	wg               *sync.WaitGroup
 	stopChan         chan struct{} // channel to indicate whether we should stop
-	subscribers      map[messages.THORChainTSSMessageType]*MessageIDSubscriber
-	subscriberLocker *sync.Mutex
 	streamCount      int64
 	BroadcastMsgChan chan *messages.BroadcastMsgChan
-	externalAddr     maddr.Multiaddr
+	externalAddrs    []maddr.Multiaddr
 	streamMgr        *StreamMgr
+	conf             NetworkConfig
+	compression      bool
+	gater            *ConnectionGater
+	peerStreams      map[peer.ID]*peerStream
+	peerStreamsLock  *sync.Mutex
+	idleTimeout      time.Duration
+	discovery        peerDiscovery
+	reachability     int32
+	healthMonitor    *HealthMonitor
+	healthObserver   func(pID peer.ID, rtt time.Duration, err error)
+	rateLimiter      *RateLimiter
 }
 
-// NewCommunication create a new instance of Communication
-func NewCommunication(rendezvous string, bootstrapPeers []maddr.Multiaddr, port int, externalIP string) (*Communication, error) {
-	addr, err := maddr.NewMultiaddr(fmt.Sprintf("/ip4/0.0.0.0/tcp/%d", port))
-	if err != nil {
-		return nil, fmt.Errorf("fail to create listen addr: %w", err)
+// NewCommunication create a new instance of Communication, the timeouts and limits left to zero in the given
+// configuration fall back to their default values
+func NewCommunication(conf Config) (*Communication, error) {
+	if err := conf.NetworkConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid network config: %w", err)
 	}
-	var externalAddr maddr.Multiaddr = nil
-	if len(externalIP) != 0 {
-		externalAddr, err = maddr.NewMultiaddr(fmt.Sprintf("/ip4/%s/tcp/%d", externalIP, port))
+	networkConf := conf.NetworkConfig.WithDefaults()
+	listenAddrs := networkConf.ListenAddrs
+	if len(listenAddrs) == 0 {
+		addr, err := maddr.NewMultiaddr(fmt.Sprintf("/ip4/0.0.0.0/tcp/%d", conf.Port))
 		if err != nil {
-			return nil, fmt.Errorf("fail to create listen with given external IP: %w", err)
+			return nil, fmt.Errorf("fail to create listen addr: %w", err)
 		}
+		listenAddrs = []maddr.Multiaddr{addr}
+	}
+	externalAddrs, err := buildExternalAddrs(conf.ExternalIP, listenAddrs, networkConf.ExternalAddrs)
+	if err != nil {
+		return nil, fmt.Errorf("fail to create listen with given external IP: %w", err)
 	}
+	gater := NewConnectionGater(trustedPeerIDs(conf.BootstrapPeers, networkConf.RelayPeers))
+	logger := log.With().Str("module", "communication").Logger()
+	rateLimiter := NewRateLimiter(gater, networkConf)
+	stopChan := make(chan struct{})
 	return &Communication{
-		rendezvous:       rendezvous,
-		bootstrapPeers:   bootstrapPeers,
-		logger:           log.With().Str("module", "communication").Logger(),
-		listenAddr:       addr,
+		messageRouter:    newMessageRouter(logger, rateLimiter, stopChan),
+		rendezvous:       conf.RendezvousString,
+		bootstrapPeers:   append(append([]maddr.Multiaddr{}, conf.KnownPeers...), conf.BootstrapPeers...),
+		logger:           logger,
+		listenAddrs:      listenAddrs,
 		wg:               &sync.WaitGroup{},
-		stopChan:         make(chan struct{}),
-		subscribers:      make(map[messages.THORChainTSSMessageType]*MessageIDSubscriber),
-		subscriberLocker: &sync.Mutex{},
+		stopChan:         stopChan,
 		streamCount:      0,
-		BroadcastMsgChan: make(chan *messages.BroadcastMsgChan, 1024),
-		externalAddr:     externalAddr,
+		BroadcastMsgChan: make(chan *messages.BroadcastMsgChan, networkConf.BroadcastBufferSize),
+		externalAddrs:    externalAddrs,
 		streamMgr:        NewStreamMgr(),
+		conf:             networkConf,
+		gater:            gater,
+		peerStreams:      make(map[peer.ID]*peerStream),
+		peerStreamsLock:  &sync.Mutex{},
+		idleTimeout:      StreamIdleTimeout,
+		rateLimiter:      rateLimiter,
 	}, nil
 }
 
+// trustedPeerIDs return the peer IDs of the bootstrap and relay peers, they are configured by the operator
+// so the connection gater always allows them, the known peers we saved in a previous run are not among them
+func trustedPeerIDs(bootstrapPeers, relayPeers []maddr.Multiaddr) []peer.ID {
+	var trustedPeers []peer.ID
+	for _, el := range append(append([]maddr.Multiaddr{}, bootstrapPeers...), relayPeers...) {
+		if pi, err := peer.AddrInfoFromP2pAddr(el); err == nil {
+			trustedPeers = append(trustedPeers, pi.ID)
+		}
+	}
+	return trustedPeers
+}
+
+// buildExternalAddrs return the addresses we advertise to the peers, the external IP replaces the IP of every
+// listen address of the same family, so we advertise it on all the transports we listen on
+func buildExternalAddrs(externalIP string, listenAddrs, extraAddrs []maddr.Multiaddr) ([]maddr.Multiaddr, error) {
+	externalAddrs := append([]maddr.Multiaddr{}, extraAddrs...)
+	if len(externalIP) == 0 {
+		return externalAddrs, nil
+	}
+	ip := net.ParseIP(externalIP)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid external IP(%s)", externalIP)
+	}
+	ipProtocol := maddr.P_IP6
+	if ip.To4() != nil {
+		ipProtocol = maddr.P_IP4
+	}
+	ipAddr, err := manet.FromIP(ip)
+	if err != nil {
+		return nil, fmt.Errorf("invalid external IP(%s): %w", externalIP, err)
+	}
+	found := false
+	for _, el := range listenAddrs {
+		first, rest := maddr.SplitFirst(el)
+		if first == nil || rest == nil || first.Protocol().Code != ipProtocol {
+			continue
+		}
+		externalAddrs = append(externalAddrs, ipAddr.Encapsulate(rest))
+		found = true
+	}
+	if !found {
+		return nil, fmt.Errorf("no listen address in the same family as the external IP(%s)", externalIP)
+	}
+	return externalAddrs, nil
+}
+
+// EnableCompression set whether we compress the payload for the peers that support it, it has to be called
+// before Start, as it decides whether we advertise the compressed tss protocol to our peers
+func (c *Communication) EnableCompression(enabled bool) {
+	c.compression = enabled
+}
+
+// SetHealthObserver set the function that will be called with the result of every ping of the health monitor,
+// it has to be called before Start
+func (c *Communication) SetHealthObserver(observer func(pID peer.ID, rtt time.Duration, err error)) {
+	c.healthObserver = observer
+}
+
+// GetHealthMonitor return the monitor that keep track of the health of the committee peers, it is nil until
+// the communication starts
+func (c *Communication) GetHealthMonitor() *HealthMonitor {
+	return c.healthMonitor
+}
+
+// tssProtocols return the tss protocols we offer when opening a stream, in our order of preference, the
+// versioned ones come first
+func (c *Communication) tssProtocols() []protocol.ID {
+	if c.compression {
+		return WithLegacyProtocolIDs(TSSCompressedStreamProtocolID, TSSStreamProtocolID, TSSCompressedProtocolID, TSSProtocolID)
+	}
+	return WithLegacyProtocolIDs(TSSStreamProtocolID, TSSProtocolID)
+}
+
+// GetNetworkConfig return the timeouts and limits the communication runs with
+func (c *Communication) GetNetworkConfig() NetworkConfig {
+	return c.conf
+}
+
+// GetRateLimiter return the rate limiter of the inbound streams, the party coordinator and the signature
+// notifier share it so a peer has the same limits on all the protocols
+func (c *Communication) GetRateLimiter() *RateLimiter {
+	return c.rateLimiter
+}
+
+// GetConnectionGater return the connection gater that restrict the peers we talk to
+func (c *Communication) GetConnectionGater() *ConnectionGater {
+	return c.gater
+}
+
+// SetAllowedPubKeys update the allowlist of the node pub keys we accept connections from, the connections to
+// the peers that are no longer allowed are closed
+func (c *Communication) SetAllowedPubKeys(pubKeys []string) error {
+	if c.host == nil {
+		_, err := c.gater.SetAllowedPubKeys(pubKeys, nil)
+		return err
+	}
+	removed, err := c.gater.SetAllowedPubKeys(pubKeys, c.host.Network().Peers())
+	if err != nil {
+		return err
+	}
+	for _, pID := range removed {
+		if err := c.host.Network().ClosePeer(pID); err != nil {
+			c.logger.Error().Err(err).Msgf("fail to close the connection to peer(%s)", pID)
+		}
+	}
+	return nil
+}
+
 // GetHost return the host
 func (c *Communication) GetHost() host.Host {
 	return c.host
 }
 
+// ID implement StreamHost
+func (c *Communication) ID() peer.ID {
+	return c.host.ID()
+}
+
+// NewStream implement StreamHost
+func (c *Communication) NewStream(ctx context.Context, p peer.ID, pids ...protocol.ID) (network.Stream, error) {
+	return c.host.NewStream(ctx, p, pids...)
+}
+
+// SetStreamHandler implement StreamHost
+func (c *Communication) SetStreamHandler(pid protocol.ID, handler network.StreamHandler) {
+	c.host.SetStreamHandler(pid, handler)
+}
+
+// RemoveStreamHandler implement StreamHost
+func (c *Communication) RemoveStreamHandler(pid protocol.ID) {
+	c.host.RemoveStreamHandler(pid)
+}
+
+// BroadcastChannel implement Transport
+func (c *Communication) BroadcastChannel() chan *messages.BroadcastMsgChan {
+	return c.BroadcastMsgChan
+}
+
 // GetLocalPeerID from p2p host
 func (c *Communication) GetLocalPeerID() string {
 	return c.host.ID().String()
@@ -103,15 +256,20 @@ func (c *Communication) GetLocalPeerID() string {
 
 // Broadcast message to Peers
 func (c *Communication) Broadcast(peers []peer.ID, msg []byte, msgID string) {
+	c.BroadcastWithDeadline(peers, msg, msgID, time.Now().Add(DefaultDeliveryTimeout))
+}
+
+// BroadcastWithDeadline broadcast the message to peers, we keep retrying the failed deliveries until the deadline
+func (c *Communication) BroadcastWithDeadline(peers []peer.ID, msg []byte, msgID string, deadline time.Time) {
 	if len(peers) == 0 {
 		return
 	}
 	// try to discover all peers and then broadcast the messages
 	c.wg.Add(1)
-	go c.broadcastToPeers(peers, msg, msgID)
+	go c.broadcastToPeers(peers, msg, msgID, deadline)
 }
 
-func (c *Communication) broadcastToPeers(peers []peer.ID, msg []byte, msgID string) {
+func (c *Communication) broadcastToPeers(peers []peer.ID, msg []byte, msgID string, deadline time.Time) {
 	defer c.wg.Done()
 	defer func() {
 		c.logger.Debug().Msgf("finished sending message to peer(%v)", peers)
@@ -121,7 +279,7 @@ func (c *Communication) broadcastToPeers(peers []peer.ID, msg []byte, msgID stri
 	for _, p := range peers {
 		go func(p peer.ID) {
 			defer wgSend.Done()
-			if err := c.writeToStream(p, msg, msgID); nil != err {
+			if err := c.writeToStream(p, msg, msgID, deadline); nil != err {
 				c.logger.Error().Err(err).Msg("fail to write to stream")
 			}
 		}(p)
@@ -129,25 +287,47 @@ func (c *Communication) broadcastToPeers(peers []peer.ID, msg []byte, msgID stri
 	wgSend.Wait()
 }
 
-func (c *Communication) writeToStream(pID peer.ID, msg []byte, msgID string) error {
-	// don't send to ourselves
-	if pID == c.host.ID() {
-		return nil
+// getPeerStream return the outbound stream to the given peer, it is created on the first use
+func (c *Communication) getPeerStream(pID peer.ID) *peerStream {
+	c.peerStreamsLock.Lock()
+	defer c.peerStreamsLock.Unlock()
+	ps, ok := c.peerStreams[pID]
+	if !ok {
+		ps = newPeerStream(pID, c.connectToOnePeer, c.retirePeerStream, c.streamMgr, c.conf, c.idleTimeout, c.logger, c.wg, c.stopChan)
+		c.peerStreams[pID] = ps
+		c.wg.Add(1)
+		go ps.run()
 	}
-	stream, err := c.connectToOnePeer(pID)
-	if err != nil {
-		return fmt.Errorf("fail to open stream to peer(%s): %w", pID, err)
+	return ps
+}
+
+// retirePeerStream remove the idle outbound stream of the peer, so we do not keep a goroutine for every peer
+// we have ever talked to, it fails if a sender is still waiting on the stream
+func (c *Communication) retirePeerStream(ps *peerStream) bool {
+	c.peerStreamsLock.Lock()
+	defer c.peerStreamsLock.Unlock()
+	if !ps.tryRetire() {
+		return false
 	}
-	if nil == stream {
-		return nil
+	if c.peerStreams[ps.remotePeer] == ps {
+		delete(c.peerStreams, ps.remotePeer)
 	}
+	return true
+}
 
-	defer func() {
-		c.streamMgr.AddStream(msgID, stream)
-	}()
+func (c *Communication) writeToStream(pID peer.ID, msg []byte, msgID string, deadline time.Time) error {
+	// don't send to ourselves
+	if pID == c.host.ID() {
+		return nil
+	}
 	c.logger.Debug().Msgf(">>>writing messages to peer(%s)", pID)
-
-	return WriteStreamWithBuffer(msg, stream)
+	for {
+		// the peer stream may retire between the moment we get it and the moment we send on it
+		err := c.getPeerStream(pID).Send(msgID, msg, deadline)
+		if !errors.Is(err, errPeerStreamRetired) {
+			return err
+		}
+	}
 }
 
 func (c *Communication) readFromStream(stream network.Stream) {
@@ -158,38 +338,76 @@ func (c *Communication) readFromStream(stream network.Stream) {
 	case <-c.stopChan:
 		return
 	default:
-		dataBuf, err := ReadStreamWithBuffer(stream)
+		dataBuf, err := ReadStreamWithLimiter(stream, c.conf, c.rateLimiter)
 		if err != nil {
 			c.logger.Error().Err(err).Msgf("fail to read from stream,peerID: %s", peerID)
 			c.streamMgr.AddStream("UNKNOWN", stream)
 			return
 		}
-		var wrappedMsg messages.WrappedMessage
-		if err := json.Unmarshal(dataBuf, &wrappedMsg); nil != err {
-			c.logger.Error().Err(err).Msg("fail to unmarshal wrapped message bytes")
+		msgID, err := c.dispatchMessage(stream.Conn().RemotePeer(), dataBuf)
+		if err != nil {
+			c.logger.Error().Err(err).Msg("fail to dispatch the message")
 			c.streamMgr.AddStream("UNKNOWN", stream)
 			return
 		}
-		c.logger.Debug().Msgf(">>>>>>>[%s] %s", wrappedMsg.MessageType, string(wrappedMsg.Payload))
-		c.streamMgr.AddStream(wrappedMsg.MsgID, stream)
-		channel := c.getSubscriber(wrappedMsg.MessageType, wrappedMsg.MsgID)
-		if nil == channel {
-			c.logger.Debug().Msgf("no MsgID %s found for this message", wrappedMsg.MsgID)
-			c.logger.Debug().Msgf("no MsgID %s found for this message", wrappedMsg.MessageType)
+		c.streamMgr.AddStream(msgID, stream)
+	}
+}
+
+// readFromPersistentStream read all the messages the peer sends over the long-lived stream until it is closed
+func (c *Communication) readFromPersistentStream(stream network.Stream) {
+	peerID := stream.Conn().RemotePeer().String()
+	c.logger.Debug().Msgf("reading from persistent stream of peer: %s", peerID)
+	streamReader := bufio.NewReader(stream)
+	for {
+		select {
+		case <-c.stopChan:
+			_ = stream.Reset()
+			return
+		default:
+		}
+		dataBuf, err := readFrame(stream, streamReader, StreamIdleTimeout, c.conf.MaxPayload, c.rateLimiter)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				c.logger.Debug().Msgf("persistent stream closed by peer: %s", peerID)
+				_ = stream.Close()
+				return
+			}
+			c.logger.Error().Err(err).Msgf("fail to read from persistent stream,peerID: %s", peerID)
+			_ = stream.Reset()
 			return
 		}
-		channel <- &Message{
-			PeerID:  stream.Conn().RemotePeer(),
-			Payload: dataBuf,
+		// acknowledge the message with an empty frame, so the peer does not need to send it again, if the
+		// acknowledgement is lost we receive the message twice. The message we could not queue is not acknowledged
+		// but refused, so the peer sends it again later, the other failures would fail again so we drop them.
+		var ack []byte
+		if _, err := c.dispatchMessage(stream.Conn().RemotePeer(), dataBuf); err != nil {
+			c.logger.Error().Err(err).Msg("fail to dispatch the message")
+			if errors.Is(err, errSubscriberQueueFull) {
+				ack = nackFrame
+			}
+		}
+		if err := WriteStreamWithConfig(ack, stream, c.conf); err != nil {
+			c.logger.Error().Err(err).Msgf("fail to acknowledge the message,peerID: %s", peerID)
+			_ = stream.Reset()
+			return
 		}
-
 	}
 }
 
 func (c *Communication) handleStream(stream network.Stream) {
 	peerID := stream.Conn().RemotePeer().String()
 	c.logger.Debug().Msgf("handle stream from peer: %s", peerID)
+	if err := c.rateLimiter.AllowStream(stream.Conn().RemotePeer()); err != nil {
+		c.logger.Warn().Err(err).Msg("drop the stream")
+		_ = stream.Reset()
+		return
+	}
 	// we will read from that stream
+	if isPersistentProtocol(stream.Protocol()) {
+		c.readFromPersistentStream(stream)
+		return
+	}
 	c.readFromStream(stream)
 }
 
@@ -209,7 +427,7 @@ func (c *Communication) bootStrapConnectivityCheck() error {
 		}
 		wg.Add(1)
 		go func() {
-			ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+			ctx, cancel := context.WithTimeout(context.Background(), c.conf.PingTimeout)
 			defer cancel()
 			defer wg.Done()
 			outChan := ping.Ping(ctx, c.host, peer.ID)
@@ -223,7 +441,7 @@ func (c *Communication) bootStrapConnectivityCheck() error {
 					atomic.AddUint32(&onlineNodes, 1)
 				}
 			case <-ctx.Done():
-				c.logger.Error().Msgf("fail to ping the node %s within 2 seconds", peer.ID)
+				c.logger.Error().Msgf("fail to ping the node %s within %s", peer.ID, c.conf.PingTimeout)
 			}
 		}()
 	}
@@ -246,53 +464,65 @@ func (c *Communication) startChannel(privKeyBytes []byte) error {
 	}
 
 	addressFactory := func(addrs []maddr.Multiaddr) []maddr.Multiaddr {
-		if c.externalAddr != nil {
-			return []maddr.Multiaddr{c.externalAddr}
+		if len(c.externalAddrs) != 0 {
+			return c.externalAddrs
 		}
 		return addrs
 	}
 
-	h, err := libp2p.New(
-		libp2p.ListenAddrs([]maddr.Multiaddr{c.listenAddr}...),
+	natOpts, err := c.natOptions()
+	if err != nil {
+		return err
+	}
+	resourceManager, err := newResourceManager(c.conf)
+	if err != nil {
+		return err
+	}
+	h, err := libp2p.New(append([]libp2p.Option{
+		libp2p.ListenAddrs(c.listenAddrs...),
 		libp2p.Identity(p2pPriKey),
 		libp2p.AddrsFactory(addressFactory),
-	)
+		libp2p.ConnectionGater(c.gater),
+		libp2p.ResourceManager(resourceManager),
+	}, natOpts...)...)
 	if err != nil {
 		return fmt.Errorf("fail to create p2p host: %w", err)
 	}
 	c.host = h
+	c.rateLimiter.setClosePeer(h.Network().ClosePeer)
+	if err := c.watchReachability(); err != nil {
+		return err
+	}
 	c.logger.Info().Msgf("Host created, we are: %s, at: %s", h.ID(), h.Addrs())
-	h.SetStreamHandler(TSSProtocolID, c.handleStream)
-	// Start a DHT, for use in peer discovery. We can't just make a new DHT
-	// client because we want each peer to maintain its own local copy of the
-	// DHT, so that the bootstrapping node of the DHT can go down without
-	// inhibiting future peer discovery.
-	kademliaDHT, err := dht.New(ctx, h, dht.Mode(dht.ModeServer))
+	for _, pID := range c.tssProtocols() {
+		h.SetStreamHandler(pID, c.handleStream)
+	}
+	discovery, err := newPeerDiscovery(c.conf.Discovery, h, c.rendezvous, c.bootstrapPeers, c.conf, c.logger)
 	if err != nil {
-		return fmt.Errorf("fail to create DHT: %w", err)
+		return err
 	}
-	c.logger.Debug().Msg("Bootstrapping the DHT")
-	if err = kademliaDHT.Bootstrap(ctx); err != nil {
-		return fmt.Errorf("fail to bootstrap DHT: %w", err)
+	c.discovery = discovery
+	c.logger.Info().Msgf("start the %s discovery", c.conf.Discovery)
+	if err := discovery.Start(ctx); err != nil {
+		return err
 	}
 
 	var connectionErr error
-	for i := 0; i < 5; i++ {
+	for i := 0; i < c.conf.BootstrapAttempts; i++ {
 		connectionErr = c.connectToBootstrapPeers()
-		if connectionErr == nil {
+		if connectionErr == nil || i == c.conf.BootstrapAttempts-1 {
 			break
 		}
-		c.logger.Error().Msg("cannot connect to any bootstrap node, retry in 5 seconds")
-		time.Sleep(time.Second * 5)
+		c.logger.Error().Msgf("cannot connect to any bootstrap node, retry in %s", c.conf.BootstrapRetryInterval)
+		time.Sleep(c.conf.BootstrapRetryInterval)
 	}
 	if connectionErr != nil {
 		return fmt.Errorf("fail to connect to bootstrap peer: %w", connectionErr)
 	}
 
-	// We use a rendezvous point "meet me here" to announce our location.
-	// This is like telling your friends to meet you at the Eiffel Tower.
-	routingDiscovery := routing.NewRoutingDiscovery(kademliaDHT)
-	routingutil.Advertise(ctx, routingDiscovery, c.rendezvous)
+	if err := discovery.Announce(ctx); err != nil {
+		return fmt.Errorf("fail to announce the node: %w", err)
+	}
 	err = c.bootStrapConnectivityCheck()
 	if err != nil {
 		return err
@@ -309,9 +539,12 @@ func (c *Communication) connectToOnePeer(pID peer.ID) (network.Stream, error) {
 		return nil, nil
 	}
 	c.logger.Debug().Msgf("connect to peer : %s", pID.String())
-	ctx, cancel := context.WithTimeout(context.Background(), TimeoutConnecting)
+	ctx, cancel := context.WithTimeout(context.Background(), c.conf.TimeoutConnecting)
 	defer cancel()
-	stream, err := c.host.NewStream(ctx, pID, TSSProtocolID)
+	// the protocols are proposed in order, the peers without compression support reject the compressed one and
+	// fall back to the plain tss protocol
+	// the nodes behind a NAT may only be reachable through a relayed connection
+	stream, err := c.host.NewStream(network.WithAllowLimitedConn(ctx, "tss"), pID, c.tssProtocols()...)
 	if err != nil {
 		return nil, fmt.Errorf("fail to create new stream to peer: %s, %w", pID, err)
 	}
@@ -335,7 +568,7 @@ func (c *Communication) connectToBootstrapPeers() error {
 		wg.Add(1)
 		go func(connRet chan bool) {
 			defer wg.Done()
-			ctx, cancel := context.WithTimeout(context.Background(), TimeoutConnecting)
+			ctx, cancel := context.WithTimeout(context.Background(), c.conf.TimeoutConnecting)
 			defer cancel()
 			if err := c.host.Connect(ctx, *pi); err != nil {
 				c.logger.Error().Err(err).Msgf("fail to connect to %s", pi.String())
@@ -361,6 +594,13 @@ func (c *Communication) Start(priKeyBytes []byte) error {
 	if err == nil {
 		c.wg.Add(1)
 		go c.ProcessBroadcast()
+		c.healthMonitor = NewHealthMonitor(c.host, c.gater, c.conf)
+		c.healthMonitor.SetObserver(c.healthObserver)
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			c.healthMonitor.Run(c.stopChan)
+		}()
 	}
 	return err
 }
@@ -368,6 +608,11 @@ func (c *Communication) Start(priKeyBytes []byte) error {
 // Stop communication
 func (c *Communication) Stop() error {
 	// we need to stop the handler and the p2p services firstly, then terminate the our communication threads
+	if c.discovery != nil {
+		if err := c.discovery.Close(); err != nil {
+			c.logger.Err(err).Msg("fail to close the peer discovery")
+		}
+	}
 	if err := c.host.Close(); err != nil {
 		c.logger.Err(err).Msg("fail to close host network")
 	}
@@ -377,47 +622,6 @@ func (c *Communication) Stop() error {
 	return nil
 }
 
-func (c *Communication) SetSubscribe(topic messages.THORChainTSSMessageType, msgID string, channel chan *Message) {
-	c.subscriberLocker.Lock()
-	defer c.subscriberLocker.Unlock()
-
-	messageIDSubscribers, ok := c.subscribers[topic]
-	if !ok {
-		messageIDSubscribers = NewMessageIDSubscriber()
-		c.subscribers[topic] = messageIDSubscribers
-	}
-	messageIDSubscribers.Subscribe(msgID, channel)
-}
-
-func (c *Communication) getSubscriber(topic messages.THORChainTSSMessageType, msgID string) chan *Message {
-	c.subscriberLocker.Lock()
-	defer c.subscriberLocker.Unlock()
-	messageIDSubscribers, ok := c.subscribers[topic]
-	if !ok {
-		c.logger.Debug().Msgf("fail to find subscribers for %s", topic)
-		return nil
-	}
-	return messageIDSubscribers.GetSubscriber(msgID)
-}
-
-func (c *Communication) CancelSubscribe(topic messages.THORChainTSSMessageType, msgID string) {
-	c.subscriberLocker.Lock()
-	defer c.subscriberLocker.Unlock()
-
-	messageIDSubscribers, ok := c.subscribers[topic]
-	if !ok {
-		c.logger.Debug().Msgf("cannot find the given channels %s", topic.String())
-		return
-	}
-	if nil == messageIDSubscribers {
-		return
-	}
-	messageIDSubscribers.UnSubscribe(msgID)
-	if messageIDSubscribers.IsEmpty() {
-		delete(c.subscribers, topic)
-	}
-}
-
 func (c *Communication) ProcessBroadcast() {
 	c.logger.Debug().Msg("start to process broadcast message channel")
 	defer c.logger.Debug().Msg("stop process broadcast message channel")
@@ -425,13 +629,17 @@ func (c *Communication) ProcessBroadcast() {
 	for {
 		select {
 		case msg := <-c.BroadcastMsgChan:
-			wrappedMsgBytes, err := json.Marshal(msg.WrappedMessage)
+			wrappedMsgBytes, err := msg.WrappedMessage.Marshal(msg.Format)
 			if err != nil {
-				c.logger.Error().Err(err).Msg("fail to marshal a wrapped message to json bytes")
+				c.logger.Error().Err(err).Msgf("fail to marshal a wrapped message to %s bytes", msg.Format)
 				continue
 			}
 			c.logger.Debug().Msgf("broadcast message %s to %+v", msg.WrappedMessage, msg.PeersID)
-			c.Broadcast(msg.PeersID, wrappedMsgBytes, msg.WrappedMessage.MsgID)
+			deadline := msg.Deadline
+			if deadline.IsZero() {
+				deadline = time.Now().Add(DefaultDeliveryTimeout)
+			}
+			c.BroadcastWithDeadline(msg.PeersID, wrappedMsgBytes, msg.WrappedMessage.MsgID, deadline)
 
 		case <-c.stopChan:
 			return
@@ -3,13 +3,17 @@ package p2p
 import (
 	"crypto/rand"
 	"encoding/base64"
+	"time"
 
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/peerstore"
+	"github.com/libp2p/go-libp2p/core/protocol"
 	maddr "github.com/multiformats/go-multiaddr"
 
 	"github.com/libp2p/go-libp2p/core/crypto"
 	. "gopkg.in/check.v1"
 
+	"github.com/ordinox/thorchain-tss/conversion"
 	"github.com/ordinox/thorchain-tss/messages"
 )
 
@@ -18,7 +22,7 @@ type CommunicationTestSuite struct{}
 var _ = Suite(&CommunicationTestSuite{})
 
 func (CommunicationTestSuite) TestBasicCommunication(c *C) {
-	comm, err := NewCommunication("rendezvous", nil, 6668, "")
+	comm, err := NewCommunication(Config{RendezvousString: "rendezvous", Port: 6668})
 	c.Assert(err, IsNil)
 	c.Assert(comm, NotNil)
 	comm.SetSubscribe(messages.TSSKeyGenMsg, "hello", make(chan *Message))
@@ -47,7 +51,7 @@ func (CommunicationTestSuite) TestEstablishP2pCommunication(c *C) {
 	c.Assert(err, IsNil)
 	privKey, err := base64.StdEncoding.DecodeString(bootstrapPrivKey)
 	c.Assert(err, IsNil)
-	comm, err := NewCommunication("commTest", nil, 2220, fakeExternalIP)
+	comm, err := NewCommunication(Config{RendezvousString: "commTest", Port: 2220, ExternalIP: fakeExternalIP})
 	c.Assert(err, IsNil)
 	c.Assert(comm.Start(privKey), IsNil)
 
@@ -55,7 +59,7 @@ func (CommunicationTestSuite) TestEstablishP2pCommunication(c *C) {
 	sk1, _, err := crypto.GenerateSecp256k1Key(rand.Reader)
 	sk1raw, _ := sk1.Raw()
 	c.Assert(err, IsNil)
-	comm2, err := NewCommunication("commTest", []maddr.Multiaddr{validMultiAddr}, 2221, "")
+	comm2, err := NewCommunication(Config{RendezvousString: "commTest", BootstrapPeers: []maddr.Multiaddr{validMultiAddr}, Port: 2221})
 	c.Assert(err, IsNil)
 	err = comm2.Start(sk1raw)
 	c.Assert(err, IsNil)
@@ -69,14 +73,14 @@ func (CommunicationTestSuite) TestEstablishP2pCommunication(c *C) {
 	invalidAddr := "/ip4/127.0.0.1/tcp/2220/p2p/" + id.String()
 	invalidMultiAddr, err := maddr.NewMultiaddr(invalidAddr)
 	c.Assert(err, IsNil)
-	comm3, err := NewCommunication("commTest", []maddr.Multiaddr{invalidMultiAddr}, 2222, "")
+	comm3, err := NewCommunication(Config{RendezvousString: "commTest", BootstrapPeers: []maddr.Multiaddr{invalidMultiAddr}, Port: 2222})
 	c.Assert(err, IsNil)
 	err = comm3.Start(sk1raw)
 	c.Assert(err, ErrorMatches, "fail to connect to bootstrap peer: fail to connect to any peer")
 	defer comm3.Stop()
 
 	// we connect to one invalid and one valid address
-	comm4, err := NewCommunication("commTest", []maddr.Multiaddr{invalidMultiAddr, validMultiAddr}, 2223, "")
+	comm4, err := NewCommunication(Config{RendezvousString: "commTest", BootstrapPeers: []maddr.Multiaddr{invalidMultiAddr, validMultiAddr}, Port: 2223})
 	c.Assert(err, IsNil)
 	err = comm4.Start(sk1raw)
 	c.Assert(err, IsNil)
@@ -89,3 +93,147 @@ func (CommunicationTestSuite) TestEstablishP2pCommunication(c *C) {
 	ps = comm4.host.Peerstore()
 	c.Assert(checkExist(ps.Addrs(comm.host.ID()), fakeExternalMultiAddr), Equals, true)
 }
+
+func (CommunicationTestSuite) TestCompressionInterop(c *C) {
+	bootstrapPeer := "/ip4/127.0.0.1/tcp/2230/p2p/16Uiu2HAm4TmEzUqy3q3Dv7HvdoSboHk5sFj2FH3npiN5vDbJC6gh"
+	bootstrapPrivKey := "6LABmWB4iXqkqOJ9H0YFEA2CSSx6bA7XAKGyI/TDtas="
+	validMultiAddr, err := maddr.NewMultiaddr(bootstrapPeer)
+	c.Assert(err, IsNil)
+	privKey, err := base64.StdEncoding.DecodeString(bootstrapPrivKey)
+	c.Assert(err, IsNil)
+	comm, err := NewCommunication(Config{RendezvousString: "commTest", Port: 2230})
+	c.Assert(err, IsNil)
+	comm.EnableCompression(true)
+	c.Assert(comm.Start(privKey), IsNil)
+	defer comm.Stop()
+
+	sk1, _, err := crypto.GenerateSecp256k1Key(rand.Reader)
+	c.Assert(err, IsNil)
+	sk1raw, _ := sk1.Raw()
+	comm2, err := NewCommunication(Config{RendezvousString: "commTest", BootstrapPeers: []maddr.Multiaddr{validMultiAddr}, Port: 2231})
+	c.Assert(err, IsNil)
+	comm2.EnableCompression(true)
+	c.Assert(comm2.Start(sk1raw), IsNil)
+	defer comm2.Stop()
+
+	sk2, _, err := crypto.GenerateSecp256k1Key(rand.Reader)
+	c.Assert(err, IsNil)
+	sk2raw, _ := sk2.Raw()
+	plain, err := NewCommunication(Config{RendezvousString: "commTest", BootstrapPeers: []maddr.Multiaddr{validMultiAddr}, Port: 2232})
+	c.Assert(err, IsNil)
+	c.Assert(plain.Start(sk2raw), IsNil)
+	defer plain.Stop()
+
+	payload := make([]byte, CompressionThreshold*4)
+	wrapped := messages.WrappedMessage{
+		MessageType: messages.TSSKeyGenMsg,
+		MsgID:       "compression",
+		Payload:     payload,
+	}
+	buf, err := wrapped.Marshal(messages.WireFormatProtobuf)
+	c.Assert(err, IsNil)
+
+	for _, receiver := range []*Communication{comm2, plain} {
+		receiver.host.Peerstore().AddAddrs(comm.host.ID(), comm.host.Addrs(), peerstore.PermanentAddrTTL)
+		comm.host.Peerstore().AddAddrs(receiver.host.ID(), receiver.host.Addrs(), peerstore.PermanentAddrTTL)
+		ch := make(chan *Message, 1)
+		receiver.SetSubscribe(messages.TSSKeyGenMsg, "compression", ch)
+		c.Assert(comm.writeToStream(receiver.host.ID(), buf, "compression", time.Now().Add(time.Minute)), IsNil)
+		select {
+		case msg := <-ch:
+			c.Assert(msg.Payload, DeepEquals, buf)
+		case <-time.After(5 * time.Second):
+			c.Fatal("timeout waiting for the message")
+		}
+		receiver.CancelSubscribe(messages.TSSKeyGenMsg, "compression")
+	}
+	c.Assert(streamProtocols(comm.host, comm2.host.ID()), DeepEquals, []protocol.ID{VersionedProtocolID(TSSCompressedStreamProtocolID)})
+	c.Assert(streamProtocols(comm.host, plain.host.ID()), DeepEquals, []protocol.ID{VersionedProtocolID(TSSStreamProtocolID)})
+}
+
+func (CommunicationTestSuite) TestBuildExternalAddrs(c *C) {
+	listenAddrs := []maddr.Multiaddr{
+		maddr.StringCast("/ip4/0.0.0.0/tcp/6668"),
+		maddr.StringCast("/ip4/0.0.0.0/udp/6668/quic-v1"),
+		maddr.StringCast("/ip6/::/tcp/6668"),
+	}
+	extra := []maddr.Multiaddr{maddr.StringCast("/dns4/tss.example.com/tcp/6668")}
+	addrs, err := buildExternalAddrs("", listenAddrs, extra)
+	c.Assert(err, IsNil)
+	c.Assert(addrs, DeepEquals, extra)
+
+	addrs, err = buildExternalAddrs("11.22.33.44", listenAddrs, extra)
+	c.Assert(err, IsNil)
+	c.Assert(addrs, HasLen, 3)
+	c.Assert(checkExist(addrs, "/ip4/11.22.33.44/tcp/6668"), Equals, true)
+	c.Assert(checkExist(addrs, "/ip4/11.22.33.44/udp/6668/quic-v1"), Equals, true)
+
+	addrs, err = buildExternalAddrs("2001:db8::1", listenAddrs, nil)
+	c.Assert(err, IsNil)
+	c.Assert(addrs, HasLen, 1)
+	c.Assert(checkExist(addrs, "/ip6/2001:db8::1/tcp/6668"), Equals, true)
+
+	_, err = buildExternalAddrs("2001:db8::1", listenAddrs[:2], nil)
+	c.Assert(err, NotNil)
+	_, err = buildExternalAddrs("not an ip", listenAddrs, nil)
+	c.Assert(err, NotNil)
+}
+
+func (CommunicationTestSuite) TestKnownPeersAreNotTrusted(c *C) {
+	conversion.SetupBech32Prefix()
+	bootstrapPeer := maddr.StringCast("/ip4/127.0.0.1/tcp/2220/p2p/16Uiu2HAm4TmEzUqy3q3Dv7HvdoSboHk5sFj2FH3npiN5vDbJC6gh")
+	knownPeer := maddr.StringCast("/ip4/127.0.0.1/tcp/2221/p2p/16Uiu2HAkxoNfxKe6NEUzzbEpGZ4zoVCHwWaxsgdc3imTsWJhoSbb")
+	comm, err := NewCommunication(Config{
+		RendezvousString: "commTest",
+		Port:             6668,
+		BootstrapPeers:   []maddr.Multiaddr{bootstrapPeer},
+		KnownPeers:       []maddr.Multiaddr{knownPeer},
+	})
+	c.Assert(err, IsNil)
+	// we dial both of them, but only the bootstrap peer of the operator bypass the allowlist
+	c.Assert(comm.bootstrapPeers, HasLen, 2)
+	c.Assert(comm.SetAllowedPubKeys([]string{conversion.GetRandomPubKey()}), IsNil)
+	bootstrapInfo, err := peer.AddrInfoFromP2pAddr(bootstrapPeer)
+	c.Assert(err, IsNil)
+	knownInfo, err := peer.AddrInfoFromP2pAddr(knownPeer)
+	c.Assert(err, IsNil)
+	c.Assert(comm.gater.IsAllowed(bootstrapInfo.ID), Equals, true)
+	c.Assert(comm.gater.IsAllowed(knownInfo.ID), Equals, false)
+}
+
+func (CommunicationTestSuite) TestMultiAddressTransports(c *C) {
+	sk, _, err := crypto.GenerateSecp256k1Key(rand.Reader)
+	c.Assert(err, IsNil)
+	skRaw, err := sk.Raw()
+	c.Assert(err, IsNil)
+	comm, err := NewCommunication(Config{RendezvousString: "commTest", NetworkConfig: NetworkConfig{
+		ListenAddrs: addrList{
+			maddr.StringCast("/ip4/127.0.0.1/tcp/2240"),
+			maddr.StringCast("/ip6/::1/tcp/2240"),
+			maddr.StringCast("/ip4/127.0.0.1/udp/2240/quic-v1"),
+			maddr.StringCast("/ip6/::1/udp/2240/quic-v1"),
+		},
+	}})
+	c.Assert(err, IsNil)
+	c.Assert(comm.Start(skRaw), IsNil)
+	defer comm.Stop()
+	c.Assert(comm.host.Addrs(), HasLen, 4)
+
+	c.Assert(checkExist(comm.host.Addrs(), "/ip6/::1/udp/2240/quic-v1"), Equals, true)
+
+	// we bootstrap one node over each address family
+	for i, el := range []string{"/ip4/127.0.0.1/tcp/2240", "/ip6/::1/tcp/2240"} {
+		bootstrapPeer, err := maddr.NewMultiaddr(el + "/p2p/" + comm.host.ID().String())
+		c.Assert(err, IsNil)
+		sk, _, err := crypto.GenerateSecp256k1Key(rand.Reader)
+		c.Assert(err, IsNil)
+		skRaw, err := sk.Raw()
+		c.Assert(err, IsNil)
+		node, err := NewCommunication(Config{RendezvousString: "commTest", BootstrapPeers: []maddr.Multiaddr{bootstrapPeer}, Port: 2241 + i})
+		c.Assert(err, IsNil)
+		c.Assert(node.Start(skRaw), IsNil, Commentf(el))
+		conns := node.host.Network().ConnsToPeer(comm.host.ID())
+		c.Assert(conns, Not(HasLen), 0)
+		c.Assert(node.Stop(), IsNil)
+	}
+}
@@ -0,0 +1,81 @@
+package p2p
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+const (
+	// CompressionThreshold is the minimum payload size we bother to compress, smaller payloads are sent as is
+	CompressionThreshold = 4096
+	// compressedFlag is set on the length header when the payload is zstd compressed, as the max payload fits
+	// into 31 bits, the highest bit of the header is never used by an uncompressed frame
+	compressedFlag uint32 = 1 << 31
+)
+
+// TSSCompressedProtocolID is the tss protocol id used between the peers that can handle zstd compressed payloads
+var TSSCompressedProtocolID protocol.ID = "/p2p/tss/zstd"
+
+var errPayloadNotCompressible = errors.New("payload is not compressible")
+
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault), zstd.WithEncoderConcurrency(1))
+	// zstdDecoders keep a decoder per max payload, as the limit is set when the decoder is created
+	zstdDecoders = &sync.Map{}
+)
+
+// getDecoder return the decoder that refuses to inflate anything larger than the max payload, which protect
+// us from decompression bombs
+func getDecoder(maxPayload int) (*zstd.Decoder, error) {
+	if decoder, ok := zstdDecoders.Load(maxPayload); ok {
+		return decoder.(*zstd.Decoder), nil
+	}
+	decoder, err := zstd.NewReader(nil, zstd.WithDecoderMaxMemory(uint64(maxPayload)), zstd.WithDecoderConcurrency(0))
+	if err != nil {
+		return nil, fmt.Errorf("fail to create the zstd decoder: %w", err)
+	}
+	actual, loaded := zstdDecoders.LoadOrStore(maxPayload, decoder)
+	if loaded {
+		decoder.Close()
+	}
+	return actual.(*zstd.Decoder), nil
+}
+
+// IsCompressionSupported tells whether the stream of the given protocol can carry compressed payloads
+func IsCompressionSupported(pID protocol.ID) bool {
+	pID = baseProtocolID(pID)
+	return pID == TSSCompressedProtocolID || pID == TSSCompressedStreamProtocolID
+}
+
+// compressPayload compress the payload if it is worthwhile, errPayloadNotCompressible is returned if the
+// payload is too small or compression does not make it any smaller
+func compressPayload(payload []byte) ([]byte, error) {
+	if len(payload) < CompressionThreshold {
+		return nil, errPayloadNotCompressible
+	}
+	compressed := zstdEncoder.EncodeAll(payload, make([]byte, 0, len(payload)/2))
+	if len(compressed) >= len(payload) {
+		return nil, errPayloadNotCompressible
+	}
+	return compressed, nil
+}
+
+// decompressPayload inflate the compressed payload, it fails if the inflated payload exceed the max payload
+func decompressPayload(compressed []byte, maxPayload int) ([]byte, error) {
+	decoder, err := getDecoder(maxPayload)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := decoder.DecodeAll(compressed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("fail to decompress the payload: %w", err)
+	}
+	if len(payload) > maxPayload {
+		return nil, fmt.Errorf("decompressed payload length:%d exceed max payload length:%d", len(payload), maxPayload)
+	}
+	return payload, nil
+}
@@ -0,0 +1,346 @@
+package p2p
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	maddr "github.com/multiformats/go-multiaddr"
+)
+
+const (
+	// DefaultTimeoutConnecting maximum time for wait for peers to connect
+	DefaultTimeoutConnecting = time.Second * 20
+	// DefaultTimeoutReadPayload maximum time to read a message from a stream
+	DefaultTimeoutReadPayload = time.Second * 20
+	// DefaultTimeoutWritePayload maximum time to write a message to a stream
+	DefaultTimeoutWritePayload = time.Second * 20
+	// DefaultMaxPayload is the largest message we accept from a peer
+	DefaultMaxPayload = 20000000 // 20M
+	// DefaultPingTimeout maximum time to wait for a bootstrap node to answer the ping
+	DefaultPingTimeout = time.Second * 2
+	// DefaultLeaderRetryInterval is how long a party member waits before asking the leader again
+	DefaultLeaderRetryInterval = time.Millisecond * 500
+	// DefaultLeaderFailoverTimeout is how long a party member tries to reach the leader before it moves to the next one
+	DefaultLeaderFailoverTimeout = time.Second * 3
+	// DefaultSignerSelection is how the leader picks the signers of a keysign when more peers than needed are ready
+	DefaultSignerSelection = SelectByArrival
+	// DefaultSignerSelectionWindow is how long the leader waits for more peers before it picks the signers
+	DefaultSignerSelectionWindow = time.Millisecond * 500
+	// DefaultBootstrapAttempts is how many times we try to connect to the bootstrap nodes
+	DefaultBootstrapAttempts = 5
+	// DefaultBootstrapRetryInterval is how long we wait between two attempts to connect to the bootstrap nodes
+	DefaultBootstrapRetryInterval = time.Second * 5
+	// DefaultBroadcastBufferSize is how many outbound messages the broadcast channel buffers
+	DefaultBroadcastBufferSize = 1024
+	// DefaultDiscoveryMode is how the node finds the other tss nodes
+	DefaultDiscoveryMode = DiscoveryDHT
+	// DefaultHealthCheckInterval is how often we ping the committee peers
+	DefaultHealthCheckInterval = time.Second * 30
+	// DefaultHealthFailureThreshold is how many pings in a row a peer fails before we consider it unreachable
+	DefaultHealthFailureThreshold = 3
+	// DefaultMaxInboundStreamsPerPeer is how many inbound streams a peer can have open at the same time
+	DefaultMaxInboundStreamsPerPeer = 128
+	// DefaultStreamRate is how many streams per second a peer can open on average
+	DefaultStreamRate = 50
+	// DefaultStreamBurst is how many streams a peer can open at once
+	DefaultStreamBurst = 200
+	// DefaultByteRate is how many bytes per second a peer can send on average
+	DefaultByteRate = 8 << 20 // 8M
+	// DefaultMessageRate is how many messages per second of a ceremony a peer can send on average
+	DefaultMessageRate = 50
+	// DefaultMessageBurst is how many messages of a ceremony a peer can send at once
+	DefaultMessageBurst = 200
+	// DefaultBanThreshold is how many violations of its limits get a peer banned
+	DefaultBanThreshold = 10
+	// DefaultBanDuration is how long a peer that violates its limits is banned
+	DefaultBanDuration = time.Minute * 10
+
+	// maxPayloadLimit is the largest payload the length header can carry, its highest bit is the compressed flag
+	maxPayloadLimit = int(^compressedFlag)
+)
+
+// NetworkConfig holds the addresses, discovery mode, NAT traversal, timeouts and limits of the p2p layer, the
+// zero value of a field means its default
+type NetworkConfig struct {
+	// ListenAddrs are the TCP or QUIC multiaddrs we listen on, over IPv4 or IPv6, empty listens on TCP IPv4 at
+	// the configured port
+	ListenAddrs addrList
+	// ExternalAddrs are advertised to the peers on top of the addresses built from the external IP
+	ExternalAddrs addrList
+	// Discovery is how the node finds the other tss nodes
+	Discovery DiscoveryMode
+	// Reachability forces the node to be public or private, empty lets AutoNAT find it out
+	Reachability string
+	// EnableNATService answer the AutoNAT requests of the other nodes, so they can find out their reachability
+	EnableNATService bool
+	// RelayPeers are the circuit relay v2 nodes we reserve a slot on when we are not publicly reachable
+	RelayPeers addrList
+	// EnableRelayService run the node as a circuit relay v2 for the nodes the connection gater allows
+	EnableRelayService bool
+	// EnableHolePunching upgrade the relayed connections to direct ones with DCUtR
+	EnableHolePunching bool
+	// TimeoutConnecting maximum time for wait for peers to connect
+	TimeoutConnecting time.Duration
+	// TimeoutReadPayload maximum time to read a message from a stream
+	TimeoutReadPayload time.Duration
+	// TimeoutWritePayload maximum time to write a message to a stream
+	TimeoutWritePayload time.Duration
+	// MaxPayload is the largest message in bytes we accept from a peer
+	MaxPayload int
+	// PingTimeout maximum time to wait for a bootstrap node to answer the connectivity check
+	PingTimeout time.Duration
+	// LeaderRetryInterval is how long a party member waits before asking the leader again
+	LeaderRetryInterval time.Duration
+	// LeaderFailoverTimeout is how long a party member tries to reach the leader before it moves to the next one
+	// of the succession
+	LeaderFailoverTimeout time.Duration
+	// SignerSelection is how the leader picks the signers of a keysign when more peers than needed are ready
+	SignerSelection SignerSelection
+	// PreferredSigners are the peers the preference signer selection picks first, in this order
+	PreferredSigners peerList
+	// SignerSelectionWindow is how long the leader waits for more peers once it has enough of them, so the
+	// signer selection has a choice, the arrival order selection does not wait
+	SignerSelectionWindow time.Duration
+	// BootstrapAttempts is how many times we try to connect to the bootstrap nodes
+	BootstrapAttempts int
+	// BootstrapRetryInterval is how long we wait between two attempts to connect to the bootstrap nodes
+	BootstrapRetryInterval time.Duration
+	// BroadcastBufferSize is how many outbound messages the broadcast channel buffers
+	BroadcastBufferSize int
+	// HealthCheckInterval is how often we ping the committee peers
+	HealthCheckInterval time.Duration
+	// HealthFailureThreshold is how many pings in a row a peer fails before we consider it unreachable
+	HealthFailureThreshold int
+	// MaxInboundStreamsPerPeer is how many inbound streams a peer can have open at the same time, it is
+	// enforced by the libp2p resource manager
+	MaxInboundStreamsPerPeer int
+	// StreamRate is how many streams per second a peer can open on average
+	StreamRate float64
+	// StreamBurst is how many streams a peer can open at once
+	StreamBurst int
+	// ByteRate is how many bytes per second a peer can send on average
+	ByteRate int
+	// ByteBurst is how many bytes a peer can send at once, it cannot be lower than the max payload and
+	// defaults to twice the max payload
+	ByteBurst int
+	// MessageRate is how many messages per second of a ceremony a peer can send on average
+	MessageRate float64
+	// MessageBurst is how many messages of a ceremony a peer can send at once
+	MessageBurst int
+	// BanThreshold is how many violations of its limits within the ban duration get a peer banned
+	BanThreshold int
+	// BanDuration is how long a peer that violates its limits is banned
+	BanDuration time.Duration
+}
+
+// DefaultNetworkConfig return the network configuration with all the default values
+func DefaultNetworkConfig() NetworkConfig {
+	return NetworkConfig{
+		Discovery:                DefaultDiscoveryMode,
+		TimeoutConnecting:        DefaultTimeoutConnecting,
+		TimeoutReadPayload:       DefaultTimeoutReadPayload,
+		TimeoutWritePayload:      DefaultTimeoutWritePayload,
+		MaxPayload:               DefaultMaxPayload,
+		PingTimeout:              DefaultPingTimeout,
+		LeaderRetryInterval:      DefaultLeaderRetryInterval,
+		LeaderFailoverTimeout:    DefaultLeaderFailoverTimeout,
+		SignerSelection:          DefaultSignerSelection,
+		SignerSelectionWindow:    DefaultSignerSelectionWindow,
+		BootstrapAttempts:        DefaultBootstrapAttempts,
+		BootstrapRetryInterval:   DefaultBootstrapRetryInterval,
+		BroadcastBufferSize:      DefaultBroadcastBufferSize,
+		HealthCheckInterval:      DefaultHealthCheckInterval,
+		HealthFailureThreshold:   DefaultHealthFailureThreshold,
+		MaxInboundStreamsPerPeer: DefaultMaxInboundStreamsPerPeer,
+		StreamRate:               DefaultStreamRate,
+		StreamBurst:              DefaultStreamBurst,
+		ByteRate:                 DefaultByteRate,
+		ByteBurst:                2 * DefaultMaxPayload,
+		MessageRate:              DefaultMessageRate,
+		MessageBurst:             DefaultMessageBurst,
+		BanThreshold:             DefaultBanThreshold,
+		BanDuration:              DefaultBanDuration,
+	}
+}
+
+// Validate check the configured values, the fields left to zero are valid as they fall back to the defaults
+func (nc NetworkConfig) Validate() error {
+	durations := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"timeout connecting", nc.TimeoutConnecting},
+		{"timeout read payload", nc.TimeoutReadPayload},
+		{"timeout write payload", nc.TimeoutWritePayload},
+		{"ping timeout", nc.PingTimeout},
+		{"leader retry interval", nc.LeaderRetryInterval},
+		{"leader failover timeout", nc.LeaderFailoverTimeout},
+		{"signer selection window", nc.SignerSelectionWindow},
+		{"bootstrap retry interval", nc.BootstrapRetryInterval},
+		{"health check interval", nc.HealthCheckInterval},
+		{"ban duration", nc.BanDuration},
+	}
+	for _, el := range durations {
+		if el.value < 0 {
+			return fmt.Errorf("%s(%s) cannot be negative", el.name, el.value)
+		}
+	}
+	if nc.MaxPayload < 0 || nc.MaxPayload > maxPayloadLimit {
+		return fmt.Errorf("max payload(%d) should be between 0 and %d", nc.MaxPayload, maxPayloadLimit)
+	}
+	if nc.BootstrapAttempts < 0 {
+		return fmt.Errorf("bootstrap attempts(%d) cannot be negative", nc.BootstrapAttempts)
+	}
+	if nc.BroadcastBufferSize < 0 {
+		return fmt.Errorf("broadcast buffer size(%d) cannot be negative", nc.BroadcastBufferSize)
+	}
+	if nc.HealthFailureThreshold < 0 {
+		return fmt.Errorf("health failure threshold(%d) cannot be negative", nc.HealthFailureThreshold)
+	}
+	limits := []struct {
+		name  string
+		value float64
+	}{
+		{"max inbound streams per peer", float64(nc.MaxInboundStreamsPerPeer)},
+		{"stream rate", nc.StreamRate},
+		{"stream burst", float64(nc.StreamBurst)},
+		{"byte rate", float64(nc.ByteRate)},
+		{"byte burst", float64(nc.ByteBurst)},
+		{"message rate", nc.MessageRate},
+		{"message burst", float64(nc.MessageBurst)},
+		{"ban threshold", float64(nc.BanThreshold)},
+	}
+	for _, el := range limits {
+		if el.value < 0 {
+			return fmt.Errorf("%s(%v) cannot be negative", el.name, el.value)
+		}
+	}
+	if maxPayload := nc.WithDefaults().MaxPayload; nc.ByteBurst != 0 && nc.ByteBurst < maxPayload {
+		return fmt.Errorf("byte burst(%d) cannot be lower than the max payload(%d)", nc.ByteBurst, maxPayload)
+	}
+	for _, el := range nc.ListenAddrs {
+		if err := validateListenAddr(el); err != nil {
+			return err
+		}
+	}
+	for _, el := range nc.ExternalAddrs {
+		if _, err := el.ValueForProtocol(maddr.P_P2P); err == nil {
+			return fmt.Errorf("external address(%s) should not contain the peer ID", el)
+		}
+	}
+	for _, el := range nc.RelayPeers {
+		if _, err := peer.AddrInfoFromP2pAddr(el); err != nil {
+			return fmt.Errorf("invalid relay peer(%s): %w", el, err)
+		}
+	}
+	if err := validateReachability(nc.Reachability); err != nil {
+		return err
+	}
+	if len(nc.SignerSelection) != 0 {
+		if err := nc.SignerSelection.Validate(); err != nil {
+			return err
+		}
+	}
+	if nc.SignerSelection == SelectByPreference && len(nc.PreferredSigners) == 0 {
+		return errors.New("the preference signer selection needs the preferred signers")
+	}
+	if len(nc.Discovery) != 0 {
+		return nc.Discovery.Validate()
+	}
+	return nil
+}
+
+// validateListenAddr check the listen address is an IPv4 or IPv6 address on one of the transports we support
+func validateListenAddr(addr maddr.Multiaddr) error {
+	first, _ := maddr.SplitFirst(addr)
+	if first == nil || (first.Protocol().Code != maddr.P_IP4 && first.Protocol().Code != maddr.P_IP6) {
+		return fmt.Errorf("listen address(%s) should start with an IPv4 or IPv6 address", addr)
+	}
+	if _, err := addr.ValueForProtocol(maddr.P_QUIC_V1); err == nil {
+		return nil
+	}
+	if _, err := addr.ValueForProtocol(maddr.P_TCP); err == nil {
+		return nil
+	}
+	return fmt.Errorf("listen address(%s) should use the TCP or QUIC transport", addr)
+}
+
+// WithDefaults return a copy of the configuration with the zero fields set to their default values
+func (nc NetworkConfig) WithDefaults() NetworkConfig {
+	defaults := DefaultNetworkConfig()
+	if len(nc.Discovery) == 0 {
+		nc.Discovery = defaults.Discovery
+	}
+	if nc.TimeoutConnecting == 0 {
+		nc.TimeoutConnecting = defaults.TimeoutConnecting
+	}
+	if nc.TimeoutReadPayload == 0 {
+		nc.TimeoutReadPayload = defaults.TimeoutReadPayload
+	}
+	if nc.TimeoutWritePayload == 0 {
+		nc.TimeoutWritePayload = defaults.TimeoutWritePayload
+	}
+	if nc.MaxPayload == 0 {
+		nc.MaxPayload = defaults.MaxPayload
+	}
+	if nc.PingTimeout == 0 {
+		nc.PingTimeout = defaults.PingTimeout
+	}
+	if nc.LeaderRetryInterval == 0 {
+		nc.LeaderRetryInterval = defaults.LeaderRetryInterval
+	}
+	if nc.LeaderFailoverTimeout == 0 {
+		nc.LeaderFailoverTimeout = defaults.LeaderFailoverTimeout
+	}
+	if len(nc.SignerSelection) == 0 {
+		nc.SignerSelection = defaults.SignerSelection
+	}
+	if nc.SignerSelectionWindow == 0 {
+		nc.SignerSelectionWindow = defaults.SignerSelectionWindow
+	}
+	if nc.BootstrapAttempts == 0 {
+		nc.BootstrapAttempts = defaults.BootstrapAttempts
+	}
+	if nc.BootstrapRetryInterval == 0 {
+		nc.BootstrapRetryInterval = defaults.BootstrapRetryInterval
+	}
+	if nc.BroadcastBufferSize == 0 {
+		nc.BroadcastBufferSize = defaults.BroadcastBufferSize
+	}
+	if nc.HealthCheckInterval == 0 {
+		nc.HealthCheckInterval = defaults.HealthCheckInterval
+	}
+	if nc.HealthFailureThreshold == 0 {
+		nc.HealthFailureThreshold = defaults.HealthFailureThreshold
+	}
+	if nc.MaxInboundStreamsPerPeer == 0 {
+		nc.MaxInboundStreamsPerPeer = defaults.MaxInboundStreamsPerPeer
+	}
+	if nc.StreamRate == 0 {
+		nc.StreamRate = defaults.StreamRate
+	}
+	if nc.StreamBurst == 0 {
+		nc.StreamBurst = defaults.StreamBurst
+	}
+	if nc.ByteRate == 0 {
+		nc.ByteRate = defaults.ByteRate
+	}
+	if nc.ByteBurst == 0 {
+		nc.ByteBurst = 2 * nc.MaxPayload
+	}
+	if nc.MessageRate == 0 {
+		nc.MessageRate = defaults.MessageRate
+	}
+	if nc.MessageBurst == 0 {
+		nc.MessageBurst = defaults.MessageBurst
+	}
+	if nc.BanThreshold == 0 {
+		nc.BanThreshold = defaults.BanThreshold
+	}
+	if nc.BanDuration == 0 {
+		nc.BanDuration = defaults.BanDuration
+	}
+	return nc
+}
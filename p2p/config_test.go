@@ -0,0 +1,80 @@
+package p2p
+
+import (
+	"testing"
+	"time"
+
+	maddr "github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNetworkConfigWithDefaults(t *testing.T) {
+	assert.Equal(t, DefaultNetworkConfig(), NetworkConfig{}.WithDefaults())
+	conf := NetworkConfig{
+		TimeoutConnecting: time.Minute,
+		MaxPayload:        1024,
+		BootstrapAttempts: 10,
+	}.WithDefaults()
+	assert.Equal(t, time.Minute, conf.TimeoutConnecting)
+	assert.Equal(t, 1024, conf.MaxPayload)
+	assert.Equal(t, 10, conf.BootstrapAttempts)
+	assert.Equal(t, DefaultTimeoutReadPayload, conf.TimeoutReadPayload)
+	assert.Equal(t, DefaultBroadcastBufferSize, conf.BroadcastBufferSize)
+	// the byte burst follows the max payload
+	assert.Equal(t, 2048, conf.ByteBurst)
+}
+
+func TestNetworkConfigValidate(t *testing.T) {
+	assert.Nil(t, NetworkConfig{}.Validate())
+	assert.Nil(t, DefaultNetworkConfig().Validate())
+	assert.Nil(t, NetworkConfig{
+		ListenAddrs: addrList{
+			maddr.StringCast("/ip4/0.0.0.0/tcp/6668"),
+			maddr.StringCast("/ip6/::/tcp/6668"),
+			maddr.StringCast("/ip4/0.0.0.0/udp/6668/quic-v1"),
+			maddr.StringCast("/ip6/::/udp/6668/quic-v1"),
+		},
+		ExternalAddrs: addrList{maddr.StringCast("/dns4/tss.example.com/tcp/6668")},
+	}.Validate())
+	invalid := []NetworkConfig{
+		{TimeoutConnecting: -time.Second},
+		{TimeoutReadPayload: -time.Second},
+		{TimeoutWritePayload: -time.Second},
+		{PingTimeout: -time.Second},
+		{LeaderRetryInterval: -time.Second},
+		{LeaderFailoverTimeout: -time.Second},
+		{SignerSelectionWindow: -time.Second},
+		{SignerSelection: "fastest"},
+		{SignerSelection: SelectByPreference},
+		{BootstrapRetryInterval: -time.Second},
+		{MaxPayload: -1},
+		{MaxPayload: maxPayloadLimit + 1},
+		{BootstrapAttempts: -1},
+		{BroadcastBufferSize: -1},
+		{HealthCheckInterval: -time.Second},
+		{HealthFailureThreshold: -1},
+		{MaxInboundStreamsPerPeer: -1},
+		{StreamRate: -1},
+		{StreamBurst: -1},
+		{ByteRate: -1},
+		{ByteBurst: -1},
+		{ByteBurst: DefaultMaxPayload - 1},
+		{MessageRate: -1},
+		{MessageBurst: -1},
+		{BanThreshold: -1},
+		{BanDuration: -time.Second},
+		{ListenAddrs: addrList{maddr.StringCast("/dns4/localhost/tcp/6668")}},
+		{ListenAddrs: addrList{maddr.StringCast("/ip4/0.0.0.0/udp/6668")}},
+		{ExternalAddrs: addrList{maddr.StringCast("/ip4/1.2.3.4/tcp/6668/p2p/16Uiu2HAm4TmEzUqy3q3Dv7HvdoSboHk5sFj2FH3npiN5vDbJC6gh")}},
+	}
+	for _, el := range invalid {
+		assert.NotNil(t, el.Validate(), "%+v", el)
+	}
+	_, err := NewCommunication(Config{RendezvousString: "test", NetworkConfig: NetworkConfig{MaxPayload: -1}})
+	assert.NotNil(t, err)
+
+	comm, err := NewCommunication(Config{RendezvousString: "test", NetworkConfig: NetworkConfig{BroadcastBufferSize: 16}})
+	assert.Nil(t, err)
+	assert.Equal(t, 16, cap(comm.BroadcastMsgChan))
+	assert.Equal(t, DefaultTimeoutConnecting, comm.GetNetworkConfig().TimeoutConnecting)
+}
@@ -0,0 +1,200 @@
+package p2p
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/connmgr"
+	"github.com/libp2p/go-libp2p/core/control"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	maddr "github.com/multiformats/go-multiaddr"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"github.com/ordinox/thorchain-tss/conversion"
+)
+
+const (
+	// RejectReasonDial is used when we refuse to dial a peer that is not in the allowlist
+	RejectReasonDial = "dial"
+	// RejectReasonSecured is used when we drop a connection from a peer that is not in the allowlist
+	RejectReasonSecured = "secured"
+	// RejectReasonBanned is used when we refuse a peer the rate limiter has temporarily banned
+	RejectReasonBanned = "banned"
+)
+
+// ConnectionGater only allow the connections from and to the known committee members, the allowlist is
+// keyed by the node pub keys and can be updated at runtime as the committee changes. Until an allowlist is
+// set, the gater allows all the peers.
+type ConnectionGater struct {
+	logger        zerolog.Logger
+	lock          *sync.RWMutex
+	allowedPeers  map[peer.ID]string
+	trustedPeers  map[peer.ID]bool
+	bannedPeers   map[peer.ID]time.Time
+	rejectedCount uint64
+	onReject      func(pID peer.ID, reason string)
+}
+
+var _ connmgr.ConnectionGater = &ConnectionGater{}
+
+// NewConnectionGater create a new instance of ConnectionGater, the trusted peers (e.g. bootstrap peers)
+// are always allowed regardless of the allowlist
+func NewConnectionGater(trustedPeers []peer.ID) *ConnectionGater {
+	trusted := make(map[peer.ID]bool, len(trustedPeers))
+	for _, el := range trustedPeers {
+		trusted[el] = true
+	}
+	return &ConnectionGater{
+		logger:       log.With().Str("module", "connection_gater").Logger(),
+		lock:         &sync.RWMutex{},
+		trustedPeers: trusted,
+		bannedPeers:  make(map[peer.ID]time.Time),
+	}
+}
+
+// SetRejectHandler set the function that will be called every time we reject a peer
+func (cg *ConnectionGater) SetRejectHandler(handler func(pID peer.ID, reason string)) {
+	cg.lock.Lock()
+	defer cg.lock.Unlock()
+	cg.onReject = handler
+}
+
+// SetAllowedPubKeys replace the allowlist with the given node pub keys, an empty list disables the gating.
+// It returns the given connected peers that are not allowed any more, so the caller can close their
+// connections, whether the gating was enabled before or not.
+func (cg *ConnectionGater) SetAllowedPubKeys(pubKeys []string, connected []peer.ID) ([]peer.ID, error) {
+	allowed := make(map[peer.ID]string, len(pubKeys))
+	for _, el := range pubKeys {
+		pID, err := conversion.GetPeerIDFromPubKey(el)
+		if err != nil {
+			return nil, fmt.Errorf("fail to get peer id from pub key(%s): %w", el, err)
+		}
+		allowed[pID] = el
+	}
+	if len(allowed) == 0 {
+		allowed = nil
+	}
+	cg.lock.Lock()
+	defer cg.lock.Unlock()
+	var removed []peer.ID
+	for _, pID := range connected {
+		if _, ok := allowed[pID]; !ok && allowed != nil && !cg.trustedPeers[pID] {
+			removed = append(removed, pID)
+		}
+	}
+	cg.allowedPeers = allowed
+	cg.logger.Info().Msgf("connection allowlist updated with %d node pub keys", len(allowed))
+	return removed, nil
+}
+
+// GetAllowedPubKeys return the node pub keys in the allowlist
+func (cg *ConnectionGater) GetAllowedPubKeys() []string {
+	cg.lock.RLock()
+	defer cg.lock.RUnlock()
+	pubKeys := make([]string, 0, len(cg.allowedPeers))
+	for _, el := range cg.allowedPeers {
+		pubKeys = append(pubKeys, el)
+	}
+	sort.Strings(pubKeys)
+	return pubKeys
+}
+
+// AllowedPeers return the peer IDs of the node pub keys in the allowlist
+func (cg *ConnectionGater) AllowedPeers() []peer.ID {
+	cg.lock.RLock()
+	defer cg.lock.RUnlock()
+	peers := make([]peer.ID, 0, len(cg.allowedPeers))
+	for pID := range cg.allowedPeers {
+		peers = append(peers, pID)
+	}
+	return peers
+}
+
+// RejectedCount return how many connection attempts we have rejected so far
+func (cg *ConnectionGater) RejectedCount() uint64 {
+	return atomic.LoadUint64(&cg.rejectedCount)
+}
+
+// IsAllowed tells whether we accept the connections from and to the given peer
+func (cg *ConnectionGater) IsAllowed(pID peer.ID) bool {
+	cg.lock.RLock()
+	defer cg.lock.RUnlock()
+	if cg.allowedPeers == nil || cg.trustedPeers[pID] {
+		return true
+	}
+	_, ok := cg.allowedPeers[pID]
+	return ok
+}
+
+// Ban refuse the connections from and to the given peer until the given time, trusted peers included
+func (cg *ConnectionGater) Ban(pID peer.ID, until time.Time) {
+	cg.lock.Lock()
+	defer cg.lock.Unlock()
+	cg.bannedPeers[pID] = until
+}
+
+// IsBanned tells whether the given peer is banned at the moment
+func (cg *ConnectionGater) IsBanned(pID peer.ID) bool {
+	cg.lock.RLock()
+	until, ok := cg.bannedPeers[pID]
+	cg.lock.RUnlock()
+	if !ok {
+		return false
+	}
+	if time.Now().Before(until) {
+		return true
+	}
+	cg.lock.Lock()
+	if cg.bannedPeers[pID] == until {
+		delete(cg.bannedPeers, pID)
+	}
+	cg.lock.Unlock()
+	return false
+}
+
+func (cg *ConnectionGater) check(pID peer.ID, reason string) bool {
+	if cg.IsBanned(pID) {
+		reason = RejectReasonBanned
+	} else if cg.IsAllowed(pID) {
+		return true
+	}
+	atomic.AddUint64(&cg.rejectedCount, 1)
+	cg.logger.Warn().Str("peer", pID.String()).Str("reason", reason).Msg("reject connection from the peer")
+	cg.lock.RLock()
+	handler := cg.onReject
+	cg.lock.RUnlock()
+	if handler != nil {
+		handler(pID, reason)
+	}
+	return false
+}
+
+// InterceptPeerDial implement connmgr.ConnectionGater
+func (cg *ConnectionGater) InterceptPeerDial(pID peer.ID) bool {
+	return cg.check(pID, RejectReasonDial)
+}
+
+// InterceptAddrDial implement connmgr.ConnectionGater
+func (cg *ConnectionGater) InterceptAddrDial(peer.ID, maddr.Multiaddr) bool {
+	return true
+}
+
+// InterceptAccept implement connmgr.ConnectionGater, we do not know the remote peer at this stage
+func (cg *ConnectionGater) InterceptAccept(network.ConnMultiaddrs) bool {
+	return true
+}
+
+// InterceptSecured implement connmgr.ConnectionGater
+func (cg *ConnectionGater) InterceptSecured(_ network.Direction, pID peer.ID, _ network.ConnMultiaddrs) bool {
+	return cg.check(pID, RejectReasonSecured)
+}
+
+// InterceptUpgraded implement connmgr.ConnectionGater
+func (cg *ConnectionGater) InterceptUpgraded(network.Conn) (bool, control.DisconnectReason) {
+	return true, 0
+}
@@ -0,0 +1,154 @@
+package p2p
+
+import (
+	"context"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ordinox/thorchain-tss/conversion"
+)
+
+func newGatedHost(t *testing.T, gater *ConnectionGater) (host.Host, string) {
+	sk, _, err := crypto.GenerateSecp256k1Key(rand.Reader)
+	assert.Nil(t, err)
+	opts := []libp2p.Option{
+		libp2p.Identity(sk),
+		libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"),
+	}
+	if gater != nil {
+		opts = append(opts, libp2p.ConnectionGater(gater))
+	}
+	h, err := libp2p.New(opts...)
+	assert.Nil(t, err)
+	pubKey, err := conversion.GetPubKeyFromPeerID(h.ID().String())
+	assert.Nil(t, err)
+	return h, pubKey
+}
+
+func randomPeerID(t *testing.T) peer.ID {
+	pID, err := conversion.GetPeerIDFromPubKey(conversion.GetRandomPubKey())
+	assert.Nil(t, err)
+	return pID
+}
+
+func TestConnectionGaterAllowlist(t *testing.T) {
+	conversion.SetupBech32Prefix()
+	trusted := randomPeerID(t)
+	gater := NewConnectionGater([]peer.ID{trusted})
+	var rejected []string
+	gater.SetRejectHandler(func(_ peer.ID, reason string) {
+		rejected = append(rejected, reason)
+	})
+	pubKey := conversion.GetRandomPubKey()
+	pID, err := conversion.GetPeerIDFromPubKey(pubKey)
+	assert.Nil(t, err)
+	stranger := randomPeerID(t)
+
+	// no allowlist, everyone is allowed
+	assert.True(t, gater.InterceptPeerDial(stranger))
+	assert.Empty(t, gater.GetAllowedPubKeys())
+
+	// the connected peers that are not in the new allowlist are removed, even if there was no allowlist before
+	removed, err := gater.SetAllowedPubKeys([]string{pubKey}, []peer.ID{pID, trusted, stranger})
+	assert.Nil(t, err)
+	assert.Equal(t, []peer.ID{stranger}, removed)
+	assert.Equal(t, []string{pubKey}, gater.GetAllowedPubKeys())
+	assert.True(t, gater.InterceptPeerDial(pID))
+	assert.True(t, gater.InterceptPeerDial(trusted))
+	assert.False(t, gater.InterceptPeerDial(stranger))
+	assert.False(t, gater.InterceptSecured(0, stranger, nil))
+	assert.Equal(t, uint64(2), gater.RejectedCount())
+	assert.Equal(t, []string{RejectReasonDial, RejectReasonSecured}, rejected)
+
+	_, err = gater.SetAllowedPubKeys([]string{"invalid pub key"}, nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, []string{pubKey}, gater.GetAllowedPubKeys())
+
+	removed, err = gater.SetAllowedPubKeys([]string{conversion.GetRandomPubKey()}, []peer.ID{pID, trusted})
+	assert.Nil(t, err)
+	assert.Equal(t, []peer.ID{pID}, removed)
+	assert.False(t, gater.InterceptPeerDial(pID))
+
+	// an empty allowlist disables the gating
+	removed, err = gater.SetAllowedPubKeys(nil, []peer.ID{pID, stranger})
+	assert.Nil(t, err)
+	assert.Empty(t, removed)
+	assert.True(t, gater.InterceptPeerDial(stranger))
+}
+
+func TestConnectionGaterRejectUnknownPeers(t *testing.T) {
+	conversion.SetupBech32Prefix()
+	gater := NewConnectionGater(nil)
+	h1, _ := newGatedHost(t, gater)
+	defer h1.Close()
+	h2, pubKey2 := newGatedHost(t, nil)
+	defer h2.Close()
+	_, err := gater.SetAllowedPubKeys([]string{conversion.GetRandomPubKey()}, nil)
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	// the dialer may finish the handshake before h1 drops the connection, so we check from h1's side
+	h1Info := peer.AddrInfo{ID: h1.ID(), Addrs: h1.Addrs()}
+	_ = h2.Connect(ctx, h1Info)
+	assert.Eventually(t, func() bool {
+		return gater.RejectedCount() > 0 && h1.Network().Connectedness(h2.ID()) != network.Connected
+	}, 5*time.Second, 50*time.Millisecond)
+
+	_, err = gater.SetAllowedPubKeys([]string{pubKey2}, nil)
+	assert.Nil(t, err)
+	_ = h2.Network().ClosePeer(h1.ID())
+	assert.Nil(t, h2.Connect(ctx, h1Info))
+	assert.Eventually(t, func() bool {
+		return h1.Network().Connectedness(h2.ID()) == network.Connected
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func TestConnectionGaterRemoveConnectedPeers(t *testing.T) {
+	conversion.SetupBech32Prefix()
+	gater := NewConnectionGater(nil)
+	h1, _ := newGatedHost(t, gater)
+	defer h1.Close()
+	h2, _ := newGatedHost(t, nil)
+	defer h2.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	// the gating is disabled, so h2 connects before we set the first allowlist
+	assert.Nil(t, h2.Connect(ctx, peer.AddrInfo{ID: h1.ID(), Addrs: h1.Addrs()}))
+	assert.Eventually(t, func() bool {
+		return h1.Network().Connectedness(h2.ID()) == network.Connected
+	}, 5*time.Second, 50*time.Millisecond)
+
+	removed, err := gater.SetAllowedPubKeys([]string{conversion.GetRandomPubKey()}, h1.Network().Peers())
+	assert.Nil(t, err)
+	assert.Equal(t, []peer.ID{h2.ID()}, removed)
+}
+
+func TestConnectionGaterBan(t *testing.T) {
+	conversion.SetupBech32Prefix()
+	trusted := randomPeerID(t)
+	gater := NewConnectionGater([]peer.ID{trusted})
+	var rejected []string
+	gater.SetRejectHandler(func(_ peer.ID, reason string) {
+		rejected = append(rejected, reason)
+	})
+	// a ban applies to the trusted peers as well
+	gater.Ban(trusted, time.Now().Add(time.Minute))
+	assert.True(t, gater.IsBanned(trusted))
+	assert.False(t, gater.InterceptPeerDial(trusted))
+	assert.False(t, gater.InterceptSecured(0, trusted, nil))
+	assert.Equal(t, []string{RejectReasonBanned, RejectReasonBanned}, rejected)
+
+	// the ban expires
+	gater.Ban(trusted, time.Now().Add(-time.Second))
+	assert.False(t, gater.IsBanned(trusted))
+	assert.True(t, gater.InterceptPeerDial(trusted))
+}
@@ -0,0 +1,170 @@
+package p2p
+
+import (
+	"context"
+	"fmt"
+
+	dht "github.com/libp2p/go-libp2p-kad-dht"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/peerstore"
+	"github.com/libp2p/go-libp2p/p2p/discovery/mdns"
+	routing "github.com/libp2p/go-libp2p/p2p/discovery/routing"
+	routingutil "github.com/libp2p/go-libp2p/p2p/discovery/util"
+	maddr "github.com/multiformats/go-multiaddr"
+	"github.com/rs/zerolog"
+)
+
+// DiscoveryMode select how the node finds the other tss nodes
+type DiscoveryMode string
+
+const (
+	// DiscoveryDHT runs a Kademlia DHT in server mode and advertise the node on the rendezvous string
+	DiscoveryDHT DiscoveryMode = "dht"
+	// DiscoveryStatic only talks to the configured bootstrap peers, it suits the fixed committees
+	DiscoveryStatic DiscoveryMode = "static"
+	// DiscoveryMDNS finds the nodes on the local network with multicast DNS, it is meant for the dev labs
+	DiscoveryMDNS DiscoveryMode = "mdns"
+)
+
+// Validate check whether the discovery mode is supported
+func (m DiscoveryMode) Validate() error {
+	switch m {
+	case DiscoveryDHT, DiscoveryStatic, DiscoveryMDNS:
+		return nil
+	}
+	return fmt.Errorf("unknown discovery mode(%s), it should be one of %s, %s or %s", m, DiscoveryDHT, DiscoveryStatic, DiscoveryMDNS)
+}
+
+// peerDiscovery finds the other tss nodes, all the modes connect to the bootstrap peers between Start and
+// Announce, and run the same bootstrap connectivity check afterwards
+type peerDiscovery interface {
+	// Start the discovery before we connect to the bootstrap peers
+	Start(ctx context.Context) error
+	// Announce the node once we are connected to the bootstrap peers
+	Announce(ctx context.Context) error
+	Close() error
+}
+
+func newPeerDiscovery(mode DiscoveryMode, h host.Host, rendezvous string, bootstrapPeers []maddr.Multiaddr, conf NetworkConfig, logger zerolog.Logger) (peerDiscovery, error) {
+	switch mode {
+	case DiscoveryDHT:
+		return &dhtDiscovery{host: h, rendezvous: rendezvous, logger: logger}, nil
+	case DiscoveryStatic:
+		return &staticDiscovery{host: h, bootstrapPeers: bootstrapPeers, logger: logger}, nil
+	case DiscoveryMDNS:
+		return &mdnsDiscovery{host: h, rendezvous: rendezvous, conf: conf, logger: logger}, nil
+	}
+	return nil, mode.Validate()
+}
+
+type dhtDiscovery struct {
+	host       host.Host
+	rendezvous string
+	logger     zerolog.Logger
+	dht        *dht.IpfsDHT
+}
+
+func (d *dhtDiscovery) Start(ctx context.Context) error {
+	// Start a DHT, for use in peer discovery. We can't just make a new DHT
+	// client because we want each peer to maintain its own local copy of the
+	// DHT, so that the bootstrapping node of the DHT can go down without
+	// inhibiting future peer discovery.
+	kademliaDHT, err := dht.New(ctx, d.host, dht.Mode(dht.ModeServer))
+	if err != nil {
+		return fmt.Errorf("fail to create DHT: %w", err)
+	}
+	d.dht = kademliaDHT
+	d.logger.Debug().Msg("Bootstrapping the DHT")
+	if err = kademliaDHT.Bootstrap(ctx); err != nil {
+		return fmt.Errorf("fail to bootstrap DHT: %w", err)
+	}
+	return nil
+}
+
+func (d *dhtDiscovery) Announce(ctx context.Context) error {
+	// We use a rendezvous point "meet me here" to announce our location.
+	// This is like telling your friends to meet you at the Eiffel Tower.
+	routingDiscovery := routing.NewRoutingDiscovery(d.dht)
+	routingutil.Advertise(ctx, routingDiscovery, d.rendezvous)
+	return nil
+}
+
+func (d *dhtDiscovery) Close() error {
+	if d.dht == nil {
+		return nil
+	}
+	return d.dht.Close()
+}
+
+type staticDiscovery struct {
+	host           host.Host
+	bootstrapPeers []maddr.Multiaddr
+	logger         zerolog.Logger
+}
+
+// Start keep the addresses of the bootstrap peers forever, as we have no other way to find them
+func (d *staticDiscovery) Start(_ context.Context) error {
+	if len(d.bootstrapPeers) == 0 {
+		d.logger.Warn().Msg("static discovery without any bootstrap peer, we can only be reached by the other nodes")
+	}
+	for _, el := range d.bootstrapPeers {
+		pi, err := peer.AddrInfoFromP2pAddr(el)
+		if err != nil {
+			return fmt.Errorf("fail to add peer: %w", err)
+		}
+		d.host.Peerstore().AddAddrs(pi.ID, pi.Addrs, peerstore.PermanentAddrTTL)
+	}
+	return nil
+}
+
+func (d *staticDiscovery) Announce(_ context.Context) error {
+	return nil
+}
+
+func (d *staticDiscovery) Close() error {
+	return nil
+}
+
+type mdnsDiscovery struct {
+	host       host.Host
+	rendezvous string
+	conf       NetworkConfig
+	logger     zerolog.Logger
+	service    mdns.Service
+}
+
+// Start the mdns service, the nodes advertising the same rendezvous string on the local network are
+// connected as soon as they are found
+func (d *mdnsDiscovery) Start(_ context.Context) error {
+	d.service = mdns.NewMdnsService(d.host, d.rendezvous, d)
+	if err := d.service.Start(); err != nil {
+		return fmt.Errorf("fail to start mdns service: %w", err)
+	}
+	return nil
+}
+
+// HandlePeerFound implement mdns.Notifee
+func (d *mdnsDiscovery) HandlePeerFound(pi peer.AddrInfo) {
+	if pi.ID == d.host.ID() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), d.conf.TimeoutConnecting)
+	defer cancel()
+	if err := d.host.Connect(ctx, pi); err != nil {
+		d.logger.Error().Err(err).Msgf("fail to connect to the peer(%s) found by mdns", pi.ID)
+		return
+	}
+	d.logger.Info().Msgf("Connection established with the peer found by mdns: %s", pi.ID)
+}
+
+func (d *mdnsDiscovery) Announce(_ context.Context) error {
+	return nil
+}
+
+func (d *mdnsDiscovery) Close() error {
+	if d.service == nil {
+		return nil
+	}
+	return d.service.Close()
+}
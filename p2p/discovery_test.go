@@ -0,0 +1,109 @@
+package p2p
+
+import (
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/network"
+	maddr "github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/assert"
+)
+
+const kademliaProtocolID = "/ipfs/kad/1.0.0"
+
+func startDiscoveryNode(t *testing.T, mode DiscoveryMode, bootstrapPeers []maddr.Multiaddr) (*Communication, error) {
+	sk, _, err := crypto.GenerateSecp256k1Key(rand.Reader)
+	assert.Nil(t, err)
+	skRaw, err := sk.Raw()
+	assert.Nil(t, err)
+	comm, err := NewCommunication(Config{
+		RendezvousString: "discovery-" + string(mode),
+		BootstrapPeers:   bootstrapPeers,
+		NetworkConfig: NetworkConfig{
+			Discovery:         mode,
+			BootstrapAttempts: 1,
+		},
+	})
+	assert.Nil(t, err)
+	return comm, comm.Start(skRaw)
+}
+
+// localP2pAddr return the loopback address of the given node to use it as bootstrap peer
+func localP2pAddr(t *testing.T, comm *Communication) maddr.Multiaddr {
+	for _, el := range comm.host.Addrs() {
+		if ip, err := el.ValueForProtocol(maddr.P_IP4); err == nil && ip == "127.0.0.1" {
+			p2pAddr, err := maddr.NewMultiaddr("/p2p/" + comm.host.ID().String())
+			assert.Nil(t, err)
+			return el.Encapsulate(p2pAddr)
+		}
+	}
+	t.Fatal("no loopback address")
+	return nil
+}
+
+func hasProtocol(comm *Communication, pID string) bool {
+	for _, el := range comm.host.Mux().Protocols() {
+		if string(el) == pID {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDiscoveryModeValidate(t *testing.T) {
+	for _, el := range []DiscoveryMode{DiscoveryDHT, DiscoveryStatic, DiscoveryMDNS} {
+		assert.Nil(t, el.Validate())
+	}
+	assert.NotNil(t, DiscoveryMode("gossip").Validate())
+	assert.NotNil(t, NetworkConfig{Discovery: "gossip"}.Validate())
+	assert.Nil(t, NetworkConfig{}.Validate())
+	assert.Equal(t, DiscoveryDHT, NetworkConfig{}.WithDefaults().Discovery)
+}
+
+func TestDiscoveryWithBootstrapPeers(t *testing.T) {
+	for _, mode := range []DiscoveryMode{DiscoveryDHT, DiscoveryStatic, DiscoveryMDNS} {
+		t.Run(string(mode), func(t *testing.T) {
+			bootstrap, err := startDiscoveryNode(t, mode, nil)
+			assert.Nil(t, err)
+			defer bootstrap.Stop()
+			node, err := startDiscoveryNode(t, mode, []maddr.Multiaddr{localP2pAddr(t, bootstrap)})
+			assert.Nil(t, err)
+			defer node.Stop()
+
+			assert.Equal(t, network.Connected, node.host.Network().Connectedness(bootstrap.host.ID()))
+			// only the dht mode runs the kademlia protocol
+			assert.Equal(t, mode == DiscoveryDHT, hasProtocol(node, kademliaProtocolID))
+			// the node keeps the address of the bootstrap peer even after the connection is closed
+			assert.Nil(t, node.host.Network().ClosePeer(bootstrap.host.ID()))
+			if mode == DiscoveryStatic {
+				assert.NotEmpty(t, node.host.Peerstore().Addrs(bootstrap.host.ID()))
+			}
+		})
+	}
+}
+
+func TestDiscoveryBootstrapConnectivityCheck(t *testing.T) {
+	offline, err := startDiscoveryNode(t, DiscoveryStatic, nil)
+	assert.Nil(t, err)
+	offlineAddr := localP2pAddr(t, offline)
+	assert.Nil(t, offline.Stop())
+	for _, mode := range []DiscoveryMode{DiscoveryDHT, DiscoveryStatic, DiscoveryMDNS} {
+		node, err := startDiscoveryNode(t, mode, []maddr.Multiaddr{offlineAddr})
+		assert.ErrorContains(t, err, "fail to connect to bootstrap peer", string(mode))
+		assert.Nil(t, node.Stop())
+	}
+}
+
+func TestMDNSDiscovery(t *testing.T) {
+	node1, err := startDiscoveryNode(t, DiscoveryMDNS, nil)
+	assert.Nil(t, err)
+	defer node1.Stop()
+	node2, err := startDiscoveryNode(t, DiscoveryMDNS, nil)
+	assert.Nil(t, err)
+	defer node2.Stop()
+	assert.Eventually(t, func() bool {
+		return node1.host.Network().Connectedness(node2.host.ID()) == network.Connected
+	}, 15*time.Second, 100*time.Millisecond)
+}
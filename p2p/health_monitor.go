@@ -0,0 +1,240 @@
+package p2p
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/p2p/protocol/ping"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// healthWindow is how many of the latest round trip times of a peer we keep to compute the percentiles
+const healthWindow = 128
+
+// PeerHealth is what the health monitor knows about a committee peer
+type PeerHealth struct {
+	PeerID string `json:"peer_id"`
+	// Reachable is false once the consecutive failures reach the configured threshold
+	Reachable bool `json:"reachable"`
+	// LastSeen is the last time the peer answered a ping, zero if it never did
+	LastSeen time.Time `json:"last_seen"`
+	// LastError is the error of the latest failed ping
+	LastError           string        `json:"last_error,omitempty"`
+	Successes           uint64        `json:"successes"`
+	Failures            uint64        `json:"failures"`
+	ConsecutiveFailures int           `json:"consecutive_failures"`
+	LastRTT             time.Duration `json:"last_rtt"`
+	RTTP50              time.Duration `json:"rtt_p50"`
+	RTTP90              time.Duration `json:"rtt_p90"`
+	RTTP99              time.Duration `json:"rtt_p99"`
+}
+
+type peerHealthRecord struct {
+	health PeerHealth
+	rtts   []time.Duration
+	next   int
+}
+
+// HealthMonitor periodically ping the committee peers, the peers in the allowlist of the connection gater or
+// the connected ones when there is no allowlist, and keep track of their round trip times and failures
+type HealthMonitor struct {
+	logger   zerolog.Logger
+	host     host.Host
+	gater    *ConnectionGater
+	conf     NetworkConfig
+	lock     *sync.RWMutex
+	records  map[peer.ID]*peerHealthRecord
+	observer func(pID peer.ID, rtt time.Duration, err error)
+}
+
+// NewHealthMonitor create a new instance of HealthMonitor, the timeouts and limits left to zero in the given
+// network configuration fall back to their default values
+func NewHealthMonitor(h host.Host, gater *ConnectionGater, conf NetworkConfig) *HealthMonitor {
+	return &HealthMonitor{
+		logger:  log.With().Str("module", "health_monitor").Logger(),
+		host:    h,
+		gater:   gater,
+		conf:    conf.WithDefaults(),
+		lock:    &sync.RWMutex{},
+		records: make(map[peer.ID]*peerHealthRecord),
+	}
+}
+
+// SetObserver set the function that will be called with the result of every ping
+func (hm *HealthMonitor) SetObserver(observer func(pID peer.ID, rtt time.Duration, err error)) {
+	hm.lock.Lock()
+	defer hm.lock.Unlock()
+	hm.observer = observer
+}
+
+// Run ping the committee peers every health check interval until the stop channel is closed
+func (hm *HealthMonitor) Run(stopChan chan struct{}) {
+	ticker := time.NewTicker(hm.conf.HealthCheckInterval)
+	defer ticker.Stop()
+	for {
+		hm.CheckPeers()
+		select {
+		case <-stopChan:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// committeePeers return the peers we monitor, the peers we have been monitoring keep being pinged so we notice
+// when they go offline
+func (hm *HealthMonitor) committeePeers() []peer.ID {
+	peers := make(map[peer.ID]bool)
+	allowed := hm.gater.AllowedPeers()
+	for _, el := range allowed {
+		peers[el] = true
+	}
+	if len(allowed) == 0 {
+		for _, el := range hm.host.Network().Peers() {
+			peers[el] = true
+		}
+	}
+	hm.lock.RLock()
+	for pID := range hm.records {
+		if len(allowed) == 0 || hm.gater.IsAllowed(pID) {
+			peers[pID] = true
+		}
+	}
+	hm.lock.RUnlock()
+	delete(peers, hm.host.ID())
+	result := make([]peer.ID, 0, len(peers))
+	for pID := range peers {
+		result = append(result, pID)
+	}
+	return result
+}
+
+// CheckPeers ping all the committee peers once
+func (hm *HealthMonitor) CheckPeers() {
+	peers := hm.committeePeers()
+	var wg sync.WaitGroup
+	wg.Add(len(peers))
+	for _, pID := range peers {
+		go func(pID peer.ID) {
+			defer wg.Done()
+			rtt, err := hm.ping(pID)
+			hm.record(pID, rtt, err)
+		}(pID)
+	}
+	wg.Wait()
+}
+
+func (hm *HealthMonitor) ping(pID peer.ID) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), hm.conf.PingTimeout)
+	defer cancel()
+	select {
+	case ret, ok := <-ping.Ping(ctx, hm.host, pID):
+		if !ok {
+			return 0, context.Canceled
+		}
+		return ret.RTT, ret.Error
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+func (hm *HealthMonitor) record(pID peer.ID, rtt time.Duration, err error) {
+	hm.lock.Lock()
+	r, ok := hm.records[pID]
+	if !ok {
+		r = &peerHealthRecord{health: PeerHealth{PeerID: pID.String()}}
+		hm.records[pID] = r
+	}
+	if err != nil {
+		r.health.Failures++
+		r.health.ConsecutiveFailures++
+		r.health.LastError = err.Error()
+		if r.health.ConsecutiveFailures == hm.conf.HealthFailureThreshold {
+			hm.logger.Warn().Err(err).Msgf("peer(%s) failed %d pings in a row", pID, r.health.ConsecutiveFailures)
+		}
+	} else {
+		r.health.Successes++
+		r.health.ConsecutiveFailures = 0
+		r.health.LastError = ""
+		r.health.LastSeen = time.Now()
+		r.health.LastRTT = rtt
+		if len(r.rtts) < healthWindow {
+			r.rtts = append(r.rtts, rtt)
+		} else {
+			r.rtts[r.next] = rtt
+			r.next = (r.next + 1) % healthWindow
+		}
+	}
+	observer := hm.observer
+	hm.lock.Unlock()
+	if observer != nil {
+		observer(pID, rtt, err)
+	}
+}
+
+// snapshot return a copy of the health of the given record with its percentiles, the lock must be held
+func (hm *HealthMonitor) snapshot(r *peerHealthRecord) PeerHealth {
+	health := r.health
+	health.Reachable = health.ConsecutiveFailures < hm.conf.HealthFailureThreshold
+	sorted := append([]time.Duration{}, r.rtts...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	health.RTTP50 = percentile(sorted, 50)
+	health.RTTP90 = percentile(sorted, 90)
+	health.RTTP99 = percentile(sorted, 99)
+	return health
+}
+
+// percentile return the nearest-rank percentile of the given sorted durations
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// GetPeersHealth return the health of all the peers we have pinged, sorted by peer ID
+func (hm *HealthMonitor) GetPeersHealth() []PeerHealth {
+	hm.lock.RLock()
+	defer hm.lock.RUnlock()
+	result := make([]PeerHealth, 0, len(hm.records))
+	for _, r := range hm.records {
+		result = append(result, hm.snapshot(r))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].PeerID < result[j].PeerID })
+	return result
+}
+
+// GetPeerHealth return the health of the given peer, false if we have never pinged it
+func (hm *HealthMonitor) GetPeerHealth(pID peer.ID) (PeerHealth, bool) {
+	hm.lock.RLock()
+	defer hm.lock.RUnlock()
+	r, ok := hm.records[pID]
+	if !ok {
+		return PeerHealth{}, false
+	}
+	return hm.snapshot(r), true
+}
+
+// Unreachable return the given peers that the last pings failed to reach, the peers we have not pinged yet are
+// given the benefit of the doubt
+func (hm *HealthMonitor) Unreachable(peers []peer.ID) []peer.ID {
+	var unreachable []peer.ID
+	for _, el := range peers {
+		if el == hm.host.ID() {
+			continue
+		}
+		if health, ok := hm.GetPeerHealth(el); ok && !health.Reachable {
+			unreachable = append(unreachable, el)
+		}
+	}
+	return unreachable
+}
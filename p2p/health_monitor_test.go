@@ -0,0 +1,88 @@
+package p2p
+
+import (
+	"crypto/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	maddr "github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPercentile(t *testing.T) {
+	assert.Equal(t, time.Duration(0), percentile(nil, 50))
+	var sorted []time.Duration
+	for i := 1; i <= 100; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+	assert.Equal(t, 50*time.Millisecond, percentile(sorted, 50))
+	assert.Equal(t, 90*time.Millisecond, percentile(sorted, 90))
+	assert.Equal(t, 99*time.Millisecond, percentile(sorted, 99))
+	assert.Equal(t, time.Millisecond, percentile(sorted[:1], 99))
+}
+
+func TestHealthMonitor(t *testing.T) {
+	conf := NetworkConfig{
+		Discovery:              DiscoveryStatic,
+		BootstrapAttempts:      1,
+		HealthCheckInterval:    50 * time.Millisecond,
+		PingTimeout:            200 * time.Millisecond,
+		HealthFailureThreshold: 2,
+		ListenAddrs:            addrList{maddr.StringCast("/ip4/127.0.0.1/tcp/0")},
+	}
+	start := func(bootstrapPeers []maddr.Multiaddr, observer func(peer.ID, time.Duration, error)) *Communication {
+		sk, _, err := crypto.GenerateSecp256k1Key(rand.Reader)
+		assert.Nil(t, err)
+		skRaw, err := sk.Raw()
+		assert.Nil(t, err)
+		comm, err := NewCommunication(Config{RendezvousString: "health", BootstrapPeers: bootstrapPeers, NetworkConfig: conf})
+		assert.Nil(t, err)
+		comm.SetHealthObserver(observer)
+		assert.Nil(t, comm.Start(skRaw))
+		return comm
+	}
+	peer1 := start(nil, nil)
+	var pings, failures int32
+	peer2 := start([]maddr.Multiaddr{localP2pAddr(t, peer1)}, func(_ peer.ID, _ time.Duration, err error) {
+		atomic.AddInt32(&pings, 1)
+		if err != nil {
+			atomic.AddInt32(&failures, 1)
+		}
+	})
+	defer peer2.Stop()
+	monitor := peer2.GetHealthMonitor()
+
+	assert.Eventually(t, func() bool {
+		health, ok := monitor.GetPeerHealth(peer1.host.ID())
+		return ok && health.Successes >= 3
+	}, 5*time.Second, 10*time.Millisecond)
+	health, _ := monitor.GetPeerHealth(peer1.host.ID())
+	assert.True(t, health.Reachable)
+	assert.False(t, health.LastSeen.IsZero())
+	assert.NotZero(t, health.RTTP50)
+	assert.True(t, health.RTTP50 <= health.RTTP90 && health.RTTP90 <= health.RTTP99)
+	assert.Empty(t, monitor.Unreachable([]peer.ID{peer1.host.ID(), peer2.host.ID()}))
+	assert.Len(t, monitor.GetPeersHealth(), 1)
+
+	// the peer goes offline, we keep pinging it until it is considered unreachable
+	assert.Nil(t, peer1.Stop())
+	assert.Eventually(t, func() bool {
+		return len(monitor.Unreachable([]peer.ID{peer1.host.ID()})) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	health, _ = monitor.GetPeerHealth(peer1.host.ID())
+	assert.False(t, health.Reachable)
+	assert.NotEmpty(t, health.LastError)
+	assert.True(t, health.ConsecutiveFailures >= 2)
+	assert.True(t, atomic.LoadInt32(&pings) >= 5)
+	assert.True(t, atomic.LoadInt32(&failures) >= 2)
+
+	// the peers we have never pinged are given the benefit of the doubt
+	sk, _, err := crypto.GenerateSecp256k1Key(rand.Reader)
+	assert.Nil(t, err)
+	stranger, err := peer.IDFromPrivateKey(sk)
+	assert.Nil(t, err)
+	assert.Empty(t, monitor.Unreachable([]peer.ID{stranger}))
+}
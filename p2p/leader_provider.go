@@ -4,14 +4,25 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
+	"math/big"
 	"sort"
 	"strconv"
 )
 
 // LeaderNode use the given input buf to calculate a hash , and consistently choose a node as a master coordinate note
 func LeaderNode(msgID string, blockHeight int64, pIDs []string) (string, error) {
+	leaders, err := LeaderNodes(msgID, blockHeight, pIDs)
+	if err != nil {
+		return "", err
+	}
+	return leaders[0], nil
+}
+
+// LeaderNodes rank all the nodes with the same hash as LeaderNode, the first one is the leader and the others
+// succeed it in order if it cannot be reached
+func LeaderNodes(msgID string, blockHeight int64, pIDs []string) ([]string, error) {
 	if len(pIDs) == 0 || len(msgID) == 0 || blockHeight == 0 {
-		return "", errors.New("invalid input for finding the leader")
+		return nil, errors.New("invalid input for finding the leader")
 	}
 	keyStore := make(map[string]string)
 	hashes := make([]string, len(pIDs))
@@ -22,5 +33,49 @@ func LeaderNode(msgID string, blockHeight int64, pIDs []string) (string, error)
 		hashes[i] = encodedSum
 	}
 	sort.Strings(hashes)
-	return keyStore[hashes[0]], nil
+	leaders := make([]string, len(hashes))
+	for i, el := range hashes {
+		leaders[i] = keyStore[el]
+	}
+	return leaders, nil
+}
+
+// WeightedLeaderNodes rank the nodes like LeaderNodes, but a node is drawn to lead with a probability proportional
+// to its weight, and so is each of its successors among the nodes left. The nodes without a weight succeed the
+// weighted ones in the order of LeaderNodes, so without any weight the ranking is the one of LeaderNodes. The draws
+// only use integers so all the nodes given the same weights agree on the ranking.
+func WeightedLeaderNodes(msgID string, blockHeight int64, pIDs []string, weights map[string]uint64) ([]string, error) {
+	leaders, err := LeaderNodes(msgID, blockHeight, pIDs)
+	if err != nil || len(weights) == 0 {
+		return leaders, err
+	}
+	var weighted, unweighted []string
+	total := new(big.Int)
+	for _, el := range leaders {
+		if weights[el] == 0 {
+			unweighted = append(unweighted, el)
+			continue
+		}
+		weighted = append(weighted, el)
+		total.Add(total, new(big.Int).SetUint64(weights[el]))
+	}
+	// the draws walk the nodes in a canonical order, so it does not depend on the order they are given in
+	sort.Strings(weighted)
+	ranked := make([]string, 0, len(leaders))
+	for draw := 0; len(weighted) != 0; draw++ {
+		sum := sha256.Sum256([]byte(msgID + strconv.FormatInt(blockHeight, 10) + strconv.Itoa(draw)))
+		point := new(big.Int).Mod(new(big.Int).SetBytes(sum[:]), total)
+		for i, el := range weighted {
+			weight := new(big.Int).SetUint64(weights[el])
+			if point.Cmp(weight) >= 0 {
+				point.Sub(point, weight)
+				continue
+			}
+			ranked = append(ranked, el)
+			total.Sub(total, weight)
+			weighted = append(weighted[:i], weighted[i+1:]...)
+			break
+		}
+	}
+	return append(ranked, unweighted...), nil
 }
@@ -1,6 +1,7 @@
 package p2p
 
 import (
+	"strconv"
 	"testing"
 
 	. "gopkg.in/check.v1"
@@ -21,3 +22,123 @@ func (t *LeaderProviderTestSuite) TestLeaderNode(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(ret, Equals, testPeers[1])
 }
+
+func (t *LeaderProviderTestSuite) TestLeaderNodes(c *C) {
+	testPeers := []string{
+		"16Uiu2HAmACG5DtqmQsHtXg4G2sLS65ttv84e7MrL4kapkjfmhxAp", "16Uiu2HAm4TmEzUqy3q3Dv7HvdoSboHk5sFj2FH3npiN5vDbJC6gh",
+		"16Uiu2HAm2FzqoUdS6Y9Esg2EaGcAG5rVe1r6BFNnmmQr2H3bqafa",
+	}
+	ret, err := LeaderNodes("HelloWorld", 10, testPeers)
+	c.Assert(err, IsNil)
+	c.Assert(ret, HasLen, len(testPeers))
+	leader, err := LeaderNode("HelloWorld", 10, testPeers)
+	c.Assert(err, IsNil)
+	c.Assert(ret[0], Equals, leader)
+	// the succession does not depend on the order of the nodes
+	reversed := []string{testPeers[2], testPeers[1], testPeers[0]}
+	ret2, err := LeaderNodes("HelloWorld", 10, reversed)
+	c.Assert(err, IsNil)
+	c.Assert(ret2, DeepEquals, ret)
+	_, err = LeaderNodes("HelloWorld", 0, testPeers)
+	c.Assert(err, NotNil)
+}
+
+func (t *LeaderProviderTestSuite) TestWeightedLeaderNodes(c *C) {
+	testPeers := []string{
+		"16Uiu2HAmACG5DtqmQsHtXg4G2sLS65ttv84e7MrL4kapkjfmhxAp", "16Uiu2HAm4TmEzUqy3q3Dv7HvdoSboHk5sFj2FH3npiN5vDbJC6gh",
+		"16Uiu2HAm2FzqoUdS6Y9Esg2EaGcAG5rVe1r6BFNnmmQr2H3bqafa",
+	}
+	expected, err := LeaderNodes("HelloWorld", 10, testPeers)
+	c.Assert(err, IsNil)
+	// without any weight the ranking is the hash based one
+	ret, err := WeightedLeaderNodes("HelloWorld", 10, testPeers, nil)
+	c.Assert(err, IsNil)
+	c.Assert(ret, DeepEquals, expected)
+	ret, err = WeightedLeaderNodes("HelloWorld", 10, testPeers, map[string]uint64{testPeers[0]: 0})
+	c.Assert(err, IsNil)
+	c.Assert(ret, DeepEquals, expected)
+
+	weights := map[string]uint64{testPeers[0]: 5, testPeers[2]: 7}
+	ret, err = WeightedLeaderNodes("HelloWorld", 10, testPeers, weights)
+	c.Assert(err, IsNil)
+	c.Assert(ret, HasLen, len(testPeers))
+	// the node without a weight comes last
+	c.Assert(ret[2], Equals, testPeers[1])
+	// the ranking does not depend on the order of the nodes
+	reversed := []string{testPeers[2], testPeers[1], testPeers[0]}
+	ret2, err := WeightedLeaderNodes("HelloWorld", 10, reversed, weights)
+	c.Assert(err, IsNil)
+	c.Assert(ret2, DeepEquals, ret)
+	_, err = WeightedLeaderNodes("HelloWorld", 0, testPeers, weights)
+	c.Assert(err, NotNil)
+}
+
+// chiSquare return the chi-square statistic of the observed counts against the expected shares of the total
+func chiSquare(observed map[string]int, shares map[string]float64, total int) float64 {
+	var stat float64
+	for key, share := range shares {
+		expected := share * float64(total)
+		diff := float64(observed[key]) - expected
+		stat += diff * diff / expected
+	}
+	return stat
+}
+
+// lastProbability return the probability that the node is the last one left when the nodes are drawn one by one
+// in proportion to their weights
+func lastProbability(node string, nodes []string, weights map[string]uint64) float64 {
+	if len(nodes) == 1 {
+		if nodes[0] == node {
+			return 1
+		}
+		return 0
+	}
+	var total float64
+	for _, el := range nodes {
+		total += float64(weights[el])
+	}
+	var probability float64
+	for i, el := range nodes {
+		if el == node {
+			continue
+		}
+		left := append(append([]string{}, nodes[:i]...), nodes[i+1:]...)
+		probability += float64(weights[el]) / total * lastProbability(node, left, weights)
+	}
+	return probability
+}
+
+func (t *LeaderProviderTestSuite) TestWeightedLeaderNodesDistribution(c *C) {
+	const rounds = 20000
+	// the critical value of the chi-square distribution with 3 degrees of freedom at p=0.001
+	const critical = 16.27
+	testPeers := []string{"A", "B", "C", "D"}
+	weights := map[string]uint64{"A": 1, "B": 2, "C": 3, "D": 4}
+	leaders := make(map[string]int)
+	lasts := make(map[string]int)
+	defaultLeaders := make(map[string]int)
+	for i := 0; i < rounds; i++ {
+		msgID := "msg" + strconv.Itoa(i)
+		ret, err := WeightedLeaderNodes(msgID, 10, testPeers, weights)
+		c.Assert(err, IsNil)
+		c.Assert(ret, HasLen, len(testPeers))
+		leaders[ret[0]]++
+		lasts[ret[3]]++
+		leader, err := LeaderNode(msgID, 10, testPeers)
+		c.Assert(err, IsNil)
+		defaultLeaders[leader]++
+	}
+	// each node leads in proportion to its weight
+	stat := chiSquare(leaders, map[string]float64{"A": 0.1, "B": 0.2, "C": 0.3, "D": 0.4}, rounds)
+	c.Assert(stat < critical, Equals, true, Commentf("chi-square %f of %v", stat, leaders))
+	// the last one is the node left once the others are drawn
+	lastShares := map[string]float64{}
+	for _, last := range testPeers {
+		lastShares[last] = lastProbability(last, testPeers, weights)
+	}
+	stat = chiSquare(lasts, lastShares, rounds)
+	c.Assert(stat < critical, Equals, true, Commentf("chi-square %f of %v", stat, lasts))
+	// the hash based selection stays uniform
+	stat = chiSquare(defaultLeaders, map[string]float64{"A": 0.25, "B": 0.25, "C": 0.25, "D": 0.25}, rounds)
+	c.Assert(stat < critical, Equals, true, Commentf("chi-square %f of %v", stat, defaultLeaders))
+}
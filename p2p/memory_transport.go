@@ -0,0 +1,373 @@
+package p2p
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/peerstore"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	maddr "github.com/multiformats/go-multiaddr"
+	"github.com/rs/zerolog/log"
+
+	"github.com/ordinox/thorchain-tss/messages"
+)
+
+// ErrPeerNotAllowed is returned when the connection gater refuses the peer we open a stream to
+var ErrPeerNotAllowed = errors.New("the connection gater does not allow the peer")
+
+// MemoryNetworkConfig shapes the in-memory network, the zero value delivers every message at once and in order
+type MemoryNetworkConfig struct {
+	// Latency is how long a message or a stream takes to reach the other node
+	Latency time.Duration
+	// Jitter is the largest random delay added to the latency of a tss message, the messages sent close to each
+	// other arrive out of order when it is set
+	Jitter time.Duration
+	// LossRate is the probability, between 0 and 1, that a tss message is lost on its way
+	LossRate float64
+	// Seed seeds the random losses and delays, so a test can replay them
+	Seed int64
+}
+
+// Validate check the configured values
+func (mc MemoryNetworkConfig) Validate() error {
+	if mc.Latency < 0 || mc.Jitter < 0 {
+		return fmt.Errorf("latency(%s) and jitter(%s) cannot be negative", mc.Latency, mc.Jitter)
+	}
+	if mc.LossRate < 0 || mc.LossRate > 1 {
+		return fmt.Errorf("loss rate(%v) should be between 0 and 1", mc.LossRate)
+	}
+	return nil
+}
+
+// MemoryNetwork connects the transports of the nodes running in the same process, the tss messages are delivered
+// in memory with the configured latency, jitter and losses, the streams of the party coordinator and the signature
+// notifier go through a libp2p mock network with the same latency
+type MemoryNetwork struct {
+	conf       MemoryNetworkConfig
+	lock       *sync.Mutex
+	rand       *rand.Rand
+	mocknet    mocknet.Mocknet
+	transports map[peer.ID]*MemoryTransport
+	links      map[[2]peer.ID]chan memoryEnvelope
+	nodes      int
+	stopChan   chan struct{}
+	wg         *sync.WaitGroup
+}
+
+type memoryEnvelope struct {
+	from      peer.ID
+	payload   []byte
+	deliverAt time.Time
+}
+
+// NewMemoryNetwork create a new instance of MemoryNetwork
+func NewMemoryNetwork(conf MemoryNetworkConfig) (*MemoryNetwork, error) {
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid memory network config: %w", err)
+	}
+	mn := mocknet.New()
+	mn.SetLinkDefaults(mocknet.LinkOptions{Latency: conf.Latency})
+	return &MemoryNetwork{
+		conf:       conf,
+		lock:       &sync.Mutex{},
+		rand:       rand.New(rand.NewSource(conf.Seed)),
+		mocknet:    mn,
+		transports: make(map[peer.ID]*MemoryTransport),
+		links:      make(map[[2]peer.ID]chan memoryEnvelope),
+		stopChan:   make(chan struct{}),
+		wg:         &sync.WaitGroup{},
+	}, nil
+}
+
+// NewTransport add a node with the given secp256k1 private key to the network and return its transport, it is
+// linked to all the nodes already in the network
+func (mn *MemoryNetwork) NewTransport(priKeyBytes []byte, conf NetworkConfig) (*MemoryTransport, error) {
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid network config: %w", err)
+	}
+	priKey, err := crypto.UnmarshalSecp256k1PrivateKey(priKeyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("fail to unmarshal the private key: %w", err)
+	}
+	mn.lock.Lock()
+	defer mn.lock.Unlock()
+	// the address only tells the nodes apart in the mock network, nothing listens on it
+	mn.nodes++
+	addr, err := maddr.NewMultiaddr(fmt.Sprintf("/ip6/100::%x/tcp/6668", mn.nodes))
+	if err != nil {
+		return nil, fmt.Errorf("fail to create the node address: %w", err)
+	}
+	h, err := mn.mocknet.AddPeer(priKey, addr)
+	if err != nil {
+		return nil, fmt.Errorf("fail to add the node to the mock network: %w", err)
+	}
+	for pID, el := range mn.transports {
+		if _, err := mn.mocknet.LinkPeers(h.ID(), pID); err != nil {
+			return nil, fmt.Errorf("fail to link the node to peer(%s): %w", pID, err)
+		}
+		h.Peerstore().AddAddrs(pID, el.host.Addrs(), peerstore.PermanentAddrTTL)
+		el.host.Peerstore().AddAddrs(h.ID(), h.Addrs(), peerstore.PermanentAddrTTL)
+	}
+	networkConf := conf.WithDefaults()
+	gater := NewConnectionGater(nil)
+	logger := log.With().Str("module", "memory_transport").Logger()
+	rateLimiter := NewRateLimiter(gater, networkConf)
+	stopChan := make(chan struct{})
+	t := &MemoryTransport{
+		messageRouter: newMessageRouter(logger, rateLimiter, stopChan),
+		network:       mn,
+		host:          h,
+		broadcastChan: make(chan *messages.BroadcastMsgChan, networkConf.BroadcastBufferSize),
+		gater:         gater,
+		rateLimiter:   rateLimiter,
+		stopChan:      stopChan,
+		wg:            &sync.WaitGroup{},
+	}
+	mn.transports[h.ID()] = t
+	t.wg.Add(1)
+	go t.processBroadcast()
+	return t, nil
+}
+
+// send deliver the tss message to the given peer, unless it is lost, after the latency and the jitter
+func (mn *MemoryNetwork) send(from, to peer.ID, payload []byte) {
+	mn.lock.Lock()
+	if mn.conf.LossRate > 0 && mn.rand.Float64() < mn.conf.LossRate {
+		mn.lock.Unlock()
+		return
+	}
+	delay := mn.conf.Latency
+	if mn.conf.Jitter > 0 {
+		delay += time.Duration(mn.rand.Int63n(int64(mn.conf.Jitter)))
+	}
+	envelope := memoryEnvelope{
+		from:      from,
+		payload:   payload,
+		deliverAt: time.Now().Add(delay),
+	}
+	if mn.conf.Jitter > 0 {
+		// every message has its own delay, so they overtake each other
+		mn.lock.Unlock()
+		time.AfterFunc(delay, func() { mn.deliver(to, envelope) })
+		return
+	}
+	// with the same delay for all the messages, a link delivers them in order
+	key := [2]peer.ID{from, to}
+	link, ok := mn.links[key]
+	if !ok {
+		link = make(chan memoryEnvelope, DefaultBroadcastBufferSize)
+		mn.links[key] = link
+		mn.wg.Add(1)
+		go mn.processLink(to, link)
+	}
+	mn.lock.Unlock()
+	select {
+	case link <- envelope:
+	case <-mn.stopChan:
+	}
+}
+
+func (mn *MemoryNetwork) processLink(to peer.ID, link chan memoryEnvelope) {
+	defer mn.wg.Done()
+	for {
+		select {
+		case envelope := <-link:
+			select {
+			case <-time.After(time.Until(envelope.deliverAt)):
+			case <-mn.stopChan:
+				return
+			}
+			mn.deliver(to, envelope)
+		case <-mn.stopChan:
+			return
+		}
+	}
+}
+
+func (mn *MemoryNetwork) deliver(to peer.ID, envelope memoryEnvelope) {
+	mn.lock.Lock()
+	receiver, ok := mn.transports[to]
+	mn.lock.Unlock()
+	if ok {
+		receiver.receive(envelope.from, envelope.payload)
+	}
+}
+
+// removeTransport take the node out of the network, the other nodes cannot reach it any more
+func (mn *MemoryNetwork) removeTransport(pID peer.ID) {
+	mn.lock.Lock()
+	defer mn.lock.Unlock()
+	delete(mn.transports, pID)
+	for el := range mn.transports {
+		_ = mn.mocknet.DisconnectPeers(pID, el)
+		_ = mn.mocknet.UnlinkPeers(pID, el)
+	}
+}
+
+// Close stop delivering the messages and close the mock network
+func (mn *MemoryNetwork) Close() error {
+	close(mn.stopChan)
+	mn.wg.Wait()
+	return mn.mocknet.Close()
+}
+
+// MemoryTransport is the transport of a node of the in-memory network
+type MemoryTransport struct {
+	*messageRouter
+	network       *MemoryNetwork
+	host          host.Host
+	broadcastChan chan *messages.BroadcastMsgChan
+	gater         *ConnectionGater
+	rateLimiter   *RateLimiter
+	stopChan      chan struct{}
+	stopOnce      sync.Once
+	wg            *sync.WaitGroup
+}
+
+var _ Transport = &MemoryTransport{}
+
+// memoryStream is a stream of the in-memory network, the mock streams do not support the deadlines so we
+// ignore them
+type memoryStream struct {
+	network.Stream
+}
+
+// SetDeadline implement network.Stream
+func (s memoryStream) SetDeadline(time.Time) error { return nil }
+
+// SetReadDeadline implement network.Stream
+func (s memoryStream) SetReadDeadline(time.Time) error { return nil }
+
+// SetWriteDeadline implement network.Stream
+func (s memoryStream) SetWriteDeadline(time.Time) error { return nil }
+
+// allowed tells whether we talk to the given peer
+func (t *MemoryTransport) allowed(pID peer.ID) bool {
+	return t.gater.IsAllowed(pID) && !t.gater.IsBanned(pID)
+}
+
+// ID implement StreamHost
+func (t *MemoryTransport) ID() peer.ID {
+	return t.host.ID()
+}
+
+// NewStream implement StreamHost
+func (t *MemoryTransport) NewStream(ctx context.Context, p peer.ID, pids ...protocol.ID) (network.Stream, error) {
+	if !t.allowed(p) {
+		return nil, ErrPeerNotAllowed
+	}
+	stream, err := t.host.NewStream(ctx, p, pids...)
+	if err != nil {
+		return nil, err
+	}
+	return memoryStream{Stream: stream}, nil
+}
+
+// SetStreamHandler implement StreamHost, the streams of the peers the connection gater refuses are reset
+func (t *MemoryTransport) SetStreamHandler(pid protocol.ID, handler network.StreamHandler) {
+	t.host.SetStreamHandler(pid, func(stream network.Stream) {
+		if !t.allowed(stream.Conn().RemotePeer()) {
+			_ = stream.Reset()
+			return
+		}
+		handler(memoryStream{Stream: stream})
+	})
+}
+
+// RemoveStreamHandler implement StreamHost
+func (t *MemoryTransport) RemoveStreamHandler(pid protocol.ID) {
+	t.host.RemoveStreamHandler(pid)
+}
+
+// BroadcastChannel implement Transport
+func (t *MemoryTransport) BroadcastChannel() chan *messages.BroadcastMsgChan {
+	return t.broadcastChan
+}
+
+// ReleaseStream implement Transport, the tss messages do not use streams in memory
+func (t *MemoryTransport) ReleaseStream(string) {}
+
+// ExportPeerAddress implement Transport
+func (t *MemoryTransport) ExportPeerAddress() map[peer.ID][]maddr.Multiaddr {
+	addressBook := make(map[peer.ID][]maddr.Multiaddr)
+	t.network.lock.Lock()
+	defer t.network.lock.Unlock()
+	for pID, el := range t.network.transports {
+		if pID != t.ID() {
+			addressBook[pID] = el.host.Addrs()
+		}
+	}
+	return addressBook
+}
+
+// GetConnectionGater implement Transport
+func (t *MemoryTransport) GetConnectionGater() *ConnectionGater {
+	return t.gater
+}
+
+// SetAllowedPubKeys implement Transport
+func (t *MemoryTransport) SetAllowedPubKeys(pubKeys []string) error {
+	_, err := t.gater.SetAllowedPubKeys(pubKeys, nil)
+	return err
+}
+
+// GetRateLimiter implement Transport
+func (t *MemoryTransport) GetRateLimiter() *RateLimiter {
+	return t.rateLimiter
+}
+
+func (t *MemoryTransport) processBroadcast() {
+	defer t.wg.Done()
+	for {
+		select {
+		case msg := <-t.broadcastChan:
+			wrappedMsgBytes, err := msg.WrappedMessage.Marshal(msg.Format)
+			if err != nil {
+				t.logger.Error().Err(err).Msgf("fail to marshal a wrapped message to %s bytes", msg.Format)
+				continue
+			}
+			for _, pID := range msg.PeersID {
+				if pID == t.ID() || !t.allowed(pID) {
+					continue
+				}
+				t.network.send(t.ID(), pID, wrappedMsgBytes)
+			}
+		case <-t.stopChan:
+			return
+		}
+	}
+}
+
+// receive dispatch the tss message the given peer sent us
+func (t *MemoryTransport) receive(from peer.ID, payload []byte) {
+	select {
+	case <-t.stopChan:
+		return
+	default:
+	}
+	if !t.allowed(from) {
+		return
+	}
+	if _, err := t.dispatchMessage(from, payload); err != nil {
+		t.logger.Error().Err(err).Msg("fail to dispatch the message")
+	}
+}
+
+// Stop implement Transport, the node leaves the network
+func (t *MemoryTransport) Stop() error {
+	t.stopOnce.Do(func() {
+		t.network.removeTransport(t.ID())
+		close(t.stopChan)
+	})
+	t.wg.Wait()
+	return nil
+}
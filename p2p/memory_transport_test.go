@@ -0,0 +1,176 @@
+package p2p
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ordinox/thorchain-tss/messages"
+)
+
+func newMemoryTransports(t *testing.T, conf MemoryNetworkConfig, n int) (*MemoryNetwork, []*MemoryTransport) {
+	mn, err := NewMemoryNetwork(conf)
+	assert.Nil(t, err)
+	transports := make([]*MemoryTransport, n)
+	for i := range transports {
+		sk, _, err := crypto.GenerateSecp256k1Key(rand.Reader)
+		assert.Nil(t, err)
+		skRaw, err := sk.Raw()
+		assert.Nil(t, err)
+		transports[i], err = mn.NewTransport(skRaw, NetworkConfig{})
+		assert.Nil(t, err)
+	}
+	return mn, transports
+}
+
+func sendMemoryMessages(sender *MemoryTransport, receiver peer.ID, msgID string, count int) {
+	for i := 0; i < count; i++ {
+		sender.BroadcastChannel() <- &messages.BroadcastMsgChan{
+			WrappedMessage: messages.WrappedMessage{
+				MessageType: messages.TSSKeyGenMsg,
+				MsgID:       msgID,
+				Payload:     []byte(fmt.Sprintf("%d", i)),
+			},
+			PeersID: []peer.ID{receiver},
+			Format:  messages.WireFormatProtobuf,
+		}
+	}
+}
+
+// receiveMemoryMessages return the payloads of the messages the channel gets within the timeout
+func receiveMemoryMessages(ch chan *Message, timeout time.Duration) []string {
+	var payloads []string
+	for {
+		select {
+		case msg := <-ch:
+			var wrapped messages.WrappedMessage
+			if err := wrapped.Unmarshal(msg.Payload); err == nil {
+				payloads = append(payloads, string(wrapped.Payload))
+			}
+		case <-time.After(timeout):
+			return payloads
+		}
+	}
+}
+
+func TestMemoryNetworkConfigValidate(t *testing.T) {
+	assert.Nil(t, MemoryNetworkConfig{}.Validate())
+	assert.Nil(t, MemoryNetworkConfig{Latency: time.Millisecond, Jitter: time.Millisecond, LossRate: 1}.Validate())
+	assert.NotNil(t, MemoryNetworkConfig{Latency: -time.Millisecond}.Validate())
+	assert.NotNil(t, MemoryNetworkConfig{Jitter: -time.Millisecond}.Validate())
+	assert.NotNil(t, MemoryNetworkConfig{LossRate: 1.5}.Validate())
+	_, err := NewMemoryNetwork(MemoryNetworkConfig{LossRate: -1})
+	assert.NotNil(t, err)
+}
+
+func TestMemoryTransportDeliverInOrder(t *testing.T) {
+	mn, transports := newMemoryTransports(t, MemoryNetworkConfig{Latency: 10 * time.Millisecond}, 2)
+	defer mn.Close()
+	sender, receiver := transports[0], transports[1]
+	ch := make(chan *Message, 50)
+	receiver.SetSubscribe(messages.TSSKeyGenMsg, "ordered", ch)
+	sendMemoryMessages(sender, receiver.ID(), "ordered", 20)
+	payloads := receiveMemoryMessages(ch, 500*time.Millisecond)
+	assert.Len(t, payloads, 20)
+	for i, el := range payloads {
+		assert.Equal(t, fmt.Sprintf("%d", i), el)
+	}
+	// we do not send to ourselves
+	sendMemoryMessages(receiver, receiver.ID(), "ordered", 1)
+	assert.Empty(t, receiveMemoryMessages(ch, 100*time.Millisecond))
+
+	receiver.CancelSubscribe(messages.TSSKeyGenMsg, "ordered")
+	sendMemoryMessages(sender, receiver.ID(), "ordered", 1)
+	assert.Empty(t, receiveMemoryMessages(ch, 100*time.Millisecond))
+}
+
+func TestMemoryTransportLoss(t *testing.T) {
+	mn, transports := newMemoryTransports(t, MemoryNetworkConfig{LossRate: 1}, 2)
+	defer mn.Close()
+	ch := make(chan *Message, 50)
+	transports[1].SetSubscribe(messages.TSSKeyGenMsg, "lost", ch)
+	sendMemoryMessages(transports[0], transports[1].ID(), "lost", 20)
+	assert.Empty(t, receiveMemoryMessages(ch, 200*time.Millisecond))
+
+	mn, transports = newMemoryTransports(t, MemoryNetworkConfig{LossRate: 0.5, Seed: 1}, 2)
+	defer mn.Close()
+	transports[1].SetSubscribe(messages.TSSKeyGenMsg, "lost", ch)
+	sendMemoryMessages(transports[0], transports[1].ID(), "lost", 40)
+	payloads := receiveMemoryMessages(ch, 200*time.Millisecond)
+	assert.NotEmpty(t, payloads)
+	assert.Less(t, len(payloads), 40)
+}
+
+func TestMemoryTransportReorder(t *testing.T) {
+	mn, transports := newMemoryTransports(t, MemoryNetworkConfig{Jitter: 50 * time.Millisecond, Seed: 1}, 2)
+	defer mn.Close()
+	ch := make(chan *Message, 50)
+	transports[1].SetSubscribe(messages.TSSKeyGenMsg, "reordered", ch)
+	sendMemoryMessages(transports[0], transports[1].ID(), "reordered", 20)
+	payloads := receiveMemoryMessages(ch, 500*time.Millisecond)
+	assert.Len(t, payloads, 20)
+	inOrder := true
+	for i, el := range payloads {
+		if el != fmt.Sprintf("%d", i) {
+			inOrder = false
+		}
+	}
+	assert.False(t, inOrder)
+}
+
+func TestMemoryTransportGater(t *testing.T) {
+	mn, transports := newMemoryTransports(t, MemoryNetworkConfig{}, 2)
+	defer mn.Close()
+	sender, receiver := transports[0], transports[1]
+	ch := make(chan *Message, 50)
+	receiver.SetSubscribe(messages.TSSKeyGenMsg, "gated", ch)
+	receiver.GetConnectionGater().Ban(sender.ID(), time.Now().Add(time.Minute))
+	sendMemoryMessages(sender, receiver.ID(), "gated", 5)
+	assert.Empty(t, receiveMemoryMessages(ch, 200*time.Millisecond))
+
+	receiver.SetStreamHandler("/memory/test", func(stream network.Stream) {
+		_ = WriteStreamWithBuffer([]byte("hello"), stream)
+	})
+	stream, err := sender.NewStream(context.Background(), receiver.ID(), "/memory/test")
+	assert.Nil(t, err)
+	_, err = ReadStreamWithBuffer(stream)
+	assert.NotNil(t, err)
+
+	// the banned peer cannot open a stream from our side either
+	_, err = receiver.NewStream(context.Background(), sender.ID(), "/memory/test")
+	assert.Equal(t, ErrPeerNotAllowed, err)
+}
+
+func TestMemoryTransportStream(t *testing.T) {
+	mn, transports := newMemoryTransports(t, MemoryNetworkConfig{Latency: 5 * time.Millisecond}, 3)
+	defer mn.Close()
+	transports[2].SetStreamHandler("/memory/test", func(stream network.Stream) {
+		buf, err := ReadStreamWithBuffer(stream)
+		assert.Nil(t, err)
+		assert.Nil(t, WriteStreamWithBuffer(append(buf, []byte(" back")...), stream))
+	})
+	stream, err := transports[0].NewStream(context.Background(), transports[2].ID(), "/memory/test")
+	assert.Nil(t, err)
+	assert.Nil(t, WriteStreamWithBuffer([]byte("hello"), stream))
+	buf, err := ReadStreamWithBuffer(stream)
+	assert.Nil(t, err)
+	assert.Equal(t, "hello back", string(buf))
+	assert.Nil(t, stream.Close())
+
+	addresses := transports[0].ExportPeerAddress()
+	assert.Len(t, addresses, 2)
+	assert.Contains(t, addresses, transports[1].ID())
+
+	// a stopped node leaves the network
+	assert.Nil(t, transports[2].Stop())
+	_, err = transports[0].NewStream(context.Background(), transports[2].ID(), "/memory/test")
+	assert.NotNil(t, err)
+	assert.Len(t, transports[0].ExportPeerAddress(), 1)
+}
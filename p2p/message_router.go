@@ -0,0 +1,200 @@
+package p2p
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/rs/zerolog"
+
+	"github.com/ordinox/thorchain-tss/messages"
+)
+
+// subscriberQueueSize is how many messages we buffer for a busy subscriber before we drop its messages
+const subscriberQueueSize = 256
+
+// errSubscriberQueueFull is returned when the message is dropped as the queue of its subscriber is full, the
+// sender should send it again later
+var errSubscriberQueueFull = errors.New("subscriber queue is full")
+
+type subscriberKey struct {
+	topic messages.THORChainTSSMessageType
+	msgID string
+}
+
+// subscriberQueue hand the messages over to a subscriber in the order we receive them. When the subscriber is
+// busy, the messages are buffered up to subscriberQueueSize and delivered by a goroutine, so a slow or finished
+// ceremony does not hold back the messages of the other ceremonies sharing the stream with it.
+type subscriberQueue struct {
+	lock    *sync.Mutex
+	channel chan *Message
+	msgs    chan *Message
+	pending int
+	done    chan struct{}
+}
+
+func newSubscriberQueue(channel chan *Message) *subscriberQueue {
+	return &subscriberQueue{
+		lock:    &sync.Mutex{},
+		channel: channel,
+		msgs:    make(chan *Message, subscriberQueueSize),
+		done:    make(chan struct{}),
+	}
+}
+
+// push deliver the message right away if the subscriber is ready and no message is queued before it, otherwise
+// the message is queued, it returns false if the queue is full
+func (q *subscriberQueue) push(msg *Message) bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.pending == 0 {
+		select {
+		case q.channel <- msg:
+			return true
+		default:
+		}
+	}
+	select {
+	case q.msgs <- msg:
+		q.pending++
+		return true
+	default:
+		return false
+	}
+}
+
+// run deliver the queued messages until the subscription is cancelled or the router stops
+func (q *subscriberQueue) run(stopChan chan struct{}) {
+	for {
+		select {
+		case msg := <-q.msgs:
+			select {
+			case q.channel <- msg:
+			case <-q.done:
+				return
+			case <-stopChan:
+				return
+			}
+			q.lock.Lock()
+			q.pending--
+			q.lock.Unlock()
+		case <-q.done:
+			return
+		case <-stopChan:
+			return
+		}
+	}
+}
+
+// messageRouter hand the inbound tss messages over to the subscribers of their type and msg id, every
+// transport shares it so the messages reach the ceremonies the same way whatever carries them
+type messageRouter struct {
+	logger           zerolog.Logger
+	subscribers      map[messages.THORChainTSSMessageType]*MessageIDSubscriber
+	queues           map[subscriberKey]*subscriberQueue
+	subscriberLocker *sync.Mutex
+	rateLimiter      *RateLimiter
+	stopChan         chan struct{}
+}
+
+func newMessageRouter(logger zerolog.Logger, rateLimiter *RateLimiter, stopChan chan struct{}) *messageRouter {
+	return &messageRouter{
+		logger:           logger,
+		subscribers:      make(map[messages.THORChainTSSMessageType]*MessageIDSubscriber),
+		queues:           make(map[subscriberKey]*subscriberQueue),
+		subscriberLocker: &sync.Mutex{},
+		rateLimiter:      rateLimiter,
+		stopChan:         stopChan,
+	}
+}
+
+// dispatchMessage deliver the message to the subscriber of its type and msg id, it returns the msg id, and
+// errSubscriberQueueFull if the message is dropped as its subscriber is too busy to take it
+func (r *messageRouter) dispatchMessage(remotePeer peer.ID, dataBuf []byte) (string, error) {
+	var wrappedMsg messages.WrappedMessage
+	if err := wrappedMsg.Unmarshal(dataBuf); nil != err {
+		return "", fmt.Errorf("fail to unmarshal wrapped message bytes: %w", err)
+	}
+	if err := r.rateLimiter.AllowMessage(remotePeer, wrappedMsg.MsgID); err != nil {
+		return wrappedMsg.MsgID, err
+	}
+	r.logger.Debug().Msgf(">>>>>>>[%s] %s", wrappedMsg.MessageType, string(wrappedMsg.Payload))
+	queue := r.getQueue(wrappedMsg.MessageType, wrappedMsg.MsgID)
+	if nil == queue {
+		r.logger.Debug().Msgf("no MsgID %s found for this message", wrappedMsg.MsgID)
+		r.logger.Debug().Msgf("no MsgID %s found for this message", wrappedMsg.MessageType)
+		return wrappedMsg.MsgID, nil
+	}
+	msg := &Message{
+		PeerID:  remotePeer,
+		Payload: dataBuf,
+	}
+	if !queue.push(msg) {
+		r.logger.Warn().Msgf("drop the message %s of %s from peer(%s) as its subscriber queue is full", wrappedMsg.MessageType, wrappedMsg.MsgID, remotePeer)
+		return wrappedMsg.MsgID, errSubscriberQueueFull
+	}
+	return wrappedMsg.MsgID, nil
+}
+
+func (r *messageRouter) SetSubscribe(topic messages.THORChainTSSMessageType, msgID string, channel chan *Message) {
+	r.subscriberLocker.Lock()
+	defer r.subscriberLocker.Unlock()
+
+	messageIDSubscribers, ok := r.subscribers[topic]
+	if !ok {
+		messageIDSubscribers = NewMessageIDSubscriber()
+		r.subscribers[topic] = messageIDSubscribers
+	}
+	messageIDSubscribers.Subscribe(msgID, channel)
+	key := subscriberKey{topic: topic, msgID: msgID}
+	if queue, ok := r.queues[key]; ok {
+		if queue.channel == channel {
+			return
+		}
+		close(queue.done)
+	}
+	queue := newSubscriberQueue(channel)
+	r.queues[key] = queue
+	go queue.run(r.stopChan)
+}
+
+func (r *messageRouter) getQueue(topic messages.THORChainTSSMessageType, msgID string) *subscriberQueue {
+	r.subscriberLocker.Lock()
+	defer r.subscriberLocker.Unlock()
+	return r.queues[subscriberKey{topic: topic, msgID: msgID}]
+}
+
+func (r *messageRouter) getSubscriber(topic messages.THORChainTSSMessageType, msgID string) chan *Message {
+	r.subscriberLocker.Lock()
+	defer r.subscriberLocker.Unlock()
+	messageIDSubscribers, ok := r.subscribers[topic]
+	if !ok {
+		r.logger.Debug().Msgf("fail to find subscribers for %s", topic)
+		return nil
+	}
+	return messageIDSubscribers.GetSubscriber(msgID)
+}
+
+func (r *messageRouter) CancelSubscribe(topic messages.THORChainTSSMessageType, msgID string) {
+	r.subscriberLocker.Lock()
+	defer r.subscriberLocker.Unlock()
+
+	key := subscriberKey{topic: topic, msgID: msgID}
+	if queue, ok := r.queues[key]; ok {
+		close(queue.done)
+		delete(r.queues, key)
+	}
+	messageIDSubscribers, ok := r.subscribers[topic]
+	if !ok {
+		r.logger.Debug().Msgf("cannot find the given channels %s", topic.String())
+		return
+	}
+	if nil == messageIDSubscribers {
+		return
+	}
+	messageIDSubscribers.UnSubscribe(msgID)
+	if messageIDSubscribers.IsEmpty() {
+		delete(r.subscribers, topic)
+	}
+}
@@ -0,0 +1,67 @@
+package p2p
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ordinox/thorchain-tss/messages"
+)
+
+func newTestRouter(stopChan chan struct{}) *messageRouter {
+	conf := NetworkConfig{MessageRate: 10000, MessageBurst: 10000}.WithDefaults()
+	return newMessageRouter(log.Logger, NewRateLimiter(NewConnectionGater(nil), conf), stopChan)
+}
+
+func TestMessageRouterQueue(t *testing.T) {
+	stopChan := make(chan struct{})
+	defer close(stopChan)
+	router := newTestRouter(stopChan)
+	remotePeer := randomPeerID(t)
+	ch := make(chan *Message)
+	router.SetSubscribe(messages.TSSKeySignMsg, "busy", ch)
+
+	// the subscriber is busy, the messages are queued up to the queue size and the others are dropped
+	for i := 0; i < subscriberQueueSize+10; i++ {
+		wrapped := messages.WrappedMessage{MessageType: messages.TSSKeySignMsg, MsgID: "busy", Payload: []byte(fmt.Sprintf("%d", i))}
+		buf, err := wrapped.Marshal(messages.WireFormatProtobuf)
+		assert.Nil(t, err)
+		msgID, err := router.dispatchMessage(remotePeer, buf)
+		if i < subscriberQueueSize {
+			assert.Nil(t, err)
+		} else {
+			assert.ErrorIs(t, err, errSubscriberQueueFull)
+		}
+		assert.Equal(t, "busy", msgID)
+	}
+	// the queued messages are delivered in order
+	for i := 0; i < subscriberQueueSize; i++ {
+		select {
+		case msg := <-ch:
+			var wrapped messages.WrappedMessage
+			assert.Nil(t, wrapped.Unmarshal(msg.Payload))
+			assert.Equal(t, fmt.Sprintf("%d", i), string(wrapped.Payload))
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting for message %d", i)
+		}
+	}
+	select {
+	case <-ch:
+		t.Fatal("the messages beyond the queue size should be dropped")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// the queue goes away with the subscription
+	queue := router.getQueue(messages.TSSKeySignMsg, "busy")
+	assert.NotNil(t, queue)
+	router.CancelSubscribe(messages.TSSKeySignMsg, "busy")
+	assert.Nil(t, router.getQueue(messages.TSSKeySignMsg, "busy"))
+	select {
+	case <-queue.done:
+	default:
+		t.Fatal("the queue should be stopped")
+	}
+}
@@ -0,0 +1,131 @@
+package p2p
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	libp2p "github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/event"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/p2p/protocol/circuitv2/relay"
+	maddr "github.com/multiformats/go-multiaddr"
+)
+
+const (
+	// ReachabilityPublic forces the node to consider itself publicly reachable
+	ReachabilityPublic = "public"
+	// ReachabilityPrivate forces the node to consider itself behind a NAT, so it reserves a slot on the relays
+	ReachabilityPrivate = "private"
+)
+
+// NATStatus describes how the other nodes can reach this node
+type NATStatus struct {
+	// Reachability is either unknown, public or private, as found out by AutoNAT or forced by the configuration
+	Reachability string `json:"reachability"`
+	// Addresses are the addresses we advertise, including the relay circuit addresses
+	Addresses []string `json:"addresses"`
+	// RelayService tells whether the node relays the connections of the committee
+	RelayService bool `json:"relay_service"`
+}
+
+// validateReachability check the forced reachability is one we support
+func validateReachability(reachability string) error {
+	switch reachability {
+	case "", ReachabilityPublic, ReachabilityPrivate:
+		return nil
+	}
+	return fmt.Errorf("unknown reachability(%s), it should be %s or %s", reachability, ReachabilityPublic, ReachabilityPrivate)
+}
+
+// relayACL only relays the connections between the peers the connection gater allows
+type relayACL struct {
+	gater *ConnectionGater
+}
+
+var _ relay.ACLFilter = &relayACL{}
+
+// AllowReserve implement relay.ACLFilter
+func (r *relayACL) AllowReserve(pID peer.ID, _ maddr.Multiaddr) bool {
+	return r.gater.IsAllowed(pID)
+}
+
+// AllowConnect implement relay.ACLFilter
+func (r *relayACL) AllowConnect(src peer.ID, _ maddr.Multiaddr, dest peer.ID) bool {
+	return r.gater.IsAllowed(src) && r.gater.IsAllowed(dest)
+}
+
+// natOptions return the libp2p options of the NAT traversal, the relay client is always enabled so we can
+// dial the relay circuit addresses of the nodes behind a NAT
+func (c *Communication) natOptions() ([]libp2p.Option, error) {
+	opts := []libp2p.Option{libp2p.EnableRelay()}
+	switch c.conf.Reachability {
+	case ReachabilityPublic:
+		opts = append(opts, libp2p.ForceReachabilityPublic())
+	case ReachabilityPrivate:
+		opts = append(opts, libp2p.ForceReachabilityPrivate())
+	}
+	if c.conf.EnableNATService {
+		opts = append(opts, libp2p.EnableNATService())
+	}
+	if len(c.conf.RelayPeers) != 0 {
+		relays, err := peer.AddrInfosFromP2pAddrs(c.conf.RelayPeers...)
+		if err != nil {
+			return nil, fmt.Errorf("fail to parse the relay peers: %w", err)
+		}
+		opts = append(opts, libp2p.EnableAutoRelayWithStaticRelays(relays))
+	}
+	if c.conf.EnableRelayService {
+		// a ceremony runs for longer and sends more data than the default limits of a relayed connection allow,
+		// as we only relay for the committee we lift the limits
+		opts = append(opts, libp2p.EnableRelayService(relay.WithInfiniteLimits(), relay.WithACL(&relayACL{gater: c.gater})))
+	}
+	if c.conf.EnableHolePunching {
+		opts = append(opts, libp2p.EnableHolePunching())
+	}
+	return opts, nil
+}
+
+// watchReachability keep track of the reachability of the node, until the communication stops
+func (c *Communication) watchReachability() error {
+	sub, err := c.host.EventBus().Subscribe(new(event.EvtLocalReachabilityChanged))
+	if err != nil {
+		return fmt.Errorf("fail to subscribe to the reachability events: %w", err)
+	}
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer sub.Close()
+		for {
+			select {
+			case <-c.stopChan:
+				return
+			case e, ok := <-sub.Out():
+				if !ok {
+					return
+				}
+				reachability := e.(event.EvtLocalReachabilityChanged).Reachability
+				atomic.StoreInt32(&c.reachability, int32(reachability))
+				c.logger.Info().Msgf("the node reachability is %s", reachability)
+			}
+		}
+	}()
+	return nil
+}
+
+// GetNATStatus return how the other nodes can reach this node
+func (c *Communication) GetNATStatus() NATStatus {
+	status := NATStatus{
+		Reachability: strings.ToLower(network.Reachability(atomic.LoadInt32(&c.reachability)).String()),
+		Addresses:    []string{},
+		RelayService: c.conf.EnableRelayService,
+	}
+	if c.host == nil {
+		return status
+	}
+	for _, el := range c.host.Addrs() {
+		status.Addresses = append(status.Addresses, el.String())
+	}
+	return status
+}
@@ -0,0 +1,142 @@
+package p2p
+
+import (
+	"crypto/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	maddr "github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ordinox/thorchain-tss/conversion"
+	"github.com/ordinox/thorchain-tss/messages"
+)
+
+func startNATNode(t *testing.T, conf NetworkConfig, bootstrapPeers []maddr.Multiaddr) *Communication {
+	sk, _, err := crypto.GenerateSecp256k1Key(rand.Reader)
+	assert.Nil(t, err)
+	skRaw, err := sk.Raw()
+	assert.Nil(t, err)
+	conf.Discovery = DiscoveryStatic
+	conf.BootstrapAttempts = 1
+	conf.ListenAddrs = addrList{maddr.StringCast("/ip4/127.0.0.1/tcp/0")}
+	comm, err := NewCommunication(Config{
+		RendezvousString: "nat",
+		BootstrapPeers:   bootstrapPeers,
+		NetworkConfig:    conf,
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, comm.Start(skRaw))
+	return comm
+}
+
+func relayAddrOf(comm *Communication) string {
+	for _, el := range comm.host.Addrs() {
+		if strings.Contains(el.String(), "/p2p-circuit") {
+			return el.String()
+		}
+	}
+	return ""
+}
+
+func TestNATConfigValidate(t *testing.T) {
+	assert.Nil(t, NetworkConfig{Reachability: ReachabilityPublic}.Validate())
+	assert.Nil(t, NetworkConfig{Reachability: ReachabilityPrivate}.Validate())
+	assert.NotNil(t, NetworkConfig{Reachability: "behind the moon"}.Validate())
+	assert.NotNil(t, NetworkConfig{RelayPeers: addrList{maddr.StringCast("/ip4/127.0.0.1/tcp/6668")}}.Validate())
+}
+
+func TestRelayACL(t *testing.T) {
+	conversion.SetupBech32Prefix()
+	pubKey := conversion.GetRandomPubKey()
+	member, err := conversion.GetPeerIDFromPubKey(pubKey)
+	assert.Nil(t, err)
+	stranger := randomPeerID(t)
+	gater := NewConnectionGater(nil)
+	acl := &relayACL{gater: gater}
+	// everyone is allowed until the allowlist is set
+	assert.True(t, acl.AllowReserve(stranger, nil))
+	_, err = gater.SetAllowedPubKeys([]string{pubKey}, nil)
+	assert.Nil(t, err)
+	assert.True(t, acl.AllowReserve(member, nil))
+	assert.False(t, acl.AllowReserve(stranger, nil))
+	assert.True(t, acl.AllowConnect(member, nil, member))
+	assert.False(t, acl.AllowConnect(member, nil, stranger))
+	assert.False(t, acl.AllowConnect(stranger, nil, member))
+}
+
+func TestNATTraversalWithRelay(t *testing.T) {
+	// the relays only advertise their public addresses, the in-process relay pretends to have one
+	relay := startNATNode(t, NetworkConfig{
+		Reachability:       ReachabilityPublic,
+		EnableRelayService: true,
+		EnableNATService:   true,
+		ExternalAddrs:      addrList{maddr.StringCast("/ip4/11.22.33.44/tcp/6668")},
+	}, nil)
+	defer relay.Stop()
+	var relayAddr maddr.Multiaddr
+	for _, el := range relay.host.Network().ListenAddresses() {
+		if _, err := el.ValueForProtocol(maddr.P_TCP); err == nil {
+			relayAddr = el.Encapsulate(maddr.StringCast("/p2p/" + relay.host.ID().String()))
+		}
+	}
+	assert.Eventually(t, func() bool {
+		return relay.GetNATStatus().Reachability == ReachabilityPublic
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, NATStatus{
+		Reachability: ReachabilityPublic,
+		Addresses:    []string{"/ip4/11.22.33.44/tcp/6668"},
+		RelayService: true,
+	}, relay.GetNATStatus())
+
+	// the node behind the NAT reserves a slot on the relay and advertise its relay circuit address
+	natted := startNATNode(t, NetworkConfig{
+		Reachability:       ReachabilityPrivate,
+		RelayPeers:         addrList{relayAddr},
+		EnableHolePunching: true,
+	}, []maddr.Multiaddr{relayAddr})
+	defer natted.Stop()
+	assert.Eventually(t, func() bool {
+		return len(relayAddrOf(natted)) != 0
+	}, 30*time.Second, 100*time.Millisecond)
+	status := natted.GetNATStatus()
+	assert.Equal(t, ReachabilityPrivate, status.Reachability)
+	assert.False(t, status.RelayService)
+	assert.Contains(t, status.Addresses, relayAddrOf(natted))
+
+	// another node only knows the relay circuit address of the node behind the NAT
+	node := startNATNode(t, NetworkConfig{}, []maddr.Multiaddr{relayAddr})
+	defer node.Stop()
+	circuitAddr, err := maddr.NewMultiaddr(relayAddrOf(natted) + "/p2p/" + natted.host.ID().String())
+	assert.Nil(t, err)
+	pi, err := peer.AddrInfoFromP2pAddr(circuitAddr)
+	assert.Nil(t, err)
+	node.host.Peerstore().AddAddrs(pi.ID, pi.Addrs, time.Minute)
+	assert.Nil(t, node.host.Network().ClosePeer(natted.host.ID()))
+
+	ch := make(chan *Message, 1)
+	natted.SetSubscribe(messages.TSSKeyGenMsg, "nat", ch)
+	wrapped := messages.WrappedMessage{
+		MessageType: messages.TSSKeyGenMsg,
+		MsgID:       "nat",
+		Payload:     []byte("hello from the other side of the NAT"),
+	}
+	buf, err := wrapped.Marshal(messages.WireFormatProtobuf)
+	assert.Nil(t, err)
+	assert.Nil(t, node.writeToStream(natted.host.ID(), buf, "nat", time.Now().Add(time.Minute)))
+	select {
+	case msg := <-ch:
+		assert.Equal(t, buf, msg.Payload)
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout waiting for the message")
+	}
+	conns := node.host.Network().ConnsToPeer(natted.host.ID())
+	assert.NotEmpty(t, conns)
+	for _, el := range conns {
+		_, err := el.RemoteMultiaddr().ValueForProtocol(maddr.P_CIRCUIT)
+		assert.Nil(t, err, "the connection should go through the relay")
+	}
+}
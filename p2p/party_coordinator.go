@@ -2,12 +2,12 @@ package p2p
 
 import (
 	"context"
+	"crypto/rand"
 	"errors"
 	"fmt"
 	"sync"
 	"time"
 
-	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/core/protocol"
@@ -17,28 +17,44 @@ import (
 
 	"github.com/ordinox/thorchain-tss/conversion"
 	"github.com/ordinox/thorchain-tss/messages"
+	"github.com/ordinox/thorchain-tss/monitor"
 )
 
+// SessionNonceSize is the size of the random nonce the leader picks for a ceremony session
+const SessionNonceSize = 32
+
 var (
 	ErrJoinPartyTimeout = errors.New("fail to join party, timeout")
 	ErrLeaderNotReady   = errors.New("leader not reachable")
 	ErrSignReceived     = errors.New("signature received")
 	ErrNotActiveSigner  = errors.New("not active signer")
 	ErrSigGenerated     = errors.New("signature generated")
+
+	// errLeaderUnreachable is returned when we cannot send our request to the leader, we move to the next one
+	errLeaderUnreachable = errors.New("fail to send the request to the leader")
 )
 
 type PartyCoordinator struct {
 	logger             zerolog.Logger
-	host               host.Host
+	host               StreamHost
 	stopChan           chan struct{}
 	timeout            time.Duration
 	peersGroup         map[string]*peerStatus
 	joinPartyGroupLock *sync.Mutex
 	streamMgr          *StreamMgr
+	conf               NetworkConfig
+	healthMonitor      *HealthMonitor
+	rateLimiter        *RateLimiter
+	sessionNonces      map[string][]byte
+	sessionNoncesLock  *sync.Mutex
+	signerPolicy       SignerPolicy
+	signerStats        *SignerStats
+	eventBus           *monitor.EventBus
 }
 
-// NewPartyCoordinator create a new instance of PartyCoordinator
-func NewPartyCoordinator(host host.Host, timeout time.Duration) *PartyCoordinator {
+// NewPartyCoordinator create a new instance of PartyCoordinator, the timeouts and limits left to zero in the
+// given network configuration fall back to their default values
+func NewPartyCoordinator(host StreamHost, timeout time.Duration, conf NetworkConfig) *PartyCoordinator {
 	// if no timeout is given, default to 10 seconds
 	if timeout.Nanoseconds() == 0 {
 		timeout = 10 * time.Second
@@ -51,17 +67,106 @@ func NewPartyCoordinator(host host.Host, timeout time.Duration) *PartyCoordinato
 		peersGroup:         make(map[string]*peerStatus),
 		joinPartyGroupLock: &sync.Mutex{},
 		streamMgr:          NewStreamMgr(),
+		conf:               conf.WithDefaults(),
+		sessionNonces:      make(map[string][]byte),
+		sessionNoncesLock:  &sync.Mutex{},
+		signerPolicy:       ArrivalOrderPolicy(),
+		signerStats:        NewSignerStats(),
+	}
+	for _, pID := range WithLegacyProtocolIDs(joinPartyProtocol) {
+		host.SetStreamHandler(pID, pc.HandleStream)
+	}
+	for _, pID := range WithLegacyProtocolIDs(joinPartyProtocolWithLeader) {
+		host.SetStreamHandler(pID, pc.HandleStreamWithLeader)
 	}
-	host.SetStreamHandler(joinPartyProtocol, pc.HandleStream)
-	host.SetStreamHandler(joinPartyProtocolWithLeader, pc.HandleStreamWithLeader)
 	return pc
 }
 
+// SetHealthMonitor set the monitor we consult to warn about the unreachable members before joining a party
+func (pc *PartyCoordinator) SetHealthMonitor(monitor *HealthMonitor) {
+	pc.healthMonitor = monitor
+}
+
+// SetRateLimiter set the limiter that bounds the streams, bytes and messages the peers send to the party
+// coordinator, it should be the one of the communication so a peer has the same limits on all the protocols
+func (pc *PartyCoordinator) SetRateLimiter(limiter *RateLimiter) {
+	pc.rateLimiter = limiter
+}
+
+// SetSignerPolicy set how we pick the signers when we lead a keysign and more peers than needed are ready
+func (pc *PartyCoordinator) SetSignerPolicy(policy SignerPolicy) {
+	pc.signerPolicy = policy
+}
+
+// SetEventBus set the bus we publish the progress of the join parties on
+func (pc *PartyCoordinator) SetEventBus(bus *monitor.EventBus) {
+	pc.eventBus = bus
+}
+
+// publishFormed publish that the party of the ceremony is formed, unless joining the party failed
+func (pc *PartyCoordinator) publishFormed(msgID, leader string, onlines []peer.ID, err error) {
+	if err != nil {
+		return
+	}
+	pc.eventBus.Publish(monitor.NewEvent(msgID, monitor.EventJoinPartyFormed, "", conversion.PeerIDsToStrings(onlines), leader))
+}
+
+// SignerStats return the outcomes of the recent keysigns of the peers, the keysigns should record theirs in it
+func (pc *PartyCoordinator) SignerStats() *SignerStats {
+	return pc.signerStats
+}
+
+// warnUnreachable log the members of the party that the health monitor cannot reach at the moment
+func (pc *PartyCoordinator) warnUnreachable(msgID string, leaderID peer.ID, peerIDs []peer.ID) {
+	if pc.healthMonitor == nil {
+		return
+	}
+	unreachable := pc.healthMonitor.Unreachable(peerIDs)
+	if len(unreachable) == 0 {
+		return
+	}
+	pc.logger.Warn().Str("msgID", msgID).Msgf("%d of %d party members are unreachable: %v", len(unreachable), len(peerIDs), unreachable)
+	for _, el := range unreachable {
+		if el == leaderID {
+			pc.logger.Warn().Str("msgID", msgID).Msgf("the leader(%s) is unreachable", leaderID)
+		}
+	}
+}
+
+// ErrUnreachableLeaders is returned when the join party fails and some leaders of the succession never answered
+// us, Leaders are the leaders we could not send our request to, NotReady is the leader that received our request
+// but never sent us the party, Err is the reason the join party failed
+type ErrUnreachableLeaders struct {
+	Leaders  []peer.ID
+	NotReady peer.ID
+	Err      error
+}
+
+func (e ErrUnreachableLeaders) Error() string {
+	if e.NotReady == "" {
+		return fmt.Sprintf("%s, unreachable leaders: %v", e.Err, e.Leaders)
+	}
+	return fmt.Sprintf("%s, unreachable leaders: %v, not ready leader: %s", e.Err, e.Leaders, e.NotReady)
+}
+
+func (e ErrUnreachableLeaders) Unwrap() error {
+	return e.Err
+}
+
+// withUnreachableLeaders attach the leaders that never answered us to the error of the join party
+func withUnreachableLeaders(leaders []peer.ID, notReady peer.ID, err error) error {
+	if err == nil || (len(leaders) == 0 && notReady == "") {
+		return err
+	}
+	return ErrUnreachableLeaders{Leaders: leaders, NotReady: notReady, Err: err}
+}
+
 // Stop the PartyCoordinator rune
 func (pc *PartyCoordinator) Stop() {
 	defer pc.logger.Info().Msg("stopping party coordinator")
-	pc.host.RemoveStreamHandler(joinPartyProtocol)
-	pc.host.RemoveStreamHandler(joinPartyProtocolWithLeader)
+	for _, pID := range WithLegacyProtocolIDs(joinPartyProtocol, joinPartyProtocolWithLeader) {
+		pc.host.RemoveStreamHandler(pID)
+	}
 	close(pc.stopChan)
 }
 
@@ -76,10 +181,8 @@ func (pc *PartyCoordinator) processRespMsg(respMsg *messages.JoinPartyLeaderComm
 		pc.logger.Info().Msgf("message ID from peer(%s) can not be found", remotePeer)
 		return
 	}
-	if remotePeer == peerGroup.getLeader() {
-		peerGroup.setLeaderResponse(respMsg)
-		peerGroup.notify <- true
-		err := WriteStreamWithBuffer([]byte("done"), stream)
+	if peerGroup.setLeaderResponse(remotePeer, respMsg) {
+		err := WriteStreamWithConfig([]byte("done"), stream, pc.conf)
 		if err != nil {
 			pc.logger.Error().Err(err).Msgf("fail to write the reply to peer: %s", remotePeer)
 			return
@@ -113,7 +216,12 @@ func (pc *PartyCoordinator) HandleStream(stream network.Stream) {
 	remotePeer := stream.Conn().RemotePeer()
 	logger := pc.logger.With().Str("remote peer", remotePeer.String()).Logger()
 	logger.Debug().Msg("reading from join party request")
-	payload, err := ReadStreamWithBuffer(stream)
+	if err := pc.rateLimiter.AllowStream(remotePeer); err != nil {
+		logger.Warn().Err(err).Msg("drop the join party stream")
+		_ = stream.Reset()
+		return
+	}
+	payload, err := ReadStreamWithLimiter(stream, pc.conf, pc.rateLimiter)
 	if err != nil {
 		logger.Err(err).Msgf("fail to read payload from stream")
 		pc.streamMgr.AddStream("UNKNOWN", stream)
@@ -125,6 +233,11 @@ func (pc *PartyCoordinator) HandleStream(stream network.Stream) {
 		pc.streamMgr.AddStream("UNKNOWN", stream)
 		return
 	}
+	if err := pc.rateLimiter.AllowMessage(remotePeer, msg.ID); err != nil {
+		logger.Warn().Err(err).Msg("drop the join party request")
+		pc.streamMgr.AddStream(msg.ID, stream)
+		return
+	}
 	pc.streamMgr.AddStream(msg.ID, stream)
 	pc.joinPartyGroupLock.Lock()
 	peerGroup, ok := pc.peersGroup[msg.ID]
@@ -145,7 +258,12 @@ func (pc *PartyCoordinator) HandleStreamWithLeader(stream network.Stream) {
 	remotePeer := stream.Conn().RemotePeer()
 	logger := pc.logger.With().Str("remote peer", remotePeer.String()).Logger()
 	logger.Debug().Msg("reading from join party request")
-	payload, err := ReadStreamWithBuffer(stream)
+	if err := pc.rateLimiter.AllowStream(remotePeer); err != nil {
+		logger.Warn().Err(err).Msg("drop the join party stream")
+		_ = stream.Reset()
+		return
+	}
+	payload, err := ReadStreamWithLimiter(stream, pc.conf, pc.rateLimiter)
 	if err != nil {
 		logger.Err(err).Msgf("fail to read payload from stream")
 		pc.streamMgr.AddStream("UNKNOWN", stream)
@@ -159,6 +277,11 @@ func (pc *PartyCoordinator) HandleStreamWithLeader(stream network.Stream) {
 		pc.streamMgr.AddStream("UNKNOWN", stream)
 		return
 	}
+	if err := pc.rateLimiter.AllowMessage(remotePeer, msg.ID); err != nil {
+		logger.Warn().Err(err).Msg("drop the join party message")
+		pc.streamMgr.AddStream(msg.ID, stream)
+		return
+	}
 
 	pc.logger.Debug().Msgf("received message type=%s", msg.MsgType)
 
@@ -168,7 +291,7 @@ func (pc *PartyCoordinator) HandleStreamWithLeader(stream network.Stream) {
 		return
 	case "response":
 		pc.processRespMsg(&msg, stream)
-		err := WriteStreamWithBuffer([]byte("done"), stream)
+		err := WriteStreamWithConfig([]byte("done"), stream, pc.conf)
 		if err != nil {
 			pc.logger.Error().Err(err).Msgf("fail to send response to leader")
 		}
@@ -186,10 +309,11 @@ func (pc *PartyCoordinator) removePeerGroup(messageID string) {
 	delete(pc.peersGroup, messageID)
 }
 
-func (pc *PartyCoordinator) createJoinPartyGroups(messageID string, leaderID peer.ID, peerIDs []peer.ID, threshold int) (*peerStatus, error) {
+func (pc *PartyCoordinator) createJoinPartyGroups(messageID string, leaderID peer.ID, peerIDs []peer.ID, threshold int, constraint SignerConstraint) (*peerStatus, error) {
 	pc.joinPartyGroupLock.Lock()
 	defer pc.joinPartyGroupLock.Unlock()
 	peerStatus := newPeerStatus(peerIDs, pc.host.ID(), leaderID, threshold)
+	peerStatus.exclude(constraint)
 	pc.peersGroup[messageID] = peerStatus
 	return peerStatus, nil
 }
@@ -267,7 +391,7 @@ func (pc *PartyCoordinator) sendMsgToPeer(msgBuf []byte, msgID string, remotePee
 	defer cancel()
 
 	pc.logger.Debug().Msgf("try to open stream to (%s) ", remotePeer)
-	stream, err := pc.host.NewStream(ctx, remotePeer, protoc)
+	stream, err := pc.host.NewStream(network.WithAllowLimitedConn(ctx, "join party"), remotePeer, WithLegacyProtocolIDs(protoc)...)
 	if err != nil {
 		streamError := fmt.Errorf("fail to create stream to peer(%s):%w", remotePeer, err)
 		return streamError
@@ -279,13 +403,13 @@ func (pc *PartyCoordinator) sendMsgToPeer(msgBuf []byte, msgID string, remotePee
 		}
 	}()
 	pc.logger.Debug().Msgf("open stream to (%s) successfully", remotePeer)
-	err = WriteStreamWithBuffer(msgBuf, stream)
+	err = WriteStreamWithConfig(msgBuf, stream, pc.conf)
 	if err != nil {
 		return fmt.Errorf("fail to write message to stream:%w", err)
 	}
 
 	if needResponse {
-		_, err := ReadStreamWithBuffer(stream)
+		_, err := ReadStreamWithConfig(stream, pc.conf)
 		if err != nil {
 			pc.logger.Error().Err(err).Msgf("fail to get the ")
 		}
@@ -294,7 +418,9 @@ func (pc *PartyCoordinator) sendMsgToPeer(msgBuf []byte, msgID string, remotePee
 	return nil
 }
 
-func (pc *PartyCoordinator) joinPartyMember(msgID string, peerGroup *peerStatus, sigChan chan string) ([]peer.ID, error) {
+// joinPartyMember send our request to the current leader and wait for its response until the deadline, if we
+// cannot send the request within the failover timeout we give up on the leader with errLeaderUnreachable
+func (pc *PartyCoordinator) joinPartyMember(ctx context.Context, msgID string, peerGroup *peerStatus, sigChan chan string, deadline time.Time) ([]peer.ID, error) {
 	leaderID := peerGroup.getLeader()
 	msg := messages.JoinPartyLeaderComm{
 		ID: msgID,
@@ -302,9 +428,11 @@ func (pc *PartyCoordinator) joinPartyMember(msgID string, peerGroup *peerStatus,
 
 	var wg sync.WaitGroup
 	done := make(chan struct{})
+	reached := make(chan struct{})
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
+		var delivered bool
 		for {
 			select {
 			case <-done:
@@ -314,32 +442,67 @@ func (pc *PartyCoordinator) joinPartyMember(msgID string, peerGroup *peerStatus,
 				err := pc.sendRequestToLeader(&msg, leaderID)
 				if err != nil {
 					pc.logger.Error().Err(err).Msg("error sending request to leader")
+				} else if !delivered {
+					delivered = true
+					close(reached)
 				}
 			}
-			time.Sleep(time.Millisecond * 500)
+			time.Sleep(pc.conf.LeaderRetryInterval)
 		}
 	}()
 
-	var stopped bool
+	var stopped, unreachable bool
 	var sigNotify string
+	var reachedCh <-chan struct{} = reached
+	failover := time.After(pc.conf.LeaderFailoverTimeout)
+	timeout := time.NewTimer(time.Until(deadline))
+	defer timeout.Stop()
 	// now we wait for the leader to notify us who we do the keygen/keysign with
-	select {
-	case <-pc.stopChan:
-		// promptly tear down this goroutine if partyCoordinator is stopped
-		pc.logger.Debug().Msg("party coordinator stopped")
-		stopped = true
-	case <-peerGroup.notify:
-		pc.logger.Debug().Msg("received a response from the leader")
-	case <-time.After(pc.timeout):
-		pc.logger.Debug().Msgf("timed out waiting for a response from the leader after %s", pc.timeout)
-	case result := <-sigChan:
-		pc.logger.Debug().Msgf("received %s from sigChan", result)
-		sigNotify = result
+wait:
+	for {
+		select {
+		case <-pc.stopChan:
+			// promptly tear down this goroutine if partyCoordinator is stopped
+			pc.logger.Debug().Msg("party coordinator stopped")
+			stopped = true
+			break wait
+		case <-ctx.Done():
+			pc.logger.Debug().Str("msgID", msgID).Msg("join party cancelled")
+			break wait
+		case <-peerGroup.leaderNotify:
+			pc.logger.Debug().Msg("received a response from the leader")
+			break wait
+		case <-timeout.C:
+			pc.logger.Debug().Msgf("timed out waiting for a response from the leader after %s", pc.timeout)
+			break wait
+		case result := <-sigChan:
+			pc.logger.Debug().Msgf("received %s from sigChan", result)
+			sigNotify = result
+			break wait
+		case <-reachedCh:
+			// the leader has our request, we wait for its response until the deadline
+			reachedCh = nil
+			failover = nil
+		case <-failover:
+			failover = nil
+			// the leader may have answered in the meantime, then we take its response
+			if peerGroup.dropLeader() {
+				unreachable = true
+				break wait
+			}
+		}
 	}
 
 	close(done)
 	wg.Wait()
 
+	if unreachable {
+		return nil, errLeaderUnreachable
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
 	if peerGroup.getLeaderResponse() == nil {
 		leaderPk, err := conversion.GetPubKeyFromPeerID(leaderID.String())
 		if err != nil {
@@ -369,6 +532,7 @@ func (pc *PartyCoordinator) joinPartyMember(msgID string, peerGroup *peerStatus,
 
 	pc.logger.Trace().Msgf("leader response message type=%s", peerGroup.getLeaderResponse().Type.String())
 	if peerGroup.getLeaderResponse().Type == messages.JoinPartyLeaderComm_Success {
+		pc.setSessionNonce(msgID, peerGroup.getLeaderResponse().SessionNonce)
 		return pIDs, nil
 	}
 
@@ -380,17 +544,22 @@ func (pc *PartyCoordinator) joinPartyMember(msgID string, peerGroup *peerStatus,
 	return pIDs, ErrJoinPartyTimeout
 }
 
-func (pc *PartyCoordinator) joinPartyLeader(msgID string, peerGroup *peerStatus, sigChan chan string) ([]peer.ID, error) {
+// joinPartyLeader wait for the requests of the members, and tell them who runs the ceremony once we have enough
+// of them or the given time is up
+func (pc *PartyCoordinator) joinPartyLeader(ctx context.Context, msgID string, peerGroup *peerStatus, sigChan chan string, wait time.Duration) ([]peer.ID, error) {
 	var sigNotify string
+	timeout := time.After(wait)
 	select {
 	case <-pc.stopChan:
 		// promptly tear down this goroutine if partyCoordinator is stopped
 		pc.logger.Debug().Msg("leader's party coordinator stopped")
+	case <-ctx.Done():
 	case <-peerGroup.notify:
 		pc.logger.Debug().Msg("we have enough participants")
-	case <-time.After(pc.timeout / 2):
+		sigNotify = pc.waitForMoreSigners(ctx, peerGroup, sigChan, timeout)
+	case <-timeout:
 		// timeout, reporting to peers before their timeout
-		pc.logger.Debug().Msgf("leader timedout waiting for peers after %s", pc.timeout/2)
+		pc.logger.Debug().Msgf("leader timedout waiting for peers after %s", wait)
 	case result := <-sigChan:
 		sigNotify = result
 	}
@@ -398,7 +567,13 @@ func (pc *PartyCoordinator) joinPartyLeader(msgID string, peerGroup *peerStatus,
 		return nil, ErrSignReceived
 	}
 	allPeers := peerGroup.getAllPeers()
-	onlinePeers, _ := peerGroup.getPeersStatus()
+	if ctx.Err() != nil {
+		// the members do not wait for us until they time out
+		pc.logger.Debug().Str("msgID", msgID).Msg("join party cancelled, sending timeout response to all peers")
+		pc.sendResponseToAll(&messages.JoinPartyLeaderComm{ID: msgID, Type: messages.JoinPartyLeaderComm_Timeout}, allPeers)
+		return nil, ctx.Err()
+	}
+	onlinePeers := pc.selectSigners(msgID, peerGroup)
 	onlinePeers = append(onlinePeers, pc.host.ID())
 
 	tssNodes := make([]string, len(onlinePeers))
@@ -419,6 +594,13 @@ func (pc *PartyCoordinator) joinPartyLeader(msgID string, peerGroup *peerStatus,
 		pc.sendResponseToAll(&msg, allPeers)
 		return onlinePeers, ErrJoinPartyTimeout
 	}
+	// the nonce tells the messages of this session from the ones of a previous ceremony with the same msg id
+	nonce := make([]byte, SessionNonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return onlinePeers, fmt.Errorf("fail to generate the session nonce: %w", err)
+	}
+	msg.SessionNonce = nonce
+	pc.setSessionNonce(msgID, nonce)
 	// we notify all the peers who to run keygen/keysign
 	// if a nodes is not in the list, it means he is not selected by the leader to run the tss
 	pc.logger.Debug().Msgf("sending success response to %d all peers", len(allPeers))
@@ -426,38 +608,142 @@ func (pc *PartyCoordinator) joinPartyLeader(msgID string, peerGroup *peerStatus,
 	return onlinePeers, nil
 }
 
+// waitForMoreSigners give the peers that are not ready yet the selection window to join, so the signer policy has
+// a choice, it returns early once all the peers are ready
+func (pc *PartyCoordinator) waitForMoreSigners(ctx context.Context, peerGroup *peerStatus, sigChan chan string, timeout <-chan time.Time) string {
+	if _, ok := pc.signerPolicy.(arrivalOrderPolicy); ok {
+		return ""
+	}
+	window := time.NewTimer(pc.conf.SignerSelectionWindow)
+	defer window.Stop()
+	select {
+	case <-pc.stopChan:
+	case <-ctx.Done():
+	case <-peerGroup.allReady:
+	case <-window.C:
+	case <-timeout:
+	case result := <-sigChan:
+		return result
+	}
+	return ""
+}
+
+// selectSigners return the ready peers that run the ceremony with us, the signer policy picks them once more
+// than threshold of them are ready
+func (pc *PartyCoordinator) selectSigners(msgID string, peerGroup *peerStatus) []peer.ID {
+	ready := peerGroup.readyPeers()
+	if len(ready) <= peerGroup.threshold {
+		return ready
+	}
+	selected := pc.signerPolicy.Select(ready, peerGroup.threshold)
+	pc.logger.Debug().Str("msgID", msgID).Msgf("picked %d signers out of the %d ready peers: %v", len(selected), len(ready), selected)
+	return selected
+}
+
+// JoinPartyWithLeader form the party of the ceremony with the leader LeaderNode picks, if a member cannot reach the
+// leader within the failover timeout it moves to the next leader of the succession LeaderNodes ranks, and leads
+// the party itself once it is its turn. It returns the leader that formed the party, or the last one we waited for
+// if none did. The leaders that never answered us are attached to the error with ErrUnreachableLeaders.
 func (pc *PartyCoordinator) JoinPartyWithLeader(msgID string, blockHeight int64, peers []string, threshold int, sigChan chan string) ([]peer.ID, string, error) {
-	leader, err := LeaderNode(msgID, blockHeight, peers)
+	return pc.JoinPartyWithLeaderCtx(context.Background(), msgID, blockHeight, peers, threshold, sigChan)
+}
+
+// JoinPartyWithLeaderCtx is JoinPartyWithLeader that gives up with the error of the context once it is done, a
+// leader tells the members the party failed before it gives up
+func (pc *PartyCoordinator) JoinPartyWithLeaderCtx(ctx context.Context, msgID string, blockHeight int64, peers []string, threshold int, sigChan chan string) ([]peer.ID, string, error) {
+	return pc.JoinPartyWithLeaderOpts(ctx, msgID, blockHeight, peers, threshold, sigChan, JoinPartyOptions{})
+}
+
+// JoinPartyOptions tune how the party of a ceremony is formed, all the members must be given the same options to
+// agree on the party
+type JoinPartyOptions struct {
+	// LeaderWeights is the weight of the peer ids to lead the party, the succession of leaders is the one
+	// WeightedLeaderNodes ranks
+	LeaderWeights map[string]uint64
+	// SignerConstraint restrict the peers the leader may pick, only a permitted peer leads as the leader always
+	// signs, and the members fail with ErrSignerConstraintViolated if the leader picks a peer it does not permit
+	SignerConstraint SignerConstraint
+}
+
+// JoinPartyWithLeaderOpts is JoinPartyWithLeaderCtx with the given options
+func (pc *PartyCoordinator) JoinPartyWithLeaderOpts(ctx context.Context, msgID string, blockHeight int64, peers []string, threshold int, sigChan chan string, opts JoinPartyOptions) ([]peer.ID, string, error) {
+	leaders, err := WeightedLeaderNodes(msgID, blockHeight, peers, opts.LeaderWeights)
 	if err != nil {
 		return nil, "", err
 	}
-	leaderID, err := peer.Decode(leader)
+	leaderIDs, err := pc.getPeerIDs(leaders)
 	if err != nil {
 		return nil, "", err
 	}
+	if !opts.SignerConstraint.IsEmpty() {
+		leaderIDs = opts.SignerConstraint.permitted(leaderIDs)
+		if len(leaderIDs) == 0 {
+			return nil, "", errors.New("the signer constraint permits none of the peers")
+		}
+		leaders = conversion.PeerIDsToStrings(leaderIDs)
+	}
 	peerIDs, err := pc.getPeerIDs(peers)
 	if err != nil {
 		return nil, "", err
 	}
 
-	peerGroup, err := pc.createJoinPartyGroups(msgID, leaderID, peerIDs, threshold)
+	pc.warnUnreachable(msgID, leaderIDs[0], peerIDs)
+	pc.eventBus.Publish(monitor.NewEvent(msgID, monitor.EventJoinPartyStart, "", conversion.PeerIDsToStrings(peerIDs), ""))
+
+	peerGroup, err := pc.createJoinPartyGroups(msgID, leaderIDs[0], peerIDs, threshold, opts.SignerConstraint)
 	if err != nil {
 		pc.logger.Error().Err(err).Msg("error creating peerStatus")
-		return nil, leader, err
+		return nil, leaders[0], err
 	}
 	defer pc.removePeerGroup(msgID)
 
-	if pc.host.ID() == leaderID {
-		onlines, err := pc.joinPartyLeader(msgID, peerGroup, sigChan)
-		return onlines, leader, err
+	deadline := time.Now().Add(pc.timeout)
+	var unreachable []peer.ID
+	leader := leaders[0]
+	for _, leaderID := range leaderIDs {
+		if time.Now().After(deadline) {
+			break
+		}
+		leader = leaderID.String()
+		peerGroup.setLeader(leaderID)
+		pc.eventBus.Publish(monitor.NewEvent(msgID, monitor.EventLeader, "", []string{leader}, ""))
+		if pc.host.ID() == leaderID {
+			// we leave the members half of the remaining time to get our response before they time out
+			onlines, err := pc.joinPartyLeader(ctx, msgID, peerGroup, sigChan, time.Until(deadline)/2)
+			pc.publishFormed(msgID, leader, onlines, err)
+			return onlines, leader, withUnreachableLeaders(unreachable, "", err)
+		}
+		// now we are just the normal peer
+		onlines, err := pc.joinPartyMember(ctx, msgID, peerGroup, sigChan, deadline)
+		switch {
+		case ctx.Err() != nil:
+			return nil, leader, ctx.Err()
+		case errors.Is(err, errLeaderUnreachable):
+			pc.logger.Warn().Str("msgID", msgID).Msgf("fail to reach the leader(%s), move to the next one", leaderID)
+			unreachable = append(unreachable, leaderID)
+			continue
+		case errors.Is(err, ErrLeaderNotReady):
+			// the leader has our request, it is online but never formed the party
+			pc.publishFormed(msgID, leader, onlines, err)
+			return onlines, leader, withUnreachableLeaders(unreachable, leaderID, err)
+		case err == nil && len(opts.SignerConstraint.Violations(onlines)) != 0:
+			violations := opts.SignerConstraint.Violations(onlines)
+			pc.logger.Error().Str("msgID", msgID).Msgf("the leader(%s) picked the signers %v the signer constraint excludes", leaderID, violations)
+			err = fmt.Errorf("%w: %v", ErrSignerConstraintViolated, violations)
+		}
+		pc.publishFormed(msgID, leader, onlines, err)
+		return onlines, leader, withUnreachableLeaders(unreachable, "", err)
 	}
-	// now we are just the normal peer
-	onlines, err := pc.joinPartyMember(msgID, peerGroup, sigChan)
-	return onlines, leader, err
+	return nil, leader, withUnreachableLeaders(unreachable, "", ErrLeaderNotReady)
 }
 
 // JoinPartyWithRetry this method provide the functionality to join party with retry and back off
 func (pc *PartyCoordinator) JoinPartyWithRetry(msgID string, peers []string) ([]peer.ID, error) {
+	return pc.JoinPartyWithRetryCtx(context.Background(), msgID, peers)
+}
+
+// JoinPartyWithRetryCtx is JoinPartyWithRetry that gives up with the error of the context once it is done
+func (pc *PartyCoordinator) JoinPartyWithRetryCtx(ctx context.Context, msgID string, peers []string) ([]peer.ID, error) {
 	msg := messages.JoinPartyRequest{
 		ID: msgID,
 	}
@@ -472,12 +758,13 @@ func (pc *PartyCoordinator) JoinPartyWithRetry(msgID string, peers []string) ([]
 		return nil, err
 	}
 
-	peerGroup, err := pc.createJoinPartyGroups(msg.ID, "NONE", peerIDs, 1)
+	peerGroup, err := pc.createJoinPartyGroups(msg.ID, "NONE", peerIDs, 1, SignerConstraint{})
 	if err != nil {
 		pc.logger.Error().Err(err).Msg("fail to create the join party group")
 		return nil, err
 	}
 	defer pc.removePeerGroup(msg.ID)
+	pc.eventBus.Publish(monitor.NewEvent(msgID, monitor.EventJoinPartyStart, "", conversion.PeerIDsToStrings(peerIDs), ""))
 	_, offline := peerGroup.getPeersStatus()
 	var wg sync.WaitGroup
 	done := make(chan struct{})
@@ -503,6 +790,10 @@ func (pc *PartyCoordinator) JoinPartyWithRetry(msgID string, peers []string) ([]
 			case <-pc.stopChan:
 				// promptly tear down this goroutine if partyCoordinator is stopped
 				pc.logger.Trace().Msg("party coordinator stopped")
+				close(done)
+				return
+			case <-ctx.Done():
+				close(done)
 				return
 			case <-peerGroup.notify:
 				pc.logger.Debug().Msg("we have found the new peer")
@@ -519,11 +810,15 @@ func (pc *PartyCoordinator) JoinPartyWithRetry(msgID string, peers []string) ([]
 	}()
 
 	wg.Wait()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
 	onlinePeers, _ := peerGroup.getPeersStatus()
 	pc.sendRequestToAll(msgID, msgSend, onlinePeers)
 	// we always set ourselves as online
 	onlinePeers = append(onlinePeers, pc.host.ID())
 	if len(onlinePeers) == len(peers) {
+		pc.publishFormed(msgID, "NONE", onlinePeers, nil)
 		return onlinePeers, nil
 	}
 	return onlinePeers, ErrJoinPartyTimeout
@@ -531,4 +826,21 @@ func (pc *PartyCoordinator) JoinPartyWithRetry(msgID string, peers []string) ([]
 
 func (pc *PartyCoordinator) ReleaseStream(msgID string) {
 	pc.streamMgr.ReleaseStream(msgID)
+	pc.sessionNoncesLock.Lock()
+	defer pc.sessionNoncesLock.Unlock()
+	delete(pc.sessionNonces, msgID)
+}
+
+func (pc *PartyCoordinator) setSessionNonce(msgID string, nonce []byte) {
+	pc.sessionNoncesLock.Lock()
+	defer pc.sessionNoncesLock.Unlock()
+	pc.sessionNonces[msgID] = nonce
+}
+
+// SessionNonce return the nonce the leader picked for the session of the given ceremony, it is empty if the
+// party was formed without a leader, or by a leader that predates the session nonce
+func (pc *PartyCoordinator) SessionNonce(msgID string) []byte {
+	pc.sessionNoncesLock.Lock()
+	defer pc.sessionNoncesLock.Unlock()
+	return pc.sessionNonces[msgID]
 }
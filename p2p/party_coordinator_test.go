@@ -1,6 +1,7 @@
 package p2p
 
 import (
+	"context"
 	"math/rand"
 	"sort"
 	"sync"
@@ -46,7 +47,7 @@ func TestPartyCoordinator(t *testing.T) {
 
 	timeout := time.Second * 10
 	for _, el := range hosts {
-		pcs = append(pcs, *NewPartyCoordinator(el, timeout))
+		pcs = append(pcs, *NewPartyCoordinator(el, timeout, DefaultNetworkConfig()))
 		peers = append(peers, el.ID().String())
 	}
 
@@ -85,7 +86,7 @@ func TestPartyCoordinatorTimeOut(t *testing.T) {
 	var pcs []*PartyCoordinator
 	var peers []string
 	for _, el := range hosts {
-		pcs = append(pcs, NewPartyCoordinator(el, timeout))
+		pcs = append(pcs, NewPartyCoordinator(el, timeout, DefaultNetworkConfig()))
 	}
 	sort.Slice(pcs, func(i, j int) bool {
 		return pcs[i].host.ID().String() > pcs[j].host.ID().String()
@@ -122,3 +123,26 @@ func TestPartyCoordinatorTimeOut(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestPartyCoordinatorCancelled(t *testing.T) {
+	hosts := setupHosts(t, 4)
+	var pcs []*PartyCoordinator
+	var peers []string
+	for _, el := range hosts {
+		pcs = append(pcs, NewPartyCoordinator(el, time.Second*6, DefaultNetworkConfig()))
+		peers = append(peers, el.ID().String())
+	}
+	defer func() {
+		for _, el := range pcs {
+			el.Stop()
+		}
+	}()
+
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*500)
+	defer cancel()
+	onlinePeers, err := pcs[0].JoinPartyWithRetryCtx(ctx, conversion.RandStringBytesMask(64), peers)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Nil(t, onlinePeers)
+	assert.Less(t, time.Since(start), time.Second*3)
+}
@@ -1,6 +1,7 @@
 package p2p
 
 import (
+	"context"
 	"math/rand"
 	"sort"
 	"sync"
@@ -9,10 +10,12 @@ import (
 
 	tnet "github.com/libp2p/go-libp2p-testing/net"
 	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
 	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/ordinox/thorchain-tss/conversion"
+	"github.com/ordinox/thorchain-tss/monitor"
 )
 
 func init() {
@@ -107,7 +110,7 @@ func TestNewPartyCoordinator(t *testing.T) {
 
 	timeout := time.Second * 4
 	for _, el := range hosts {
-		pcs = append(pcs, NewPartyCoordinator(el, timeout))
+		pcs = append(pcs, NewPartyCoordinator(el, timeout, DefaultNetworkConfig()))
 		peers = append(peers, el.ID().String())
 	}
 
@@ -134,9 +137,39 @@ func TestNewPartyCoordinator(t *testing.T) {
 		}
 	}
 	assert.Equal(t, pcs[0].host.ID().String(), leader)
+	bus := monitor.NewEventBus()
+	pcs[1].SetEventBus(bus)
+	events, cancelEvents := bus.Subscribe(msgID)
+	defer cancelEvents()
 	// now we test the leader appears firstly and the the members
 	leaderAppersFirstTest(t, msgID, peers, pcs)
+	// the member published how the party was formed
+	var eventTypes []monitor.EventType
+	for len(events) > 0 {
+		event := <-events
+		eventTypes = append(eventTypes, event.Type)
+		switch event.Type {
+		case monitor.EventLeader:
+			assert.Equal(t, []string{leader}, event.Peers)
+		case monitor.EventJoinPartyFormed:
+			assert.Len(t, event.Peers, 4)
+			assert.Equal(t, leader, event.Detail)
+		}
+	}
+	assert.Equal(t, []monitor.EventType{monitor.EventJoinPartyStart, monitor.EventLeader, monitor.EventJoinPartyFormed}, eventTypes)
+	pcs[1].SetEventBus(nil)
+	// every member got the session nonce of the leader
+	nonce := pcs[0].SessionNonce(msgID)
+	assert.Len(t, nonce, SessionNonceSize)
+	for _, el := range pcs[1:] {
+		assert.Equal(t, nonce, el.SessionNonce(msgID))
+	}
 	leaderAppearsLastTest(t, msgID, peers, pcs)
+	// the new session has a new nonce, released with the streams
+	assert.NotEqual(t, nonce, pcs[0].SessionNonce(msgID))
+	assert.Equal(t, pcs[0].SessionNonce(msgID), pcs[1].SessionNonce(msgID))
+	pcs[0].ReleaseStream(msgID)
+	assert.Empty(t, pcs[0].SessionNonce(msgID))
 }
 
 func TestNewPartyCoordinatorTimeOut(t *testing.T) {
@@ -145,7 +178,7 @@ func TestNewPartyCoordinatorTimeOut(t *testing.T) {
 	var pcs []*PartyCoordinator
 	var peers []string
 	for _, el := range hosts {
-		pcs = append(pcs, NewPartyCoordinator(el, timeout))
+		pcs = append(pcs, NewPartyCoordinator(el, timeout, DefaultNetworkConfig()))
 	}
 	sort.Slice(pcs, func(i, j int) bool {
 		return pcs[i].host.ID().String() > pcs[j].host.ID().String()
@@ -186,7 +219,12 @@ func TestNewPartyCoordinatorTimeOut(t *testing.T) {
 			defer wg.Done()
 			sigChan := make(chan string)
 			_, _, err := coordinator.JoinPartyWithLeader(msgID, 10, peers, 3, sigChan)
-			assert.Equal(t, err, ErrLeaderNotReady)
+			assert.ErrorIs(t, err, ErrLeaderNotReady)
+			// the leader is online but never answers, so we do not move to the next one and it is not unreachable
+			var unreachable ErrUnreachableLeaders
+			assert.ErrorAs(t, err, &unreachable)
+			assert.Empty(t, unreachable.Leaders)
+			assert.Equal(t, pcs[0].host.ID(), unreachable.NotReady)
 		}(el)
 
 	}
@@ -214,6 +252,271 @@ func TestNewPartyCoordinatorTimeOut(t *testing.T) {
 	wg.Wait()
 }
 
+// joinPartyWithoutLeader run the join party of the given threshold among 4 nodes, the first leader of the succession
+// is stopped so it cannot be reached
+func joinPartyWithoutLeader(t *testing.T, threshold int, check func(onlinePeers []peer.ID, leader string, err error)) []string {
+	hosts := setupHosts(t, 4)
+	pcs := make(map[string]*PartyCoordinator)
+	var peers []string
+	for _, el := range hosts {
+		pcs[el.ID().String()] = NewPartyCoordinator(el, time.Second*6, NetworkConfig{LeaderFailoverTimeout: time.Second})
+		peers = append(peers, el.ID().String())
+	}
+	msgID := conversion.RandStringBytesMask(64)
+	leaders, err := LeaderNodes(msgID, 10, peers)
+	assert.Nil(t, err)
+	pcs[leaders[0]].Stop()
+	defer func() {
+		for _, el := range leaders[1:] {
+			pcs[el].Stop()
+		}
+	}()
+
+	wg := sync.WaitGroup{}
+	for _, el := range leaders[1:] {
+		wg.Add(1)
+		go func(coordinator *PartyCoordinator) {
+			defer wg.Done()
+			onlinePeers, leader, err := coordinator.JoinPartyWithLeader(msgID, 10, peers, threshold, make(chan string))
+			check(onlinePeers, leader, err)
+		}(pcs[el])
+	}
+	wg.Wait()
+	return leaders
+}
+
+func TestJoinPartyLeaderFailover(t *testing.T) {
+	// the second leader forms the party in place of the first one
+	var leaders []string
+	var formedBy []string
+	lock := &sync.Mutex{}
+	leaders = joinPartyWithoutLeader(t, 2, func(onlinePeers []peer.ID, leader string, err error) {
+		assert.Nil(t, err)
+		assert.Len(t, onlinePeers, 3)
+		lock.Lock()
+		defer lock.Unlock()
+		formedBy = append(formedBy, leader)
+	})
+	assert.Equal(t, []string{leaders[1], leaders[1], leaders[1]}, formedBy)
+
+	// the second leader answers the party cannot be formed, only the first one is blamed
+	formedBy = nil
+	var blamed [][]peer.ID
+	leaders = joinPartyWithoutLeader(t, 3, func(onlinePeers []peer.ID, leader string, err error) {
+		assert.ErrorIs(t, err, ErrJoinPartyTimeout)
+		var unreachable ErrUnreachableLeaders
+		assert.ErrorAs(t, err, &unreachable)
+		lock.Lock()
+		defer lock.Unlock()
+		formedBy = append(formedBy, leader)
+		blamed = append(blamed, unreachable.Leaders)
+		assert.Empty(t, unreachable.NotReady)
+	})
+	assert.Equal(t, []string{leaders[1], leaders[1], leaders[1]}, formedBy)
+	leaderID, err := peer.Decode(leaders[0])
+	assert.Nil(t, err)
+	assert.Equal(t, [][]peer.ID{{leaderID}, {leaderID}, {leaderID}}, blamed)
+}
+
+func TestJoinPartyWithWeightedLeader(t *testing.T) {
+	hosts := setupHosts(t, 4)
+	pcs := make(map[string]*PartyCoordinator)
+	var peers []string
+	for _, el := range hosts {
+		pcs[el.ID().String()] = NewPartyCoordinator(el, time.Second*6, DefaultNetworkConfig())
+		peers = append(peers, el.ID().String())
+	}
+	defer func() {
+		for _, el := range pcs {
+			el.Stop()
+		}
+	}()
+	msgID := conversion.RandStringBytesMask(64)
+	leaders, err := LeaderNodes(msgID, 10, peers)
+	assert.Nil(t, err)
+	// the only weighted node leads in place of the one the hash picks
+	weights := map[string]uint64{leaders[3]: 1}
+	var formedBy []string
+	lock := &sync.Mutex{}
+	wg := sync.WaitGroup{}
+	for _, el := range pcs {
+		wg.Add(1)
+		go func(coordinator *PartyCoordinator) {
+			defer wg.Done()
+			onlinePeers, leader, err := coordinator.JoinPartyWithLeaderOpts(context.Background(), msgID, 10, peers, 3, make(chan string), JoinPartyOptions{LeaderWeights: weights})
+			assert.Nil(t, err)
+			assert.Len(t, onlinePeers, 4)
+			lock.Lock()
+			defer lock.Unlock()
+			formedBy = append(formedBy, leader)
+		}(el)
+	}
+	wg.Wait()
+	assert.Equal(t, []string{leaders[3], leaders[3], leaders[3], leaders[3]}, formedBy)
+}
+
+func TestJoinPartyWithSignerConstraint(t *testing.T) {
+	hosts := setupHosts(t, 5)
+	pcs := make(map[string]*PartyCoordinator)
+	var peers []string
+	for _, el := range hosts {
+		pcs[el.ID().String()] = NewPartyCoordinator(el, time.Second*6, DefaultNetworkConfig())
+		peers = append(peers, el.ID().String())
+	}
+	defer func() {
+		for _, el := range pcs {
+			el.Stop()
+		}
+	}()
+	msgID := conversion.RandStringBytesMask(64)
+	leaders, err := LeaderNodes(msgID, 10, peers)
+	assert.Nil(t, err)
+	leaderIDs, err := pcs[leaders[0]].getPeerIDs(leaders)
+	assert.Nil(t, err)
+
+	// the denied node neither leads nor signs, the others leave it out of the party
+	opts := JoinPartyOptions{SignerConstraint: SignerConstraint{Denied: leaderIDs[:1]}}
+	lock := &sync.Mutex{}
+	formedBy := make(map[string]string)
+	wg := sync.WaitGroup{}
+	for id, el := range pcs {
+		wg.Add(1)
+		go func(id string, coordinator *PartyCoordinator) {
+			defer wg.Done()
+			onlinePeers, leader, err := coordinator.JoinPartyWithLeaderOpts(context.Background(), msgID, 10, peers, 2, make(chan string), opts)
+			assert.Nil(t, err)
+			assert.NotContains(t, onlinePeers, leaderIDs[0])
+			lock.Lock()
+			defer lock.Unlock()
+			formedBy[id] = leader
+		}(id, el)
+	}
+	wg.Wait()
+	for _, el := range leaders {
+		assert.Equal(t, leaders[1], formedBy[el])
+	}
+
+	// the members fail the party of a leader that ignores the constraint
+	msgID = conversion.RandStringBytesMask(64)
+	leaders, err = LeaderNodes(msgID, 10, peers)
+	assert.Nil(t, err)
+	leaderIDs, err = pcs[leaders[0]].getPeerIDs(leaders)
+	assert.Nil(t, err)
+	opts = JoinPartyOptions{SignerConstraint: SignerConstraint{Denied: leaderIDs[4:]}}
+	for _, el := range leaders[1:] {
+		wg.Add(1)
+		go func(coordinator *PartyCoordinator) {
+			defer wg.Done()
+			_, leader, err := coordinator.JoinPartyWithLeaderOpts(context.Background(), msgID, 10, peers, 4, make(chan string), opts)
+			assert.ErrorIs(t, err, ErrSignerConstraintViolated)
+			assert.Equal(t, leaders[0], leader)
+		}(pcs[el])
+	}
+	onlinePeers, _, err := pcs[leaders[0]].JoinPartyWithLeader(msgID, 10, peers, 4, make(chan string))
+	assert.Nil(t, err)
+	assert.Contains(t, onlinePeers, leaderIDs[4])
+	wg.Wait()
+}
+
+func TestJoinPartyLeaderSignerPolicy(t *testing.T) {
+	hosts := setupHosts(t, 5)
+	pcs := make(map[string]*PartyCoordinator)
+	var peers []string
+	for _, el := range hosts {
+		pcs[el.ID().String()] = NewPartyCoordinator(el, time.Second*6, NetworkConfig{SignerSelectionWindow: time.Second * 2})
+		peers = append(peers, el.ID().String())
+	}
+	defer func() {
+		for _, el := range pcs {
+			el.Stop()
+		}
+	}()
+	msgID := conversion.RandStringBytesMask(64)
+	leaders, err := LeaderNodes(msgID, 10, peers)
+	assert.Nil(t, err)
+	leaderIDs, err := pcs[leaders[0]].getPeerIDs(leaders)
+	assert.Nil(t, err)
+	// the leader prefers the members that come last
+	pcs[leaders[0]].SetSignerPolicy(NewPreferencePolicy(leaderIDs[3:], ArrivalOrderPolicy()))
+
+	var results [][]peer.ID
+	lock := &sync.Mutex{}
+	wg := sync.WaitGroup{}
+	join := func(coordinator *PartyCoordinator) {
+		defer wg.Done()
+		onlinePeers, _, err := coordinator.JoinPartyWithLeader(msgID, 10, peers, 2, make(chan string))
+		assert.Nil(t, err)
+		sortPeers(onlinePeers)
+		lock.Lock()
+		defer lock.Unlock()
+		results = append(results, onlinePeers)
+	}
+	for _, el := range leaders[:3] {
+		wg.Add(1)
+		go join(pcs[el])
+	}
+	time.Sleep(time.Millisecond * 300)
+	for _, el := range leaders[3:] {
+		wg.Add(1)
+		go join(pcs[el])
+	}
+	wg.Wait()
+
+	expected := []peer.ID{leaderIDs[0], leaderIDs[3], leaderIDs[4]}
+	sortPeers(expected)
+	assert.Len(t, results, 5)
+	for _, el := range results {
+		assert.Equal(t, expected, el)
+	}
+}
+
+func TestJoinPartyWithLeaderCancelled(t *testing.T) {
+	hosts := setupHosts(t, 4)
+	pcs := make(map[string]*PartyCoordinator)
+	var peers []string
+	for _, el := range hosts {
+		pcs[el.ID().String()] = NewPartyCoordinator(el, time.Second*6, DefaultNetworkConfig())
+		peers = append(peers, el.ID().String())
+	}
+	defer func() {
+		for _, el := range pcs {
+			el.Stop()
+		}
+	}()
+	msgID := conversion.RandStringBytesMask(64)
+	leaders, err := LeaderNodes(msgID, 10, peers)
+	assert.Nil(t, err)
+
+	// the leader gives up and tells the members, they do not wait until they time out
+	start := time.Now()
+	wg := sync.WaitGroup{}
+	for _, el := range leaders[1:3] {
+		wg.Add(1)
+		go func(coordinator *PartyCoordinator) {
+			defer wg.Done()
+			_, _, err := coordinator.JoinPartyWithLeader(msgID, 10, peers, 3, make(chan string))
+			assert.NotNil(t, err)
+		}(pcs[el])
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*500)
+	defer cancel()
+	_, _, err = pcs[leaders[0]].JoinPartyWithLeaderCtx(ctx, msgID, 10, peers, 3, make(chan string))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	wg.Wait()
+	assert.Less(t, time.Since(start), time.Second*3)
+
+	// a member gives up while it waits for the leader
+	msgID = conversion.RandStringBytesMask(64)
+	leaders, err = LeaderNodes(msgID, 10, peers)
+	assert.Nil(t, err)
+	start = time.Now()
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond*500)
+	defer cancel()
+	_, _, err = pcs[leaders[1]].JoinPartyWithLeaderCtx(ctx, msgID, 10, peers, 3, make(chan string))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second*3)
+}
+
 func TestGetPeerIDs(t *testing.T) {
 	id1 := tnet.RandIdentityOrFatal(t)
 	mn := mocknet.New()
@@ -226,7 +529,7 @@ func TestGetPeerIDs(t *testing.T) {
 	}
 	p1 := h1.ID()
 	timeout := time.Second * 2
-	pc := NewPartyCoordinator(h1, timeout)
+	pc := NewPartyCoordinator(h1, timeout, DefaultNetworkConfig())
 	r, err := pc.getPeerIDs([]string{})
 	assert.Nil(t, err)
 	assert.Len(t, r, 0)
@@ -15,10 +15,16 @@ type peerStatus struct {
 	peerStatusLock *sync.RWMutex
 	allPeers       []peer.ID
 	notify         chan bool
+	leaderNotify   chan bool
 	leaderResponse *messages.JoinPartyLeaderComm
 	leader         peer.ID
 	threshold      int
 	reqCount       int
+	// ready are the peers that asked the leader to join, in the order their requests arrived
+	ready    []peer.ID
+	allReady chan struct{}
+	// excluded are the peers that may not sign, the leader does not count them as ready
+	excluded map[peer.ID]bool
 }
 
 func (ps *peerStatus) getLeaderResponse() *messages.JoinPartyLeaderComm {
@@ -27,10 +33,16 @@ func (ps *peerStatus) getLeaderResponse() *messages.JoinPartyLeaderComm {
 	return ps.leaderResponse
 }
 
-func (ps *peerStatus) setLeaderResponse(resp *messages.JoinPartyLeaderComm) {
+// setLeaderResponse record the response if it comes from the current leader, and notify the member waiting for it
+func (ps *peerStatus) setLeaderResponse(from peer.ID, resp *messages.JoinPartyLeaderComm) bool {
 	ps.peerStatusLock.Lock()
 	defer ps.peerStatusLock.Unlock()
+	if from != ps.leader || ps.leaderResponse != nil {
+		return false
+	}
 	ps.leaderResponse = resp
+	ps.leaderNotify <- true
+	return true
 }
 
 func (ps *peerStatus) getLeader() peer.ID {
@@ -39,6 +51,24 @@ func (ps *peerStatus) getLeader() peer.ID {
 	return ps.leader
 }
 
+// setLeader make the given node the leader we wait for
+func (ps *peerStatus) setLeader(leader peer.ID) {
+	ps.peerStatusLock.Lock()
+	defer ps.peerStatusLock.Unlock()
+	ps.leader = leader
+}
+
+// dropLeader stop accepting the response of the current leader, it fails if the leader has already answered
+func (ps *peerStatus) dropLeader() bool {
+	ps.peerStatusLock.Lock()
+	defer ps.peerStatusLock.Unlock()
+	if ps.leaderResponse != nil {
+		return false
+	}
+	ps.leader = ""
+	return true
+}
+
 func newPeerStatus(peerNodes []peer.ID, myPeerID, leaderID peer.ID, threshold int) *peerStatus {
 	dat := make(map[peer.ID]bool)
 	for _, el := range peerNodes {
@@ -51,14 +81,30 @@ func newPeerStatus(peerNodes []peer.ID, myPeerID, leaderID peer.ID, threshold in
 		peersResponse:  dat,
 		peerStatusLock: &sync.RWMutex{},
 		notify:         make(chan bool, len(peerNodes)),
+		leaderNotify:   make(chan bool, 1),
 		allPeers:       peerNodes,
 		leader:         leaderID,
 		threshold:      threshold,
 		reqCount:       0,
+		allReady:       make(chan struct{}),
+		excluded:       make(map[peer.ID]bool),
 	}
 	return peerStatus
 }
 
+// exclude stop the peers the constraint does not permit from counting as ready, they still get the response of
+// the leader to learn they do not sign
+func (ps *peerStatus) exclude(constraint SignerConstraint) {
+	ps.peerStatusLock.Lock()
+	defer ps.peerStatusLock.Unlock()
+	for peerNode := range ps.peersResponse {
+		if !constraint.Permits(peerNode) {
+			delete(ps.peersResponse, peerNode)
+			ps.excluded[peerNode] = true
+		}
+	}
+}
+
 func (ps *peerStatus) getCoordinationStatus() bool {
 	_, offline := ps.getPeersStatus()
 	return len(offline) == 0
@@ -89,6 +135,9 @@ func (ps *peerStatus) getPeersStatus() ([]peer.ID, []peer.ID) {
 func (ps *peerStatus) updatePeer(peerNode peer.ID) (bool, error) {
 	ps.peerStatusLock.Lock()
 	defer ps.peerStatusLock.Unlock()
+	if ps.excluded[peerNode] {
+		return false, nil
+	}
 	val, ok := ps.peersResponse[peerNode]
 	if !ok {
 		return false, errors.New("key not found")
@@ -102,17 +151,26 @@ func (ps *peerStatus) updatePeer(peerNode peer.ID) (bool, error) {
 		return false, nil
 	}
 
-	// we already have enough participants
-	if ps.reqCount >= ps.threshold {
+	if val {
 		return false, nil
 	}
-	if !val {
-		ps.peersResponse[peerNode] = true
-		ps.reqCount++
-		log.Debug().Msgf("leader has %d out of %d participants", ps.reqCount, ps.threshold)
-		if ps.reqCount >= ps.threshold {
-			return true, nil
-		}
+	// we keep the peers that come after we have enough participants, so the leader can pick the signers
+	ps.peersResponse[peerNode] = true
+	ps.ready = append(ps.ready, peerNode)
+	if len(ps.ready) == len(ps.peersResponse) {
+		close(ps.allReady)
 	}
-	return false, nil
+	if ps.reqCount >= ps.threshold {
+		return false, nil
+	}
+	ps.reqCount++
+	log.Debug().Msgf("leader has %d out of %d participants", ps.reqCount, ps.threshold)
+	return ps.reqCount >= ps.threshold, nil
+}
+
+// readyPeers return the peers that asked the leader to join, in the order their requests arrived
+func (ps *peerStatus) readyPeers() []peer.ID {
+	ps.peerStatusLock.RLock()
+	defer ps.peerStatusLock.RUnlock()
+	return append([]peer.ID{}, ps.ready...)
 }
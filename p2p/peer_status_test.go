@@ -7,6 +7,8 @@ import (
 	tnet "github.com/libp2p/go-libp2p-testing/net"
 	"github.com/libp2p/go-libp2p/core/peer"
 	. "gopkg.in/check.v1"
+
+	"github.com/ordinox/thorchain-tss/messages"
 )
 
 // Hook up gocheck into the "go test" runner.
@@ -68,3 +70,47 @@ func (s *PeerStatusTestSuite) TestPeerStatus(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(ret, Equals, false)
 }
+
+func (s *PeerStatusTestSuite) TestPeerStatusExclude(c *C) {
+	peers := generateRandomPeers(c, 4)
+	peerStatus := newPeerStatus(peers, peers[0], peers[0], 2)
+	peerStatus.exclude(SignerConstraint{Denied: peers[1:2]})
+
+	// the excluded peer is not ready, nor is it unknown
+	ret, err := peerStatus.updatePeer(peers[1])
+	c.Assert(err, IsNil)
+	c.Assert(ret, Equals, false)
+	ret, err = peerStatus.updatePeer(peers[2])
+	c.Assert(err, IsNil)
+	c.Assert(ret, Equals, false)
+	ret, err = peerStatus.updatePeer(peers[3])
+	c.Assert(err, IsNil)
+	c.Assert(ret, Equals, true)
+	c.Assert(peerStatus.readyPeers(), DeepEquals, peers[2:])
+	select {
+	case <-peerStatus.allReady:
+	default:
+		c.Fatal("all the permitted peers are ready")
+	}
+	// it still gets the response of the leader
+	c.Assert(peerStatus.getAllPeers(), DeepEquals, peers)
+}
+
+func (s *PeerStatusTestSuite) TestLeaderSuccession(c *C) {
+	peers := generateRandomPeers(c, 4)
+	peerStatus := newPeerStatus(peers, peers[0], peers[1], 2)
+	resp := &messages.JoinPartyLeaderComm{ID: "msgID", Type: messages.JoinPartyLeaderComm_Success}
+
+	// only the current leader is listened to
+	c.Assert(peerStatus.setLeaderResponse(peers[2], resp), Equals, false)
+	c.Assert(peerStatus.dropLeader(), Equals, true)
+	c.Assert(peerStatus.setLeaderResponse(peers[1], resp), Equals, false)
+	peerStatus.setLeader(peers[2])
+	c.Assert(peerStatus.setLeaderResponse(peers[2], resp), Equals, true)
+	c.Assert(peerStatus.getLeaderResponse(), Equals, resp)
+	c.Assert(<-peerStatus.leaderNotify, Equals, true)
+	// the leader that has answered is kept
+	c.Assert(peerStatus.dropLeader(), Equals, false)
+	c.Assert(peerStatus.getLeader(), Equals, peers[2])
+	c.Assert(peerStatus.setLeaderResponse(peers[2], resp), Equals, false)
+}
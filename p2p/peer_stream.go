@@ -0,0 +1,385 @@
+package p2p
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// peerStreamQueueSize is how many outbound messages we buffer for a peer before the senders get blocked
+	peerStreamQueueSize = 256
+	// StreamIdleTimeout is how long we keep a persistent stream open without any traffic
+	StreamIdleTimeout = time.Minute * 5
+	// DefaultDeliveryTimeout is how long we keep trying to deliver a message that has no deadline of its own
+	DefaultDeliveryTimeout = time.Minute
+	// maxSendAttempts is how many times we try to deliver a message before giving up
+	maxSendAttempts = 5
+	// retryBackoffBase is the delay before the first retry, it doubles on every following retry
+	retryBackoffBase = time.Millisecond * 200
+	// retryBackoffMax is the longest delay between two retries
+	retryBackoffMax = time.Second * 5
+)
+
+var (
+	// TSSStreamProtocolID is the tss protocol carrying all the messages to a peer over a single long-lived
+	// stream, the receiver acknowledges every message with an empty frame on the same stream, or refuses it with
+	// nackFrame when it cannot take it now
+	TSSStreamProtocolID protocol.ID = "/p2p/tss/stream"
+	// TSSCompressedStreamProtocolID is TSSStreamProtocolID with zstd compressed payloads
+	TSSCompressedStreamProtocolID protocol.ID = "/p2p/tss/stream/zstd"
+)
+
+// nackFrame is the frame the receiver answers a message it cannot take now with, the sender retries the message,
+// the older receivers acknowledge all the messages with an empty frame
+var nackFrame = []byte{1}
+
+var (
+	errPeerStreamClosed  = errors.New("peer stream is closed")
+	errPeerStreamRetired = errors.New("peer stream is retired")
+	errMessageRefused    = errors.New("peer refused the message")
+)
+
+// isPersistentProtocol tells whether the stream of the given protocol carries more than one message
+func isPersistentProtocol(pID protocol.ID) bool {
+	pID = baseProtocolID(pID)
+	return pID == TSSStreamProtocolID || pID == TSSCompressedStreamProtocolID
+}
+
+// retryBackoff return how long we wait before the given attempt
+func retryBackoff(attempt int) time.Duration {
+	backoff := retryBackoffBase << (attempt - 1)
+	if backoff > retryBackoffMax || backoff <= 0 {
+		return retryBackoffMax
+	}
+	return backoff
+}
+
+type outboundMsg struct {
+	msgID    string
+	payload  []byte
+	deadline time.Time
+	attempts int
+	result   chan error
+}
+
+// peerStream multiplex all the outbound tss messages to a peer over one long-lived stream. The messages are written
+// by a single goroutine and stay in flight until the peer acknowledges them. When the stream breaks, the messages
+// in flight are retried with exponential backoff over a new stream, until they are acknowledged, run out of
+// attempts or pass their deadline. The delivery is at least once: a message whose acknowledgement is lost is sent
+// again, so the peer may receive it twice, TssCommon drops the duplicated shares and confirmations. The senders are
+// blocked once the queue is full. For the peers that only speak the legacy protocol, we fall back to one stream per
+// message without acknowledgement, the stream is parked in the StreamMgr until the ceremony releases it. Once the
+// peer stream is idle and no sender waits on it, the retire function removes it from its owner and its goroutine
+// exits. The messages still queued or in flight when the peer stream stops are failed, so their senders do not wait
+// for nothing.
+type peerStream struct {
+	remotePeer  peer.ID
+	openStream  func(pID peer.ID) (network.Stream, error)
+	retire      func(ps *peerStream) bool
+	streamMgr   *StreamMgr
+	conf        NetworkConfig
+	idleTimeout time.Duration
+	queue       chan *outboundMsg
+	retryQueue  chan *outboundMsg
+	lock        *sync.Mutex
+	stream      network.Stream
+	inflight    []*outboundMsg
+	senders     int
+	retired     bool
+	logger      zerolog.Logger
+	wg          *sync.WaitGroup
+	stopChan    chan struct{}
+	doneChan    chan struct{}
+}
+
+func newPeerStream(remotePeer peer.ID, openStream func(pID peer.ID) (network.Stream, error), retire func(ps *peerStream) bool, streamMgr *StreamMgr, conf NetworkConfig, idleTimeout time.Duration, logger zerolog.Logger, wg *sync.WaitGroup, stopChan chan struct{}) *peerStream {
+	return &peerStream{
+		remotePeer:  remotePeer,
+		openStream:  openStream,
+		retire:      retire,
+		streamMgr:   streamMgr,
+		conf:        conf,
+		idleTimeout: idleTimeout,
+		queue:       make(chan *outboundMsg, peerStreamQueueSize),
+		retryQueue:  make(chan *outboundMsg, peerStreamQueueSize),
+		lock:        &sync.Mutex{},
+		logger:      logger.With().Str("peer", remotePeer.String()).Logger(),
+		wg:          wg,
+		stopChan:    stopChan,
+		doneChan:    make(chan struct{}),
+	}
+}
+
+// Send queue the message to the peer and wait until the peer has it or we give up on it, errPeerStreamRetired
+// is returned if the peer stream has been retired, the caller should send the message over a new one
+func (ps *peerStream) Send(msgID string, payload []byte, deadline time.Time) error {
+	ps.lock.Lock()
+	if ps.retired {
+		ps.lock.Unlock()
+		return errPeerStreamRetired
+	}
+	ps.senders++
+	ps.lock.Unlock()
+	defer func() {
+		ps.lock.Lock()
+		ps.senders--
+		ps.lock.Unlock()
+	}()
+	msg := &outboundMsg{
+		msgID:    msgID,
+		payload:  payload,
+		deadline: deadline,
+		result:   make(chan error, 1),
+	}
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case ps.queue <- msg:
+	case <-ps.doneChan:
+		return errPeerStreamClosed
+	case <-timer.C:
+		return fmt.Errorf("outbound queue to peer(%s) is full", ps.remotePeer)
+	}
+	select {
+	case err := <-msg.result:
+		return err
+	case <-ps.doneChan:
+		// the pending messages are failed before the peer stream is done, unless they were already delivered
+		select {
+		case err := <-msg.result:
+			return err
+		default:
+			return errPeerStreamClosed
+		}
+	case <-timer.C:
+		return fmt.Errorf("fail to deliver the message to peer(%s) before the deadline", ps.remotePeer)
+	}
+}
+
+func (ps *peerStream) run() {
+	defer ps.wg.Done()
+	defer close(ps.doneChan)
+	defer ps.closeStream(true)
+	idleTimer := time.NewTimer(ps.idleTimeout)
+	defer idleTimer.Stop()
+	for {
+		select {
+		case msg := <-ps.queue:
+			ps.send(msg)
+		case msg := <-ps.retryQueue:
+			ps.send(msg)
+		case <-idleTimer.C:
+			ps.closeStream(false)
+			if ps.retire != nil && ps.retire(ps) {
+				ps.logger.Debug().Msg("retire the idle peer stream")
+				return
+			}
+		case <-ps.stopChan:
+			return
+		}
+		if !idleTimer.Stop() {
+			select {
+			case <-idleTimer.C:
+			default:
+			}
+		}
+		idleTimer.Reset(ps.idleTimeout)
+	}
+}
+
+// tryRetire mark the peer stream as retired if no sender waits on it, a retired peer stream accepts no message
+func (ps *peerStream) tryRetire() bool {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+	if ps.senders != 0 {
+		return false
+	}
+	ps.retired = true
+	return true
+}
+
+func (ps *peerStream) send(msg *outboundMsg) {
+	if time.Now().After(msg.deadline) {
+		msg.result <- fmt.Errorf("message to peer(%s) passed its deadline", ps.remotePeer)
+		return
+	}
+	stream, err := ps.getStream()
+	if err != nil {
+		ps.retry(msg, err)
+		return
+	}
+	if !isPersistentProtocol(stream.Protocol()) {
+		err := WriteStreamWithConfig(msg.payload, stream, ps.conf)
+		ps.streamMgr.AddStream(msg.msgID, stream)
+		if err != nil {
+			ps.retry(msg, err)
+			return
+		}
+		msg.result <- nil
+		return
+	}
+	ps.lock.Lock()
+	if ps.stream != stream {
+		// the stream broke while we were opening it
+		ps.lock.Unlock()
+		ps.retry(msg, errPeerStreamClosed)
+		return
+	}
+	ps.inflight = append(ps.inflight, msg)
+	ps.lock.Unlock()
+	if err := WriteStreamWithConfig(msg.payload, stream, ps.conf); err != nil {
+		ps.failStream(stream, err)
+		return
+	}
+	// the peer should acknowledge the message shortly
+	if ApplyDeadline {
+		if err := stream.SetReadDeadline(time.Now().Add(ps.conf.TimeoutReadPayload)); err != nil {
+			ps.failStream(stream, err)
+		}
+	}
+}
+
+// getStream return the current persistent stream, or open a new stream to the peer
+func (ps *peerStream) getStream() (network.Stream, error) {
+	ps.lock.Lock()
+	stream := ps.stream
+	ps.lock.Unlock()
+	if stream != nil {
+		return stream, nil
+	}
+	stream, err := ps.openStream(ps.remotePeer)
+	if err != nil {
+		return nil, err
+	}
+	if isPersistentProtocol(stream.Protocol()) {
+		ps.lock.Lock()
+		ps.stream = stream
+		ps.lock.Unlock()
+		ps.wg.Add(1)
+		go ps.readAcks(stream)
+	}
+	return stream, nil
+}
+
+// readAcks read the acknowledgements from the persistent stream, the peer acknowledges or refuses the messages in
+// the order we sent them, the refused messages are retried
+func (ps *peerStream) readAcks(stream network.Stream) {
+	defer ps.wg.Done()
+	streamReader := bufio.NewReader(stream)
+	for {
+		ps.lock.Lock()
+		timeout := ps.idleTimeout
+		if len(ps.inflight) != 0 {
+			timeout = ps.conf.TimeoutReadPayload
+		}
+		ps.lock.Unlock()
+		frame, err := readFrame(stream, streamReader, timeout, ps.conf.MaxPayload, nil)
+		if err != nil {
+			ps.failStream(stream, err)
+			return
+		}
+		ps.lock.Lock()
+		if ps.stream != stream || len(ps.inflight) == 0 {
+			ps.lock.Unlock()
+			ps.logger.Warn().Msg("receive an unexpected acknowledgement")
+			continue
+		}
+		msg := ps.inflight[0]
+		ps.inflight = ps.inflight[1:]
+		ps.lock.Unlock()
+		if len(frame) != 0 {
+			ps.retry(msg, errMessageRefused)
+			continue
+		}
+		msg.result <- nil
+	}
+}
+
+// failStream drop the broken stream and retry all the messages that were not acknowledged on it
+func (ps *peerStream) failStream(stream network.Stream, err error) {
+	ps.lock.Lock()
+	if ps.stream != stream {
+		ps.lock.Unlock()
+		return
+	}
+	pending := ps.inflight
+	ps.inflight = nil
+	ps.stream = nil
+	ps.lock.Unlock()
+	if len(pending) != 0 {
+		ps.logger.Warn().Err(err).Msgf("persistent stream broke with %d messages in flight", len(pending))
+	}
+	if errReset := stream.Reset(); errReset != nil {
+		ps.logger.Error().Err(errReset).Msg("fail to reset the stream")
+	}
+	for _, msg := range pending {
+		ps.retry(msg, err)
+	}
+}
+
+// retry schedule the message for another attempt, unless it runs out of attempts or time
+func (ps *peerStream) retry(msg *outboundMsg, err error) {
+	msg.attempts++
+	backoff := retryBackoff(msg.attempts)
+	if msg.attempts >= maxSendAttempts || time.Now().Add(backoff).After(msg.deadline) {
+		msg.result <- fmt.Errorf("fail to deliver the message to peer(%s) after %d attempts: %w", ps.remotePeer, msg.attempts, err)
+		return
+	}
+	ps.logger.Debug().Err(err).Msgf("retry the message in %s", backoff)
+	time.AfterFunc(backoff, func() {
+		select {
+		case ps.retryQueue <- msg:
+		case <-ps.doneChan:
+			msg.result <- errPeerStreamClosed
+		}
+	})
+}
+
+// closeStream close the persistent stream, unless there are messages waiting for acknowledgement on it. When
+// forced, the messages in flight and the ones still queued are failed, as nobody is going to send them.
+func (ps *peerStream) closeStream(force bool) {
+	ps.lock.Lock()
+	stream := ps.stream
+	if !force && (stream == nil || len(ps.inflight) != 0) {
+		ps.lock.Unlock()
+		return
+	}
+	pending := ps.inflight
+	ps.stream = nil
+	ps.inflight = nil
+	ps.lock.Unlock()
+	if force {
+		for _, msg := range pending {
+			msg.result <- errPeerStreamClosed
+		}
+		ps.failQueued()
+	}
+	if stream == nil {
+		return
+	}
+	if err := stream.Close(); err != nil {
+		ps.logger.Error().Err(err).Msg("fail to close the persistent stream")
+	}
+}
+
+// failQueued fail all the messages waiting in the queues
+func (ps *peerStream) failQueued() {
+	for {
+		select {
+		case msg := <-ps.queue:
+			msg.result <- errPeerStreamClosed
+		case msg := <-ps.retryQueue:
+			msg.result <- errPeerStreamClosed
+		default:
+			return
+		}
+	}
+}
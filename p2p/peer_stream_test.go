@@ -0,0 +1,361 @@
+package p2p
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ordinox/thorchain-tss/messages"
+)
+
+// newTestCommunication create a Communication on top of the given host without going through the bootstrap
+func newTestCommunication(t *testing.T, h host.Host, compression bool) *Communication {
+	comm, err := NewCommunication(Config{RendezvousString: "test"})
+	assert.Nil(t, err)
+	comm.EnableCompression(compression)
+	comm.host = h
+	for _, pID := range comm.tssProtocols() {
+		h.SetStreamHandler(pID, comm.handleStream)
+	}
+	return comm
+}
+
+// streamProtocols return the protocols of the tss streams we have opened to the given peer
+func streamProtocols(h host.Host, pID peer.ID) []protocol.ID {
+	var protocols []protocol.ID
+	for _, conn := range h.Network().ConnsToPeer(pID) {
+		for _, stream := range conn.GetStreams() {
+			if stream.Stat().Direction == network.DirOutbound && strings.HasPrefix(string(stream.Protocol()), string(TSSProtocolID)) {
+				protocols = append(protocols, stream.Protocol())
+			}
+		}
+	}
+	return protocols
+}
+
+func testDeadline() time.Time {
+	return time.Now().Add(time.Minute)
+}
+
+func wrapTestMessage(t *testing.T, msgID string) []byte {
+	wrapped := messages.WrappedMessage{
+		MessageType: messages.TSSKeySignMsg,
+		MsgID:       msgID,
+		Payload:     []byte("hello " + msgID),
+	}
+	buf, err := wrapped.Marshal(messages.WireFormatProtobuf)
+	assert.Nil(t, err)
+	return buf
+}
+
+func TestPersistentPeerStream(t *testing.T) {
+	ApplyDeadline = false
+	hosts := setupHostsLocally(t, 2)
+	sender := newTestCommunication(t, hosts[0], false)
+	receiver := newTestCommunication(t, hosts[1], false)
+	defer close(sender.stopChan)
+	defer close(receiver.stopChan)
+
+	const msgNum = 50
+	channels := make([]chan *Message, msgNum)
+	for i := 0; i < msgNum; i++ {
+		channels[i] = make(chan *Message, 1)
+		receiver.SetSubscribe(messages.TSSKeySignMsg, fmt.Sprintf("msg%d", i), channels[i])
+	}
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < msgNum; i++ {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			assert.Nil(t, sender.writeToStream(hosts[1].ID(), wrapTestMessage(t, fmt.Sprintf("msg%d", idx)), fmt.Sprintf("msg%d", idx), testDeadline()))
+		}(i)
+	}
+	wg.Wait()
+	for i := 0; i < msgNum; i++ {
+		select {
+		case msg := <-channels[i]:
+			assert.Equal(t, hosts[0].ID(), msg.PeerID)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting for msg%d", i)
+		}
+	}
+	// all the messages share a single stream, and nothing is parked for release
+	assert.Equal(t, []protocol.ID{VersionedProtocolID(TSSStreamProtocolID)}, streamProtocols(hosts[0], hosts[1].ID()))
+	assert.Empty(t, sender.streamMgr.unusedStreams)
+
+	// the stream is reopened once it is broken
+	for _, conn := range hosts[0].Network().ConnsToPeer(hosts[1].ID()) {
+		for _, stream := range conn.GetStreams() {
+			assert.Nil(t, stream.Reset())
+		}
+	}
+	assert.Nil(t, sender.writeToStream(hosts[1].ID(), wrapTestMessage(t, "msg0"), "msg0", testDeadline()))
+	select {
+	case <-channels[0]:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the message after reconnection")
+	}
+	assert.Equal(t, []protocol.ID{VersionedProtocolID(TSSStreamProtocolID)}, streamProtocols(hosts[0], hosts[1].ID()))
+}
+
+func TestPeerStreamWithLegacyPeer(t *testing.T) {
+	ApplyDeadline = false
+	hosts := setupHostsLocally(t, 2)
+	sender := newTestCommunication(t, hosts[0], true)
+	defer close(sender.stopChan)
+	received := make(chan []byte, 10)
+	// the legacy peer only speaks the single message per stream protocol
+	hosts[1].SetStreamHandler(TSSProtocolID, func(stream network.Stream) {
+		buf, err := ReadStreamWithBuffer(stream)
+		assert.Nil(t, err)
+		received <- buf
+	})
+
+	for i := 0; i < 3; i++ {
+		msg := wrapTestMessage(t, "legacy")
+		assert.Nil(t, sender.writeToStream(hosts[1].ID(), msg, "legacy", testDeadline()))
+		select {
+		case buf := <-received:
+			assert.Equal(t, msg, buf)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for the message")
+		}
+	}
+	assert.Len(t, sender.streamMgr.unusedStreams["legacy"], 3)
+	sender.ReleaseStream("legacy")
+	assert.Empty(t, sender.streamMgr.unusedStreams)
+}
+
+func TestRetryBackoff(t *testing.T) {
+	assert.Equal(t, retryBackoffBase, retryBackoff(1))
+	assert.Equal(t, retryBackoffBase*2, retryBackoff(2))
+	assert.Equal(t, retryBackoffBase*4, retryBackoff(3))
+	assert.Equal(t, retryBackoffMax, retryBackoff(10))
+	assert.Equal(t, retryBackoffMax, retryBackoff(100))
+}
+
+func TestPeerStreamAcknowledgement(t *testing.T) {
+	ApplyDeadline = false
+	hosts := setupHostsLocally(t, 2)
+	sender := newTestCommunication(t, hosts[0], false)
+	receiver := newTestCommunication(t, hosts[1], false)
+	defer close(sender.stopChan)
+	defer close(receiver.stopChan)
+	ch := make(chan *Message, 10)
+	receiver.SetSubscribe(messages.TSSKeySignMsg, "ack", ch)
+	for i := 0; i < 5; i++ {
+		assert.Nil(t, sender.writeToStream(hosts[1].ID(), wrapTestMessage(t, "ack"), "ack", testDeadline()))
+	}
+	assert.Len(t, ch, 5)
+	ps := sender.getPeerStream(hosts[1].ID())
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+	assert.Empty(t, ps.inflight)
+}
+
+func TestPeerStreamRetryUntilPeerIsBack(t *testing.T) {
+	ApplyDeadline = false
+	hosts := setupHostsLocally(t, 2)
+	sender := newTestCommunication(t, hosts[0], false)
+	defer close(sender.stopChan)
+	receiver, err := NewCommunication(Config{RendezvousString: "test"})
+	assert.Nil(t, err)
+	receiver.host = hosts[1]
+	defer close(receiver.stopChan)
+	ch := make(chan *Message, 1)
+	receiver.SetSubscribe(messages.TSSKeySignMsg, "retry", ch)
+
+	// the first message is received, but the stream breaks before it is acknowledged
+	var once sync.Once
+	hosts[1].SetStreamHandler(TSSStreamProtocolID, func(stream network.Stream) {
+		once.Do(func() {
+			_, err := ReadStreamWithBuffer(stream)
+			assert.Nil(t, err)
+			assert.Nil(t, stream.Reset())
+			// the peer is offline for a while
+			hosts[1].RemoveStreamHandler(TSSStreamProtocolID)
+			time.AfterFunc(time.Second, func() {
+				hosts[1].SetStreamHandler(TSSStreamProtocolID, receiver.handleStream)
+			})
+		})
+	})
+	start := time.Now()
+	assert.Nil(t, sender.writeToStream(hosts[1].ID(), wrapTestMessage(t, "retry"), "retry", testDeadline()))
+	assert.True(t, time.Since(start) >= time.Second)
+	select {
+	case <-ch:
+	case <-time.After(time.Second):
+		t.Fatal("the message should be delivered after the retry")
+	}
+}
+
+func TestPeerStreamResendAfterLostAck(t *testing.T) {
+	ApplyDeadline = false
+	hosts := setupHostsLocally(t, 2)
+	sender := newTestCommunication(t, hosts[0], false)
+	defer close(sender.stopChan)
+	receiver, err := NewCommunication(Config{RendezvousString: "test"})
+	assert.Nil(t, err)
+	receiver.host = hosts[1]
+	defer close(receiver.stopChan)
+	ch := make(chan *Message, 2)
+	receiver.SetSubscribe(messages.TSSKeySignMsg, "resend", ch)
+
+	// the first message is dispatched, but the stream breaks before it is acknowledged
+	var once sync.Once
+	hosts[1].SetStreamHandler(TSSStreamProtocolID, func(stream network.Stream) {
+		handled := false
+		once.Do(func() {
+			handled = true
+			buf, err := ReadStreamWithBuffer(stream)
+			assert.Nil(t, err)
+			_, err = receiver.dispatchMessage(stream.Conn().RemotePeer(), buf)
+			assert.Nil(t, err)
+			assert.Nil(t, stream.Reset())
+		})
+		if !handled {
+			receiver.handleStream(stream)
+		}
+	})
+	assert.Nil(t, sender.writeToStream(hosts[1].ID(), wrapTestMessage(t, "resend"), "resend", testDeadline()))
+	// the delivery is at least once, the receiver gets the message twice
+	for i := 0; i < 2; i++ {
+		select {
+		case msg := <-ch:
+			assert.Equal(t, wrapTestMessage(t, "resend"), msg.Payload)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for the message")
+		}
+	}
+}
+
+func TestPeerStreamResendRefusedMessage(t *testing.T) {
+	ApplyDeadline = false
+	hosts := setupHostsLocally(t, 2)
+	sender := newTestCommunication(t, hosts[0], false)
+	receiver := newTestCommunication(t, hosts[1], false)
+	defer close(sender.stopChan)
+	defer close(receiver.stopChan)
+	ch := make(chan *Message)
+	receiver.SetSubscribe(messages.TSSKeySignMsg, "busy", ch)
+	// the subscriber is busy and its queue is full
+	for i := 0; i < subscriberQueueSize; i++ {
+		_, err := receiver.dispatchMessage(hosts[0].ID(), wrapTestMessage(t, "busy"))
+		assert.Nil(t, err)
+	}
+
+	msg := wrapTestMessage(t, "busy")
+	result := make(chan error, 1)
+	go func() {
+		result <- sender.writeToStream(hosts[1].ID(), msg, "busy", testDeadline())
+	}()
+	// the receiver refuses the message rather than acknowledging a message it dropped
+	select {
+	case err := <-result:
+		t.Fatalf("the message should not be acknowledged, err: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	// once the subscriber catches up, the message is sent again and delivered
+	for i := 0; i < subscriberQueueSize; i++ {
+		<-ch
+	}
+	select {
+	case received := <-ch:
+		assert.Equal(t, msg, received.Payload)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the refused message")
+	}
+	assert.Nil(t, <-result)
+}
+
+func TestPeerStreamGiveUp(t *testing.T) {
+	ApplyDeadline = false
+	hosts := setupHostsLocally(t, 2)
+	sender := newTestCommunication(t, hosts[0], false)
+	defer close(sender.stopChan)
+
+	// the peer does not speak tss at all, we run out of attempts
+	err := sender.writeToStream(hosts[1].ID(), wrapTestMessage(t, "give-up"), "give-up", testDeadline())
+	assert.ErrorContains(t, err, "after 5 attempts")
+
+	// we do not try beyond the deadline
+	start := time.Now()
+	err = sender.writeToStream(hosts[1].ID(), wrapTestMessage(t, "give-up"), "give-up", time.Now().Add(time.Millisecond*500))
+	assert.NotNil(t, err)
+	assert.True(t, time.Since(start) < time.Second)
+}
+
+func TestPeerStreamFailPendingOnClose(t *testing.T) {
+	ApplyDeadline = false
+	hosts := setupHostsLocally(t, 2)
+	sender := newTestCommunication(t, hosts[0], false)
+	// the peer reads the message, but never acknowledges it
+	received := make(chan struct{}, 1)
+	hosts[1].SetStreamHandler(TSSStreamProtocolID, func(stream network.Stream) {
+		_, err := ReadStreamWithBuffer(stream)
+		assert.Nil(t, err)
+		received <- struct{}{}
+	})
+	result := make(chan error, 2)
+	go func() {
+		result <- sender.writeToStream(hosts[1].ID(), wrapTestMessage(t, "inflight"), "inflight", testDeadline())
+	}()
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the message")
+	}
+	go func() {
+		result <- sender.writeToStream(hosts[1].ID(), wrapTestMessage(t, "queued"), "queued", testDeadline())
+	}()
+
+	// the senders of the messages in flight and in the queue learn right away that the stream is closed
+	close(sender.stopChan)
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-result:
+			assert.ErrorIs(t, err, errPeerStreamClosed)
+		case <-time.After(5 * time.Second):
+			t.Fatal("the sender should not wait until its deadline")
+		}
+	}
+}
+
+func TestPeerStreamRetireWhenIdle(t *testing.T) {
+	ApplyDeadline = false
+	hosts := setupHostsLocally(t, 2)
+	sender := newTestCommunication(t, hosts[0], false)
+	receiver := newTestCommunication(t, hosts[1], false)
+	defer close(sender.stopChan)
+	defer close(receiver.stopChan)
+	sender.idleTimeout = time.Millisecond * 200
+	ch := make(chan *Message, 2)
+	receiver.SetSubscribe(messages.TSSKeySignMsg, "idle", ch)
+
+	assert.Nil(t, sender.writeToStream(hosts[1].ID(), wrapTestMessage(t, "idle"), "idle", testDeadline()))
+	ps := sender.getPeerStream(hosts[1].ID())
+	// the goroutine of the idle peer stream exits, and the peer stream is removed
+	select {
+	case <-ps.doneChan:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the idle peer stream should be retired")
+	}
+	sender.peerStreamsLock.Lock()
+	assert.Empty(t, sender.peerStreams)
+	sender.peerStreamsLock.Unlock()
+	assert.ErrorIs(t, ps.Send("idle", wrapTestMessage(t, "idle"), testDeadline()), errPeerStreamRetired)
+
+	// we send over a new peer stream
+	assert.Nil(t, sender.writeToStream(hosts[1].ID(), wrapTestMessage(t, "idle"), "idle", testDeadline()))
+	assert.Len(t, ch, 2)
+	assert.NotEqual(t, ps, sender.getPeerStream(hosts[1].ID()))
+}
@@ -0,0 +1,307 @@
+package p2p
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	libp2p "github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	rcmgr "github.com/libp2p/go-libp2p/p2p/host/resource-manager"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// ViolationStreams is reported when a peer opens streams faster than the stream rate
+	ViolationStreams = "streams"
+	// ViolationBytes is reported when a peer sends more bytes than the byte rate
+	ViolationBytes = "bytes"
+	// ViolationMessages is reported when a peer sends more messages of a ceremony than the message rate
+	ViolationMessages = "messages"
+	// ViolationBanned is reported when a banned peer keeps sending
+	ViolationBanned = "banned"
+
+	// peerMemoryPayloads is how many payloads of the largest size a peer can have in flight at the same time
+	peerMemoryPayloads = 4
+)
+
+// ErrRateLimited is returned when a peer exceeds one of its limits
+type ErrRateLimited struct {
+	PeerID peer.ID
+	Reason string
+}
+
+// Error implement the error interface
+func (e ErrRateLimited) Error() string {
+	return fmt.Sprintf("peer(%s) exceeds the %s rate limit", e.PeerID, e.Reason)
+}
+
+// Violation is a peer that exceeded its limits and why
+type Violation struct {
+	PeerID peer.ID
+	Reason string
+}
+
+// tokenBucket allows rate events per second on average, with bursts of up to burst events
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int, now time.Time) *tokenBucket {
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   now,
+	}
+}
+
+// allow take n tokens out of the bucket, it returns false and leaves the bucket untouched if there are not enough
+func (tb *tokenBucket) allow(n float64, now time.Time) bool {
+	if elapsed := now.Sub(tb.last).Seconds(); elapsed > 0 {
+		tb.tokens += elapsed * tb.rate
+		if tb.tokens > tb.burst {
+			tb.tokens = tb.burst
+		}
+	}
+	tb.last = now
+	if tb.tokens < n {
+		return false
+	}
+	tb.tokens -= n
+	return true
+}
+
+type peerLimits struct {
+	streams        *tokenBucket
+	bytes          *tokenBucket
+	messages       map[string]*tokenBucket
+	violations     int
+	firstViolation time.Time
+	lastViolation  string
+}
+
+// RateLimiter enforce the per-peer limits on the inbound streams, the bytes and the messages of each ceremony.
+// A peer that violates its limits BanThreshold times within BanDuration is banned for BanDuration, the
+// connection gater refuses its connections and the ceremonies it takes part in blame it.
+type RateLimiter struct {
+	logger    zerolog.Logger
+	conf      NetworkConfig
+	gater     *ConnectionGater
+	lock      *sync.Mutex
+	peers     map[peer.ID]*peerLimits
+	evidence  map[string]map[peer.ID]string
+	closePeer func(pID peer.ID) error
+	observer  func(pID peer.ID, reason string, banned bool)
+	now       func() time.Time
+}
+
+// NewRateLimiter create a new instance of RateLimiter, the limits left to zero in the given network
+// configuration fall back to their default values
+func NewRateLimiter(gater *ConnectionGater, conf NetworkConfig) *RateLimiter {
+	return &RateLimiter{
+		logger:   log.With().Str("module", "rate_limiter").Logger(),
+		conf:     conf.WithDefaults(),
+		gater:    gater,
+		lock:     &sync.Mutex{},
+		peers:    make(map[peer.ID]*peerLimits),
+		evidence: make(map[string]map[peer.ID]string),
+		now:      time.Now,
+	}
+}
+
+// SetObserver set the function that will be called with every violation, banned is true when it gets the
+// peer banned
+func (rl *RateLimiter) SetObserver(observer func(pID peer.ID, reason string, banned bool)) {
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+	rl.observer = observer
+}
+
+// setClosePeer set the function that closes the connections to a peer once it is banned
+func (rl *RateLimiter) setClosePeer(closePeer func(pID peer.ID) error) {
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+	rl.closePeer = closePeer
+}
+
+// getPeer return the limits of the given peer, the lock must be held
+func (rl *RateLimiter) getPeer(pID peer.ID, now time.Time) *peerLimits {
+	limits, ok := rl.peers[pID]
+	if !ok {
+		limits = &peerLimits{
+			streams:  newTokenBucket(rl.conf.StreamRate, rl.conf.StreamBurst, now),
+			bytes:    newTokenBucket(float64(rl.conf.ByteRate), rl.conf.ByteBurst, now),
+			messages: make(map[string]*tokenBucket),
+		}
+		rl.peers[pID] = limits
+	}
+	return limits
+}
+
+// AllowStream take a token out of the stream bucket of the given peer
+func (rl *RateLimiter) AllowStream(pID peer.ID) error {
+	return rl.take(pID, "", ViolationStreams, func(limits *peerLimits, now time.Time) bool {
+		return limits.streams.allow(1, now)
+	})
+}
+
+// AllowBytes take n tokens out of the byte bucket of the given peer, before we read a payload of n bytes
+func (rl *RateLimiter) AllowBytes(pID peer.ID, n int) error {
+	return rl.take(pID, "", ViolationBytes, func(limits *peerLimits, now time.Time) bool {
+		return limits.bytes.allow(float64(n), now)
+	})
+}
+
+// AllowMessage take a token out of the message bucket of the given peer for the given ceremony
+func (rl *RateLimiter) AllowMessage(pID peer.ID, msgID string) error {
+	return rl.take(pID, msgID, ViolationMessages, func(limits *peerLimits, now time.Time) bool {
+		bucket, ok := limits.messages[msgID]
+		if !ok {
+			bucket = newTokenBucket(rl.conf.MessageRate, rl.conf.MessageBurst, now)
+			limits.messages[msgID] = bucket
+		}
+		return bucket.allow(1, now)
+	})
+}
+
+// take run the given check on the limits of the peer and record a violation if it fails, a nil rate limiter
+// allows everything
+func (rl *RateLimiter) take(pID peer.ID, msgID, reason string, allow func(limits *peerLimits, now time.Time) bool) error {
+	if rl == nil {
+		return nil
+	}
+	if rl.gater.IsBanned(pID) {
+		rl.lock.Lock()
+		rl.recordEvidence(pID, msgID, ViolationBanned)
+		rl.lock.Unlock()
+		return ErrRateLimited{PeerID: pID, Reason: ViolationBanned}
+	}
+	now := rl.now()
+	rl.lock.Lock()
+	limits := rl.getPeer(pID, now)
+	if allow(limits, now) {
+		rl.lock.Unlock()
+		return nil
+	}
+	banned := rl.violation(pID, msgID, reason, limits, now)
+	observer := rl.observer
+	closePeer := rl.closePeer
+	rl.lock.Unlock()
+	if banned && closePeer != nil {
+		if err := closePeer(pID); err != nil {
+			rl.logger.Error().Err(err).Msgf("fail to close the connections to the banned peer(%s)", pID)
+		}
+	}
+	if observer != nil {
+		observer(pID, reason, banned)
+	}
+	return ErrRateLimited{PeerID: pID, Reason: reason}
+}
+
+// violation record the violation of the given peer and ban it once it reaches the threshold, the lock must be held
+func (rl *RateLimiter) violation(pID peer.ID, msgID, reason string, limits *peerLimits, now time.Time) bool {
+	if now.Sub(limits.firstViolation) > rl.conf.BanDuration {
+		limits.violations = 0
+		limits.firstViolation = now
+	}
+	limits.violations++
+	limits.lastViolation = reason
+	rl.recordEvidence(pID, msgID, reason)
+	rl.logger.Warn().Str("peer", pID.String()).Str("reason", reason).Msgf("peer exceeds its rate limit, %d violations", limits.violations)
+	if limits.violations < rl.conf.BanThreshold {
+		return false
+	}
+	limits.violations = 0
+	rl.gater.Ban(pID, now.Add(rl.conf.BanDuration))
+	rl.logger.Warn().Str("peer", pID.String()).Msgf("ban the peer for %s", rl.conf.BanDuration)
+	return true
+}
+
+// recordEvidence keep the latest violation of the peer in the given ceremony, the lock must be held
+func (rl *RateLimiter) recordEvidence(pID peer.ID, msgID, reason string) {
+	if len(msgID) == 0 {
+		return
+	}
+	peers, ok := rl.evidence[msgID]
+	if !ok {
+		peers = make(map[peer.ID]string)
+		rl.evidence[msgID] = peers
+	}
+	peers[pID] = reason
+}
+
+// Violators return the peers that violated their limits in the given ceremony and the given peers that are
+// banned, along with the reason, sorted by peer ID
+func (rl *RateLimiter) Violators(msgID string, peers []peer.ID) []Violation {
+	found := make(map[peer.ID]string)
+	rl.lock.Lock()
+	for pID, reason := range rl.evidence[msgID] {
+		found[pID] = reason
+	}
+	for _, pID := range peers {
+		if _, ok := found[pID]; ok || !rl.gater.IsBanned(pID) {
+			continue
+		}
+		reason := ViolationBanned
+		if limits, ok := rl.peers[pID]; ok && len(limits.lastViolation) != 0 {
+			reason = limits.lastViolation
+		}
+		found[pID] = reason
+	}
+	rl.lock.Unlock()
+	violations := make([]Violation, 0, len(found))
+	for pID, reason := range found {
+		violations = append(violations, Violation{PeerID: pID, Reason: reason})
+	}
+	sort.Slice(violations, func(i, j int) bool { return violations[i].PeerID < violations[j].PeerID })
+	return violations
+}
+
+// Release forget the message buckets and the evidence of the given ceremony
+func (rl *RateLimiter) Release(msgID string) {
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+	delete(rl.evidence, msgID)
+	for _, limits := range rl.peers {
+		delete(limits.messages, msgID)
+	}
+}
+
+// newResourceManager return the libp2p resource manager with the default limits, scaled to the memory of the
+// machine, except for the inbound streams and the memory of each peer that we take from the configuration
+func newResourceManager(conf NetworkConfig) (network.ResourceManager, error) {
+	limits := rcmgr.DefaultLimits
+	libp2p.SetDefaultServiceLimits(&limits)
+	peerLimits := rcmgr.PartialLimitConfig{
+		PeerDefault: rcmgr.ResourceLimits{
+			StreamsInbound: rcmgr.LimitVal(conf.MaxInboundStreamsPerPeer),
+			Memory:         rcmgr.LimitVal64(int64(conf.MaxPayload) * peerMemoryPayloads),
+		},
+	}
+	resourceManager, err := rcmgr.NewResourceManager(rcmgr.NewFixedLimiter(peerLimits.Build(limits.AutoScale())))
+	if err != nil {
+		return nil, fmt.Errorf("fail to create the resource manager: %w", err)
+	}
+	return resourceManager, nil
+}
+
+// reserveMemory account the payload of the given length on the resource manager scope of the remote peer, so
+// the payloads a peer has in flight are bounded by its memory limit. It returns the function that releases it.
+func reserveMemory(stream network.Stream, length int) (func(), error) {
+	scope, ok := stream.Scope().(interface{ PeerScope() network.PeerScope })
+	if !ok || length == 0 {
+		return func() {}, nil
+	}
+	peerScope := scope.PeerScope()
+	if err := peerScope.ReserveMemory(length, network.ReservationPriorityAlways); err != nil {
+		return nil, fmt.Errorf("fail to reserve %d bytes for the payload: %w", length, err)
+	}
+	return func() { peerScope.ReleaseMemory(length) }, nil
+}
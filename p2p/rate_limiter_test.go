@@ -0,0 +1,134 @@
+package p2p
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	maddr "github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ordinox/thorchain-tss/conversion"
+	"github.com/ordinox/thorchain-tss/messages"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Now()
+	tb := newTokenBucket(10, 5, now)
+	for i := 0; i < 5; i++ {
+		assert.True(t, tb.allow(1, now))
+	}
+	assert.False(t, tb.allow(1, now))
+	// a token every 100ms
+	assert.True(t, tb.allow(1, now.Add(100*time.Millisecond)))
+	assert.False(t, tb.allow(1, now.Add(100*time.Millisecond)))
+	// the bucket never holds more than the burst
+	assert.False(t, tb.allow(6, now.Add(time.Hour)))
+	assert.True(t, tb.allow(5, now.Add(time.Hour)))
+}
+
+func TestRateLimiter(t *testing.T) {
+	conversion.SetupBech32Prefix()
+	gater := NewConnectionGater(nil)
+	rl := NewRateLimiter(gater, NetworkConfig{
+		StreamRate:   1,
+		StreamBurst:  1,
+		MaxPayload:   500,
+		ByteRate:     100,
+		ByteBurst:    1000,
+		MessageRate:  1,
+		MessageBurst: 2,
+		BanThreshold: 3,
+	})
+	now := time.Now()
+	rl.now = func() time.Time { return now }
+	var closed []peer.ID
+	rl.setClosePeer(func(pID peer.ID) error {
+		closed = append(closed, pID)
+		return nil
+	})
+	var violations []string
+	bans := 0
+	rl.SetObserver(func(_ peer.ID, reason string, banned bool) {
+		violations = append(violations, reason)
+		if banned {
+			bans++
+		}
+	})
+	pID := randomPeerID(t)
+	other := randomPeerID(t)
+
+	assert.Nil(t, rl.AllowStream(pID))
+	assert.Equal(t, ErrRateLimited{PeerID: pID, Reason: ViolationStreams}, rl.AllowStream(pID))
+	assert.Nil(t, rl.AllowBytes(pID, 1000))
+	assert.Equal(t, ErrRateLimited{PeerID: pID, Reason: ViolationBytes}, rl.AllowBytes(pID, 1))
+	// every ceremony has its own message bucket
+	assert.Nil(t, rl.AllowMessage(pID, "msg1"))
+	assert.Nil(t, rl.AllowMessage(pID, "msg1"))
+	assert.Nil(t, rl.AllowMessage(pID, "msg2"))
+	assert.False(t, gater.IsBanned(pID))
+	assert.Equal(t, ErrRateLimited{PeerID: pID, Reason: ViolationMessages}, rl.AllowMessage(pID, "msg1"))
+
+	// the third violation gets the peer banned and disconnected
+	assert.True(t, gater.IsBanned(pID))
+	assert.Equal(t, []peer.ID{pID}, closed)
+	assert.Equal(t, []string{ViolationStreams, ViolationBytes, ViolationMessages}, violations)
+	assert.Equal(t, 1, bans)
+	assert.Equal(t, ErrRateLimited{PeerID: pID, Reason: ViolationBanned}, rl.AllowMessage(pID, "msg2"))
+	assert.Nil(t, rl.AllowStream(other))
+
+	assert.Equal(t, []Violation{{PeerID: pID, Reason: ViolationMessages}}, rl.Violators("msg1", nil))
+	assert.Equal(t, []Violation{{PeerID: pID, Reason: ViolationBanned}}, rl.Violators("msg2", nil))
+	// the banned participants of a ceremony are blamed even if they did not send anything in it
+	assert.Equal(t, []Violation{{PeerID: pID, Reason: ViolationMessages}}, rl.Violators("msg3", []peer.ID{pID, other}))
+	assert.Empty(t, rl.Violators("msg3", []peer.ID{other}))
+	rl.Release("msg1")
+	assert.Empty(t, rl.Violators("msg1", nil))
+
+	// a nil rate limiter allows everything
+	var noLimit *RateLimiter
+	assert.Nil(t, noLimit.AllowStream(pID))
+	assert.Nil(t, noLimit.AllowMessage(pID, "msg1"))
+}
+
+func TestRateLimitedPeerIsBanned(t *testing.T) {
+	receiver := startNATNode(t, NetworkConfig{
+		MessageRate:  0.001,
+		MessageBurst: 2,
+		BanThreshold: 2,
+	}, nil)
+	defer receiver.Stop()
+	receiverAddr := receiver.host.Addrs()[0].Encapsulate(maddr.StringCast("/p2p/" + receiver.host.ID().String()))
+	sender := startNATNode(t, NetworkConfig{}, []maddr.Multiaddr{receiverAddr})
+	defer sender.Stop()
+
+	ch := make(chan *Message, 10)
+	receiver.SetSubscribe(messages.TSSKeyGenMsg, "flood", ch)
+	wrapped := messages.WrappedMessage{
+		MessageType: messages.TSSKeyGenMsg,
+		MsgID:       "flood",
+		Payload:     []byte("flood"),
+	}
+	buf, err := wrapped.Marshal(messages.WireFormatProtobuf)
+	assert.Nil(t, err)
+	for i := 0; i < 4; i++ {
+		// the last message gets the sender banned, its delivery fails
+		_ = sender.writeToStream(receiver.host.ID(), buf, "flood", time.Now().Add(2*time.Second))
+	}
+	assert.Len(t, ch, 2)
+	assert.Eventually(t, func() bool {
+		return receiver.GetConnectionGater().IsBanned(sender.host.ID())
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []Violation{{PeerID: sender.host.ID(), Reason: ViolationMessages}},
+		receiver.GetRateLimiter().Violators("flood", nil))
+
+	// the banned sender cannot connect again
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_ = sender.host.Network().ClosePeer(receiver.host.ID())
+	_ = sender.host.Connect(ctx, peer.AddrInfo{ID: receiver.host.ID(), Addrs: receiver.host.Addrs()})
+	assert.Eventually(t, func() bool {
+		return len(receiver.host.Network().ConnsToPeer(sender.host.ID())) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,63 @@
+package p2p
+
+import (
+	"errors"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// ErrSignerConstraintViolated is returned to the members when the leader formed the party with signers the
+// signer constraint of the ceremony excludes
+var ErrSignerConstraintViolated = errors.New("the leader picked signers the signer constraint excludes")
+
+// SignerConstraint restrict the peers that may sign, a peer is permitted if it is allowed and not denied, all the
+// peers are allowed when the allowed list is empty
+type SignerConstraint struct {
+	Allowed []peer.ID
+	Denied  []peer.ID
+}
+
+// IsEmpty check whether the constraint permits all the peers
+func (c SignerConstraint) IsEmpty() bool {
+	return len(c.Allowed) == 0 && len(c.Denied) == 0
+}
+
+// Permits check whether the peer may sign
+func (c SignerConstraint) Permits(id peer.ID) bool {
+	for _, el := range c.Denied {
+		if el == id {
+			return false
+		}
+	}
+	if len(c.Allowed) == 0 {
+		return true
+	}
+	for _, el := range c.Allowed {
+		if el == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Violations return the given peers the constraint does not permit
+func (c SignerConstraint) Violations(ids []peer.ID) []peer.ID {
+	var violations []peer.ID
+	for _, el := range ids {
+		if !c.Permits(el) {
+			violations = append(violations, el)
+		}
+	}
+	return violations
+}
+
+// permitted return the given peers the constraint permits, in the same order
+func (c SignerConstraint) permitted(ids []peer.ID) []peer.ID {
+	var result []peer.ID
+	for _, el := range ids {
+		if c.Permits(el) {
+			result = append(result, el)
+		}
+	}
+	return result
+}
@@ -0,0 +1,32 @@
+package p2p
+
+import (
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignerConstraint(t *testing.T) {
+	peers := randomPeerIDs(t, 4)
+	var empty SignerConstraint
+	assert.True(t, empty.IsEmpty())
+	assert.Empty(t, empty.Violations(peers))
+	assert.Equal(t, peers, empty.permitted(peers))
+
+	denied := SignerConstraint{Denied: peers[:1]}
+	assert.False(t, denied.IsEmpty())
+	assert.False(t, denied.Permits(peers[0]))
+	assert.True(t, denied.Permits(peers[1]))
+	assert.Equal(t, peers[:1], denied.Violations(peers))
+	assert.Equal(t, peers[1:], denied.permitted(peers))
+
+	// a peer both allowed and denied is denied
+	allowed := SignerConstraint{Allowed: peers[1:3], Denied: peers[2:3]}
+	assert.False(t, allowed.Permits(peers[0]))
+	assert.True(t, allowed.Permits(peers[1]))
+	assert.False(t, allowed.Permits(peers[2]))
+	assert.False(t, allowed.Permits(peers[3]))
+	assert.Equal(t, []peer.ID{peers[0], peers[2], peers[3]}, allowed.Violations(peers))
+	assert.Equal(t, peers[1:2], allowed.permitted(peers))
+}
@@ -0,0 +1,233 @@
+package p2p
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// signerStatsWindow is how many of the latest keysign outcomes of a peer the success rate is computed on
+const signerStatsWindow = 64
+
+// SignerSelection select how the leader picks the signers of a keysign when more peers than needed are ready
+type SignerSelection string
+
+const (
+	// SelectByArrival picks the peers whose requests arrived first
+	SelectByArrival SignerSelection = "arrival"
+	// SelectByRTT picks the peers with the lowest median round trip time the health monitor measured
+	SelectByRTT SignerSelection = "rtt"
+	// SelectBySuccessRate picks the peers that completed most of their recent keysigns
+	SelectBySuccessRate SignerSelection = "success-rate"
+	// SelectByPreference picks the preferred peers first, in the configured order, then by arrival
+	SelectByPreference SignerSelection = "preference"
+)
+
+// Validate check whether the signer selection is supported
+func (s SignerSelection) Validate() error {
+	switch s {
+	case SelectByArrival, SelectByRTT, SelectBySuccessRate, SelectByPreference:
+		return nil
+	}
+	return fmt.Errorf("unknown signer selection(%s), it should be one of %s, %s, %s or %s", s, SelectByArrival, SelectByRTT, SelectBySuccessRate, SelectByPreference)
+}
+
+// SignerPolicy pick the signers of a keysign among the peers that are ready, the leader excluded
+type SignerPolicy interface {
+	// Select return n of the ready peers, which are given in the order their requests arrived
+	Select(ready []peer.ID, n int) []peer.ID
+}
+
+// NewSignerPolicy create the policy of the given selection, the health monitor and the signer stats feed the
+// rtt and success-rate selections, and preferred is the list of the preference selection
+func NewSignerPolicy(selection SignerSelection, preferred []peer.ID, monitor *HealthMonitor, stats *SignerStats) (SignerPolicy, error) {
+	switch selection {
+	case "", SelectByArrival:
+		return ArrivalOrderPolicy(), nil
+	case SelectByRTT:
+		if monitor == nil {
+			return nil, errors.New("the rtt signer selection needs the health monitor")
+		}
+		return NewRTTPolicy(monitor), nil
+	case SelectBySuccessRate:
+		if stats == nil {
+			return nil, errors.New("the success-rate signer selection needs the signer stats")
+		}
+		return NewSuccessRatePolicy(stats), nil
+	case SelectByPreference:
+		if len(preferred) == 0 {
+			return nil, errors.New("the preference signer selection needs the preferred signers")
+		}
+		return NewPreferencePolicy(preferred, ArrivalOrderPolicy()), nil
+	}
+	return nil, selection.Validate()
+}
+
+// selectBy return the first n of the ready peers sorted with less, the peers less cannot tell apart keep their
+// arrival order
+func selectBy(ready []peer.ID, n int, less func(a, b peer.ID) bool) []peer.ID {
+	sorted := append([]peer.ID{}, ready...)
+	sort.SliceStable(sorted, func(i, j int) bool { return less(sorted[i], sorted[j]) })
+	if n < len(sorted) {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+type arrivalOrderPolicy struct{}
+
+// ArrivalOrderPolicy pick the peers whose requests arrived first
+func ArrivalOrderPolicy() SignerPolicy {
+	return arrivalOrderPolicy{}
+}
+
+func (arrivalOrderPolicy) Select(ready []peer.ID, n int) []peer.ID {
+	return selectBy(ready, n, func(a, b peer.ID) bool { return false })
+}
+
+type rttPolicy struct {
+	monitor *HealthMonitor
+}
+
+// NewRTTPolicy pick the peers with the lowest median round trip time, the unreachable peers and the ones the
+// monitor has not measured yet come last
+func NewRTTPolicy(monitor *HealthMonitor) SignerPolicy {
+	return rttPolicy{monitor: monitor}
+}
+
+func (p rttPolicy) Select(ready []peer.ID, n int) []peer.ID {
+	rtts := make(map[peer.ID]time.Duration, len(ready))
+	for _, el := range ready {
+		if health, ok := p.monitor.GetPeerHealth(el); ok && health.Reachable && health.RTTP50 > 0 {
+			rtts[el] = health.RTTP50
+		}
+	}
+	return selectBy(ready, n, func(a, b peer.ID) bool {
+		rttA, okA := rtts[a]
+		rttB, okB := rtts[b]
+		if okA != okB {
+			return okA
+		}
+		return rttA < rttB
+	})
+}
+
+type successRatePolicy struct {
+	stats *SignerStats
+}
+
+// NewSuccessRatePolicy pick the peers that completed most of their recent keysigns
+func NewSuccessRatePolicy(stats *SignerStats) SignerPolicy {
+	return successRatePolicy{stats: stats}
+}
+
+func (p successRatePolicy) Select(ready []peer.ID, n int) []peer.ID {
+	rates := make(map[peer.ID]float64, len(ready))
+	for _, el := range ready {
+		rates[el] = p.stats.SuccessRate(el)
+	}
+	return selectBy(ready, n, func(a, b peer.ID) bool { return rates[a] > rates[b] })
+}
+
+type preferencePolicy struct {
+	rank     map[peer.ID]int
+	fallback SignerPolicy
+}
+
+// NewPreferencePolicy pick the preferred peers first, in the given order, and the other peers with the fallback
+// policy
+func NewPreferencePolicy(preferred []peer.ID, fallback SignerPolicy) SignerPolicy {
+	rank := make(map[peer.ID]int, len(preferred))
+	for i, el := range preferred {
+		if _, ok := rank[el]; !ok {
+			rank[el] = i
+		}
+	}
+	return preferencePolicy{rank: rank, fallback: fallback}
+}
+
+func (p preferencePolicy) Select(ready []peer.ID, n int) []peer.ID {
+	var preferred, others []peer.ID
+	for _, el := range ready {
+		if _, ok := p.rank[el]; ok {
+			preferred = append(preferred, el)
+		} else {
+			others = append(others, el)
+		}
+	}
+	selected := selectBy(preferred, n, func(a, b peer.ID) bool { return p.rank[a] < p.rank[b] })
+	if len(selected) < n {
+		selected = append(selected, p.fallback.Select(others, n-len(selected))...)
+	}
+	return selected
+}
+
+type signerOutcomes struct {
+	results []bool
+	next    int
+}
+
+// SignerStats keep the outcomes of the latest keysigns of every peer
+type SignerStats struct {
+	lock     *sync.RWMutex
+	outcomes map[peer.ID]*signerOutcomes
+}
+
+// NewSignerStats create a new instance of SignerStats
+func NewSignerStats() *SignerStats {
+	return &SignerStats{
+		lock:     &sync.RWMutex{},
+		outcomes: make(map[peer.ID]*signerOutcomes),
+	}
+}
+
+// Record the outcome of a keysign, the failed peers are the ones blamed for its failure and the other signers
+// have done their part
+func (s *SignerStats) Record(signers, failed []peer.ID) {
+	blamed := make(map[peer.ID]bool, len(failed))
+	for _, el := range failed {
+		blamed[el] = true
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, el := range signers {
+		s.record(el, !blamed[el])
+	}
+}
+
+// record the outcome of the given peer, the lock must be held
+func (s *SignerStats) record(pID peer.ID, success bool) {
+	o, ok := s.outcomes[pID]
+	if !ok {
+		o = &signerOutcomes{}
+		s.outcomes[pID] = o
+	}
+	if len(o.results) < signerStatsWindow {
+		o.results = append(o.results, success)
+		return
+	}
+	o.results[o.next] = success
+	o.next = (o.next + 1) % signerStatsWindow
+}
+
+// SuccessRate return the share of the recent keysigns the peer completed, smoothed so a peer we know nothing
+// about stands at 0.5 and a single outcome does not rank a peer first or last
+func (s *SignerStats) SuccessRate(pID peer.ID) float64 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	successes := 0
+	total := 0
+	if o, ok := s.outcomes[pID]; ok {
+		total = len(o.results)
+		for _, el := range o.results {
+			if el {
+				successes++
+			}
+		}
+	}
+	return float64(successes+1) / float64(total+2)
+}
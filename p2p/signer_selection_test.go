@@ -0,0 +1,81 @@
+package p2p
+
+import (
+	"testing"
+	"time"
+
+	tnet "github.com/libp2p/go-libp2p-testing/net"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+func randomPeerIDs(t *testing.T, n int) []peer.ID {
+	var peers []peer.ID
+	for i := 0; i < n; i++ {
+		peers = append(peers, tnet.RandIdentityOrFatal(t).ID())
+	}
+	return peers
+}
+
+func TestSignerSelectionValidate(t *testing.T) {
+	for _, el := range []SignerSelection{SelectByArrival, SelectByRTT, SelectBySuccessRate, SelectByPreference} {
+		assert.Nil(t, el.Validate())
+	}
+	assert.NotNil(t, SignerSelection("fastest").Validate())
+
+	policy, err := NewSignerPolicy("", nil, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, ArrivalOrderPolicy(), policy)
+	_, err = NewSignerPolicy(SelectByRTT, nil, nil, nil)
+	assert.NotNil(t, err)
+	_, err = NewSignerPolicy(SelectBySuccessRate, nil, nil, nil)
+	assert.NotNil(t, err)
+	_, err = NewSignerPolicy(SelectByPreference, nil, nil, nil)
+	assert.NotNil(t, err)
+	_, err = NewSignerPolicy("fastest", nil, nil, nil)
+	assert.NotNil(t, err)
+}
+
+func TestSignerPolicies(t *testing.T) {
+	ready := randomPeerIDs(t, 5)
+
+	assert.Equal(t, ready[:3], ArrivalOrderPolicy().Select(ready, 3))
+	assert.Equal(t, ready, ArrivalOrderPolicy().Select(ready, 10))
+
+	// the peers the monitor has not measured or cannot reach come last
+	monitor := NewHealthMonitor(nil, nil, NetworkConfig{HealthFailureThreshold: 1})
+	monitor.record(ready[1], 30*time.Millisecond, nil)
+	monitor.record(ready[2], 10*time.Millisecond, nil)
+	monitor.record(ready[3], 20*time.Millisecond, nil)
+	monitor.record(ready[4], time.Millisecond, nil)
+	monitor.record(ready[4], 0, errLeaderUnreachable)
+	assert.Equal(t, []peer.ID{ready[2], ready[3], ready[1], ready[0]}, NewRTTPolicy(monitor).Select(ready, 4))
+
+	// the peers we know nothing about rank between the reliable and the flaky ones
+	stats := NewSignerStats()
+	stats.Record(ready[:3], ready[:1])
+	stats.Record(ready[1:4], nil)
+	assert.Equal(t, []peer.ID{ready[1], ready[2], ready[3], ready[4]}, NewSuccessRatePolicy(stats).Select(ready, 4))
+
+	// the preferred peers come in the given order, the others are picked by the fallback policy
+	preference := NewPreferencePolicy([]peer.ID{ready[4], ready[2]}, ArrivalOrderPolicy())
+	assert.Equal(t, []peer.ID{ready[4], ready[2], ready[0]}, preference.Select(ready, 3))
+	assert.Equal(t, []peer.ID{ready[4]}, preference.Select(ready, 1))
+	assert.Equal(t, []peer.ID{ready[0], ready[1]}, preference.Select(ready[:2], 2))
+}
+
+func TestSignerStats(t *testing.T) {
+	peers := randomPeerIDs(t, 2)
+	stats := NewSignerStats()
+	assert.Equal(t, 0.5, stats.SuccessRate(peers[0]))
+	stats.Record(peers, peers[1:])
+	assert.InDelta(t, 2.0/3, stats.SuccessRate(peers[0]), 1e-9)
+	assert.InDelta(t, 1.0/3, stats.SuccessRate(peers[1]), 1e-9)
+
+	// only the latest outcomes count
+	for i := 0; i < signerStatsWindow; i++ {
+		stats.Record(peers, peers[:1])
+	}
+	assert.InDelta(t, 1.0/float64(signerStatsWindow+2), stats.SuccessRate(peers[0]), 1e-9)
+	assert.InDelta(t, float64(signerStatsWindow+1)/float64(signerStatsWindow+2), stats.SuccessRate(peers[1]), 1e-9)
+}
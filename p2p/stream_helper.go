@@ -14,10 +14,7 @@ import (
 )
 
 const (
-	LengthHeader        = 4 // LengthHeader represent how many bytes we used as header
-	TimeoutReadPayload  = time.Second * 20
-	TimeoutWritePayload = time.Second * 20
-	MaxPayload          = 20000000 // 20M
+	LengthHeader = 4 // LengthHeader represent how many bytes we used as header
 )
 
 // applyDeadline will be true , and only disable it when we are doing test
@@ -73,41 +70,89 @@ func (sm *StreamMgr) AddStream(msgID string, stream network.Stream) {
 	}
 }
 
-// ReadStreamWithBuffer read data from the given stream
+// ReadStreamWithBuffer read data from the given stream with the default network configuration, compressed
+// payload is inflated transparently
 func ReadStreamWithBuffer(stream network.Stream) ([]byte, error) {
+	return ReadStreamWithConfig(stream, DefaultNetworkConfig())
+}
+
+// ReadStreamWithConfig read data from the given stream with the timeout and payload limit of the given
+// network configuration
+func ReadStreamWithConfig(stream network.Stream, conf NetworkConfig) ([]byte, error) {
+	return readFrame(stream, bufio.NewReader(stream), conf.TimeoutReadPayload, conf.MaxPayload, nil)
+}
+
+// ReadStreamWithLimiter read data from the given stream like ReadStreamWithConfig, the payload is charged to the
+// byte limit of the remote peer before we read it
+func ReadStreamWithLimiter(stream network.Stream, conf NetworkConfig, limiter *RateLimiter) ([]byte, error) {
+	return readFrame(stream, bufio.NewReader(stream), conf.TimeoutReadPayload, conf.MaxPayload, limiter)
+}
+
+// readFrame read a single frame from the reader that wraps the given stream, on a long-lived stream the same
+// reader has to be used for all the frames as it may have buffered the beginning of the next frame. The payload
+// is charged to the rate limiter, if any, and accounted on the resource manager scope of the remote peer.
+func readFrame(stream network.Stream, streamReader *bufio.Reader, timeout time.Duration, maxPayload int, limiter *RateLimiter) ([]byte, error) {
 	if ApplyDeadline {
-		if err := stream.SetReadDeadline(time.Now().Add(TimeoutReadPayload)); nil != err {
+		if err := stream.SetReadDeadline(time.Now().Add(timeout)); nil != err {
 			if errReset := stream.Reset(); errReset != nil {
 				return nil, errReset
 			}
 			return nil, err
 		}
 	}
-	streamReader := bufio.NewReader(stream)
 	lengthBytes := make([]byte, LengthHeader)
 	n, err := io.ReadFull(streamReader, lengthBytes)
 	if n != LengthHeader || err != nil {
 		return nil, fmt.Errorf("error in read the message head %w", err)
 	}
-	length := binary.LittleEndian.Uint32(lengthBytes)
-	if length > MaxPayload {
-		return nil, fmt.Errorf("payload length:%d exceed max payload length:%d", length, MaxPayload)
+	header := binary.LittleEndian.Uint32(lengthBytes)
+	compressed := header&compressedFlag != 0
+	length := header &^ compressedFlag
+	if int64(length) > int64(maxPayload) {
+		return nil, fmt.Errorf("payload length:%d exceed max payload length:%d", length, maxPayload)
+	}
+	if limiter != nil {
+		if err := limiter.AllowBytes(stream.Conn().RemotePeer(), int(length)); err != nil {
+			return nil, err
+		}
+	}
+	release, err := reserveMemory(stream, int(length))
+	if err != nil {
+		return nil, err
 	}
+	defer release()
 	dataBuf := make([]byte, length)
 	n, err = io.ReadFull(streamReader, dataBuf)
 	if uint32(n) != length || err != nil {
 		return nil, fmt.Errorf("short read err(%w), we would like to read: %d, however we only read: %d", err, length, n)
 	}
+	if compressed {
+		return decompressPayload(dataBuf, maxPayload)
+	}
 	return dataBuf, nil
 }
 
-// WriteStreamWithBuffer write the message to stream
+// WriteStreamWithBuffer write the message to stream with the default network configuration, the message is
+// compressed if the stream protocol supports it
 func WriteStreamWithBuffer(msg []byte, stream network.Stream) error {
+	return WriteStreamWithConfig(msg, stream, DefaultNetworkConfig())
+}
+
+// WriteStreamWithConfig write the message to stream with the write timeout of the given network configuration
+func WriteStreamWithConfig(msg []byte, stream network.Stream, conf NetworkConfig) error {
+	var flag uint32
+	if IsCompressionSupported(stream.Protocol()) {
+		compressed, err := compressPayload(msg)
+		if err == nil {
+			msg = compressed
+			flag = compressedFlag
+		}
+	}
 	length := uint32(len(msg))
 	lengthBytes := make([]byte, LengthHeader)
-	binary.LittleEndian.PutUint32(lengthBytes, length)
+	binary.LittleEndian.PutUint32(lengthBytes, length|flag)
 	if ApplyDeadline {
-		if err := stream.SetWriteDeadline(time.Now().Add(TimeoutWritePayload)); nil != err {
+		if err := stream.SetWriteDeadline(time.Now().Add(conf.TimeoutWritePayload)); nil != err {
 			if errReset := stream.Reset(); errReset != nil {
 				return errReset
 			}
@@ -253,3 +253,81 @@ func TestStreamManager(t *testing.T) {
 	streamMgr.ReleaseStream("3")
 	assert.Equal(t, len(streamMgr.unusedStreams), 0)
 }
+
+func TestCompressedPayload(t *testing.T) {
+	ApplyDeadline = true
+	largePayload := bytes.Repeat([]byte("tss compression "), CompressionThreshold)
+
+	stream := NewMockNetworkStream()
+	stream.protocol = TSSCompressedProtocolID
+	err := WriteStreamWithBuffer(largePayload, stream)
+	assert.Equal(t, err, nil)
+	header := binary.LittleEndian.Uint32(stream.Bytes()[:LengthHeader])
+	assert.Equal(t, header&compressedFlag, compressedFlag)
+	assert.Equal(t, int(header&^compressedFlag) < len(largePayload), true)
+	result, err := ReadStreamWithBuffer(stream)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, bytes.Equal(result, largePayload), true)
+
+	// payload below the threshold is sent as is
+	stream = NewMockNetworkStream()
+	stream.protocol = TSSCompressedProtocolID
+	assert.Equal(t, WriteStreamWithBuffer([]byte("hello world"), stream), nil)
+	header = binary.LittleEndian.Uint32(stream.Bytes()[:LengthHeader])
+	assert.Equal(t, header, uint32(len("hello world")))
+
+	// the peers that do not support compression get the raw payload
+	stream = NewMockNetworkStream()
+	stream.protocol = TSSProtocolID
+	assert.Equal(t, WriteStreamWithBuffer(largePayload, stream), nil)
+	header = binary.LittleEndian.Uint32(stream.Bytes()[:LengthHeader])
+	assert.Equal(t, header, uint32(len(largePayload)))
+	result, err = ReadStreamWithBuffer(stream)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, bytes.Equal(result, largePayload), true)
+}
+
+func TestDecompressionBomb(t *testing.T) {
+	ApplyDeadline = true
+	bomb := zstdEncoder.EncodeAll(make([]byte, DefaultMaxPayload+1), nil)
+	stream := NewMockNetworkStream()
+	buf := make([]byte, LengthHeader)
+	binary.LittleEndian.PutUint32(buf, uint32(len(bomb))|compressedFlag)
+	stream.Buffer.Write(buf)
+	stream.Buffer.Write(bomb)
+	_, err := ReadStreamWithBuffer(stream)
+	if err == nil {
+		t.Fatal("expecting the decompression bomb to be rejected")
+	}
+
+	stream = NewMockNetworkStream()
+	binary.LittleEndian.PutUint32(buf, 16|compressedFlag)
+	stream.Buffer.Write(buf)
+	stream.Buffer.Write(bytes.Repeat([]byte("a"), 16))
+	_, err = ReadStreamWithBuffer(stream)
+	if err == nil {
+		t.Fatal("expecting the corrupted compressed payload to be rejected")
+	}
+}
+
+func TestStreamWithConfiguredMaxPayload(t *testing.T) {
+	ApplyDeadline = true
+	conf := NetworkConfig{MaxPayload: 1024}.WithDefaults()
+	stream := NewMockNetworkStream()
+	assert.Equal(t, WriteStreamWithConfig(make([]byte, 1025), stream, conf), nil)
+	_, err := ReadStreamWithConfig(stream, conf)
+	assert.Equal(t, err != nil, true)
+
+	stream = NewMockNetworkStream()
+	assert.Equal(t, WriteStreamWithConfig(make([]byte, 1024), stream, conf), nil)
+	result, err := ReadStreamWithConfig(stream, conf)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(result), 1024)
+
+	// the limit applies to the inflated payload as well
+	stream = NewMockNetworkStream()
+	stream.protocol = TSSCompressedProtocolID
+	assert.Equal(t, WriteStreamWithConfig(make([]byte, CompressionThreshold), stream, conf), nil)
+	_, err = ReadStreamWithConfig(stream, conf)
+	assert.Equal(t, err != nil, true)
+}
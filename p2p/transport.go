@@ -0,0 +1,51 @@
+package p2p
+
+import (
+	"context"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	maddr "github.com/multiformats/go-multiaddr"
+
+	"github.com/ordinox/thorchain-tss/messages"
+)
+
+// StreamHost is the part of the libp2p host the party coordinator and the signature notifier use to run their
+// own protocols, a libp2p host implements it
+type StreamHost interface {
+	// ID return the peer ID of the local node
+	ID() peer.ID
+	// NewStream open a stream to the given peer on the first of the given protocols it supports
+	NewStream(ctx context.Context, p peer.ID, pids ...protocol.ID) (network.Stream, error)
+	// SetStreamHandler set the handler of the streams the peers open on the given protocol
+	SetStreamHandler(pid protocol.ID, handler network.StreamHandler)
+	// RemoveStreamHandler remove the handler of the given protocol
+	RemoveStreamHandler(pid protocol.ID)
+}
+
+// Transport carries the tss messages and the streams of the other protocols between the nodes, Communication
+// implements it over libp2p and MemoryTransport within the process
+type Transport interface {
+	StreamHost
+	// BroadcastChannel return the channel the ceremonies send their outbound tss messages to
+	BroadcastChannel() chan *messages.BroadcastMsgChan
+	// SetSubscribe deliver the inbound tss messages of the given type and msg id to the given channel
+	SetSubscribe(topic messages.THORChainTSSMessageType, msgID string, channel chan *Message)
+	// CancelSubscribe stop delivering the inbound tss messages of the given type and msg id
+	CancelSubscribe(topic messages.THORChainTSSMessageType, msgID string)
+	// ReleaseStream release the resources of the given ceremony
+	ReleaseStream(msgID string)
+	// ExportPeerAddress return the addresses we know for every peer
+	ExportPeerAddress() map[peer.ID][]maddr.Multiaddr
+	// GetConnectionGater return the connection gater that restrict the peers we talk to
+	GetConnectionGater() *ConnectionGater
+	// SetAllowedPubKeys update the allowlist of the node pub keys we talk to
+	SetAllowedPubKeys(pubKeys []string) error
+	// GetRateLimiter return the rate limiter of the inbound streams and messages
+	GetRateLimiter() *RateLimiter
+	// Stop release all the resources of the transport
+	Stop() error
+}
+
+var _ Transport = &Communication{}
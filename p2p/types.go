@@ -3,18 +3,24 @@ package p2p
 import (
 	"strings"
 
+	"github.com/libp2p/go-libp2p/core/peer"
 	maddr "github.com/multiformats/go-multiaddr"
 )
 
 // A new type we need for writing a custom flag parser
 type addrList []maddr.Multiaddr
 
+// peerList is the flag parser of a list of peer IDs
+type peerList []peer.ID
+
 // Config is configuration for P2P
 type Config struct {
 	RendezvousString string
 	Port             int
 	BootstrapPeers   addrList
+	KnownPeers       addrList // peers saved in a previous run, dialed like the bootstrap peers but not trusted
 	ExternalIP       string
+	NetworkConfig
 }
 
 // String implement fmt.Stringer
@@ -35,3 +41,22 @@ func (al *addrList) Set(value string) error {
 	*al = append(*al, addr)
 	return nil
 }
+
+// String implement fmt.Stringer
+func (pl *peerList) String() string {
+	ids := make([]string, len(*pl))
+	for i, pID := range *pl {
+		ids[i] = pID.String()
+	}
+	return strings.Join(ids, ",")
+}
+
+// Set add the given peer ID to peerList
+func (pl *peerList) Set(value string) error {
+	pID, err := peer.Decode(value)
+	if err != nil {
+		return err
+	}
+	*pl = append(*pl, pID)
+	return nil
+}
@@ -13,3 +13,12 @@ func (AddrListTestSuite) TestAddressList(c *C) {
 	c.Assert(al.Set("/ip4/127.0.0.1/tcp/6668/p2p/16Uiu2HAm1PcCAcUZd6N4RZWnbmBHjb14Hm5iE98BY6xi7R4otHCP"), IsNil)
 	c.Assert(al.String(), Equals, "/ip4/127.0.0.1/tcp/6668/p2p/16Uiu2HAm1PcCAcUZd6N4RZWnbmBHjb14Hm5iE98BY6xi7R4otHCP")
 }
+
+func (AddrListTestSuite) TestPeerList(c *C) {
+	pl := peerList{}
+	c.Assert(pl.Set("16Uiu2HAm1PcCAcUZd6N4RZWnbmBHjb14Hm5iE98BY6xi7R4otHCP"), IsNil)
+	c.Assert(pl.Set("16Uiu2HAmAWKWf5vnpiAhfdSQebTbbB3Bg35qtyG7Hr4ce23VFA8V"), IsNil)
+	c.Assert(pl.String(), Equals, "16Uiu2HAm1PcCAcUZd6N4RZWnbmBHjb14Hm5iE98BY6xi7R4otHCP,16Uiu2HAmAWKWf5vnpiAhfdSQebTbbB3Bg35qtyG7Hr4ce23VFA8V")
+	c.Assert(pl.Set("not a peer id"), NotNil)
+	c.Assert(pl, HasLen, 2)
+}
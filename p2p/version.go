@@ -0,0 +1,302 @@
+package p2p
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/golang/protobuf/proto"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	msmux "github.com/multiformats/go-multistream"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"github.com/ordinox/thorchain-tss/messages"
+)
+
+const (
+	// ProtocolVersion is the version of our p2p protocols, it is appended to their protocol ids
+	ProtocolVersion = "1.0.0"
+	// versionTTL is how long we trust the version a peer told us before we ask again
+	versionTTL = time.Minute * 10
+	// handshakeTimeout is how long we wait to open the handshake stream to a peer
+	handshakeTimeout = time.Second * 4
+	// legacyVersion is the version we assume for the peers that predate the handshake
+	legacyVersion = messages.PROTOBUFWIREVERSION
+)
+
+// HandshakeProtocolID is the protocol the peers exchange the versions and features they support on
+var HandshakeProtocolID = VersionedProtocolID("/p2p/handshake")
+
+// VersionedProtocolID return the given protocol id at our protocol version
+func VersionedProtocolID(pID protocol.ID) protocol.ID {
+	return protocol.ID(fmt.Sprintf("%s/%s", pID, ProtocolVersion))
+}
+
+// WithLegacyProtocolIDs return the versioned ids of the given protocols followed by their unversioned ids, the
+// peers that predate the versioned ids only accept the latter, so we register and propose both
+func WithLegacyProtocolIDs(pIDs ...protocol.ID) []protocol.ID {
+	ret := make([]protocol.ID, 0, len(pIDs)*2)
+	for _, el := range pIDs {
+		ret = append(ret, VersionedProtocolID(el))
+	}
+	return append(ret, pIDs...)
+}
+
+// baseProtocolID strip our protocol version off the given protocol id
+func baseProtocolID(pID protocol.ID) protocol.ID {
+	return protocol.ID(strings.TrimSuffix(string(pID), "/"+ProtocolVersion))
+}
+
+// PeerVersion is the version of the tss protocol a peer supports and its features
+type PeerVersion struct {
+	PeerID   peer.ID  `json:"peer_id"`
+	Version  string   `json:"version"`
+	Features []string `json:"features"`
+	// Legacy is set when the peer predates the handshake, its version is assumed
+	Legacy    bool      `json:"legacy"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ErrVersionMismatch is returned when some peers of a ceremony do not support the version it requires
+type ErrVersionMismatch struct {
+	Required string
+	// Lagging are the peers that only support an older version
+	Lagging []PeerVersion
+}
+
+func (e ErrVersionMismatch) Error() string {
+	lagging := make([]string, len(e.Lagging))
+	for i, el := range e.Lagging {
+		lagging[i] = fmt.Sprintf("%s(%s)", el.PeerID, el.Version)
+	}
+	return fmt.Sprintf("version %s is not supported by the lagging peers: %s", e.Required, strings.Join(lagging, ", "))
+}
+
+// VersionNegotiator exchange the supported versions with the peers and decide the version of a ceremony
+type VersionNegotiator struct {
+	logger      zerolog.Logger
+	host        StreamHost
+	conf        NetworkConfig
+	local       PeerVersion
+	rateLimiter *RateLimiter
+	lock        *sync.Mutex
+	peers       map[peer.ID]PeerVersion
+}
+
+// NewVersionNegotiator create a new instance of VersionNegotiator that announces our current version
+func NewVersionNegotiator(host StreamHost, conf NetworkConfig) *VersionNegotiator {
+	vn := &VersionNegotiator{
+		logger: log.With().Str("module", "version_negotiator").Logger(),
+		host:   host,
+		conf:   conf.WithDefaults(),
+		local: PeerVersion{
+			PeerID:   host.ID(),
+			Version:  messages.CURRENTVERSION,
+			Features: messages.Features(messages.CURRENTVERSION),
+		},
+		lock:  &sync.Mutex{},
+		peers: make(map[peer.ID]PeerVersion),
+	}
+	host.SetStreamHandler(HandshakeProtocolID, vn.handleStream)
+	return vn
+}
+
+// SetRateLimiter set the limiter that bounds the handshake streams the peers open
+func (vn *VersionNegotiator) SetRateLimiter(limiter *RateLimiter) {
+	vn.rateLimiter = limiter
+}
+
+// Stop handling the handshakes of the peers
+func (vn *VersionNegotiator) Stop() {
+	vn.host.RemoveStreamHandler(HandshakeProtocolID)
+}
+
+func (vn *VersionNegotiator) handleStream(stream network.Stream) {
+	remotePeer := stream.Conn().RemotePeer()
+	logger := vn.logger.With().Str("remote peer", remotePeer.String()).Logger()
+	if err := vn.rateLimiter.AllowStream(remotePeer); err != nil {
+		logger.Warn().Err(err).Msg("drop the handshake stream")
+		_ = stream.Reset()
+		return
+	}
+	defer func() {
+		if err := stream.Close(); err != nil {
+			logger.Error().Err(err).Msg("fail to close the handshake stream")
+		}
+	}()
+	payload, err := ReadStreamWithLimiter(stream, vn.conf, vn.rateLimiter)
+	if err != nil {
+		logger.Error().Err(err).Msg("fail to read the handshake")
+		return
+	}
+	if _, err := vn.record(remotePeer, payload); err != nil {
+		logger.Error().Err(err).Msg("fail to record the handshake")
+		return
+	}
+	if err := vn.writeHandshake(stream); err != nil {
+		logger.Error().Err(err).Msg("fail to answer the handshake")
+	}
+}
+
+func (vn *VersionNegotiator) writeHandshake(stream network.Stream) error {
+	buf, err := proto.Marshal(&messages.HandshakeMsg{
+		Version:  vn.local.Version,
+		Features: vn.local.Features,
+	})
+	if err != nil {
+		return fmt.Errorf("fail to marshal the handshake: %w", err)
+	}
+	return WriteStreamWithConfig(buf, stream, vn.conf)
+}
+
+// record save the version of the peer from its handshake
+func (vn *VersionNegotiator) record(pID peer.ID, payload []byte) (PeerVersion, error) {
+	var msg messages.HandshakeMsg
+	if err := proto.Unmarshal(payload, &msg); err != nil {
+		return PeerVersion{}, fmt.Errorf("fail to unmarshal the handshake: %w", err)
+	}
+	if _, err := semver.Make(msg.Version); err != nil {
+		return PeerVersion{}, fmt.Errorf("invalid version(%s): %w", msg.Version, err)
+	}
+	pv := PeerVersion{
+		PeerID:    pID,
+		Version:   msg.Version,
+		Features:  msg.Features,
+		UpdatedAt: time.Now(),
+	}
+	vn.lock.Lock()
+	defer vn.lock.Unlock()
+	vn.peers[pID] = pv
+	return pv, nil
+}
+
+// handshake tell the peer our version and record its own, the peers that do not know the handshake protocol
+// are recorded with the legacy version
+func (vn *VersionNegotiator) handshake(pID peer.ID) (PeerVersion, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), handshakeTimeout)
+	defer cancel()
+	stream, err := vn.host.NewStream(network.WithAllowLimitedConn(ctx, "handshake"), pID, HandshakeProtocolID)
+	if err != nil {
+		if !errors.Is(err, msmux.ErrNotSupported[protocol.ID]{}) {
+			return PeerVersion{}, fmt.Errorf("fail to create stream to peer(%s): %w", pID, err)
+		}
+		pv := PeerVersion{
+			PeerID:    pID,
+			Version:   legacyVersion,
+			Features:  messages.Features(legacyVersion),
+			Legacy:    true,
+			UpdatedAt: time.Now(),
+		}
+		vn.lock.Lock()
+		defer vn.lock.Unlock()
+		vn.peers[pID] = pv
+		return pv, nil
+	}
+	defer func() {
+		if err := stream.Close(); err != nil {
+			vn.logger.Error().Err(err).Msg("fail to close the handshake stream")
+		}
+	}()
+	if err := vn.writeHandshake(stream); err != nil {
+		return PeerVersion{}, fmt.Errorf("fail to send the handshake to peer(%s): %w", pID, err)
+	}
+	payload, err := ReadStreamWithConfig(stream, vn.conf)
+	if err != nil {
+		return PeerVersion{}, fmt.Errorf("fail to read the handshake of peer(%s): %w", pID, err)
+	}
+	return vn.record(pID, payload)
+}
+
+// GetPeerVersion return the version of the given peer, a handshake is made if we do not know it or it is stale
+func (vn *VersionNegotiator) GetPeerVersion(pID peer.ID) (PeerVersion, error) {
+	if pID == vn.local.PeerID {
+		return vn.local, nil
+	}
+	vn.lock.Lock()
+	pv, ok := vn.peers[pID]
+	vn.lock.Unlock()
+	if ok && time.Since(pv.UpdatedAt) < versionTTL {
+		return pv, nil
+	}
+	return vn.handshake(pID)
+}
+
+// GetPeerVersions return the versions of all the peers we had a handshake with
+func (vn *VersionNegotiator) GetPeerVersions() []PeerVersion {
+	vn.lock.Lock()
+	defer vn.lock.Unlock()
+	ret := make([]PeerVersion, 0, len(vn.peers))
+	for _, el := range vn.peers {
+		ret = append(ret, el)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].PeerID < ret[j].PeerID
+	})
+	return ret
+}
+
+// Negotiate return the version of the ceremony among the given peers, it is the required version if every peer
+// supports it, or the highest version they have in common if none is required. ErrVersionMismatch names the
+// peers that do not support the required version, we are one of them if we lag ourselves. The peers we
+// cannot reach are left out, the join party finds them anyway.
+func (vn *VersionNegotiator) Negotiate(peers []peer.ID, required string) (string, error) {
+	var requiredVer semver.Version
+	if len(required) != 0 {
+		var err error
+		requiredVer, err = semver.Make(required)
+		if err != nil {
+			return "", fmt.Errorf("invalid version(%s): %w", required, err)
+		}
+	}
+	versions := []PeerVersion{vn.local}
+	lock := &sync.Mutex{}
+	wg := &sync.WaitGroup{}
+	for _, el := range peers {
+		if el == vn.local.PeerID {
+			continue
+		}
+		wg.Add(1)
+		go func(pID peer.ID) {
+			defer wg.Done()
+			pv, err := vn.GetPeerVersion(pID)
+			if err != nil {
+				vn.logger.Warn().Err(err).Msgf("fail to get the version of peer(%s)", pID)
+				return
+			}
+			lock.Lock()
+			defer lock.Unlock()
+			versions = append(versions, pv)
+		}(el)
+	}
+	wg.Wait()
+
+	commonVer := semver.MustParse(vn.local.Version)
+	var lagging []PeerVersion
+	for _, el := range versions {
+		v := semver.MustParse(el.Version)
+		if v.LT(commonVer) {
+			commonVer = v
+		}
+		if len(required) != 0 && v.LT(requiredVer) {
+			lagging = append(lagging, el)
+		}
+	}
+	if len(lagging) != 0 {
+		sort.Slice(lagging, func(i, j int) bool {
+			return lagging[i].PeerID < lagging[j].PeerID
+		})
+		return "", ErrVersionMismatch{Required: required, Lagging: lagging}
+	}
+	if len(required) != 0 {
+		return required, nil
+	}
+	return commonVer.String(), nil
+}
@@ -0,0 +1,91 @@
+package p2p
+
+import (
+	"errors"
+	"testing"
+
+	tnet "github.com/libp2p/go-libp2p-testing/net"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ordinox/thorchain-tss/messages"
+)
+
+func TestVersionedProtocolID(t *testing.T) {
+	assert.Equal(t, protocol.ID("/p2p/tss/stream/"+ProtocolVersion), VersionedProtocolID(TSSStreamProtocolID))
+	assert.Equal(t, []protocol.ID{
+		VersionedProtocolID(TSSStreamProtocolID),
+		VersionedProtocolID(TSSProtocolID),
+		TSSStreamProtocolID,
+		TSSProtocolID,
+	}, WithLegacyProtocolIDs(TSSStreamProtocolID, TSSProtocolID))
+	assert.Equal(t, TSSProtocolID, baseProtocolID(VersionedProtocolID(TSSProtocolID)))
+	assert.Equal(t, TSSProtocolID, baseProtocolID(TSSProtocolID))
+	assert.True(t, isPersistentProtocol(VersionedProtocolID(TSSStreamProtocolID)))
+	assert.False(t, isPersistentProtocol(VersionedProtocolID(TSSProtocolID)))
+	assert.True(t, IsCompressionSupported(VersionedProtocolID(TSSCompressedProtocolID)))
+	assert.False(t, IsCompressionSupported(VersionedProtocolID(TSSStreamProtocolID)))
+}
+
+func TestVersionNegotiator(t *testing.T) {
+	ApplyDeadline = false
+	hosts := setupHostsLocally(t, 4)
+	negotiators := make([]*VersionNegotiator, 3)
+	for i := range negotiators {
+		negotiators[i] = NewVersionNegotiator(hosts[i], NetworkConfig{})
+		defer negotiators[i].Stop()
+	}
+	// the third node runs an older version, the fourth one predates the handshake
+	negotiators[2].local.Version = messages.NEWJOINPARTYVERSION
+	negotiators[2].local.Features = messages.Features(messages.NEWJOINPARTYVERSION)
+	peers := []peer.ID{hosts[0].ID(), hosts[1].ID()}
+
+	version, err := negotiators[0].Negotiate(peers, "")
+	assert.Nil(t, err)
+	assert.Equal(t, messages.CURRENTVERSION, version)
+	// the handshake records the version on both sides
+	pv, err := negotiators[1].GetPeerVersion(hosts[0].ID())
+	assert.Nil(t, err)
+	assert.Equal(t, messages.CURRENTVERSION, pv.Version)
+	assert.Equal(t, messages.Features(messages.CURRENTVERSION), pv.Features)
+	assert.False(t, pv.Legacy)
+
+	version, err = negotiators[0].Negotiate(append(peers, hosts[3].ID()), "")
+	assert.Nil(t, err)
+	assert.Equal(t, messages.PROTOBUFWIREVERSION, version)
+	pv, err = negotiators[0].GetPeerVersion(hosts[3].ID())
+	assert.Nil(t, err)
+	assert.True(t, pv.Legacy)
+	assert.Equal(t, []string{messages.FeatureLeaderJoinParty, messages.FeatureProtobufWire}, pv.Features)
+
+	// the required version is kept if everyone supports it
+	version, err = negotiators[0].Negotiate(append(peers, hosts[3].ID()), messages.NEWJOINPARTYVERSION)
+	assert.Nil(t, err)
+	assert.Equal(t, messages.NEWJOINPARTYVERSION, version)
+
+	_, err = negotiators[0].Negotiate(append(peers, hosts[2].ID(), hosts[3].ID()), messages.CURRENTVERSION)
+	var mismatch ErrVersionMismatch
+	assert.True(t, errors.As(err, &mismatch))
+	assert.Equal(t, messages.CURRENTVERSION, mismatch.Required)
+	var lagging []peer.ID
+	for _, el := range mismatch.Lagging {
+		lagging = append(lagging, el.PeerID)
+	}
+	assert.ElementsMatch(t, []peer.ID{hosts[2].ID(), hosts[3].ID()}, lagging)
+	assert.ErrorContains(t, err, hosts[2].ID().String()+"("+messages.NEWJOINPARTYVERSION+")")
+	assert.ErrorContains(t, err, hosts[3].ID().String()+"("+messages.PROTOBUFWIREVERSION+")")
+
+	// we lag ourselves
+	_, err = negotiators[2].Negotiate(peers, messages.CURRENTVERSION)
+	assert.ErrorContains(t, err, hosts[2].ID().String())
+
+	// the peers we cannot reach are left out
+	version, err = negotiators[0].Negotiate(append(peers, tnet.RandIdentityOrFatal(t).ID()), "")
+	assert.Nil(t, err)
+	assert.Equal(t, messages.CURRENTVERSION, version)
+
+	_, err = negotiators[0].Negotiate(peers, "invalid")
+	assert.NotNil(t, err)
+	assert.Len(t, negotiators[0].GetPeerVersions(), 3)
+}
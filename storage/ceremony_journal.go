@@ -0,0 +1,211 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/ordinox/thorchain-tss/blame"
+)
+
+const (
+	// CeremonyKeygen marks a journal entry produced by a keygen
+	CeremonyKeygen = "keygen"
+	// CeremonyKeysign marks a journal entry produced by a keysign
+	CeremonyKeysign = "keysign"
+
+	journalFileName = "ceremony_journal.log"
+)
+
+// CeremonySignature is the signature produced by a keysign ceremony
+type CeremonySignature struct {
+	Msg        string `json:"signed_msg"`
+	R          string `json:"r"`
+	S          string `json:"s"`
+	RecoveryID string `json:"recovery_id"`
+}
+
+// CeremonyRecord is the journal entry we keep for every keygen/keysign ceremony
+type CeremonyRecord struct {
+	MsgID       string              `json:"msg_id"`
+	Type        string              `json:"type"`
+	RequestHash string              `json:"request_hash"`
+	PoolPubKey  string              `json:"pool_pub_key"`
+	Signers     []string            `json:"signers"`
+	Leader      string              `json:"leader"`
+	StartTime   time.Time           `json:"start_time"`
+	Duration    time.Duration       `json:"duration"`
+	Status      string              `json:"status"`
+	Error       string              `json:"error,omitempty"`
+	Blame       blame.Blame         `json:"blame"`
+	Signatures  []CeremonySignature `json:"signatures,omitempty"`
+}
+
+// CeremonyFilter select the journal entries we would like to read back, empty fields match everything
+type CeremonyFilter struct {
+	MsgID      string
+	Type       string
+	PoolPubKey string
+	Signer     string
+	Status     string
+	From       time.Time
+	To         time.Time
+	Limit      int
+}
+
+// Match return true if the given record satisfies all the conditions of the filter
+func (f CeremonyFilter) Match(record CeremonyRecord) bool {
+	if len(f.MsgID) != 0 && f.MsgID != record.MsgID {
+		return false
+	}
+	if len(f.Type) != 0 && f.Type != record.Type {
+		return false
+	}
+	if len(f.PoolPubKey) != 0 && f.PoolPubKey != record.PoolPubKey {
+		return false
+	}
+	if len(f.Status) != 0 && f.Status != record.Status {
+		return false
+	}
+	if !f.From.IsZero() && record.StartTime.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && record.StartTime.After(f.To) {
+		return false
+	}
+	if len(f.Signer) != 0 {
+		for _, el := range record.Signers {
+			if el == f.Signer {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func (fsm *FileStateMgr) getJournalFilePathName() string {
+	if len(fsm.folder) > 0 {
+		return filepath.Join(fsm.folder, journalFileName)
+	}
+	return journalFileName
+}
+
+// SaveCeremonyRecord append the given record to the ceremony journal
+func (fsm *FileStateMgr) SaveCeremonyRecord(record CeremonyRecord) error {
+	buf, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("fail to marshal CeremonyRecord to json: %w", err)
+	}
+	buf = append(buf, '\n')
+	fsm.writeLock.Lock()
+	defer fsm.writeLock.Unlock()
+	f, err := os.OpenFile(fsm.getJournalFilePathName(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o655)
+	if err != nil {
+		return fmt.Errorf("fail to open the ceremony journal: %w", err)
+	}
+	if _, err := f.Write(buf); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("fail to append to the ceremony journal: %w", err)
+	}
+	return f.Close()
+}
+
+// readCeremonyRecords load all the records from the journal, the caller should hold the lock
+func (fsm *FileStateMgr) readCeremonyRecords() ([]CeremonyRecord, error) {
+	input, err := ioutil.ReadFile(fsm.getJournalFilePathName())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var records []CeremonyRecord
+	scanner := bufio.NewScanner(bytes.NewReader(input))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(input)+1)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		// we skip the empty entry
+		if len(line) == 0 {
+			continue
+		}
+		var record CeremonyRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return nil, fmt.Errorf("invalid record in ceremony journal: %w", err)
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+// GetCeremonyRecords return the journal entries that match the filter, the latest first
+func (fsm *FileStateMgr) GetCeremonyRecords(filter CeremonyFilter) ([]CeremonyRecord, error) {
+	fsm.writeLock.RLock()
+	records, err := fsm.readCeremonyRecords()
+	fsm.writeLock.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	result := []CeremonyRecord{}
+	for i := len(records) - 1; i >= 0; i-- {
+		if !filter.Match(records[i]) {
+			continue
+		}
+		result = append(result, records[i])
+		if filter.Limit > 0 && len(result) >= filter.Limit {
+			break
+		}
+	}
+	return result, nil
+}
+
+// PruneCeremonyRecords apply the retention policy to the journal, it removes the records started before the given
+// time and keeps at most maxEntries of the latest records. maxEntries of 0 means no limit.
+func (fsm *FileStateMgr) PruneCeremonyRecords(before time.Time, maxEntries int) error {
+	fsm.writeLock.Lock()
+	defer fsm.writeLock.Unlock()
+	records, err := fsm.readCeremonyRecords()
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].StartTime.Before(records[j].StartTime)
+	})
+	kept := records[:0]
+	for _, el := range records {
+		if !before.IsZero() && el.StartTime.Before(before) {
+			continue
+		}
+		kept = append(kept, el)
+	}
+	if maxEntries > 0 && len(kept) > maxEntries {
+		kept = kept[len(kept)-maxEntries:]
+	}
+	if len(kept) == len(records) {
+		return nil
+	}
+	var buf bytes.Buffer
+	for _, el := range kept {
+		line, err := json.Marshal(el)
+		if err != nil {
+			return fmt.Errorf("fail to marshal CeremonyRecord to json: %w", err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	filePathName := fsm.getJournalFilePathName()
+	tmpFile := filePathName + ".tmp"
+	if err := ioutil.WriteFile(tmpFile, buf.Bytes(), 0o655); err != nil {
+		return fmt.Errorf("fail to write the pruned ceremony journal: %w", err)
+	}
+	return os.Rename(tmpFile, filePathName)
+}
@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/ordinox/thorchain-tss/blame"
+)
+
+type CeremonyJournalTestSuite struct{}
+
+var _ = Suite(&CeremonyJournalTestSuite{})
+
+func (s *CeremonyJournalTestSuite) TestCeremonyFilter(c *C) {
+	record := CeremonyRecord{
+		MsgID:      "msg1",
+		Type:       CeremonyKeysign,
+		PoolPubKey: "pool",
+		Signers:    []string{"A", "B", "C"},
+		StartTime:  time.Unix(1000, 0),
+		Status:     "success",
+	}
+	c.Assert(CeremonyFilter{}.Match(record), Equals, true)
+	c.Assert(CeremonyFilter{MsgID: "msg1", Type: CeremonyKeysign, PoolPubKey: "pool"}.Match(record), Equals, true)
+	c.Assert(CeremonyFilter{MsgID: "msg2"}.Match(record), Equals, false)
+	c.Assert(CeremonyFilter{Type: CeremonyKeygen}.Match(record), Equals, false)
+	c.Assert(CeremonyFilter{Status: "fail"}.Match(record), Equals, false)
+	c.Assert(CeremonyFilter{Signer: "B"}.Match(record), Equals, true)
+	c.Assert(CeremonyFilter{Signer: "D"}.Match(record), Equals, false)
+	c.Assert(CeremonyFilter{From: time.Unix(999, 0), To: time.Unix(1001, 0)}.Match(record), Equals, true)
+	c.Assert(CeremonyFilter{From: time.Unix(1001, 0)}.Match(record), Equals, false)
+	c.Assert(CeremonyFilter{To: time.Unix(999, 0)}.Match(record), Equals, false)
+}
+
+func (s *CeremonyJournalTestSuite) TestSaveCeremonyRecord(c *C) {
+	f := filepath.Join(os.TempDir(), "test-journal")
+	defer func() {
+		err := os.RemoveAll(f)
+		c.Assert(err, IsNil)
+	}()
+	fsm, err := NewFileStateMgr(f)
+	c.Assert(err, IsNil)
+	records, err := fsm.GetCeremonyRecords(CeremonyFilter{})
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 0)
+
+	start := time.Now().Add(-time.Hour)
+	for i := 0; i < 5; i++ {
+		record := CeremonyRecord{
+			MsgID:     "msg" + string(rune('0'+i)),
+			Type:      CeremonyKeysign,
+			StartTime: start.Add(time.Duration(i) * time.Minute),
+			Status:    "success",
+			Blame:     blame.NewBlame(blame.TssTimeout, []blame.Node{blame.NewNode("A", nil, nil)}),
+			Signatures: []CeremonySignature{
+				{Msg: "msg", R: "r", S: "s", RecoveryID: "v"},
+			},
+		}
+		if i%2 == 1 {
+			record.Status = "fail"
+		}
+		c.Assert(fsm.SaveCeremonyRecord(record), IsNil)
+	}
+	records, err = fsm.GetCeremonyRecords(CeremonyFilter{})
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 5)
+	// the latest record comes first
+	c.Assert(records[0].MsgID, Equals, "msg4")
+	c.Assert(records[4].MsgID, Equals, "msg0")
+	c.Assert(records[0].Blame.FailReason, Equals, blame.TssTimeout)
+	c.Assert(records[0].Signatures, HasLen, 1)
+
+	records, err = fsm.GetCeremonyRecords(CeremonyFilter{Status: "fail"})
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 2)
+	records, err = fsm.GetCeremonyRecords(CeremonyFilter{Limit: 2})
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 2)
+	c.Assert(records[1].MsgID, Equals, "msg3")
+
+	// drop the records older than the third one
+	c.Assert(fsm.PruneCeremonyRecords(start.Add(2*time.Minute), 0), IsNil)
+	records, err = fsm.GetCeremonyRecords(CeremonyFilter{})
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 3)
+	c.Assert(records[2].MsgID, Equals, "msg2")
+
+	// keep at most 2 records
+	c.Assert(fsm.PruneCeremonyRecords(time.Time{}, 2), IsNil)
+	records, err = fsm.GetCeremonyRecords(CeremonyFilter{})
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 2)
+	c.Assert(records[1].MsgID, Equals, "msg3")
+
+	// the journal is still appendable after pruning
+	c.Assert(fsm.SaveCeremonyRecord(CeremonyRecord{MsgID: "msg5", StartTime: time.Now()}), IsNil)
+	records, err = fsm.GetCeremonyRecords(CeremonyFilter{})
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 3)
+	c.Assert(records[0].MsgID, Equals, "msg5")
+}
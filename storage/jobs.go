@@ -0,0 +1,167 @@
+package storage
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// JobRunning marks a job whose ceremony has not finished yet
+	JobRunning = "running"
+	// JobDone marks a job whose ceremony finished, the result holds its response with the status and the blame
+	JobDone = "done"
+	// JobFailed marks a job whose ceremony could not run or did not finish, the error tells why
+	JobFailed = "failed"
+
+	jobsFolderName = "jobs"
+	jobFileExt     = ".json"
+)
+
+// ErrJobNotFound is returned when there is no job with the given id
+var ErrJobNotFound = errors.New("job not found")
+
+// JobRecord is the state of a keygen/keysign the caller polls for instead of waiting for the ceremony
+type JobRecord struct {
+	ID                string          `json:"id"`
+	Type              string          `json:"type"`
+	MsgID             string          `json:"msg_id"`
+	Phase             string          `json:"phase"`
+	Request           json.RawMessage `json:"request"`
+	Result            json.RawMessage `json:"result,omitempty"`
+	Error             string          `json:"error,omitempty"`
+	CallbackURL       string          `json:"callback_url,omitempty"`
+	CallbackDelivered bool            `json:"callback_delivered"`
+	CreatedAt         time.Time       `json:"created_at"`
+	UpdatedAt         time.Time       `json:"updated_at"`
+}
+
+// Finished tell whether the job is done or failed
+func (j JobRecord) Finished() bool {
+	return j.Phase == JobDone || j.Phase == JobFailed
+}
+
+// ValidateJobID check the job id is the hex string we generate, so it is safe to use as a file name
+func ValidateJobID(id string) error {
+	if len(id) == 0 {
+		return errors.New("empty job id")
+	}
+	if _, err := hex.DecodeString(id); err != nil {
+		return fmt.Errorf("invalid job id(%s): %w", id, err)
+	}
+	return nil
+}
+
+func (fsm *FileStateMgr) getJobsFolder() string {
+	if len(fsm.folder) > 0 {
+		return filepath.Join(fsm.folder, jobsFolderName)
+	}
+	return jobsFolderName
+}
+
+// SaveJob write the given job, it replaces the previous state of the job
+func (fsm *FileStateMgr) SaveJob(job JobRecord) error {
+	if err := ValidateJobID(job.ID); err != nil {
+		return err
+	}
+	buf, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("fail to marshal JobRecord to json: %w", err)
+	}
+	fsm.writeLock.Lock()
+	defer fsm.writeLock.Unlock()
+	folder := fsm.getJobsFolder()
+	if err := os.MkdirAll(folder, os.ModePerm); err != nil {
+		return fmt.Errorf("fail to create the jobs folder: %w", err)
+	}
+	filePathName := filepath.Join(folder, job.ID+jobFileExt)
+	tmpFile := filePathName + ".tmp"
+	if err := ioutil.WriteFile(tmpFile, buf, 0o655); err != nil {
+		return fmt.Errorf("fail to write the job: %w", err)
+	}
+	return os.Rename(tmpFile, filePathName)
+}
+
+// GetJob return the job of the given id, or ErrJobNotFound
+func (fsm *FileStateMgr) GetJob(id string) (JobRecord, error) {
+	if err := ValidateJobID(id); err != nil {
+		return JobRecord{}, err
+	}
+	fsm.writeLock.RLock()
+	defer fsm.writeLock.RUnlock()
+	return fsm.readJob(filepath.Join(fsm.getJobsFolder(), id+jobFileExt))
+}
+
+func (fsm *FileStateMgr) readJob(filePathName string) (JobRecord, error) {
+	buf, err := ioutil.ReadFile(filePathName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return JobRecord{}, ErrJobNotFound
+		}
+		return JobRecord{}, err
+	}
+	var job JobRecord
+	if err := json.Unmarshal(buf, &job); err != nil {
+		return JobRecord{}, fmt.Errorf("invalid job file(%s): %w", filePathName, err)
+	}
+	return job, nil
+}
+
+// readJobs load all the jobs, the oldest first, the caller should hold the lock
+func (fsm *FileStateMgr) readJobs() ([]JobRecord, error) {
+	entries, err := ioutil.ReadDir(fsm.getJobsFolder())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var jobs []JobRecord
+	for _, el := range entries {
+		if el.IsDir() || !strings.HasSuffix(el.Name(), jobFileExt) {
+			continue
+		}
+		job, err := fsm.readJob(filepath.Join(fsm.getJobsFolder(), el.Name()))
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+	})
+	return jobs, nil
+}
+
+// GetJobs return all the jobs, the oldest first
+func (fsm *FileStateMgr) GetJobs() ([]JobRecord, error) {
+	fsm.writeLock.RLock()
+	defer fsm.writeLock.RUnlock()
+	return fsm.readJobs()
+}
+
+// PruneJobs remove the finished jobs that have not been updated since the given time
+func (fsm *FileStateMgr) PruneJobs(before time.Time) error {
+	fsm.writeLock.Lock()
+	defer fsm.writeLock.Unlock()
+	jobs, err := fsm.readJobs()
+	if err != nil {
+		return err
+	}
+	for _, el := range jobs {
+		if !el.Finished() || !el.UpdatedAt.Before(before) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(fsm.getJobsFolder(), el.ID+jobFileExt)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("fail to remove the job(%s): %w", el.ID, err)
+		}
+	}
+	return nil
+}
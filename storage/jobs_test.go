@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type JobsTestSuite struct{}
+
+var _ = Suite(&JobsTestSuite{})
+
+func (s *JobsTestSuite) TestValidateJobID(c *C) {
+	c.Assert(ValidateJobID("0123abcd"), IsNil)
+	c.Assert(ValidateJobID(""), NotNil)
+	c.Assert(ValidateJobID("../ceremony_journal"), NotNil)
+	c.Assert(ValidateJobID("whatever"), NotNil)
+}
+
+func (s *JobsTestSuite) TestSaveJob(c *C) {
+	f := filepath.Join(os.TempDir(), "test-jobs")
+	defer func() {
+		err := os.RemoveAll(f)
+		c.Assert(err, IsNil)
+	}()
+	fsm, err := NewFileStateMgr(f)
+	c.Assert(err, IsNil)
+	jobs, err := fsm.GetJobs()
+	c.Assert(err, IsNil)
+	c.Assert(jobs, HasLen, 0)
+	_, err = fsm.GetJob("0a")
+	c.Assert(err, Equals, ErrJobNotFound)
+
+	start := time.Now().Add(-time.Hour)
+	for i := 0; i < 3; i++ {
+		job := JobRecord{
+			ID:        "0" + string(rune('a'+i)),
+			Type:      CeremonyKeysign,
+			Phase:     JobRunning,
+			Request:   json.RawMessage(`{"pool_pub_key":"pool"}`),
+			CreatedAt: start.Add(time.Duration(i) * time.Minute),
+			UpdatedAt: start.Add(time.Duration(i) * time.Minute),
+		}
+		c.Assert(fsm.SaveJob(job), IsNil)
+	}
+	c.Assert(fsm.SaveJob(JobRecord{ID: "../escape"}), NotNil)
+
+	// a job is replaced by its latest state
+	job, err := fsm.GetJob("0a")
+	c.Assert(err, IsNil)
+	c.Assert(job.Finished(), Equals, false)
+	job.Phase = JobDone
+	job.Result = json.RawMessage(`{"status":1}`)
+	c.Assert(fsm.SaveJob(job), IsNil)
+	job, err = fsm.GetJob("0a")
+	c.Assert(err, IsNil)
+	c.Assert(job.Finished(), Equals, true)
+	c.Assert(string(job.Result), Equals, `{"status":1}`)
+	c.Assert(string(job.Request), Equals, `{"pool_pub_key":"pool"}`)
+
+	jobs, err = fsm.GetJobs()
+	c.Assert(err, IsNil)
+	c.Assert(jobs, HasLen, 3)
+	c.Assert(jobs[0].ID, Equals, "0a")
+	c.Assert(jobs[2].ID, Equals, "0c")
+
+	// only the finished jobs are pruned
+	c.Assert(fsm.PruneJobs(time.Now()), IsNil)
+	jobs, err = fsm.GetJobs()
+	c.Assert(err, IsNil)
+	c.Assert(jobs, HasLen, 2)
+	c.Assert(jobs[0].ID, Equals, "0b")
+}
@@ -10,9 +10,11 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/libp2p/go-libp2p/core/peer"
 	ma "github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
 	"github.com/ordinox/thorchain-tss-lib/ecdsa/keygen"
 
 	"github.com/ordinox/thorchain-tss/conversion"
@@ -33,6 +35,13 @@ type LocalStateManager interface {
 	GetLocalState(pubKey string) (KeygenLocalState, error)
 	SaveAddressBook(addressBook map[peer.ID][]ma.Multiaddr) error
 	RetrieveP2PAddresses() ([]ma.Multiaddr, error)
+	SaveCeremonyRecord(record CeremonyRecord) error
+	GetCeremonyRecords(filter CeremonyFilter) ([]CeremonyRecord, error)
+	PruneCeremonyRecords(before time.Time, maxEntries int) error
+	SaveJob(job JobRecord) error
+	GetJob(id string) (JobRecord, error)
+	GetJobs() ([]JobRecord, error)
+	PruneJobs(before time.Time) error
 }
 
 // FileStateMgr save the local state to file
@@ -119,8 +128,9 @@ func (fsm *FileStateMgr) SaveAddressBook(address map[peer.ID][]ma.Multiaddr) err
 
 	for peer, addrs := range address {
 		for _, addr := range addrs {
-			// we do not save the loopback addr
-			if strings.Contains(addr.String(), "127.0.0.1") {
+			// we do not save the loopback and link local addrs of any address family, they cannot be dialed from
+			// another host
+			if manet.IsIPLoopback(addr) || manet.IsIP6LinkLocal(addr) {
 				continue
 			}
 			record := addr.String() + "/p2p/" + peer.String() + "\n"
@@ -85,6 +85,13 @@ func (s *FileStateMgrTestSuite) TestSaveAddressBook(c *C) {
 	for _, each := range peers {
 		testAddresses[each] = []maddr.Multiaddr{mockAddr}
 	}
+	// the addresses of every family are saved, except the loopback and link local ones
+	testAddresses[id1.ID()] = append(testAddresses[id1.ID()],
+		maddr.StringCast("/ip6/2001:db8::5/udp/6668/quic-v1"),
+		maddr.StringCast("/ip4/127.0.0.1/tcp/6668"),
+		maddr.StringCast("/ip6/::1/tcp/6668"),
+		maddr.StringCast("/ip6/fe80::1/tcp/6668"),
+	)
 	folder := os.TempDir()
 	f := filepath.Join(folder, "test")
 	defer func() {
@@ -100,5 +107,12 @@ func (s *FileStateMgrTestSuite) TestSaveAddressBook(c *C) {
 	c.Assert(err, IsNil)
 	item, err := fsm.RetrieveP2PAddresses()
 	c.Assert(err, IsNil)
-	c.Assert(item, HasLen, 3)
+	c.Assert(item, HasLen, 4)
+	found := false
+	for _, el := range item {
+		if el.String() == "/ip6/2001:db8::5/udp/6668/quic-v1/p2p/"+id1.ID().String() {
+			found = true
+		}
+	}
+	c.Assert(found, Equals, true)
 }
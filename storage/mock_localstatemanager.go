@@ -1,6 +1,8 @@
 package storage
 
 import (
+	"time"
+
 	"github.com/libp2p/go-libp2p/core/peer"
 	ma "github.com/multiformats/go-multiaddr"
 )
@@ -24,3 +26,31 @@ func (s *MockLocalStateManager) SaveAddressBook(address map[peer.ID][]ma.Multiad
 func (s *MockLocalStateManager) RetrieveP2PAddresses() ([]ma.Multiaddr, error) {
 	return nil, nil
 }
+
+func (s *MockLocalStateManager) SaveCeremonyRecord(record CeremonyRecord) error {
+	return nil
+}
+
+func (s *MockLocalStateManager) GetCeremonyRecords(filter CeremonyFilter) ([]CeremonyRecord, error) {
+	return nil, nil
+}
+
+func (s *MockLocalStateManager) PruneCeremonyRecords(before time.Time, maxEntries int) error {
+	return nil
+}
+
+func (s *MockLocalStateManager) SaveJob(job JobRecord) error {
+	return nil
+}
+
+func (s *MockLocalStateManager) GetJob(id string) (JobRecord, error) {
+	return JobRecord{}, ErrJobNotFound
+}
+
+func (s *MockLocalStateManager) GetJobs() ([]JobRecord, error) {
+	return nil, nil
+}
+
+func (s *MockLocalStateManager) PruneJobs(before time.Time) error {
+	return nil
+}
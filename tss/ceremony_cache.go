@@ -0,0 +1,151 @@
+package tss
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ordinox/thorchain-tss/keygen"
+	"github.com/ordinox/thorchain-tss/keysign"
+	"github.com/ordinox/thorchain-tss/storage"
+)
+
+// ceremonyCall is a ceremony the duplicate requests attach to, and its outcome once it is done
+type ceremonyCall struct {
+	done      chan struct{}
+	resp      interface{}
+	err       error
+	expiry    time.Time
+	waiters   int
+	cancel    context.CancelFunc
+	cancelled bool
+}
+
+// ceremonyCache run a single ceremony per request at a time, the duplicate requests that come while it runs wait
+// for its outcome, and the outcomes the ceremony asks to keep are kept for the ttl so the retries of a request get
+// them too. The key must tell apart the requests that may not share their outcome.
+type ceremonyCache struct {
+	lock  *sync.Mutex
+	ttl   time.Duration
+	calls map[string]*ceremonyCall
+}
+
+func newCeremonyCache(ttl time.Duration) *ceremonyCache {
+	return &ceremonyCache{
+		lock:  &sync.Mutex{},
+		ttl:   ttl,
+		calls: make(map[string]*ceremonyCall),
+	}
+}
+
+// prune remove the outcomes that have expired, the lock must be held
+func (c *ceremonyCache) prune(now time.Time) {
+	for key, call := range c.calls {
+		if !call.expiry.IsZero() && now.After(call.expiry) {
+			delete(c.calls, key)
+		}
+	}
+}
+
+// do run the ceremony of the given key unless it is running or its outcome is cached, in which case it returns
+// that outcome, shared tells whether it did. The outcome is cached when run tells to keep it, the other ceremonies
+// are run again by the next request. A request that is cancelled stops waiting, the ceremony is
+// cancelled once all the requests waiting for it are.
+func (c *ceremonyCache) do(ctx context.Context, key string, run func(ctx context.Context) (resp interface{}, keep bool, err error)) (resp interface{}, shared bool, err error) {
+	c.lock.Lock()
+	c.prune(time.Now())
+	call, shared := c.calls[key]
+	for shared && call.cancelled {
+		// the ceremony nobody waits for anymore is tearing down, we start a new one once it is done
+		c.lock.Unlock()
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		}
+		c.lock.Lock()
+		call, shared = c.calls[key]
+	}
+	if !shared {
+		runCtx, cancel := context.WithCancel(context.Background())
+		call = &ceremonyCall{done: make(chan struct{}), cancel: cancel}
+		c.calls[key] = call
+		go c.run(runCtx, key, call, run)
+	}
+	call.waiters++
+	c.lock.Unlock()
+
+	select {
+	case <-call.done:
+		return call.resp, shared, call.err
+	case <-ctx.Done():
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	call.waiters--
+	if call.waiters == 0 {
+		call.cancelled = true
+		call.cancel()
+	}
+	return nil, shared, ctx.Err()
+}
+
+func (c *ceremonyCache) run(ctx context.Context, key string, call *ceremonyCall, run func(ctx context.Context) (interface{}, bool, error)) {
+	resp, keep, err := run(ctx)
+	c.lock.Lock()
+	call.resp, call.err = resp, err
+	if keep && err == nil && c.ttl > 0 {
+		// the outcome is cached even if the requests gave up on it meanwhile
+		call.cancelled = false
+		call.expiry = time.Now().Add(c.ttl)
+	} else {
+		delete(c.calls, key)
+	}
+	c.lock.Unlock()
+	call.cancel()
+	close(call.done)
+}
+
+// keygenCeremonyKey return the key of the keygen request in the ceremony cache, the msg id only tells its party set,
+// not the leader weights its leader succession is ranked with
+func keygenCeremonyKey(msgID string, req keygen.Request) string {
+	return fmt.Sprintf("%s/%s/%d/%s/%s", storage.CeremonyKeygen, msgID, req.BlockHeight, req.Version,
+		leaderWeightsHash(req.LeaderWeights))
+}
+
+// keysignCeremonyKey return the key of the keysign request in the ceremony cache, the msg id only tells its signers
+// and messages, not the pool that signs them nor the leader weights and the signer constraint the party is formed
+// with
+func keysignCeremonyKey(msgID string, req keysign.Request) string {
+	return fmt.Sprintf("%s/%s/%s/%d/%s/%s/%s", storage.CeremonyKeysign, msgID, req.PoolPubKey, req.BlockHeight, req.Version,
+		leaderWeightsHash(req.LeaderWeights), signerConstraintHash(req.AllowedSigners, req.DeniedSigners))
+}
+
+// leaderWeightsHash return the hash of the leader weights, sorted by pub key so the order of the map does not matter
+func leaderWeightsHash(leaderWeights map[string]uint64) string {
+	pubKeys := make([]string, 0, len(leaderWeights))
+	for pk := range leaderWeights {
+		pubKeys = append(pubKeys, pk)
+	}
+	sort.Strings(pubKeys)
+	h := sha256.New()
+	for _, pk := range pubKeys {
+		_, _ = fmt.Fprintf(h, "%s=%d,", pk, leaderWeights[pk])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// signerConstraintHash return the hash of the allowed and denied signers, sorted so their order does not matter
+func signerConstraintHash(allowedSigners, deniedSigners []string) string {
+	allowed := append([]string(nil), allowedSigners...)
+	denied := append([]string(nil), deniedSigners...)
+	sort.Strings(allowed)
+	sort.Strings(denied)
+	h := sha256.Sum256([]byte("allowed:" + strings.Join(allowed, ",") + "/denied:" + strings.Join(denied, ",")))
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,207 @@
+package tss
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/ordinox/thorchain-tss/keygen"
+	"github.com/ordinox/thorchain-tss/keysign"
+)
+
+type CeremonyCacheTestSuite struct{}
+
+var _ = Suite(&CeremonyCacheTestSuite{})
+
+func (s *CeremonyCacheTestSuite) TestDuplicatesAttach(c *C) {
+	cache := newCeremonyCache(time.Minute)
+	var runs, shared int32
+	release := make(chan struct{})
+	wg := sync.WaitGroup{}
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, isShared, err := cache.do(context.Background(), "keysign/msg", func(ctx context.Context) (interface{}, bool, error) {
+				atomic.AddInt32(&runs, 1)
+				<-release
+				return "signature", true, nil
+			})
+			c.Assert(err, IsNil)
+			c.Assert(resp, Equals, "signature")
+			if isShared {
+				atomic.AddInt32(&shared, 1)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	c.Assert(runs, Equals, int32(1))
+	c.Assert(shared, Equals, int32(4))
+
+	// the retries get the cached outcome, the other ceremonies run
+	resp, isShared, err := cache.do(context.Background(), "keysign/msg", func(ctx context.Context) (interface{}, bool, error) {
+		return "another signature", true, nil
+	})
+	c.Assert(err, IsNil)
+	c.Assert(isShared, Equals, true)
+	c.Assert(resp, Equals, "signature")
+	resp, isShared, err = cache.do(context.Background(), "keygen/msg", func(ctx context.Context) (interface{}, bool, error) {
+		return "pool", true, nil
+	})
+	c.Assert(err, IsNil)
+	c.Assert(isShared, Equals, false)
+	c.Assert(resp, Equals, "pool")
+}
+
+func (s *CeremonyCacheTestSuite) TestOutcomeExpiry(c *C) {
+	cache := newCeremonyCache(50 * time.Millisecond)
+	runs := 0
+	run := func(ctx context.Context) (interface{}, bool, error) {
+		runs++
+		return runs, true, nil
+	}
+	resp, _, _ := cache.do(context.Background(), "msg", run)
+	c.Assert(resp, Equals, 1)
+	resp, _, _ = cache.do(context.Background(), "msg", run)
+	c.Assert(resp, Equals, 1)
+	time.Sleep(100 * time.Millisecond)
+	resp, _, _ = cache.do(context.Background(), "msg", run)
+	c.Assert(resp, Equals, 2)
+	c.Assert(cache.calls, HasLen, 1)
+}
+
+func (s *CeremonyCacheTestSuite) TestFailuresAreNotCached(c *C) {
+	cache := newCeremonyCache(time.Minute)
+	_, _, err := cache.do(context.Background(), "msg", func(ctx context.Context) (interface{}, bool, error) {
+		return nil, false, errors.New("fail to sign")
+	})
+	c.Assert(err, ErrorMatches, "fail to sign")
+	resp, isShared, err := cache.do(context.Background(), "msg", func(ctx context.Context) (interface{}, bool, error) {
+		return "failed response", false, nil
+	})
+	c.Assert(err, IsNil)
+	c.Assert(isShared, Equals, false)
+	c.Assert(resp, Equals, "failed response")
+	resp, isShared, err = cache.do(context.Background(), "msg", func(ctx context.Context) (interface{}, bool, error) {
+		return "signature", true, nil
+	})
+	c.Assert(err, IsNil)
+	c.Assert(isShared, Equals, false)
+	c.Assert(resp, Equals, "signature")
+
+	// without ttl we only attach to the running ceremonies
+	cache = newCeremonyCache(0)
+	_, _, _ = cache.do(context.Background(), "msg", func(ctx context.Context) (interface{}, bool, error) {
+		return "signature", true, nil
+	})
+	c.Assert(cache.calls, HasLen, 0)
+}
+
+func (s *CeremonyCacheTestSuite) TestCancellation(c *C) {
+	cache := newCeremonyCache(time.Minute)
+	started := make(chan context.Context, 2)
+	run := func(ctx context.Context) (interface{}, bool, error) {
+		started <- ctx
+		<-ctx.Done()
+		return nil, false, ctx.Err()
+	}
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	result1 := make(chan error, 1)
+	result2 := make(chan error, 1)
+	go func() {
+		_, _, err := cache.do(ctx1, "msg", run)
+		result1 <- err
+	}()
+	runCtx := <-started
+	go func() {
+		_, _, err := cache.do(ctx2, "msg", run)
+		result2 <- err
+	}()
+	for i := 0; i < 100; i++ {
+		cache.lock.Lock()
+		waiters := cache.calls["msg"].waiters
+		cache.lock.Unlock()
+		if waiters == 2 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// the ceremony goes on as long as a request waits for it
+	cancel1()
+	c.Assert(<-result1, Equals, context.Canceled)
+	select {
+	case <-runCtx.Done():
+		c.Fatal("the ceremony should not be cancelled")
+	case <-time.After(50 * time.Millisecond):
+	}
+	cancel2()
+	c.Assert(<-result2, Equals, context.Canceled)
+	select {
+	case <-runCtx.Done():
+	case <-time.After(time.Second):
+		c.Fatal("the ceremony should be cancelled")
+	}
+
+	// the next request runs a new ceremony
+	resp, isShared, err := cache.do(context.Background(), "msg", func(ctx context.Context) (interface{}, bool, error) {
+		return "signature", true, nil
+	})
+	c.Assert(err, IsNil)
+	c.Assert(isShared, Equals, false)
+	c.Assert(resp, Equals, "signature")
+}
+
+func (s *CeremonyCacheTestSuite) TestCeremonyKeys(c *C) {
+	keygenReq := keygen.NewRequest([]string{"a", "b"}, 10, "0.14.0")
+	key := keygenCeremonyKey("msg", keygenReq)
+	c.Assert(keygenCeremonyKey("msg", keygenReq), Equals, key)
+	c.Assert(keygenCeremonyKey("msg", keygen.NewRequest([]string{"a", "b"}, 11, "0.14.0")), Not(Equals), key)
+	c.Assert(keygenCeremonyKey("msg", keygen.NewRequest([]string{"a", "b"}, 10, "0.13.0")), Not(Equals), key)
+
+	keysignReq := keysign.NewRequest("pool", []string{"m"}, 10, []string{"a", "b"}, "0.14.0")
+	key = keysignCeremonyKey("msg", keysignReq)
+	c.Assert(keysignCeremonyKey("msg", keysignReq), Equals, key)
+	c.Assert(keysignCeremonyKey("msg", keysign.NewRequest("another pool", []string{"m"}, 10, []string{"a", "b"}, "0.14.0")), Not(Equals), key)
+	c.Assert(keysignCeremonyKey("msg", keysign.NewRequest("pool", []string{"m"}, 11, []string{"a", "b"}, "0.14.0")), Not(Equals), key)
+	c.Assert(keysignCeremonyKey("msg", keysign.NewRequest("pool", []string{"m"}, 10, []string{"a", "b"}, "0.13.0")), Not(Equals), key)
+
+	// a retry with another signer constraint forms another party, the order of the lists does not matter
+	constrained := keysignReq
+	constrained.AllowedSigners = []string{"a", "b"}
+	constrained.DeniedSigners = []string{"c", "d"}
+	key = keysignCeremonyKey("msg", constrained)
+	c.Assert(key, Not(Equals), keysignCeremonyKey("msg", keysignReq))
+	reordered := constrained
+	reordered.AllowedSigners = []string{"b", "a"}
+	reordered.DeniedSigners = []string{"d", "c"}
+	c.Assert(keysignCeremonyKey("msg", reordered), Equals, key)
+	denied := constrained
+	denied.DeniedSigners = []string{"c"}
+	c.Assert(keysignCeremonyKey("msg", denied), Not(Equals), key)
+	// an allowed signer is not a denied one
+	swapped := constrained
+	swapped.AllowedSigners, swapped.DeniedSigners = constrained.DeniedSigners, constrained.AllowedSigners
+	c.Assert(keysignCeremonyKey("msg", swapped), Not(Equals), key)
+
+	// a request with other leader weights ranks another leader succession
+	weighted := keygenReq
+	weighted.LeaderWeights = map[string]uint64{"a": 3, "b": 1}
+	key = keygenCeremonyKey("msg", weighted)
+	c.Assert(key, Not(Equals), keygenCeremonyKey("msg", keygenReq))
+	c.Assert(keygenCeremonyKey("msg", keygen.Request{Keys: []string{"a", "b"}, BlockHeight: 10, Version: "0.14.0",
+		LeaderWeights: map[string]uint64{"b": 1, "a": 3}}), Equals, key)
+	weighted.LeaderWeights = map[string]uint64{"a": 1, "b": 3}
+	c.Assert(keygenCeremonyKey("msg", weighted), Not(Equals), key)
+
+	weightedSign := keysignReq
+	weightedSign.LeaderWeights = map[string]uint64{"a": 3, "b": 1}
+	c.Assert(keysignCeremonyKey("msg", weightedSign), Not(Equals), keysignCeremonyKey("msg", keysignReq))
+}
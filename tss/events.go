@@ -0,0 +1,31 @@
+package tss
+
+import (
+	"fmt"
+
+	"github.com/ordinox/thorchain-tss/blame"
+	"github.com/ordinox/thorchain-tss/common"
+	"github.com/ordinox/thorchain-tss/monitor"
+)
+
+// SubscribeEvents return the progress events of the ceremony of the given msg id, or of all the ceremonies if it
+// is empty, the returned function ends the subscription
+func (t *TssServer) SubscribeEvents(msgID string) (<-chan monitor.Event, func()) {
+	return t.eventBus.Subscribe(msgID)
+}
+
+// publishOutcome publish the nodes the ceremony blamed and that it is done
+func (t *TssServer) publishOutcome(msgID string, status common.Status, blameNodes blame.Blame, errCeremony error) {
+	if len(blameNodes.BlameNodes) != 0 {
+		pubKeys := make([]string, len(blameNodes.BlameNodes))
+		for i, el := range blameNodes.BlameNodes {
+			pubKeys[i] = el.Pubkey
+		}
+		t.eventBus.Publish(monitor.NewEvent(msgID, monitor.EventBlame, blameNodes.Round, pubKeys, blameNodes.FailReason))
+	}
+	detail := status.String()
+	if errCeremony != nil {
+		detail = fmt.Sprintf("%s: %s", common.Fail, errCeremony)
+	}
+	t.eventBus.Publish(monitor.NewEvent(msgID, monitor.EventDone, "", nil, detail))
+}
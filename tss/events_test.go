@@ -0,0 +1,47 @@
+package tss
+
+import (
+	"errors"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/ordinox/thorchain-tss/blame"
+	"github.com/ordinox/thorchain-tss/common"
+	"github.com/ordinox/thorchain-tss/monitor"
+)
+
+type EventsTestSuite struct{}
+
+var _ = Suite(&EventsTestSuite{})
+
+func (s *EventsTestSuite) TestPublishOutcome(c *C) {
+	server := &TssServer{
+		eventBus: monitor.NewEventBus(),
+	}
+	events, cancel := server.SubscribeEvents("msg1")
+	defer cancel()
+
+	server.publishOutcome("msg2", common.Success, blame.Blame{}, nil)
+	server.publishOutcome("msg1", common.Success, blame.Blame{}, nil)
+	event := <-events
+	c.Assert(event.MsgID, Equals, "msg1")
+	c.Assert(event.Type, Equals, monitor.EventDone)
+	c.Assert(event.Detail, Equals, "success")
+
+	blameNodes := blame.NewBlame(blame.TssTimeout, []blame.Node{
+		blame.NewNode("pubkey1", nil, nil),
+		blame.NewNode("pubkey2", nil, nil),
+	})
+	blameNodes.Round = "round1"
+	server.publishOutcome("msg1", common.Fail, blameNodes, nil)
+	event = <-events
+	c.Assert(event.Type, Equals, monitor.EventBlame)
+	c.Assert(event.Round, Equals, "round1")
+	c.Assert(event.Peers, DeepEquals, []string{"pubkey1", "pubkey2"})
+	c.Assert(event.Detail, Equals, blame.TssTimeout)
+	c.Assert((<-events).Detail, Equals, "fail")
+
+	server.publishOutcome("msg1", common.NA, blame.Blame{}, errors.New("keysign is cancelled"))
+	c.Assert((<-events).Detail, Equals, "fail: keysign is cancelled")
+	c.Assert(events, HasLen, 0)
+}
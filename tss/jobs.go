@@ -0,0 +1,271 @@
+package tss
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ordinox/thorchain-tss/keygen"
+	"github.com/ordinox/thorchain-tss/keysign"
+	"github.com/ordinox/thorchain-tss/storage"
+)
+
+const (
+	// JobSignatureHeader carries the base64 signature of the callback body by the key of the node, the receiver
+	// verifies it with the pub key the node reports on /pubkey
+	JobSignatureHeader = "X-Tss-Signature"
+
+	jobIDSize            = 16
+	jobCallbackAttempts  = 3
+	jobCallbackTimeout   = 10 * time.Second
+	jobCallbackRetryWait = 2 * time.Second
+)
+
+// ErrInvalidCallbackURL is returned when the callback url of a job is not an http(s) url
+var ErrInvalidCallbackURL = errors.New("invalid callback url")
+
+// errJobInterrupted is the error of the jobs that were running when the tss server stopped
+var errJobInterrupted = errors.New("the tss server stopped before the job finished")
+
+// SubmitKeygenJob run the keygen of the request in the background, the caller polls the returned job with GetJob,
+// and gets it posted to the callback url once it is finished if one is given
+func (t *TssServer) SubmitKeygenJob(req keygen.Request, callbackURL string) (storage.JobRecord, error) {
+	msgID, err := t.requestToMsgId(req)
+	if err != nil {
+		return storage.JobRecord{}, err
+	}
+	job, err := t.newJob(storage.CeremonyKeygen, msgID, req, callbackURL)
+	if err != nil {
+		return storage.JobRecord{}, err
+	}
+	go t.runJob(job, func(ctx context.Context) (interface{}, error) {
+		return t.KeygenCtx(ctx, req)
+	})
+	return job, nil
+}
+
+// SubmitKeySignJob run the keysign of the request in the background, the caller polls the returned job with
+// GetJob, and gets it posted to the callback url once it is finished if one is given
+func (t *TssServer) SubmitKeySignJob(req keysign.Request, callbackURL string) (storage.JobRecord, error) {
+	if err := req.Priority.Validate(); err != nil {
+		return storage.JobRecord{}, err
+	}
+	msgID, err := t.requestToMsgId(req)
+	if err != nil {
+		return storage.JobRecord{}, err
+	}
+	job, err := t.newJob(storage.CeremonyKeysign, msgID, req, callbackURL)
+	if err != nil {
+		return storage.JobRecord{}, err
+	}
+	go t.runJob(job, func(ctx context.Context) (interface{}, error) {
+		return t.KeySignCtx(ctx, req)
+	})
+	return job, nil
+}
+
+// GetJob return the job of the given id
+func (t *TssServer) GetJob(id string) (storage.JobRecord, error) {
+	return t.stateManager.GetJob(id)
+}
+
+func (t *TssServer) newJob(jobType, msgID string, request interface{}, callbackURL string) (storage.JobRecord, error) {
+	if len(callbackURL) != 0 {
+		u, err := url.Parse(callbackURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+			return storage.JobRecord{}, fmt.Errorf("%w(%s)", ErrInvalidCallbackURL, callbackURL)
+		}
+	}
+	buf, err := json.Marshal(request)
+	if err != nil {
+		return storage.JobRecord{}, fmt.Errorf("fail to marshal the job request: %w", err)
+	}
+	id := make([]byte, jobIDSize)
+	if _, err := rand.Read(id); err != nil {
+		return storage.JobRecord{}, fmt.Errorf("fail to generate the job id: %w", err)
+	}
+	now := time.Now()
+	job := storage.JobRecord{
+		ID:          hex.EncodeToString(id),
+		Type:        jobType,
+		MsgID:       msgID,
+		Phase:       storage.JobRunning,
+		Request:     buf,
+		CallbackURL: callbackURL,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := t.stateManager.SaveJob(job); err != nil {
+		return storage.JobRecord{}, fmt.Errorf("fail to save the job: %w", err)
+	}
+	return job, nil
+}
+
+// runJob run the ceremony of the job and record its outcome, a ceremony that returns an error fails the job. The
+// ceremony is cancelled once the tss server stops, and the job fails as interrupted, its callback is delivered
+// once we restart.
+func (t *TssServer) runJob(job storage.JobRecord, run func(ctx context.Context) (interface{}, error)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-t.stopChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	resp, err := run(ctx)
+	select {
+	case <-t.stopChan:
+		t.logger.Info().Str("job", job.ID).Msg("the tss server is stopped, the job is interrupted")
+		job.Phase = storage.JobFailed
+		job.Error = errJobInterrupted.Error()
+		job.UpdatedAt = time.Now()
+		if err := t.stateManager.SaveJob(job); err != nil {
+			t.logger.Error().Err(err).Str("job", job.ID).Msg("fail to save the interrupted job")
+		}
+		return
+	default:
+	}
+	job.Phase = storage.JobDone
+	if err != nil {
+		job.Phase = storage.JobFailed
+		job.Error = err.Error()
+	} else {
+		buf, errMarshal := json.Marshal(resp)
+		if errMarshal != nil {
+			job.Phase = storage.JobFailed
+			job.Error = fmt.Sprintf("fail to marshal the response: %s", errMarshal)
+		}
+		job.Result = buf
+	}
+	t.finishJob(job)
+}
+
+// finishJob save the finished job and post it to its callback url
+func (t *TssServer) finishJob(job storage.JobRecord) {
+	job.UpdatedAt = time.Now()
+	if err := t.stateManager.SaveJob(job); err != nil {
+		t.logger.Error().Err(err).Str("job", job.ID).Msg("fail to save the finished job")
+	}
+	t.notifyJob(job)
+}
+
+// notifyJob post the finished job to its callback url, and record the delivery
+func (t *TssServer) notifyJob(job storage.JobRecord) {
+	if len(job.CallbackURL) == 0 {
+		return
+	}
+	if err := t.deliverJobCallback(job); err != nil {
+		t.logger.Error().Err(err).Str("job", job.ID).Msgf("fail to post the job to %s", job.CallbackURL)
+		return
+	}
+	job.CallbackDelivered = true
+	if err := t.stateManager.SaveJob(job); err != nil {
+		t.logger.Error().Err(err).Str("job", job.ID).Msg("fail to save the job callback delivery")
+	}
+}
+
+// deliverJobCallback post the job signed by the key of the node to its callback url, we retry a few times until
+// the receiver answers with a 2xx status
+func (t *TssServer) deliverJobCallback(job storage.JobRecord) error {
+	body, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("fail to marshal the job: %w", err)
+	}
+	sig, err := t.privateKey.Sign(body)
+	if err != nil {
+		return fmt.Errorf("fail to sign the job: %w", err)
+	}
+	client := &http.Client{Timeout: jobCallbackTimeout}
+	for i := 0; ; i++ {
+		err = postJobCallback(client, job.CallbackURL, body, sig)
+		if err == nil || i == jobCallbackAttempts-1 {
+			return err
+		}
+		t.logger.Warn().Err(err).Str("job", job.ID).Msg("fail to post the job, we retry")
+		select {
+		case <-t.stopChan:
+			return err
+		case <-time.After(jobCallbackRetryWait * time.Duration(i+1)):
+		}
+	}
+}
+
+func postJobCallback(client *http.Client, callbackURL string, body, sig []byte) error {
+	req, err := http.NewRequest(http.MethodPost, callbackURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(JobSignatureHeader, base64.StdEncoding.EncodeToString(sig))
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("the callback answered with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// recoverJobs fail the jobs the previous run of the server left unfinished, it returns the finished jobs whose
+// callback is not delivered yet
+func (t *TssServer) recoverJobs() []storage.JobRecord {
+	jobs, err := t.stateManager.GetJobs()
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to load the jobs")
+		return nil
+	}
+	var undelivered []storage.JobRecord
+	for _, el := range jobs {
+		if !el.Finished() {
+			el.Phase = storage.JobFailed
+			el.Error = errJobInterrupted.Error()
+			el.UpdatedAt = time.Now()
+			if err := t.stateManager.SaveJob(el); err != nil {
+				t.logger.Error().Err(err).Str("job", el.ID).Msg("fail to save the interrupted job")
+			}
+		}
+		if len(el.CallbackURL) != 0 && !el.CallbackDelivered {
+			undelivered = append(undelivered, el)
+		}
+	}
+	return undelivered
+}
+
+func (t *TssServer) pruneJobs() {
+	if err := t.stateManager.PruneJobs(time.Now().Add(-t.conf.JobRetention)); err != nil {
+		t.logger.Error().Err(err).Msg("fail to prune the jobs")
+	}
+}
+
+// jobsLoop post the jobs whose callback is not delivered yet, and apply the retention policy to the jobs
+func (t *TssServer) jobsLoop(undelivered []storage.JobRecord) {
+	for _, el := range undelivered {
+		t.notifyJob(el)
+	}
+	if t.conf.JobRetention <= 0 {
+		return
+	}
+	t.pruneJobs()
+	ticker := time.NewTicker(journalPruneInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.stopChan:
+			return
+		case <-ticker.C:
+			t.pruneJobs()
+		}
+	}
+}
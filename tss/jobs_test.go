@@ -0,0 +1,192 @@
+package tss
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/tendermint/tendermint/crypto/secp256k1"
+	. "gopkg.in/check.v1"
+
+	"github.com/ordinox/thorchain-tss/blame"
+	"github.com/ordinox/thorchain-tss/common"
+	"github.com/ordinox/thorchain-tss/keysign"
+	"github.com/ordinox/thorchain-tss/storage"
+)
+
+type JobsTestSuite struct {
+	folder string
+	server *TssServer
+}
+
+var _ = Suite(&JobsTestSuite{})
+
+func (s *JobsTestSuite) SetUpTest(c *C) {
+	s.folder = filepath.Join(os.TempDir(), "test-tss-jobs")
+	fsm, err := storage.NewFileStateMgr(s.folder)
+	c.Assert(err, IsNil)
+	s.server = &TssServer{
+		logger:       log.With().Str("module", "tss").Logger(),
+		stateManager: fsm,
+		privateKey:   secp256k1.GenPrivKey(),
+		stopChan:     make(chan struct{}),
+	}
+}
+
+func (s *JobsTestSuite) TearDownTest(c *C) {
+	close(s.server.stopChan)
+	c.Assert(os.RemoveAll(s.folder), IsNil)
+}
+
+// callbackReceiver record the jobs posted to it, it fails the first given number of posts
+type callbackReceiver struct {
+	lock     *sync.Mutex
+	failures int
+	bodies   [][]byte
+	sigs     []string
+}
+
+func (r *callbackReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.failures > 0 {
+		r.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	body, _ := ioutil.ReadAll(req.Body)
+	r.bodies = append(r.bodies, body)
+	r.sigs = append(r.sigs, req.Header.Get(JobSignatureHeader))
+}
+
+func (s *JobsTestSuite) TestJob(c *C) {
+	receiver := &callbackReceiver{lock: &sync.Mutex{}, failures: 1}
+	srv := httptest.NewServer(receiver)
+	defer srv.Close()
+
+	_, err := s.server.newJob(storage.CeremonyKeysign, "msg", keysign.Request{}, "ftp://whatever")
+	c.Assert(errors.Is(err, ErrInvalidCallbackURL), Equals, true)
+
+	job, err := s.server.newJob(storage.CeremonyKeysign, "msg", keysign.Request{PoolPubKey: "pool"}, srv.URL)
+	c.Assert(err, IsNil)
+	saved, err := s.server.GetJob(job.ID)
+	c.Assert(err, IsNil)
+	c.Assert(saved.Phase, Equals, storage.JobRunning)
+	c.Assert(saved.MsgID, Equals, "msg")
+
+	// the callback is retried until the receiver takes it
+	s.server.runJob(job, func(ctx context.Context) (interface{}, error) {
+		return keysign.NewResponse(nil, common.Success, blame.Blame{}), nil
+	})
+	saved, err = s.server.GetJob(job.ID)
+	c.Assert(err, IsNil)
+	c.Assert(saved.Phase, Equals, storage.JobDone)
+	c.Assert(saved.CallbackDelivered, Equals, true)
+	var resp keysign.Response
+	c.Assert(json.Unmarshal(saved.Result, &resp), IsNil)
+	c.Assert(resp.Status, Equals, common.Success)
+
+	c.Assert(receiver.bodies, HasLen, 1)
+	var posted storage.JobRecord
+	c.Assert(json.Unmarshal(receiver.bodies[0], &posted), IsNil)
+	c.Assert(posted.ID, Equals, job.ID)
+	c.Assert(posted.Phase, Equals, storage.JobDone)
+	sig, err := base64.StdEncoding.DecodeString(receiver.sigs[0])
+	c.Assert(err, IsNil)
+	c.Assert(s.server.privateKey.PubKey().VerifySignature(receiver.bodies[0], sig), Equals, true)
+
+	// a job without callback
+	job, err = s.server.newJob(storage.CeremonyKeysign, "msg", keysign.Request{}, "")
+	c.Assert(err, IsNil)
+	s.server.runJob(job, func(ctx context.Context) (interface{}, error) {
+		return keysign.Response{}, errors.New("fail to sign")
+	})
+	saved, err = s.server.GetJob(job.ID)
+	c.Assert(err, IsNil)
+	c.Assert(saved.Phase, Equals, storage.JobFailed)
+	c.Assert(saved.Error, Equals, "fail to sign")
+	c.Assert(receiver.bodies, HasLen, 1)
+}
+
+func (s *JobsTestSuite) TestJobInterrupted(c *C) {
+	job, err := s.server.newJob(storage.CeremonyKeysign, "msg", keysign.Request{}, "http://127.0.0.1:1")
+	c.Assert(err, IsNil)
+	started := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		s.server.runJob(job, func(ctx context.Context) (interface{}, error) {
+			close(started)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+	}()
+	<-started
+	// the stop cancels the ceremony and the job is interrupted right away
+	close(s.server.stopChan)
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		c.Fatal("the ceremony of the job should be cancelled")
+	}
+	s.server.stopChan = make(chan struct{})
+	saved, err := s.server.GetJob(job.ID)
+	c.Assert(err, IsNil)
+	c.Assert(saved.Phase, Equals, storage.JobFailed)
+	c.Assert(saved.Error, Equals, errJobInterrupted.Error())
+	c.Assert(saved.CallbackDelivered, Equals, false)
+	// its callback is delivered once we restart
+	c.Assert(s.server.recoverJobs(), HasLen, 1)
+}
+
+func (s *JobsTestSuite) TestRecoverJobs(c *C) {
+	receiver := &callbackReceiver{lock: &sync.Mutex{}}
+	srv := httptest.NewServer(receiver)
+	defer srv.Close()
+
+	running, err := s.server.newJob(storage.CeremonyKeygen, "msg", keysign.Request{}, srv.URL)
+	c.Assert(err, IsNil)
+	done, err := s.server.newJob(storage.CeremonyKeysign, "msg", keysign.Request{}, "")
+	c.Assert(err, IsNil)
+	done.Phase = storage.JobDone
+	done.UpdatedAt = time.Now().Add(-time.Hour)
+	c.Assert(s.server.stateManager.SaveJob(done), IsNil)
+
+	// the job left running by the previous run fails, and its callback is due
+	undelivered := s.server.recoverJobs()
+	c.Assert(undelivered, HasLen, 1)
+	c.Assert(undelivered[0].ID, Equals, running.ID)
+	saved, err := s.server.GetJob(running.ID)
+	c.Assert(err, IsNil)
+	c.Assert(saved.Phase, Equals, storage.JobFailed)
+	c.Assert(saved.Error, Equals, errJobInterrupted.Error())
+	saved, err = s.server.GetJob(done.ID)
+	c.Assert(err, IsNil)
+	c.Assert(saved.Phase, Equals, storage.JobDone)
+
+	s.server.conf.JobRetention = time.Minute
+	go s.server.jobsLoop(undelivered)
+	for i := 0; i < 100; i++ {
+		saved, err = s.server.GetJob(running.ID)
+		c.Assert(err, IsNil)
+		_, errDone := s.server.GetJob(done.ID)
+		if saved.CallbackDelivered && errDone == storage.ErrJobNotFound {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Assert(saved.CallbackDelivered, Equals, true)
+	c.Assert(receiver.bodies, HasLen, 1)
+	// the old finished job is pruned
+	_, err = s.server.GetJob(done.ID)
+	c.Assert(err, Equals, storage.ErrJobNotFound)
+}
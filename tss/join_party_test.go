@@ -0,0 +1,114 @@
+package tss
+
+import (
+	"sync"
+
+	"github.com/rs/zerolog"
+	. "gopkg.in/check.v1"
+
+	"github.com/ordinox/thorchain-tss/blame"
+	"github.com/ordinox/thorchain-tss/conversion"
+	"github.com/ordinox/thorchain-tss/p2p"
+)
+
+type JoinPartyTestSuite struct{}
+
+var _ = Suite(&JoinPartyTestSuite{})
+
+func (s *JoinPartyTestSuite) SetUpSuite(c *C) {
+	conversion.SetupBech32Prefix()
+}
+
+func (s *JoinPartyTestSuite) TestJoinPartyOptions(c *C) {
+	peerIDs, err := conversion.GetPeerIDsFromPubKeys(testPubKeys)
+	c.Assert(err, IsNil)
+	opts, err := joinPartyOptions(nil, nil, nil, nil)
+	c.Assert(err, IsNil)
+	c.Assert(opts.LeaderWeights, IsNil)
+	c.Assert(opts.SignerConstraint.IsEmpty(), Equals, true)
+
+	opts, err = joinPartyOptions(map[string]uint64{testPubKeys[0]: 3}, nil, testPubKeys[1:3], testPubKeys[3:])
+	c.Assert(err, IsNil)
+	c.Assert(opts.LeaderWeights, DeepEquals, map[string]uint64{peerIDs[0].String(): 3})
+	c.Assert(opts.SignerConstraint, DeepEquals, p2p.SignerConstraint{
+		Allowed: peerIDs[1:3],
+		Denied:  peerIDs[3:],
+	})
+
+	_, err = joinPartyOptions(map[string]uint64{"whatever": 3}, nil, nil, nil)
+	c.Assert(err, NotNil)
+	_, err = joinPartyOptions(nil, nil, []string{"whatever"}, nil)
+	c.Assert(err, NotNil)
+	_, err = joinPartyOptions(nil, nil, nil, []string{"whatever"})
+	c.Assert(err, NotNil)
+}
+
+func (s *JoinPartyTestSuite) TestJoinPartyOptionsWithSigners(c *C) {
+	peerIDs, err := conversion.GetPeerIDsFromPubKeys(testPubKeys)
+	c.Assert(err, IsNil)
+	// the signers of the request are the allowed signers
+	opts, err := joinPartyOptions(nil, testPubKeys[:3], nil, testPubKeys[2:3])
+	c.Assert(err, IsNil)
+	c.Assert(opts.SignerConstraint, DeepEquals, p2p.SignerConstraint{
+		Allowed: peerIDs[:3],
+		Denied:  peerIDs[2:3],
+	})
+	c.Assert(opts.SignerConstraint.Violations(peerIDs), DeepEquals, peerIDs[2:])
+
+	// a node must be both among the signers and the allowed signers
+	opts, err = joinPartyOptions(nil, testPubKeys[:3], testPubKeys[1:], nil)
+	c.Assert(err, IsNil)
+	c.Assert(opts.SignerConstraint.Allowed, DeepEquals, peerIDs[1:3])
+
+	_, err = joinPartyOptions(nil, testPubKeys[:1], testPubKeys[1:], nil)
+	c.Assert(err, NotNil)
+}
+
+func (s *JoinPartyTestSuite) TestLeaderBlame(c *C) {
+	peerIDs, err := conversion.GetPeerIDsFromPubKeys(testPubKeys)
+	c.Assert(err, IsNil)
+	t := &TssServer{logger: zerolog.Nop()}
+	result := t.leaderBlame(p2p.ErrJoinPartyTimeout)
+	c.Assert(result.FailReason, Equals, blame.TssSyncFail)
+	c.Assert(result.BlameNodes, HasLen, 0)
+
+	// the leaders we could not reach fail to sync
+	result = t.leaderBlame(p2p.ErrUnreachableLeaders{Leaders: peerIDs[:2], Err: p2p.ErrJoinPartyTimeout})
+	c.Assert(result.FailReason, Equals, blame.TssSyncFail)
+	c.Assert(result.BlameNodes, HasLen, 2)
+	c.Assert(result.BlameNodes[0].Pubkey, Equals, testPubKeys[0])
+	c.Assert(result.BlameNodes[1].Pubkey, Equals, testPubKeys[1])
+
+	// the leader that has our request but never formed the party has its own reason
+	result = t.leaderBlame(p2p.ErrUnreachableLeaders{Leaders: peerIDs[:1], NotReady: peerIDs[2], Err: p2p.ErrLeaderNotReady})
+	c.Assert(result.FailReason, Equals, blame.LeaderNotReady)
+	c.Assert(result.BlameNodes, HasLen, 2)
+	c.Assert(result.BlameNodes[0].Pubkey, Equals, testPubKeys[0])
+	c.Assert(result.BlameNodes[1].Pubkey, Equals, testPubKeys[2])
+}
+
+func (s *JoinPartyTestSuite) TestJoinPartyChanPerMsgID(c *C) {
+	t := &TssServer{
+		joinPartyChanLock: &sync.Mutex{},
+		joinPartyChans:    make(map[string]chan struct{}),
+	}
+	// two keygens of different party sets form their parties at the same time, each notifies its own channel
+	wg := sync.WaitGroup{}
+	for _, msgID := range []string{"party a", "party b"} {
+		wg.Add(1)
+		go func(msgID string) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				jpc := make(chan struct{}, 1)
+				t.setJoinPartyChan(msgID, jpc)
+				t.notifyJoinPartyChan(msgID)
+				c.Assert(jpc, HasLen, 1)
+				t.unsetJoinPartyChan(msgID)
+				t.notifyJoinPartyChan(msgID)
+				c.Assert(jpc, HasLen, 1)
+			}
+		}(msgID)
+	}
+	wg.Wait()
+	c.Assert(t.joinPartyChans, HasLen, 0)
+}
@@ -0,0 +1,90 @@
+package tss
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/ordinox/thorchain-tss/blame"
+	"github.com/ordinox/thorchain-tss/common"
+	"github.com/ordinox/thorchain-tss/keysign"
+	"github.com/ordinox/thorchain-tss/storage"
+)
+
+// journalPruneInterval is how often we apply the retention policy to the ceremony journal
+const journalPruneInterval = time.Hour
+
+func (t *TssServer) newCeremonyRecord(ceremonyType, msgID, poolPubKey string, request interface{}, signers []string) *storage.CeremonyRecord {
+	record := &storage.CeremonyRecord{
+		MsgID:      msgID,
+		Type:       ceremonyType,
+		PoolPubKey: poolPubKey,
+		Signers:    signers,
+		StartTime:  time.Now(),
+	}
+	buf, err := json.Marshal(request)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to marshal the request for the ceremony journal")
+		return record
+	}
+	requestHash, err := common.MsgToHashString(buf)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to hash the request for the ceremony journal")
+		return record
+	}
+	record.RequestHash = requestHash
+	return record
+}
+
+// saveCeremonyRecord complete the record with the outcome of the ceremony and append it to the journal
+func (t *TssServer) saveCeremonyRecord(record *storage.CeremonyRecord, status common.Status, blameNodes blame.Blame, signatures []keysign.Signature, errCeremony error) {
+	record.Duration = time.Since(record.StartTime)
+	record.Status = status.String()
+	if errCeremony != nil {
+		record.Status = common.Fail.String()
+		record.Error = errCeremony.Error()
+	}
+	record.Blame = blameNodes
+	for _, el := range signatures {
+		record.Signatures = append(record.Signatures, storage.CeremonySignature{
+			Msg:        el.Msg,
+			R:          el.R,
+			S:          el.S,
+			RecoveryID: el.RecoveryID,
+		})
+	}
+	if err := t.stateManager.SaveCeremonyRecord(*record); err != nil {
+		t.logger.Error().Err(err).Str("msgID", record.MsgID).Msg("fail to save the ceremony record")
+	}
+}
+
+// GetCeremonyHistory return the ceremonies recorded in the journal that match the given filter
+func (t *TssServer) GetCeremonyHistory(filter storage.CeremonyFilter) ([]storage.CeremonyRecord, error) {
+	return t.stateManager.GetCeremonyRecords(filter)
+}
+
+func (t *TssServer) pruneCeremonyJournal() {
+	if t.conf.JournalRetention == 0 && t.conf.JournalMaxEntries == 0 {
+		return
+	}
+	var before time.Time
+	if t.conf.JournalRetention > 0 {
+		before = time.Now().Add(-t.conf.JournalRetention)
+	}
+	if err := t.stateManager.PruneCeremonyRecords(before, t.conf.JournalMaxEntries); err != nil {
+		t.logger.Error().Err(err).Msg("fail to prune the ceremony journal")
+	}
+}
+
+func (t *TssServer) journalRetentionLoop() {
+	t.pruneCeremonyJournal()
+	ticker := time.NewTicker(journalPruneInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.stopChan:
+			return
+		case <-ticker.C:
+			t.pruneCeremonyJournal()
+		}
+	}
+}
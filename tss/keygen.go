@@ -1,6 +1,7 @@
 package tss
 
 import (
+	"context"
 	"time"
 
 	"github.com/ordinox/thorchain-tss/blame"
@@ -8,49 +9,110 @@ import (
 	"github.com/ordinox/thorchain-tss/conversion"
 	"github.com/ordinox/thorchain-tss/keygen"
 	"github.com/ordinox/thorchain-tss/messages"
+	"github.com/ordinox/thorchain-tss/storage"
 )
 
 func (t *TssServer) Keygen(req keygen.Request) (keygen.Response, error) {
-	t.tssKeyGenLocker.Lock()
-	defer t.tssKeyGenLocker.Unlock()
-	status := common.Success
+	return t.KeygenCtx(context.Background(), req)
+}
+
+// KeygenCtx is Keygen that gives up with the error of the context once it is done, the keygen goes on as long as
+// a duplicate request still waits for it
+func (t *TssServer) KeygenCtx(ctx context.Context, req keygen.Request) (keygen.Response, error) {
 	msgID, err := t.requestToMsgId(req)
 	if err != nil {
 		return keygen.Response{}, err
 	}
+	// a retry of the request attaches to the running keygen, the outcome is not kept as a new keygen of the same
+	// party set makes a new pool
+	resp, shared, err := t.ceremonies.do(ctx, keygenCeremonyKey(msgID, req), func(ctx context.Context) (interface{}, bool, error) {
+		// the msg id of a keygen is derived from its party set, so the keygens of different party sets run in
+		// parallel, and the ones of the same party set one after the other
+		unlock := t.keygenLocks.Lock(msgID)
+		defer unlock()
+		resp, err := t.runKeygen(ctx, msgID, req)
+		return resp, false, err
+	})
+	if shared {
+		t.logger.Info().Str("msgID", msgID).Msg("the keygen request is a duplicate, return the outcome of its keygen")
+	}
+	keygenResp, _ := resp.(keygen.Response)
+	return keygenResp, err
+}
+
+func (t *TssServer) runKeygen(ctx context.Context, msgID string, req keygen.Request) (keygen.Response, error) {
+	record := t.newCeremonyRecord(storage.CeremonyKeygen, msgID, "", req, req.Keys)
+	var resp keygen.Response
+	version, versionBlame, err := t.negotiateVersion(req.Version, req.Keys)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to negotiate the keygen version")
+		resp = keygen.Response{Status: common.Fail, Blame: versionBlame}
+	} else {
+		req.Version = version
+		resp, err = t.keygen(ctx, msgID, req, record)
+	}
+	if resp.Status == common.Fail {
+		resp.Blame = t.blameRateLimited(msgID, req.Keys, resp.Blame)
+	}
+	t.transport.GetRateLimiter().Release(msgID)
+	record.PoolPubKey = resp.PubKey
+	t.saveCeremonyRecord(record, resp.Status, resp.Blame, nil, err)
+	t.publishOutcome(msgID, resp.Status, resp.Blame, err)
+	return resp, err
+}
+
+func (t *TssServer) keygen(ctx context.Context, msgID string, req keygen.Request, record *storage.CeremonyRecord) (keygen.Response, error) {
+	status := common.Success
+	stopChan, releaseStopChan := t.ceremonyStopChan(ctx)
+	defer releaseStopChan()
 
 	keygenInstance := keygen.NewTssKeyGen(
-		t.p2pCommunication.GetLocalPeerID(),
+		t.transport.ID().String(),
 		t.conf,
 		t.localNodePubKey,
-		t.p2pCommunication.BroadcastMsgChan,
-		t.stopChan,
+		t.transport.BroadcastChannel(),
+		stopChan,
 		t.preParams,
 		msgID,
 		t.stateManager,
 		t.privateKey,
-		t.p2pCommunication)
+		t.transport)
 
+	keygenInstance.GetTssCommonStruct().SetWireFormat(t.wireFormat(req.Version))
+	keygenInstance.GetTssCommonStruct().SetEventBus(t.eventBus)
 	keygenMsgChannel := keygenInstance.GetTssKeyGenChannels()
-	t.p2pCommunication.SetSubscribe(messages.TSSKeyGenMsg, msgID, keygenMsgChannel)
-	t.p2pCommunication.SetSubscribe(messages.TSSKeyGenVerMsg, msgID, keygenMsgChannel)
-	t.p2pCommunication.SetSubscribe(messages.TSSControlMsg, msgID, keygenMsgChannel)
-	t.p2pCommunication.SetSubscribe(messages.TSSTaskDone, msgID, keygenMsgChannel)
+	t.transport.SetSubscribe(messages.TSSKeyGenMsg, msgID, keygenMsgChannel)
+	t.transport.SetSubscribe(messages.TSSKeyGenVerMsg, msgID, keygenMsgChannel)
+	t.transport.SetSubscribe(messages.TSSControlMsg, msgID, keygenMsgChannel)
+	t.transport.SetSubscribe(messages.TSSTaskDone, msgID, keygenMsgChannel)
 
 	defer func() {
-		t.p2pCommunication.CancelSubscribe(messages.TSSKeyGenMsg, msgID)
-		t.p2pCommunication.CancelSubscribe(messages.TSSKeyGenVerMsg, msgID)
-		t.p2pCommunication.CancelSubscribe(messages.TSSControlMsg, msgID)
-		t.p2pCommunication.CancelSubscribe(messages.TSSTaskDone, msgID)
+		t.transport.CancelSubscribe(messages.TSSKeyGenMsg, msgID)
+		t.transport.CancelSubscribe(messages.TSSKeyGenVerMsg, msgID)
+		t.transport.CancelSubscribe(messages.TSSControlMsg, msgID)
+		t.transport.CancelSubscribe(messages.TSSTaskDone, msgID)
 
-		t.p2pCommunication.ReleaseStream(msgID)
+		t.transport.ReleaseStream(msgID)
 		t.partyCoordinator.ReleaseStream(msgID)
 	}()
+	joinPartyOpts, err := joinPartyOptions(req.LeaderWeights, nil, nil, nil)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("invalid leader weights")
+		return keygen.Response{
+			Status: common.Fail,
+			Blame:  blame.NewBlame(blame.InternalError, []blame.Node{}),
+		}, nil
+	}
 	sigChan := make(chan string)
 	blameMgr := keygenInstance.GetTssCommonStruct().GetBlameMgr()
 	joinPartyStartTime := time.Now()
-	onlinePeers, leader, errJoinParty := t.joinParty(msgID, req.Version, req.BlockHeight, req.Keys, len(req.Keys)-1, sigChan)
+	onlinePeers, leader, errJoinParty := t.joinParty(ctx, msgID, req.Version, req.BlockHeight, req.Keys, len(req.Keys)-1, joinPartyOpts, sigChan)
 	joinPartyTime := time.Since(joinPartyStartTime)
+	record.Leader = leader
+	if ctx.Err() != nil {
+		t.logger.Info().Str("msgID", msgID).Msg("the keygen is cancelled")
+		return keygen.Response{}, ctx.Err()
+	}
 	if errJoinParty != nil {
 		t.logger.Error().Err(errJoinParty).Msgf("failed to joinParty after %s, onlinePeers=%v", joinPartyTime, onlinePeers)
 
@@ -59,7 +121,7 @@ func (t *TssServer) Keygen(req keygen.Request) (keygen.Response, error) {
 		// this indicate we are processing the leaderless join party
 		if leader == "NONE" {
 			if onlinePeers == nil {
-				t.logger.Error().Err(err).Msg("error before we start join party")
+				t.logger.Error().Err(errJoinParty).Msg("error before we start join party")
 				return keygen.Response{
 					Status: common.Fail,
 					Blame:  blame.NewBlame(blame.InternalError, []blame.Node{}),
@@ -78,26 +140,19 @@ func (t *TssServer) Keygen(req keygen.Request) (keygen.Response, error) {
 
 		}
 
-		var blameLeader blame.Blame
 		var blameNodes blame.Blame
-		blameNodes, err = blameMgr.NodeSyncBlame(req.Keys, onlinePeers)
+		blameNodes, err := blameMgr.NodeSyncBlame(req.Keys, onlinePeers)
 		if err != nil {
 			t.logger.Error().Err(err).Msg("failed to blame nodes for joinParty failure")
 		}
-		leaderPubKey, err := conversion.GetPubKeyFromPeerID(leader)
-		if err != nil {
-			t.logger.Error().Err(err).Msgf("failed to convert peerID->pubkey for leader %s", leader)
-			blameLeader = blame.NewBlame(blame.TssSyncFail, []blame.Node{})
-		} else {
-			blameLeader = blame.NewBlame(blame.TssSyncFail, []blame.Node{{Pubkey: leaderPubKey, BlameData: nil, BlameSignature: nil}})
-		}
+		blameLeader := t.leaderBlame(errJoinParty)
 
 		if len(onlinePeers) != 0 {
-			t.logger.Trace().Msgf("there were %d onlinePeers, adding leader to %d existing nodes blamed",
+			t.logger.Trace().Msgf("there were %d onlinePeers, adding the unreachable leaders to %d existing nodes blamed",
 				len(onlinePeers), len(blameNodes.BlameNodes))
 			blameNodes.AddBlameNodes(blameLeader.BlameNodes...)
 		} else {
-			t.logger.Trace().Msgf("there were %d onlinePeers, setting blame nodes to just the leader",
+			t.logger.Trace().Msgf("there were %d onlinePeers, setting blame nodes to just the unreachable leaders",
 				len(onlinePeers))
 			blameNodes = blameLeader
 		}
@@ -111,7 +166,8 @@ func (t *TssServer) Keygen(req keygen.Request) (keygen.Response, error) {
 	}
 
 	t.logger.Info().Msg("joinParty succeeded, keygen party formed")
-	t.notifyJoinPartyChan()
+	keygenInstance.GetTssCommonStruct().SetSessionNonce(t.sessionNonce(msgID, req.Version))
+	t.notifyJoinPartyChan(msgID)
 	t.tssMetrics.KeygenJoinParty(joinPartyTime, true)
 
 	// the statistic of keygen only care about Tss it self, even if the
@@ -120,6 +176,10 @@ func (t *TssServer) Keygen(req keygen.Request) (keygen.Response, error) {
 	beforeKeygen := time.Now()
 	k, err := keygenInstance.GenerateNewKey(req)
 	keygenTime := time.Since(beforeKeygen)
+	if err != nil && ctx.Err() != nil {
+		t.logger.Info().Str("msgID", msgID).Msg("the keygen is cancelled")
+		return keygen.Response{}, ctx.Err()
+	}
 	if err != nil {
 		t.tssMetrics.UpdateKeyGen(keygenTime, false)
 		blameNodes := *blameMgr.GetBlame()
@@ -1,6 +1,7 @@
 package tss
 
 import (
+	"context"
 	"encoding/base64"
 	"errors"
 	"fmt"
@@ -21,9 +22,9 @@ import (
 	"github.com/ordinox/thorchain-tss/storage"
 )
 
-func (t *TssServer) waitForSignatures(msgID, poolPubKey string, msgsToSign [][]byte, sigChan chan string) (keysign.Response, error) {
+func (t *TssServer) waitForSignatures(ctx context.Context, msgID, poolPubKey string, msgsToSign [][]byte, sigChan chan string) (keysign.Response, error) {
 	// TSS keysign include both form party and keysign itself, thus we wait twice of the timeout
-	data, err := t.signatureNotifier.WaitForSignature(msgID, msgsToSign, poolPubKey, t.conf.KeySignTimeout, sigChan)
+	data, err := t.signatureNotifier.WaitForSignatureCtx(ctx, msgID, msgsToSign, poolPubKey, t.conf.KeySignTimeout, sigChan)
 	if err != nil {
 		return keysign.Response{}, err
 	}
@@ -35,7 +36,7 @@ func (t *TssServer) waitForSignatures(msgID, poolPubKey string, msgsToSign [][]b
 	return t.batchSignatures(data, msgsToSign), nil
 }
 
-func (t *TssServer) generateSignature(msgID string, msgsToSign [][]byte, req keysign.Request, threshold int, allParticipants []string, localStateItem storage.KeygenLocalState, blameMgr *blame.Manager, keysignInstance *keysign.TssKeySign, sigChan chan string) (keysign.Response, error) {
+func (t *TssServer) generateSignature(ctx context.Context, msgID string, msgsToSign [][]byte, req keysign.Request, threshold int, allParticipants []string, localStateItem storage.KeygenLocalState, blameMgr *blame.Manager, keysignInstance *keysign.TssKeySign, sigChan chan string, record *storage.CeremonyRecord) (keysign.Response, error) {
 	allPeersID, err := conversion.GetPeerIDsFromPubKeys(allParticipants)
 	if err != nil {
 		t.logger.Error().Msg("invalid block height or public key")
@@ -44,6 +45,14 @@ func (t *TssServer) generateSignature(msgID string, msgsToSign [][]byte, req key
 			Blame:  blame.NewBlame(blame.InternalError, []blame.Node{}),
 		}, nil
 	}
+	joinPartyOpts, err := joinPartyOptions(req.LeaderWeights, req.SignerPubKeys, req.AllowedSigners, req.DeniedSigners)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("invalid leader weights or signer constraint")
+		return keysign.Response{
+			Status: common.Fail,
+			Blame:  blame.NewBlame(blame.InternalError, []blame.Node{}),
+		}, nil
+	}
 
 	oldJoinParty, err := conversion.VersionLTCheck(req.Version, messages.NEWJOINPARTYVERSION)
 	if err != nil {
@@ -55,9 +64,9 @@ func (t *TssServer) generateSignature(msgID string, msgsToSign [][]byte, req key
 	// we use the old join party
 	if oldJoinParty {
 		allParticipants = req.SignerPubKeys
-		myPk, err := conversion.GetPubKeyFromPeerID(t.p2pCommunication.GetHost().ID().String())
+		myPk, err := conversion.GetPubKeyFromPeerID(t.transport.ID().String())
 		if err != nil {
-			t.logger.Info().Msgf("fail to convert the p2p id(%s) to pubkey, turn to wait for signature", t.p2pCommunication.GetHost().ID().String())
+			t.logger.Info().Msgf("fail to convert the p2p id(%s) to pubkey, turn to wait for signature", t.transport.ID().String())
 			return keysign.Response{}, p2p.ErrNotActiveSigner
 		}
 		isSignMember := false
@@ -68,15 +77,19 @@ func (t *TssServer) generateSignature(msgID string, msgsToSign [][]byte, req key
 			}
 		}
 		if !isSignMember {
-			t.logger.Info().Msgf("we(%s) are not the active signer", t.p2pCommunication.GetHost().ID().String())
+			t.logger.Info().Msgf("we(%s) are not the active signer", t.transport.ID().String())
 			return keysign.Response{}, p2p.ErrNotActiveSigner
 		}
 
 	}
 
 	joinPartyStartTime := time.Now()
-	onlinePeers, leader, errJoinParty := t.joinParty(msgID, req.Version, req.BlockHeight, allParticipants, threshold, sigChan)
+	onlinePeers, leader, errJoinParty := t.joinParty(ctx, msgID, req.Version, req.BlockHeight, allParticipants, threshold, joinPartyOpts, sigChan)
 	joinPartyTime := time.Since(joinPartyStartTime)
+	record.Leader = leader
+	if ctx.Err() != nil {
+		return keysign.Response{}, ctx.Err()
+	}
 	if errJoinParty != nil {
 		// we received the signature from waiting for signature
 		if errors.Is(errJoinParty, p2p.ErrSignReceived) {
@@ -107,17 +120,19 @@ func (t *TssServer) generateSignature(msgID string, msgsToSign [][]byte, req key
 			}, nil
 		}
 
-		var blameLeader blame.Blame
-		leaderPubKey, err := conversion.GetPubKeyFromPeerID(leader)
-		if err != nil {
-			t.logger.Error().Err(errJoinParty).Msgf("fail to convert the peerID to public key %s", leader)
-			blameLeader = blame.NewBlame(blame.TssSyncFail, []blame.Node{})
-		} else {
-			blameLeader = blame.NewBlame(blame.TssSyncFail, []blame.Node{{leaderPubKey, nil, nil}})
+		if errors.Is(errJoinParty, p2p.ErrSignerConstraintViolated) {
+			t.broadcastKeysignFailure(msgID, allPeersID)
+			t.logger.Error().Err(errJoinParty).Msgf("messagesID(%s) the leader(%s) violated the signer constraint", msgID, leader)
+			return keysign.Response{
+				Status: common.Fail,
+				Blame:  blame.NewBlame(blame.SignerConstraintViolated, t.leaderNode(leader)),
+			}, nil
 		}
 
+		blameLeader := t.leaderBlame(errJoinParty)
+
 		t.broadcastKeysignFailure(msgID, allPeersID)
-		// make sure we blame the leader as well
+		// we only blame the leaders that never answered us
 		t.logger.Error().Err(errJoinParty).Msgf("messagesID(%s)fail to form keysign party with online:%v", msgID, onlinePeers)
 		return keysign.Response{
 			Status: common.Fail,
@@ -126,15 +141,16 @@ func (t *TssServer) generateSignature(msgID string, msgsToSign [][]byte, req key
 
 	}
 	t.tssMetrics.KeysignJoinParty(joinPartyTime, true)
+	keysignInstance.GetTssCommonStruct().SetSessionNonce(t.sessionNonce(msgID, req.Version))
 	isKeySignMember := false
 	for _, el := range onlinePeers {
-		if el == t.p2pCommunication.GetHost().ID() {
+		if el == t.transport.ID() {
 			isKeySignMember = true
 		}
 	}
 	if !isKeySignMember {
 		// we are not the keysign member so we quit keysign and waiting for signature
-		t.logger.Info().Msgf("we(%s) are not the active signer", t.p2pCommunication.GetHost().ID().String())
+		t.logger.Info().Msgf("we(%s) are not the active signer", t.transport.ID().String())
 		return keysign.Response{}, p2p.ErrNotActiveSigner
 	}
 	parsedPeers := make([]string, len(onlinePeers))
@@ -150,19 +166,26 @@ func (t *TssServer) generateSignature(msgID string, msgsToSign [][]byte, req key
 			Blame:  blame.Blame{},
 		}, nil
 	}
+	record.Signers = signers
 	signatureData, err := keysignInstance.SignMessage(msgsToSign, localStateItem, signers)
 	// the statistic of keygen only care about Tss it self, even if the following http response aborts,
 	// it still counted as a successful keygen as the Tss model runs successfully.
+	if err != nil && ctx.Err() != nil {
+		sigChan <- "signature generated"
+		return keysign.Response{}, ctx.Err()
+	}
 	if err != nil {
 		t.logger.Error().Err(err).Msg("err in keysign")
 		sigChan <- "signature generated"
 		t.broadcastKeysignFailure(msgID, allPeersID)
 		blameNodes := *blameMgr.GetBlame()
+		t.recordSignerFailure(onlinePeers, blameNodes.BlameNodes)
 		return keysign.Response{
 			Status: common.Fail,
 			Blame:  blameNodes,
 		}, nil
 	}
+	t.partyCoordinator.SignerStats().Record(onlinePeers, nil)
 
 	sigChan <- "signature generated"
 	// update signature notification
@@ -173,6 +196,24 @@ func (t *TssServer) generateSignature(msgID string, msgsToSign [][]byte, req key
 	return t.batchSignatures(signatureData, msgsToSign), nil
 }
 
+// recordSignerFailure feed the failed keysign to the signer stats, the blamed nodes failed and the other signers
+// did their part, a failure nobody is blamed for tells nothing about the signers
+func (t *TssServer) recordSignerFailure(signers []peer.ID, blameNodes []blame.Node) {
+	var failed []peer.ID
+	for _, el := range blameNodes {
+		pID, err := conversion.GetPeerIDFromPubKey(el.Pubkey)
+		if err != nil {
+			t.logger.Error().Err(err).Msgf("fail to get the peer id of the blamed node(%s)", el.Pubkey)
+			continue
+		}
+		failed = append(failed, pID)
+	}
+	if len(failed) == 0 {
+		return
+	}
+	t.partyCoordinator.SignerStats().Record(signers, failed)
+}
+
 func (t *TssServer) updateKeySignResult(result keysign.Response, timeSpent time.Duration) {
 	if result.Status == common.Success {
 		t.tssMetrics.UpdateKeySign(timeSpent, true)
@@ -183,41 +224,111 @@ func (t *TssServer) updateKeySignResult(result keysign.Response, timeSpent time.
 }
 
 func (t *TssServer) KeySign(req keysign.Request) (keysign.Response, error) {
+	return t.KeySignCtx(context.Background(), req)
+}
+
+// KeySignCtx is KeySign that gives up with the error of the context once it is done, the keysign goes on as long
+// as a duplicate request still waits for it
+func (t *TssServer) KeySignCtx(ctx context.Context, req keysign.Request) (keysign.Response, error) {
 	t.logger.Info().Str("pool pub key", req.PoolPubKey).
 		Str("signer pub keys", strings.Join(req.SignerPubKeys, ",")).
 		Str("msg", strings.Join(req.Messages, ",")).
 		Msg("received keysign request")
-	emptyResp := keysign.Response{}
 	msgID, err := t.requestToMsgId(req)
 	if err != nil {
-		return emptyResp, err
+		return keysign.Response{}, err
+	}
+	if err := req.Priority.Validate(); err != nil {
+		return keysign.Response{}, err
+	}
+	// a retry of the request attaches to the running keysign or gets its outcome
+	resp, shared, err := t.ceremonies.do(ctx, keysignCeremonyKey(msgID, req), func(ctx context.Context) (interface{}, bool, error) {
+		// a second keysign of the same msg id would take over the subscriptions of the first one
+		unlock := t.keysignLocks.Lock(msgID)
+		defer unlock()
+		resp, err := t.scheduleKeySign(ctx, msgID, req)
+		return resp, err == nil && resp.Status == common.Success, err
+	})
+	if shared {
+		t.logger.Info().Str("msgID", msgID).Msg("the keysign request is a duplicate, return the outcome of its keysign")
+	}
+	keysignResp, _ := resp.(keysign.Response)
+	return keysignResp, err
+}
+
+func (t *TssServer) scheduleKeySign(ctx context.Context, msgID string, req keysign.Request) (keysign.Response, error) {
+	stopChan, releaseStopChan := t.ceremonyStopChan(ctx)
+	defer releaseStopChan()
+	release, err := t.keysignScheduler.acquire(req.PoolPubKey, req.Priority.OrDefault(), stopChan)
+	if err != nil && ctx.Err() != nil {
+		t.publishOutcome(msgID, common.Fail, blame.Blame{}, ctx.Err())
+		return keysign.Response{}, ctx.Err()
+	}
+	if err != nil {
+		t.logger.Error().Err(err).Str("msgID", msgID).Msg("fail to schedule the keysign")
+		t.publishOutcome(msgID, common.Fail, blame.Blame{}, err)
+		return keysign.Response{}, err
+	}
+	defer release()
+	record := t.newCeremonyRecord(storage.CeremonyKeysign, msgID, req.PoolPubKey, req, req.SignerPubKeys)
+	var resp keysign.Response
+	version, versionBlame, err := t.negotiateVersion(req.Version, t.keysignParties(req))
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to negotiate the keysign version")
+		resp = keysign.Response{Status: common.Fail, Blame: versionBlame}
+	} else {
+		req.Version = version
+		resp, err = t.keySign(ctx, stopChan, msgID, req, record)
+	}
+	if resp.Status == common.Fail {
+		resp.Blame = t.blameRateLimited(msgID, req.SignerPubKeys, resp.Blame)
+	}
+	t.transport.GetRateLimiter().Release(msgID)
+	t.saveCeremonyRecord(record, resp.Status, resp.Blame, resp.Signatures, err)
+	t.publishOutcome(msgID, resp.Status, resp.Blame, err)
+	return resp, err
+}
+
+// keysignParties return the parties that may join the keysign, the join party with a leader picks the signers
+// among all the parties of the pool
+func (t *TssServer) keysignParties(req keysign.Request) []string {
+	localStateItem, err := t.stateManager.GetLocalState(req.PoolPubKey)
+	if err != nil {
+		return req.SignerPubKeys
 	}
+	return localStateItem.ParticipantKeys
+}
+
+func (t *TssServer) keySign(ctx context.Context, stopChan chan struct{}, msgID string, req keysign.Request, record *storage.CeremonyRecord) (keysign.Response, error) {
+	emptyResp := keysign.Response{}
 
 	keysignInstance := keysign.NewTssKeySign(
-		t.p2pCommunication.GetLocalPeerID(),
+		t.transport.ID().String(),
 		t.conf,
-		t.p2pCommunication.BroadcastMsgChan,
-		t.stopChan,
+		t.transport.BroadcastChannel(),
+		stopChan,
 		msgID,
 		t.privateKey,
-		t.p2pCommunication,
+		t.transport,
 		t.stateManager,
 		len(req.Messages),
 	)
 
+	keysignInstance.GetTssCommonStruct().SetWireFormat(t.wireFormat(req.Version))
+	keysignInstance.GetTssCommonStruct().SetEventBus(t.eventBus)
 	keySignChannels := keysignInstance.GetTssKeySignChannels()
-	t.p2pCommunication.SetSubscribe(messages.TSSKeySignMsg, msgID, keySignChannels)
-	t.p2pCommunication.SetSubscribe(messages.TSSKeySignVerMsg, msgID, keySignChannels)
-	t.p2pCommunication.SetSubscribe(messages.TSSControlMsg, msgID, keySignChannels)
-	t.p2pCommunication.SetSubscribe(messages.TSSTaskDone, msgID, keySignChannels)
+	t.transport.SetSubscribe(messages.TSSKeySignMsg, msgID, keySignChannels)
+	t.transport.SetSubscribe(messages.TSSKeySignVerMsg, msgID, keySignChannels)
+	t.transport.SetSubscribe(messages.TSSControlMsg, msgID, keySignChannels)
+	t.transport.SetSubscribe(messages.TSSTaskDone, msgID, keySignChannels)
 
 	defer func() {
-		t.p2pCommunication.CancelSubscribe(messages.TSSKeySignMsg, msgID)
-		t.p2pCommunication.CancelSubscribe(messages.TSSKeySignVerMsg, msgID)
-		t.p2pCommunication.CancelSubscribe(messages.TSSControlMsg, msgID)
-		t.p2pCommunication.CancelSubscribe(messages.TSSTaskDone, msgID)
+		t.transport.CancelSubscribe(messages.TSSKeySignMsg, msgID)
+		t.transport.CancelSubscribe(messages.TSSKeySignVerMsg, msgID)
+		t.transport.CancelSubscribe(messages.TSSControlMsg, msgID)
+		t.transport.CancelSubscribe(messages.TSSTaskDone, msgID)
 
-		t.p2pCommunication.ReleaseStream(msgID)
+		t.transport.ReleaseStream(msgID)
 		t.signatureNotifier.ReleaseStream(msgID)
 		t.partyCoordinator.ReleaseStream(msgID)
 	}()
@@ -284,7 +395,7 @@ func (t *TssServer) KeySign(req keysign.Request) (keysign.Response, error) {
 	// we wait for signatures
 	go func() {
 		defer wg.Done()
-		receivedSig, errWait = t.waitForSignatures(msgID, req.PoolPubKey, msgsToSign, sigChan)
+		receivedSig, errWait = t.waitForSignatures(ctx, msgID, req.PoolPubKey, msgsToSign, sigChan)
 		// we received an valid signature indeed
 		if errWait == nil {
 			sigChan <- "signature received"
@@ -297,10 +408,14 @@ func (t *TssServer) KeySign(req keysign.Request) (keysign.Response, error) {
 	// we generate the signature ourselves
 	go func() {
 		defer wg.Done()
-		generatedSig, errGen = t.generateSignature(msgID, msgsToSign, req, threshold, localStateItem.ParticipantKeys, localStateItem, blameMgr, keysignInstance, sigChan)
+		generatedSig, errGen = t.generateSignature(ctx, msgID, msgsToSign, req, threshold, localStateItem.ParticipantKeys, localStateItem, blameMgr, keysignInstance, sigChan, record)
 	}()
 	wg.Wait()
 	close(sigChan)
+	if ctx.Err() != nil {
+		t.logger.Info().Str("msgID", msgID).Msg("the keysign is cancelled")
+		return emptyResp, ctx.Err()
+	}
 	keysignTime := time.Since(keysignStartTime)
 	// we received the generated verified signature, so we return
 	if errWait == nil {
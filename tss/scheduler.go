@@ -0,0 +1,192 @@
+package tss
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ordinox/thorchain-tss/keysign"
+	"github.com/ordinox/thorchain-tss/monitor"
+)
+
+// ErrQueueFull is returned when the keysign cannot run now and the queue of its priority class is full
+var ErrQueueFull = errors.New("keysign queue is full")
+
+// errSchedulerStopped is returned to the keysigns still queued when the tss server stops
+var errSchedulerStopped = errors.New("tss server is stopped")
+
+// keysignTicket is a keysign waiting in the queue of the scheduler
+type keysignTicket struct {
+	pool       string
+	ready      chan struct{}
+	dispatched bool
+}
+
+// keysignScheduler bound the keysigns that run at the same time, overall and per pool, the keysigns over the
+// limits wait in the queue of their priority class, the higher classes are dispatched first
+type keysignScheduler struct {
+	lock          *sync.Mutex
+	maxRunning    int
+	maxPerPool    int
+	queueSize     int
+	running       int
+	runningByPool map[string]int
+	queues        map[keysign.Priority][]*keysignTicket
+	metrics       *monitor.Metric
+}
+
+// newKeysignScheduler create a new instance of keysignScheduler, a zero limit means no limit, the queue size is
+// per priority class
+func newKeysignScheduler(maxRunning, maxPerPool, queueSize int, metrics *monitor.Metric) *keysignScheduler {
+	return &keysignScheduler{
+		lock:          &sync.Mutex{},
+		maxRunning:    maxRunning,
+		maxPerPool:    maxPerPool,
+		queueSize:     queueSize,
+		runningByPool: make(map[string]int),
+		queues:        make(map[keysign.Priority][]*keysignTicket),
+		metrics:       metrics,
+	}
+}
+
+// canRun tell whether a keysign of the given pool is within the limits, the lock must be held
+func (s *keysignScheduler) canRun(pool string) bool {
+	if s.maxRunning > 0 && s.running >= s.maxRunning {
+		return false
+	}
+	return s.maxPerPool <= 0 || s.runningByPool[pool] < s.maxPerPool
+}
+
+// start count a keysign of the given pool as running, the lock must be held
+func (s *keysignScheduler) start(pool string) {
+	s.running++
+	s.runningByPool[pool]++
+	s.metrics.KeysignRunning(s.running)
+}
+
+// acquire wait until a keysign of the given pool can run, the returned function must be called once it is done.
+// It fails with ErrQueueFull when the queue of the priority class is full, and with errSchedulerStopped when the
+// stop channel is closed while we wait.
+func (s *keysignScheduler) acquire(pool string, priority keysign.Priority, stopChan chan struct{}) (func(), error) {
+	release := func() { s.release(pool) }
+	s.lock.Lock()
+	if s.canRun(pool) {
+		s.start(pool)
+		s.lock.Unlock()
+		return release, nil
+	}
+	if len(s.queues[priority]) >= s.queueSize {
+		s.lock.Unlock()
+		s.metrics.KeysignRejected(string(priority))
+		return nil, fmt.Errorf("%w, %d keysigns of %s priority are waiting", ErrQueueFull, s.queueSize, priority)
+	}
+	ticket := &keysignTicket{pool: pool, ready: make(chan struct{})}
+	s.queues[priority] = append(s.queues[priority], ticket)
+	s.metrics.KeysignQueued(string(priority), len(s.queues[priority]))
+	s.lock.Unlock()
+
+	queuedAt := time.Now()
+	select {
+	case <-ticket.ready:
+		s.metrics.KeysignQueueWait(string(priority), time.Since(queuedAt))
+		return release, nil
+	case <-stopChan:
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if ticket.dispatched {
+		// we have been dispatched in the meantime, give the slot to the next one
+		s.finish(pool)
+		return nil, errSchedulerStopped
+	}
+	queue := s.queues[priority]
+	for i, el := range queue {
+		if el == ticket {
+			s.queues[priority] = append(queue[:i:i], queue[i+1:]...)
+			break
+		}
+	}
+	s.metrics.KeysignQueued(string(priority), len(s.queues[priority]))
+	return nil, errSchedulerStopped
+}
+
+func (s *keysignScheduler) release(pool string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.finish(pool)
+}
+
+// finish count a keysign of the given pool as done and dispatch the queued keysigns that can run now, the
+// lock must be held
+func (s *keysignScheduler) finish(pool string) {
+	s.running--
+	s.runningByPool[pool]--
+	if s.runningByPool[pool] <= 0 {
+		delete(s.runningByPool, pool)
+	}
+	s.metrics.KeysignRunning(s.running)
+	s.dispatch()
+}
+
+// dispatch start the queued keysigns that are within the limits, the higher priority classes first and in the
+// order they were queued within a class, the lock must be held
+func (s *keysignScheduler) dispatch() {
+	for _, priority := range keysign.Priorities {
+		queue := s.queues[priority]
+		remaining := queue[:0]
+		for _, el := range queue {
+			if !s.canRun(el.pool) {
+				remaining = append(remaining, el)
+				continue
+			}
+			s.start(el.pool)
+			el.dispatched = true
+			close(el.ready)
+		}
+		s.queues[priority] = remaining
+		s.metrics.KeysignQueued(string(priority), len(remaining))
+	}
+}
+
+// partyLocks serialize the ceremonies of the same msg id, such as the keygens of the same party set, so they do not
+// take over the subscriptions of each other, the ceremonies of different msg ids run in parallel
+type partyLocks struct {
+	lock  *sync.Mutex
+	locks map[string]*partyLock
+}
+
+type partyLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+func newPartyLocks() *partyLocks {
+	return &partyLocks{
+		lock:  &sync.Mutex{},
+		locks: make(map[string]*partyLock),
+	}
+}
+
+// Lock wait until no other ceremony of the given msg id runs, the returned function unlocks it
+func (p *partyLocks) Lock(key string) func() {
+	p.lock.Lock()
+	l, ok := p.locks[key]
+	if !ok {
+		l = &partyLock{}
+		p.locks[key] = l
+	}
+	l.refs++
+	p.lock.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+		p.lock.Lock()
+		defer p.lock.Unlock()
+		l.refs--
+		if l.refs == 0 {
+			delete(p.locks, key)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package tss
+
+import (
+	"errors"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/ordinox/thorchain-tss/keysign"
+	"github.com/ordinox/thorchain-tss/monitor"
+)
+
+type SchedulerTestSuite struct{}
+
+var _ = Suite(&SchedulerTestSuite{})
+
+type acquireResult struct {
+	release func()
+	err     error
+}
+
+func acquireAsync(scheduler *keysignScheduler, pool string, priority keysign.Priority, stopChan chan struct{}) chan acquireResult {
+	result := make(chan acquireResult, 1)
+	go func() {
+		release, err := scheduler.acquire(pool, priority, stopChan)
+		result <- acquireResult{release: release, err: err}
+	}()
+	return result
+}
+
+func waitQueued(c *C, scheduler *keysignScheduler, priority keysign.Priority, n int) {
+	for i := 0; i < 100; i++ {
+		scheduler.lock.Lock()
+		queued := len(scheduler.queues[priority])
+		scheduler.lock.Unlock()
+		if queued == n {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Fatalf("%d keysigns of %s priority are not queued", n, priority)
+}
+
+func waitAcquired(c *C, result chan acquireResult) func() {
+	select {
+	case r := <-result:
+		c.Assert(r.err, IsNil)
+		return r.release
+	case <-time.After(time.Second):
+		c.Fatal("the keysign has not been dispatched")
+	}
+	return nil
+}
+
+func assertWaiting(c *C, result chan acquireResult) {
+	select {
+	case <-result:
+		c.Fatal("the keysign should still be queued")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func (s *SchedulerTestSuite) TestKeysignScheduler(c *C) {
+	stopChan := make(chan struct{})
+	scheduler := newKeysignScheduler(2, 1, 1, monitor.NewMetric())
+	releaseA, err := scheduler.acquire("poolA", keysign.PriorityNormal, stopChan)
+	c.Assert(err, IsNil)
+	releaseB, err := scheduler.acquire("poolB", keysign.PriorityNormal, stopChan)
+	c.Assert(err, IsNil)
+
+	// we are at the overall limit, the next keysigns wait in the queue of their priority
+	low := acquireAsync(scheduler, "poolC", keysign.PriorityLow, stopChan)
+	waitQueued(c, scheduler, keysign.PriorityLow, 1)
+	normal := acquireAsync(scheduler, "poolA", keysign.PriorityNormal, stopChan)
+	waitQueued(c, scheduler, keysign.PriorityNormal, 1)
+	high := acquireAsync(scheduler, "poolD", keysign.PriorityHigh, stopChan)
+	waitQueued(c, scheduler, keysign.PriorityHigh, 1)
+	_, err = scheduler.acquire("poolE", keysign.PriorityNormal, stopChan)
+	c.Assert(errors.Is(err, ErrQueueFull), Equals, true)
+
+	// the high priority keysign goes first
+	releaseB()
+	releaseHigh := waitAcquired(c, high)
+	assertWaiting(c, normal)
+	assertWaiting(c, low)
+
+	// poolA is at its limit, so the low priority keysign of poolC goes before the normal one of poolA
+	releaseHigh()
+	releaseLow := waitAcquired(c, low)
+	assertWaiting(c, normal)
+	releaseA()
+	releaseNormal := waitAcquired(c, normal)
+
+	// the queued keysigns give up once the server stops
+	queued := acquireAsync(scheduler, "poolF", keysign.PriorityLow, stopChan)
+	waitQueued(c, scheduler, keysign.PriorityLow, 1)
+	close(stopChan)
+	r := <-queued
+	c.Assert(r.err, Equals, errSchedulerStopped)
+	releaseLow()
+	releaseNormal()
+	c.Assert(scheduler.running, Equals, 0)
+	c.Assert(scheduler.runningByPool, HasLen, 0)
+	c.Assert(scheduler.queues[keysign.PriorityLow], HasLen, 0)
+}
+
+func (s *SchedulerTestSuite) TestKeysignSchedulerWithoutLimits(c *C) {
+	scheduler := newKeysignScheduler(0, 0, 0, monitor.NewMetric())
+	var releases []func()
+	for i := 0; i < 10; i++ {
+		release, err := scheduler.acquire("pool", keysign.PriorityLow, nil)
+		c.Assert(err, IsNil)
+		releases = append(releases, release)
+	}
+	for _, el := range releases {
+		el()
+	}
+	c.Assert(scheduler.running, Equals, 0)
+}
+
+func (s *SchedulerTestSuite) TestPartyLocks(c *C) {
+	locks := newPartyLocks()
+	unlockA := locks.Lock("a")
+	// another party set is not blocked
+	unlockB := locks.Lock("b")
+	unlockB()
+
+	locked := make(chan func())
+	go func() {
+		locked <- locks.Lock("a")
+	}()
+	select {
+	case <-locked:
+		c.Fatal("the keygens of the same party set should not run in parallel")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlockA()
+	unlockA = <-locked
+	unlockA()
+	c.Assert(locks.locks, HasLen, 0)
+}
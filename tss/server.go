@@ -1,8 +1,13 @@
 package tss
 
 import (
+	"context"
+
 	"github.com/ordinox/thorchain-tss/keygen"
 	"github.com/ordinox/thorchain-tss/keysign"
+	"github.com/ordinox/thorchain-tss/monitor"
+	"github.com/ordinox/thorchain-tss/p2p"
+	"github.com/ordinox/thorchain-tss/storage"
 )
 
 // Server define the necessary functionality should be provide by a TSS Server implementation
@@ -14,4 +19,16 @@ type Server interface {
 	GetKnownPeers() []PeerInfo
 	Keygen(req keygen.Request) (keygen.Response, error)
 	KeySign(req keysign.Request) (keysign.Response, error)
+	KeygenCtx(ctx context.Context, req keygen.Request) (keygen.Response, error)
+	KeySignCtx(ctx context.Context, req keysign.Request) (keysign.Response, error)
+	SubmitKeygenJob(req keygen.Request, callbackURL string) (storage.JobRecord, error)
+	SubmitKeySignJob(req keysign.Request, callbackURL string) (storage.JobRecord, error)
+	GetJob(id string) (storage.JobRecord, error)
+	GetCeremonyHistory(filter storage.CeremonyFilter) ([]storage.CeremonyRecord, error)
+	SubscribeEvents(msgID string) (<-chan monitor.Event, func())
+	SetAllowedPubKeys(pubKeys []string) error
+	GetAllowedPubKeys() []string
+	GetNATStatus() p2p.NATStatus
+	GetPeersHealth() []p2p.PeerHealth
+	GetPeerVersions() []p2p.PeerVersion
 }
@@ -1,21 +1,25 @@
 package tss
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	coskey "github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
 	sdk "github.com/cosmos/cosmos-sdk/types/bech32/legacybech32"
 	"github.com/libp2p/go-libp2p/core/peer"
 	maddr "github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
 	bkeygen "github.com/ordinox/thorchain-tss-lib/ecdsa/keygen"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	tcrypto "github.com/tendermint/tendermint/crypto"
 
+	"github.com/ordinox/thorchain-tss/blame"
 	"github.com/ordinox/thorchain-tss/common"
 	"github.com/ordinox/thorchain-tss/conversion"
 	"github.com/ordinox/thorchain-tss/keygen"
@@ -30,22 +34,32 @@ import (
 type TssServer struct {
 	conf              common.TssConfig
 	logger            zerolog.Logger
+	transport         p2p.Transport
 	p2pCommunication  *p2p.Communication
 	localNodePubKey   string
 	preParams         *bkeygen.LocalPreParams
-	tssKeyGenLocker   *sync.Mutex
+	keygenLocks       *partyLocks
+	keysignLocks      *partyLocks
+	keysignScheduler  *keysignScheduler
+	ceremonies        *ceremonyCache
 	stopChan          chan struct{}
-	joinPartyChan     chan struct{}
+	joinPartyChanLock *sync.Mutex
+	joinPartyChans    map[string]chan struct{}
 	partyCoordinator  *p2p.PartyCoordinator
 	stateManager      storage.LocalStateManager
 	signatureNotifier *keysign.SignatureNotifier
+	versionNegotiator *p2p.VersionNegotiator
 	privateKey        tcrypto.PrivKey
 	tssMetrics        *monitor.Metric
+	eventBus          *monitor.EventBus
 }
 
 type PeerInfo struct {
-	ID      string
+	ID string
+	// Address is the IPv4 or IPv6 address of the connection to the peer
 	Address string
+	// Addresses are all the multiaddrs we know for the peer, of every address family and transport
+	Addresses []string
 }
 
 // NewTss create a new instance of Tss
@@ -76,24 +90,98 @@ func NewTss(
 		return nil, fmt.Errorf("fail to create file state manager")
 	}
 
-	var bootstrapPeers []maddr.Multiaddr
+	// the saved peers help us to reconnect, but only the bootstrap peers of the operator are trusted
 	savedPeers, err := stateManager.RetrieveP2PAddresses()
 	if err != nil {
-		bootstrapPeers = cmdBootstrapPeers
-	} else {
-		bootstrapPeers = savedPeers
-		bootstrapPeers = append(bootstrapPeers, cmdBootstrapPeers...)
+		savedPeers = nil
 	}
-	comm, err := p2p.NewCommunication(rendezvous, bootstrapPeers, p2pPort, externalIP)
+	comm, err := p2p.NewCommunication(p2p.Config{
+		RendezvousString: rendezvous,
+		Port:             p2pPort,
+		BootstrapPeers:   cmdBootstrapPeers,
+		KnownPeers:       savedPeers,
+		ExternalIP:       externalIP,
+		NetworkConfig:    conf.Network,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("fail to create communication layer: %w", err)
 	}
+	comm.EnableCompression(conf.EnableCompression)
+	metrics := monitor.NewMetric()
+	if err := setupTransport(comm, conf, metrics); err != nil {
+		return nil, err
+	}
+	comm.SetHealthObserver(func(pID peer.ID, rtt time.Duration, err error) {
+		metrics.PeerPing(pID.String(), rtt, err == nil)
+	})
+	preParams, err = ensurePreParams(preParams, conf)
+	if err != nil {
+		return nil, err
+	}
+	priKeyRawBytes, err := conversion.GetPriKeyRawBytes(priKey)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get private key")
+	}
+	if err := comm.Start(priKeyRawBytes); nil != err {
+		return nil, fmt.Errorf("fail to start p2p network: %w", err)
+	}
+	return newTssServer(comm, priKey, pubKey, stateManager, conf, preParams, metrics)
+}
+
+// NewTssWithTransport create a new instance of Tss that talks to the other nodes over the given transport,
+// which must be ready to use, it lets the whole server run within the process on top of a MemoryNetwork
+func NewTssWithTransport(
+	transport p2p.Transport,
+	priKey tcrypto.PrivKey,
+	baseFolder string,
+	conf common.TssConfig,
+	preParams *bkeygen.LocalPreParams,
+) (*TssServer, error) {
+	pk := coskey.PubKey{
+		Key: priKey.PubKey().Bytes()[:],
+	}
+	pubKey, err := sdk.MarshalPubKey(sdk.AccPK, &pk)
+	if err != nil {
+		return nil, fmt.Errorf("fail to genearte the key: %w", err)
+	}
+	stateManager, err := storage.NewFileStateMgr(baseFolder)
+	if err != nil {
+		return nil, fmt.Errorf("fail to create file state manager")
+	}
+	metrics := monitor.NewMetric()
+	if err := setupTransport(transport, conf, metrics); err != nil {
+		return nil, err
+	}
+	preParams, err = ensurePreParams(preParams, conf)
+	if err != nil {
+		return nil, err
+	}
+	return newTssServer(transport, priKey, pubKey, stateManager, conf, preParams, metrics)
+}
+
+// setupTransport apply the allowlist to the given transport and report its rejections and violations to the metrics
+func setupTransport(transport p2p.Transport, conf common.TssConfig, metrics *monitor.Metric) error {
+	if err := transport.SetAllowedPubKeys(conf.AllowedPubKeys); err != nil {
+		return fmt.Errorf("fail to set the allowed pub keys: %w", err)
+	}
+	transport.GetConnectionGater().SetRejectHandler(func(_ peer.ID, reason string) {
+		metrics.RejectedConnection(reason)
+	})
+	transport.GetRateLimiter().SetObserver(func(_ peer.ID, reason string, banned bool) {
+		metrics.RateLimited(reason, banned)
+	})
+	return nil
+}
+
+// ensurePreParams return the given pre parameters, or generate them if they are missing or invalid
+func ensurePreParams(preParams *bkeygen.LocalPreParams, conf common.TssConfig) (*bkeygen.LocalPreParams, error) {
 	// When using the keygen party it is recommended that you pre-compute the
 	// "safe primes" and Paillier secret beforehand because this can take some
 	// time.
 	// This code will generate those parameters using a concurrency limit equal
 	// to the number of available CPU cores.
 	if preParams == nil || !preParams.Validate() {
+		var err error
 		preParams, err = bkeygen.GeneratePreParams(conf.PreParamTimeout)
 		if err != nil {
 			return nil, fmt.Errorf("fail to generate pre parameters: %w", err)
@@ -102,33 +190,64 @@ func NewTss(
 	if !preParams.Validate() {
 		return nil, errors.New("invalid preparams")
 	}
+	return preParams, nil
+}
 
-	priKeyRawBytes, err := conversion.GetPriKeyRawBytes(priKey)
-	if err != nil {
-		return nil, fmt.Errorf("fail to get private key")
+// newTssServer wire the tss server on top of the given transport, the libp2p only features are only
+// available when it is a Communication
+func newTssServer(
+	transport p2p.Transport,
+	priKey tcrypto.PrivKey,
+	pubKey string,
+	stateManager storage.LocalStateManager,
+	conf common.TssConfig,
+	preParams *bkeygen.LocalPreParams,
+	metrics *monitor.Metric,
+) (*TssServer, error) {
+	pc := p2p.NewPartyCoordinator(transport, conf.PartyTimeout, conf.Network.WithDefaults())
+	comm, _ := transport.(*p2p.Communication)
+	var healthMonitor *p2p.HealthMonitor
+	if comm != nil {
+		healthMonitor = comm.GetHealthMonitor()
+		pc.SetHealthMonitor(healthMonitor)
 	}
-	if err := comm.Start(priKeyRawBytes); nil != err {
-		return nil, fmt.Errorf("fail to start p2p network: %w", err)
+	signerPolicy, err := p2p.NewSignerPolicy(conf.Network.SignerSelection, conf.Network.PreferredSigners, healthMonitor, pc.SignerStats())
+	if err != nil {
+		pc.Stop()
+		return nil, fmt.Errorf("fail to create the signer policy: %w", err)
 	}
-	pc := p2p.NewPartyCoordinator(comm.GetHost(), conf.PartyTimeout)
-	sn := keysign.NewSignatureNotifier(comm.GetHost())
-	metrics := monitor.NewMetric()
+	pc.SetSignerPolicy(signerPolicy)
+	pc.SetRateLimiter(transport.GetRateLimiter())
+	eventBus := monitor.NewEventBus()
+	pc.SetEventBus(eventBus)
+	sn := keysign.NewSignatureNotifier(transport)
+	sn.SetRateLimiter(transport.GetRateLimiter())
+	vn := p2p.NewVersionNegotiator(transport, conf.Network)
+	vn.SetRateLimiter(transport.GetRateLimiter())
 	if conf.EnableMonitor {
 		metrics.Enable()
 	}
 	tssServer := TssServer{
 		conf:              conf,
-		logger:            logger,
+		logger:            log.With().Str("module", "tss").Logger(),
+		transport:         transport,
 		p2pCommunication:  comm,
 		localNodePubKey:   pubKey,
 		preParams:         preParams,
-		tssKeyGenLocker:   &sync.Mutex{},
+		keygenLocks:       newPartyLocks(),
+		keysignLocks:      newPartyLocks(),
+		keysignScheduler:  newKeysignScheduler(conf.MaxConcurrentKeysigns, conf.MaxConcurrentKeysignsPerPool, conf.KeysignQueueSize, metrics),
+		ceremonies:        newCeremonyCache(conf.CeremonyCacheTTL),
 		stopChan:          make(chan struct{}),
+		joinPartyChanLock: &sync.Mutex{},
+		joinPartyChans:    make(map[string]chan struct{}),
 		partyCoordinator:  pc,
 		stateManager:      stateManager,
 		signatureNotifier: sn,
+		versionNegotiator: vn,
 		privateKey:        priKey,
 		tssMetrics:        metrics,
+		eventBus:          eventBus,
 	}
 
 	return &tssServer, nil
@@ -137,6 +256,11 @@ func NewTss(
 // Start Tss server
 func (t *TssServer) Start() error {
 	t.logger.Info().Msg("starting the tss servers")
+	if t.conf.JournalRetention != 0 || t.conf.JournalMaxEntries != 0 {
+		go t.journalRetentionLoop()
+	}
+	// the jobs the previous run left unfinished are failed before we take new ones
+	go t.jobsLoop(t.recoverJobs())
 	return nil
 }
 
@@ -144,24 +268,35 @@ func (t *TssServer) Start() error {
 func (t *TssServer) Stop() {
 	close(t.stopChan)
 	// stop the p2p and finish the p2p wait group
-	err := t.p2pCommunication.Stop()
+	err := t.transport.Stop()
 	if err != nil {
 		t.logger.Error().Msgf("error in shutdown the p2p server")
 	}
 	t.partyCoordinator.Stop()
+	t.versionNegotiator.Stop()
 	t.logger.Info().Msg("The tss and p2p server has been stopped successfully")
 }
 
-func (t *TssServer) setJoinPartyChan(jpc chan struct{}) {
-	t.joinPartyChan = jpc
+// setJoinPartyChan make the keygen of the given msg id notify the channel once its party is formed, the keygens
+// of different msg ids run in parallel so each has its own channel
+func (t *TssServer) setJoinPartyChan(msgID string, jpc chan struct{}) {
+	t.joinPartyChanLock.Lock()
+	defer t.joinPartyChanLock.Unlock()
+	t.joinPartyChans[msgID] = jpc
 }
-func (t *TssServer) unsetJoinPartyChan() {
-	t.joinPartyChan = nil
+
+func (t *TssServer) unsetJoinPartyChan(msgID string) {
+	t.joinPartyChanLock.Lock()
+	defer t.joinPartyChanLock.Unlock()
+	delete(t.joinPartyChans, msgID)
 }
 
-func (t *TssServer) notifyJoinPartyChan() {
-	if t.joinPartyChan != nil {
-		t.joinPartyChan <- struct{}{}
+func (t *TssServer) notifyJoinPartyChan(msgID string) {
+	t.joinPartyChanLock.Lock()
+	jpc := t.joinPartyChans[msgID]
+	t.joinPartyChanLock.Unlock()
+	if jpc != nil {
+		jpc <- struct{}{}
 	}
 }
 
@@ -188,7 +323,59 @@ func (t *TssServer) requestToMsgId(request interface{}) (string, error) {
 	return common.MsgToHashString(dat)
 }
 
-func (t *TssServer) joinParty(msgID, version string, blockHeight int64, participants []string, threshold int, sigChan chan string) ([]peer.ID, string, error) {
+// wireFormat return the encoding of the tss messages for a ceremony of the given version, all the parties of a
+// ceremony receive the same version, so they agree on the encoding of the shares they hash and sign
+func (t *TssServer) wireFormat(version string) messages.WireFormat {
+	legacy, err := conversion.VersionLTCheck(version, messages.PROTOBUFWIREVERSION)
+	if err != nil {
+		t.logger.Error().Err(err).Msgf("fail to parse the version(%s), fallback to json wire format", version)
+		return messages.WireFormatJSON
+	}
+	if legacy {
+		return messages.WireFormatJSON
+	}
+	return messages.WireFormatProtobuf
+}
+
+// sessionNonce return the nonce the leader picked for the session of the ceremony, the ceremonies older than the
+// replay protection run without it, as their peers cannot verify the messages that carry it
+func (t *TssServer) sessionNonce(msgID, version string) []byte {
+	legacy, err := conversion.VersionLTCheck(version, messages.REPLAYPROTECTIONVERSION)
+	if err != nil || legacy {
+		return nil
+	}
+	return t.partyCoordinator.SessionNonce(msgID)
+}
+
+// negotiateVersion decide the version of the ceremony among the given parties, the version of the request is the
+// one the ceremony requires, if it is empty we run the highest version all the parties support. The parties
+// that do not support the required version are blamed.
+func (t *TssServer) negotiateVersion(version string, participants []string) (string, blame.Blame, error) {
+	peerIDs, err := conversion.GetPeerIDsFromPubKeys(participants)
+	if err != nil {
+		return "", blame.NewBlame(blame.InternalError, []blame.Node{}), fmt.Errorf("fail to convert pub key to peer id: %w", err)
+	}
+	negotiated, err := t.versionNegotiator.Negotiate(peerIDs, version)
+	if err == nil {
+		return negotiated, blame.Blame{}, nil
+	}
+	var mismatch p2p.ErrVersionMismatch
+	if !errors.As(err, &mismatch) {
+		return "", blame.NewBlame(blame.InternalError, []blame.Node{}), err
+	}
+	var blameNodes []blame.Node
+	for _, el := range mismatch.Lagging {
+		pubKey, errPubKey := conversion.GetPubKeyFromPeerID(el.PeerID.String())
+		if errPubKey != nil {
+			t.logger.Error().Err(errPubKey).Msgf("fail to get the pub key of peer(%s)", el.PeerID)
+			continue
+		}
+		blameNodes = append(blameNodes, blame.NewNode(pubKey, []byte(el.Version), nil))
+	}
+	return "", blame.NewBlame(blame.VersionMismatch, blameNodes), err
+}
+
+func (t *TssServer) joinParty(ctx context.Context, msgID, version string, blockHeight int64, participants []string, threshold int, opts p2p.JoinPartyOptions, sigChan chan string) ([]peer.ID, string, error) {
 	oldJoinParty, err := conversion.VersionLTCheck(version, messages.NEWJOINPARTYVERSION)
 	if err != nil {
 		return nil, "", fmt.Errorf("fail to parse the version with error:%w", err)
@@ -203,7 +390,7 @@ func (t *TssServer) joinParty(msgID, version string, blockHeight int64, particip
 		for _, el := range peerIDs {
 			peersIDStr = append(peersIDStr, el.String())
 		}
-		onlines, err := t.partyCoordinator.JoinPartyWithRetry(msgID, peersIDStr)
+		onlines, err := t.partyCoordinator.JoinPartyWithRetryCtx(ctx, msgID, peersIDStr)
 		return onlines, "NONE", err
 	} else {
 		t.logger.Info().Msg("we apply the join party with a leader")
@@ -220,14 +407,150 @@ func (t *TssServer) joinParty(msgID, version string, blockHeight int64, particip
 		for _, el := range peersID {
 			peersIDStr = append(peersIDStr, el.String())
 		}
+		return t.partyCoordinator.JoinPartyWithLeaderOpts(ctx, msgID, blockHeight, peersIDStr, threshold, sigChan, opts)
+	}
+}
+
+// joinPartyOptions convert the leader weights and the allowed and denied signers of a request, which are keyed by
+// the node pub keys, to the join party options, which are keyed by the peer ids. The signers of the request, when
+// given, are allowed too, a node signs only if it is among them and among the allowed signers.
+func joinPartyOptions(leaderWeights map[string]uint64, signers, allowedSigners, deniedSigners []string) (p2p.JoinPartyOptions, error) {
+	var opts p2p.JoinPartyOptions
+	if len(leaderWeights) != 0 {
+		opts.LeaderWeights = make(map[string]uint64, len(leaderWeights))
+		for pubKey, weight := range leaderWeights {
+			peerID, err := conversion.GetPeerIDFromPubKey(pubKey)
+			if err != nil {
+				return opts, fmt.Errorf("fail to convert the pub key of the leader weight to peer ID: %w", err)
+			}
+			opts.LeaderWeights[peerID.String()] = weight
+		}
+	}
+	allowed, err := allowedSignersOf(signers, allowedSigners)
+	if err != nil {
+		return opts, err
+	}
+	opts.SignerConstraint.Allowed, err = conversion.GetPeerIDsFromPubKeys(allowed)
+	if err != nil {
+		return opts, fmt.Errorf("fail to convert the allowed signers to peer IDs: %w", err)
+	}
+	opts.SignerConstraint.Denied, err = conversion.GetPeerIDsFromPubKeys(deniedSigners)
+	if err != nil {
+		return opts, fmt.Errorf("fail to convert the denied signers to peer IDs: %w", err)
+	}
+	return opts, nil
+}
+
+// allowedSignersOf return the node pub keys that are both among the signers and the allowed signers of a request,
+// an empty list of either allows everyone
+func allowedSignersOf(signers, allowedSigners []string) ([]string, error) {
+	if len(signers) == 0 {
+		return allowedSigners, nil
+	}
+	if len(allowedSigners) == 0 {
+		return signers, nil
+	}
+	var allowed []string
+	for _, el := range signers {
+		for _, pk := range allowedSigners {
+			if el == pk {
+				allowed = append(allowed, el)
+				break
+			}
+		}
+	}
+	if len(allowed) == 0 {
+		return nil, errors.New("none of the signer pub keys is an allowed signer")
+	}
+	return allowed, nil
+}
+
+// ceremonyStopChan return a channel that is closed once the tss server stops or the context of the ceremony is
+// done, so cancelling a ceremony does not stop the others, the returned function releases it
+func (t *TssServer) ceremonyStopChan(ctx context.Context) (chan struct{}, func()) {
+	stopChan := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-t.stopChan:
+		case <-ctx.Done():
+		case <-done:
+			return
+		}
+		close(stopChan)
+	}()
+	return stopChan, func() { close(done) }
+}
+
+// leaderBlame return the blame of the leaders that never answered us during the join party, the leaders we could
+// not reach fail to sync, the leader that has our request but never formed the party is not ready, the leader that
+// formed the party is not blamed for its failure
+func (t *TssServer) leaderBlame(errJoinParty error) blame.Blame {
+	var unreachable p2p.ErrUnreachableLeaders
+	if !errors.As(errJoinParty, &unreachable) {
+		return blame.NewBlame(blame.TssSyncFail, []blame.Node{})
+	}
+	nodes := make([]blame.Node, 0, len(unreachable.Leaders)+1)
+	leaders := unreachable.Leaders
+	reason := blame.TssSyncFail
+	if unreachable.NotReady != "" {
+		leaders = append(leaders[:len(leaders):len(leaders)], unreachable.NotReady)
+		reason = blame.LeaderNotReady
+	}
+	for _, el := range leaders {
+		pk, err := conversion.GetPubKeyFromPeerID(el.String())
+		if err != nil {
+			t.logger.Error().Err(err).Msgf("fail to convert the peerID to public key %s", el)
+			continue
+		}
+		nodes = append(nodes, blame.NewNode(pk, nil, nil))
+	}
+	return blame.NewBlame(reason, nodes)
+}
+
+// leaderNode return the blame node of the leader of the join party
+func (t *TssServer) leaderNode(leader string) []blame.Node {
+	pk, err := conversion.GetPubKeyFromPeerID(leader)
+	if err != nil {
+		t.logger.Error().Err(err).Msgf("fail to convert the peerID to public key %s", leader)
+		return []blame.Node{}
+	}
+	return []blame.Node{blame.NewNode(pk, nil, nil)}
+}
 
-		return t.partyCoordinator.JoinPartyWithLeader(msgID, blockHeight, peersIDStr, threshold, sigChan)
+// blameRateLimited add the peers that exceeded their p2p rate limits during the ceremony, or that are banned
+// for it, to the blame of a failed ceremony, the blame data of each node is the limit it exceeded
+func (t *TssServer) blameRateLimited(msgID string, participants []string, result blame.Blame) blame.Blame {
+	peerIDs, err := conversion.GetPeerIDsFromPubKeys(participants)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to convert the participants pub keys to peer IDs")
+	}
+	violations := t.transport.GetRateLimiter().Violators(msgID, peerIDs)
+	if len(violations) == 0 {
+		return result
+	}
+	// the zero blame of some failures cannot be added to, so we work on a copy
+	merged := blame.NewBlame(result.FailReason, result.BlameNodes)
+	merged.IsUnicast = result.IsUnicast
+	merged.Round = result.Round
+	if len(merged.FailReason) == 0 {
+		merged.FailReason = blame.RateLimited
+	}
+	for _, el := range violations {
+		pubKey, err := conversion.GetPubKeyFromPeerID(el.PeerID.String())
+		if err != nil {
+			t.logger.Error().Err(err).Msgf("fail to get the pub key of peer(%s)", el.PeerID)
+			continue
+		}
+		t.logger.Warn().Str("msgID", msgID).Msgf("blame peer(%s) for exceeding its %s rate limit", el.PeerID, el.Reason)
+		merged.AddBlameNodes(blame.NewNode(pubKey, []byte(el.Reason), nil))
 	}
+	return merged
 }
 
 // GetLocalPeerID return the local peer
 func (t *TssServer) GetLocalPeerID() string {
-	return t.p2pCommunication.GetLocalPeerID()
+	return t.transport.ID().String()
 }
 
 // GetLocalPeerID return the local peer
@@ -235,20 +558,62 @@ func (t *TssServer) GetLocalPubKey() string {
 	return t.localNodePubKey
 }
 
-// GetKnownPeers return the the ID and IP address of all peers.
+// GetKnownPeers return the the ID and the addresses of all peers, it is empty when we do not run over libp2p.
 func (t *TssServer) GetKnownPeers() []PeerInfo {
 	infos := []PeerInfo{}
+	if t.p2pCommunication == nil {
+		return infos
+	}
 	host := t.p2pCommunication.GetHost()
 
 	for _, conn := range host.Network().Conns() {
 		peer := conn.RemotePeer()
-		addrs := conn.RemoteMultiaddr()
-		ip, _ := addrs.ValueForProtocol(maddr.P_IP4)
+		var address string
+		if ip, err := manet.ToIP(conn.RemoteMultiaddr()); err == nil {
+			address = ip.String()
+		}
+		var addresses []string
+		for _, el := range host.Peerstore().Addrs(peer) {
+			addresses = append(addresses, el.String())
+		}
 		pi := PeerInfo{
-			ID:      peer.String(),
-			Address: ip,
+			ID:        peer.String(),
+			Address:   address,
+			Addresses: addresses,
 		}
 		infos = append(infos, pi)
 	}
 	return infos
 }
+
+// SetAllowedPubKeys update the allowlist of the node pub keys we accept p2p connections from, it should be
+// called every time the committee changes, an empty list allows everyone
+func (t *TssServer) SetAllowedPubKeys(pubKeys []string) error {
+	return t.transport.SetAllowedPubKeys(pubKeys)
+}
+
+// GetAllowedPubKeys return the node pub keys in the p2p allowlist
+func (t *TssServer) GetAllowedPubKeys() []string {
+	return t.transport.GetConnectionGater().GetAllowedPubKeys()
+}
+
+// GetPeersHealth return the round trip times, failures and last seen times of the committee peers
+func (t *TssServer) GetPeersHealth() []p2p.PeerHealth {
+	if t.p2pCommunication == nil {
+		return []p2p.PeerHealth{}
+	}
+	return t.p2pCommunication.GetHealthMonitor().GetPeersHealth()
+}
+
+// GetPeerVersions return the versions and features of the peers we had a handshake with
+func (t *TssServer) GetPeerVersions() []p2p.PeerVersion {
+	return t.versionNegotiator.GetPeerVersions()
+}
+
+// GetNATStatus return the reachability of the node and the addresses it advertises, including the relay ones
+func (t *TssServer) GetNATStatus() p2p.NATStatus {
+	if t.p2pCommunication == nil {
+		return p2p.NATStatus{Reachability: "unknown", Addresses: []string{}}
+	}
+	return t.p2pCommunication.GetNATStatus()
+}
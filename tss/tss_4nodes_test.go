@@ -80,8 +80,8 @@ func (s *FourNodeTestSuite) SetUpTest(c *C) {
 	s.preParams = getPreparams(c)
 	s.servers = make([]*TssServer, partyNum)
 	s.tssConfig = common.TssConfig{
-		KeyGenTimeout:   90 * time.Second,
-		KeySignTimeout:  90 * time.Second,
+		KeyGenTimeout:   60 * time.Second,
+		KeySignTimeout:  60 * time.Second,
 		PreParamTimeout: 5 * time.Second,
 		EnableMonitor:   false,
 	}
@@ -255,19 +255,10 @@ func (s *FourNodeTestSuite) doTestFailJoinParty(c *C, version string) {
 	for _, item := range keygenResult {
 		c.Assert(item.PubKey, Equals, "")
 		c.Assert(item.Status, Equals, common.Fail)
-		var expectedFailNode string
-		if version == newJoinPartyVersion {
-			c.Assert(item.Blame.BlameNodes, HasLen, 2)
-			expectedFailNode := []string{
-				"thorpub1addwnpepqtdklw8tf3anjz7nn5fly3uvq2e67w2apn560s4smmrt9e3x52nt2svmmu3",
-				"thorpub1addwnpepq2ryyje5zr09lq7gqptjwnxqsy2vcdngvwd6z7yt5yjcnyj8c8cn559xe69",
-			}
-			c.Assert(item.Blame.BlameNodes[0].Pubkey, Equals, expectedFailNode[0])
-			c.Assert(item.Blame.BlameNodes[1].Pubkey, Equals, expectedFailNode[1])
-		} else {
-			expectedFailNode = "thorpub1addwnpepqtdklw8tf3anjz7nn5fly3uvq2e67w2apn560s4smmrt9e3x52nt2svmmu3"
-			c.Assert(item.Blame.BlameNodes[0].Pubkey, Equals, expectedFailNode)
-		}
+		// only the node that never joined is blamed, a leader that has answered is not
+		c.Assert(item.Blame.BlameNodes, HasLen, 1)
+		expectedFailNode := "thorpub1addwnpepqtdklw8tf3anjz7nn5fly3uvq2e67w2apn560s4smmrt9e3x52nt2svmmu3"
+		c.Assert(item.Blame.BlameNodes[0].Pubkey, Equals, expectedFailNode)
 	}
 }
 
@@ -276,12 +267,16 @@ func (s *FourNodeTestSuite) doTestBlame(c *C, version string) {
 	lock := &sync.Mutex{}
 	keygenResult := make(map[int]keygen.Response)
 	joinPartyChan := make(chan struct{})
+	msgID, err := s.servers[0].requestToMsgId(keygen.NewRequest(copyTestPubKeys(), 10, version))
+	c.Assert(err, IsNil)
+	for i := 0; i < partyNum; i++ {
+		s.servers[i].setJoinPartyChan(msgID, joinPartyChan)
+	}
 	for i := 0; i < partyNum; i++ {
 		wg.Add(1)
 		go func(idx int) {
 			defer wg.Done()
 			req := keygen.NewRequest(copyTestPubKeys(), 10, version)
-			s.servers[idx].setJoinPartyChan(joinPartyChan)
 			res, err := s.servers[idx].Keygen(req)
 			c.Assert(err, NotNil, check.Commentf("idx=%d", idx))
 			lock.Lock()
@@ -319,7 +314,7 @@ func (s *FourNodeTestSuite) doTestBlame(c *C, version string) {
 
 		// Unset the join channel so Keygen does not block after joinParty for other tests
 		for i := 0; i < partyNum; i++ {
-			s.servers[i].unsetJoinPartyChan()
+			s.servers[i].unsetJoinPartyChan(msgID)
 		}
 	}()
 
@@ -0,0 +1,181 @@
+package tss
+
+import (
+	"os"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"gopkg.in/check.v1"
+	. "gopkg.in/check.v1"
+
+	"github.com/ordinox/thorchain-tss/blame"
+	"github.com/ordinox/thorchain-tss/common"
+	"github.com/ordinox/thorchain-tss/conversion"
+	"github.com/ordinox/thorchain-tss/internal/faultinject"
+	"github.com/ordinox/thorchain-tss/keygen"
+	"github.com/ordinox/thorchain-tss/messages"
+	"github.com/ordinox/thorchain-tss/p2p"
+)
+
+// FaultInjectionTestSuite run the four nodes over the in-memory network with a fault injector on each of them,
+// and check the blame of the ceremonies a node misbehaves in
+type FaultInjectionTestSuite struct {
+	network   *p2p.MemoryNetwork
+	injectors []*faultinject.Injector
+	servers   []*TssServer
+	peerIDs   []peer.ID
+}
+
+var _ = Suite(&FaultInjectionTestSuite{})
+
+func (s *FaultInjectionTestSuite) SetUpTest(c *C) {
+	common.InitLog("info", true, "fault_injection_test")
+	conversion.SetupBech32Prefix()
+	preParams := getPreparams(c)
+	var err error
+	s.network, err = p2p.NewMemoryNetwork(p2p.MemoryNetworkConfig{})
+	c.Assert(err, IsNil)
+	conf := common.TssConfig{
+		KeyGenTimeout:   30 * time.Second,
+		KeySignTimeout:  30 * time.Second,
+		PreParamTimeout: 5 * time.Second,
+	}
+	s.injectors = make([]*faultinject.Injector, partyNum)
+	s.servers = make([]*TssServer, partyNum)
+	s.peerIDs = make([]peer.ID, partyNum)
+	for i := 0; i < partyNum; i++ {
+		priKey, err := conversion.GetPriKey(testPriKeyArr[i])
+		c.Assert(err, IsNil)
+		priKeyBytes, err := conversion.GetPriKeyRawBytes(priKey)
+		c.Assert(err, IsNil)
+		transport, err := s.network.NewTransport(priKeyBytes, conf.Network)
+		c.Assert(err, IsNil)
+		s.peerIDs[i] = transport.ID()
+		s.injectors[i] = faultinject.NewInjector(transport)
+		baseHome := path.Join(os.TempDir(), "fault_injection_test", strconv.Itoa(i))
+		c.Assert(os.MkdirAll(baseHome, os.ModePerm), IsNil)
+		s.servers[i], err = NewTssWithTransport(s.injectors[i], priKey, baseHome, conf, preParams[i])
+		c.Assert(err, IsNil)
+		c.Assert(s.servers[i].Start(), IsNil)
+	}
+}
+
+func (s *FaultInjectionTestSuite) TearDownTest(c *C) {
+	for _, el := range s.servers {
+		el.Stop()
+	}
+	c.Assert(s.network.Close(), IsNil)
+	os.RemoveAll(path.Join(os.TempDir(), "fault_injection_test"))
+}
+
+func (s *FaultInjectionTestSuite) keygen(c *C) map[int]keygen.Response {
+	wg := sync.WaitGroup{}
+	lock := &sync.Mutex{}
+	keygenResult := make(map[int]keygen.Response)
+	for i := 0; i < partyNum; i++ {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			req := keygen.NewRequest(copyTestPubKeys(), 10, newJoinPartyVersion)
+			res, err := s.servers[idx].Keygen(req)
+			// the failed keygen returns its blame along with the error
+			c.Assert(err == nil, Equals, res.Status == common.Success, check.Commentf("idx=%d err=%v", idx, err))
+			lock.Lock()
+			defer lock.Unlock()
+			keygenResult[idx] = res
+		}(i)
+	}
+	wg.Wait()
+	return keygenResult
+}
+
+// assertSuccess check every node got the same pool pub key
+func assertSuccess(c *C, keygenResult map[int]keygen.Response) {
+	var poolPubKey string
+	for idx, item := range keygenResult {
+		comment := check.Commentf("idx=%d", idx)
+		c.Assert(item.Status, Equals, common.Success, comment)
+		c.Assert(item.Blame.BlameNodes, HasLen, 0, comment)
+		if len(poolPubKey) == 0 {
+			poolPubKey = item.PubKey
+		} else {
+			c.Assert(item.PubKey, Equals, poolPubKey, comment)
+		}
+	}
+}
+
+// assertBlamed check the given nodes failed the keygen on a timeout that blames the faulty node alone, the blame
+// of the missing broadcast messages carries no fail reason
+func assertBlamed(c *C, keygenResult map[int]keygen.Response, faulty int, unicast bool, nodes ...int) {
+	for _, idx := range nodes {
+		item := keygenResult[idx]
+		comment := check.Commentf("idx=%d", idx)
+		c.Assert(item.Status, Equals, common.Fail, comment)
+		if unicast {
+			c.Assert(item.Blame.FailReason, Equals, blame.TssTimeout, comment)
+		} else {
+			c.Assert(item.Blame.FailReason, Equals, "", comment)
+		}
+		c.Assert(item.Blame.IsUnicast, Equals, unicast, comment)
+		c.Assert(item.Blame.BlameNodes, HasLen, 1, comment)
+		c.Assert(item.Blame.BlameNodes[0].Pubkey, Equals, testPubKeys[faulty], comment)
+	}
+}
+
+func (s *FaultInjectionTestSuite) TestDropRound3Broadcast(c *C) {
+	s.injectors[3].AddFault(faultinject.Fault{
+		Action: faultinject.Drop,
+		Round:  messages.KEYGEN3,
+	})
+	// node 3 gets the round 3 messages of the others and completes the keygen on its own
+	assertBlamed(c, s.keygen(c), 3, false, 0, 1, 2)
+}
+
+func (s *FaultInjectionTestSuite) TestDropAndCorruptUnicastShare(c *C) {
+	s.injectors[3].AddFault(faultinject.Fault{
+		Action:       faultinject.Drop,
+		MessageTypes: []messages.THORChainTSSMessageType{messages.TSSKeyGenMsg},
+		Round:        messages.KEYGEN2aUnicast,
+		To:           s.peerIDs[0],
+	})
+	// the tampered share fails the signature check, so the receiver never gets a valid share from the sender
+	s.injectors[2].AddFault(faultinject.Fault{
+		Action:       faultinject.Corrupt,
+		MessageTypes: []messages.THORChainTSSMessageType{messages.TSSKeyGenMsg},
+		Round:        messages.KEYGEN2aUnicast,
+		To:           s.peerIDs[1],
+	})
+	keygenResult := s.keygen(c)
+	assertBlamed(c, keygenResult, 3, true, 0)
+	assertBlamed(c, keygenResult, 2, true, 1)
+}
+
+func (s *FaultInjectionTestSuite) TestTolerableFaults(c *C) {
+	// the faults the protocol recovers from do not get anyone blamed, node 0 loses the round 1 broadcast of
+	// node 2 and the other nodes forward it on request
+	s.injectors[0].AddFault(faultinject.Fault{
+		Action:       faultinject.Drop,
+		Inbound:      true,
+		MessageTypes: []messages.THORChainTSSMessageType{messages.TSSKeyGenMsg},
+		Round:        messages.KEYGEN1,
+		From:         s.peerIDs[2],
+	})
+	s.injectors[1].AddFault(faultinject.Fault{
+		Action: faultinject.Delay,
+		Round:  messages.KEYGEN2b,
+		Delay:  time.Second,
+	})
+	s.injectors[2].AddFault(faultinject.Fault{
+		Action: faultinject.Duplicate,
+		Copies: 2,
+	})
+	s.injectors[3].AddFault(faultinject.Fault{
+		Action:       faultinject.Reorder,
+		MessageTypes: []messages.THORChainTSSMessageType{messages.TSSKeyGenMsg},
+		Delay:        500 * time.Millisecond,
+	})
+	assertSuccess(c, s.keygen(c))
+}
@@ -0,0 +1,133 @@
+package tss
+
+import (
+	"encoding/base64"
+	"os"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/ordinox/thorchain-tss/common"
+	"github.com/ordinox/thorchain-tss/conversion"
+	"github.com/ordinox/thorchain-tss/keygen"
+	"github.com/ordinox/thorchain-tss/keysign"
+	"github.com/ordinox/thorchain-tss/messages"
+	"github.com/ordinox/thorchain-tss/p2p"
+	"github.com/ordinox/thorchain-tss/storage"
+)
+
+// MemoryNetworkTestSuite run the four nodes within the process over the in-memory network, at the current version
+// of the tss protocol
+type MemoryNetworkTestSuite struct {
+	network *p2p.MemoryNetwork
+	servers []*TssServer
+}
+
+var _ = Suite(&MemoryNetworkTestSuite{})
+
+func (s *MemoryNetworkTestSuite) SetUpTest(c *C) {
+	common.InitLog("info", true, "memory_network_test")
+	conversion.SetupBech32Prefix()
+	preParams := getPreparams(c)
+	var err error
+	s.network, err = p2p.NewMemoryNetwork(p2p.MemoryNetworkConfig{
+		Latency: 5 * time.Millisecond,
+		Jitter:  10 * time.Millisecond,
+		Seed:    1,
+	})
+	c.Assert(err, IsNil)
+	conf := common.TssConfig{
+		KeyGenTimeout:   90 * time.Second,
+		KeySignTimeout:  90 * time.Second,
+		PreParamTimeout: 5 * time.Second,
+	}
+	s.servers = make([]*TssServer, partyNum)
+	for i := 0; i < partyNum; i++ {
+		priKey, err := conversion.GetPriKey(testPriKeyArr[i])
+		c.Assert(err, IsNil)
+		priKeyBytes, err := conversion.GetPriKeyRawBytes(priKey)
+		c.Assert(err, IsNil)
+		transport, err := s.network.NewTransport(priKeyBytes, conf.Network)
+		c.Assert(err, IsNil)
+		baseHome := path.Join(os.TempDir(), "memory_network_test", strconv.Itoa(i))
+		c.Assert(os.MkdirAll(baseHome, os.ModePerm), IsNil)
+		s.servers[i], err = NewTssWithTransport(transport, priKey, baseHome, conf, preParams[i])
+		c.Assert(err, IsNil)
+		c.Assert(s.servers[i].Start(), IsNil)
+	}
+}
+
+func (s *MemoryNetworkTestSuite) TearDownTest(c *C) {
+	for _, el := range s.servers {
+		el.Stop()
+	}
+	c.Assert(s.network.Close(), IsNil)
+	os.RemoveAll(path.Join(os.TempDir(), "memory_network_test"))
+}
+
+func (s *MemoryNetworkTestSuite) TestKeygenAndKeySign(c *C) {
+	c.Assert(s.servers[0].GetKnownPeers(), HasLen, 0)
+	c.Assert(s.servers[0].GetNATStatus().Reachability, Equals, "unknown")
+
+	wg := sync.WaitGroup{}
+	lock := &sync.Mutex{}
+	keygenResult := make(map[int]keygen.Response)
+	for i := 0; i < partyNum; i++ {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			req := keygen.NewRequest(copyTestPubKeys(), 10, messages.CURRENTVERSION)
+			res, err := s.servers[idx].Keygen(req)
+			c.Assert(err, IsNil)
+			lock.Lock()
+			defer lock.Unlock()
+			keygenResult[idx] = res
+		}(i)
+	}
+	wg.Wait()
+	var poolPubKey string
+	for _, item := range keygenResult {
+		c.Assert(item.Status, Equals, common.Success)
+		if len(poolPubKey) == 0 {
+			poolPubKey = item.PubKey
+		} else {
+			c.Assert(poolPubKey, Equals, item.PubKey)
+		}
+	}
+
+	keysignResult := make(map[int]keysign.Response)
+	for i := 0; i < partyNum; i++ {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			msgs := []string{
+				base64.StdEncoding.EncodeToString(hash([]byte("helloworld"))),
+				base64.StdEncoding.EncodeToString(hash([]byte("helloworld2"))),
+			}
+			req := keysign.NewRequest(poolPubKey, msgs, 10, copyTestPubKeys(), messages.CURRENTVERSION)
+			// the only weighted node leads the join party, and the denied node waits for the signature
+			req.LeaderWeights = map[string]uint64{testPubKeys[3]: 1}
+			req.DeniedSigners = []string{testPubKeys[2]}
+			res, err := s.servers[idx].KeySign(req)
+			c.Assert(err, IsNil)
+			lock.Lock()
+			defer lock.Unlock()
+			keysignResult[idx] = res
+		}(i)
+	}
+	wg.Wait()
+	checkSignResult(c, keysignResult)
+	leaderID, err := conversion.GetPeerIDFromPubKey(testPubKeys[3])
+	c.Assert(err, IsNil)
+	records, err := s.servers[0].GetCeremonyHistory(storage.CeremonyFilter{Type: storage.CeremonyKeysign})
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 1)
+	c.Assert(records[0].Leader, Equals, leaderID.String())
+	c.Assert(records[0].Signers, HasLen, 3)
+	for _, el := range records[0].Signers {
+		c.Assert(el, Not(Equals), testPubKeys[2])
+	}
+}